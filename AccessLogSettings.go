@@ -0,0 +1,98 @@
+package jrm1
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+const (
+	AccessLogMessage        = "RPC call"
+	AccessLogRedactedValue  = "[REDACTED]"
+	AccessLogOutcomeSuccess = "success"
+	AccessLogOutcomeFailure = "failure"
+)
+
+// Names of attributes of access log records.
+const (
+	LogAttr_RequestId     = "request_id"
+	LogAttr_Method        = "method"
+	LogAttr_RemoteAddress = "remote_addr"
+	LogAttr_Duration      = "duration"
+	LogAttr_Outcome       = "outcome"
+	LogAttr_ErrorCode     = "error_code"
+	LogAttr_Parameters    = "params"
+	LogAttr_Panic         = "panic"
+	LogAttr_Stack         = "stack"
+	LogAttr_Error         = "error"
+	LogAttr_Url           = "url"
+	LogAttr_UnknownFields = "unknown_fields"
+	LogAttr_IncidentId    = "incident_id"
+)
+
+// AccessLogSettings are settings of the access log of an RPC processor
+// (server). When enabled, a structured log record is written for every
+// function call.
+type AccessLogSettings struct {
+	// Only one of N successful function calls is journaled when this number is
+	// greater than one. Failed function calls are always journaled.
+	SamplingRate uint
+
+	// When enabled, parameters of function calls are journaled.
+	LogParameters bool
+
+	// Names of root fields of parameters which must not be journaled. Values
+	// of these fields are replaced with a placeholder.
+	RedactedParameters []string
+
+	// Custom function which prepares parameters for the journal. When set, it
+	// is used instead of the list of redacted parameters.
+	ParametersRedactor func(method string, params *json.RawMessage) any
+}
+
+// isSampled tells whether a successful function call having the specified
+// sequence number must be journaled.
+func (als *AccessLogSettings) isSampled(n uint64) bool {
+	if als.SamplingRate <= 1 {
+		return true
+	}
+
+	return n%uint64(als.SamplingRate) == 0
+}
+
+// redactParameters prepares parameters of a function call for the journal.
+func (als *AccessLogSettings) redactParameters(method string, params *json.RawMessage) any {
+	if als.ParametersRedactor != nil {
+		return als.ParametersRedactor(method, params)
+	}
+
+	if params == nil {
+		return nil
+	}
+
+	if len(als.RedactedParameters) == 0 {
+		return string(*params)
+	}
+
+	var fields map[string]json.RawMessage
+	decoder := json.NewDecoder(bytes.NewReader(*params))
+	err := decoder.Decode(&fields)
+	if err != nil {
+		// Parameters which are not an object can not be redacted partially.
+		return AccessLogRedactedValue
+	}
+
+	redactedValue, _ := json.Marshal(AccessLogRedactedValue)
+	for _, name := range als.RedactedParameters {
+		_, exists := fields[name]
+		if exists {
+			fields[name] = redactedValue
+		}
+	}
+
+	buf, err := json.Marshal(fields)
+	if err != nil {
+		return AccessLogRedactedValue
+	}
+
+	return string(buf)
+}
@@ -0,0 +1,52 @@
+package jrm1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_AccessLogSettings_isSampled(t *testing.T) {
+	aTest := tester.New(t)
+	var als *AccessLogSettings
+
+	// Test #1. Sampling is disabled.
+	als = &AccessLogSettings{SamplingRate: 0}
+	aTest.MustBeEqual(als.isSampled(1), true)
+	aTest.MustBeEqual(als.isSampled(2), true)
+
+	// Test #2. One of three.
+	als = &AccessLogSettings{SamplingRate: 3}
+	aTest.MustBeEqual(als.isSampled(1), false)
+	aTest.MustBeEqual(als.isSampled(2), false)
+	aTest.MustBeEqual(als.isSampled(3), true)
+	aTest.MustBeEqual(als.isSampled(6), true)
+}
+
+func Test_AccessLogSettings_redactParameters(t *testing.T) {
+	aTest := tester.New(t)
+	var als *AccessLogSettings
+	params := json.RawMessage(`{"login":"user","password":"secret"}`)
+
+	// Test #1. Nothing is redacted.
+	als = &AccessLogSettings{}
+	aTest.MustBeEqual(als.redactParameters("m", &params), `{"login":"user","password":"secret"}`)
+	aTest.MustBeEqual(als.redactParameters("m", nil), nil)
+
+	// Test #2. Field is redacted.
+	als = &AccessLogSettings{RedactedParameters: []string{"password", "token"}}
+	aTest.MustBeEqual(als.redactParameters("m", &params), `{"login":"user","password":"[REDACTED]"}`)
+
+	// Test #3. Parameters are not an object.
+	notAnObject := json.RawMessage(`[1,2,3]`)
+	aTest.MustBeEqual(als.redactParameters("m", &notAnObject), AccessLogRedactedValue)
+
+	// Test #4. Custom redactor.
+	als = &AccessLogSettings{
+		ParametersRedactor: func(method string, _ *json.RawMessage) any {
+			return "custom " + method
+		},
+	}
+	aTest.MustBeEqual(als.redactParameters("m", &params), "custom m")
+}
@@ -0,0 +1,43 @@
+package jrm1
+
+import (
+	"context"
+	"net/http"
+)
+
+// AccessPolicy is a callback which decides whether a function call is allowed.
+// It is called for every call of every function when it is set in processor
+// settings. 'True' allows the call, 'False' denies it.
+type AccessPolicy func(ac *AccessCheck) (allowed bool)
+
+// AccessCheck is information about a function call which is being authorised.
+type AccessCheck struct {
+	// Name of the requested RPC function (method, procedure).
+	Method string
+
+	// Identifier of request.
+	RequestId string
+
+	// Requirements declared by the function when it was registered.
+	// Null value means that the function declared no requirements.
+	Requirements *AccessRequirements
+
+	// HTTP request from which the RPC request was received. A policy may use
+	// it to identify the caller, e.g. by its headers or TLS certificate. It is
+	// null when the request is not received via HTTP.
+	HttpRequest *http.Request
+
+	// Context of the call. When the processor is used without HTTP, a policy
+	// may use values of the context to identify the caller.
+	Context context.Context
+}
+
+// NewAccessCheck is a constructor of an access check.
+func NewAccessCheck(method string, requestId string, requirements *AccessRequirements, httpRequest *http.Request) (ac *AccessCheck) {
+	return &AccessCheck{
+		Method:       method,
+		RequestId:    requestId,
+		Requirements: requirements,
+		HttpRequest:  httpRequest,
+	}
+}
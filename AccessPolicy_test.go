@@ -0,0 +1,23 @@
+package jrm1
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_NewAccessCheck(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	ar := NewAccessRequirements([]string{"admin"}, nil)
+	req := new(http.Request)
+	ac := NewAccessCheck("m", "1", ar, req)
+	aTest.MustBeEqual(ac, &AccessCheck{
+		Method:       "m",
+		RequestId:    "1",
+		Requirements: ar,
+		HttpRequest:  req,
+	})
+}
@@ -0,0 +1,61 @@
+package jrm1
+
+// AccessRequirements are requirements which a caller must satisfy to be
+// allowed to call an RPC function (method, procedure).
+type AccessRequirements struct {
+	// Roles accepted by the function. If the list is not empty, the caller
+	// must have at least one of these roles.
+	Roles []string
+
+	// Scopes required by the function. If the list is not empty, the caller
+	// must have all of these scopes.
+	Scopes []string
+}
+
+// NewAccessRequirements is a constructor of access requirements.
+func NewAccessRequirements(roles []string, scopes []string) (ar *AccessRequirements) {
+	return &AccessRequirements{
+		Roles:  roles,
+		Scopes: scopes,
+	}
+}
+
+// IsSatisfiedBy tells whether a caller having the specified roles and scopes
+// satisfies the requirements. Null requirements are satisfied by anyone.
+func (ar *AccessRequirements) IsSatisfiedBy(callerRoles []string, callerScopes []string) bool {
+	if ar == nil {
+		return true
+	}
+
+	if len(ar.Roles) > 0 {
+		var hasRole bool
+		for _, role := range ar.Roles {
+			if containsString(callerRoles, role) {
+				hasRole = true
+				break
+			}
+		}
+		if !hasRole {
+			return false
+		}
+	}
+
+	for _, scope := range ar.Scopes {
+		if !containsString(callerScopes, scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// containsString tells whether the list contains the string.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,57 @@
+package jrm1
+
+import (
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_NewAccessRequirements(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	ar := NewAccessRequirements([]string{"admin"}, []string{"read"})
+	aTest.MustBeEqual(ar, &AccessRequirements{
+		Roles:  []string{"admin"},
+		Scopes: []string{"read"},
+	})
+}
+
+func Test_AccessRequirements_IsSatisfiedBy(t *testing.T) {
+	aTest := tester.New(t)
+	var ar *AccessRequirements
+
+	// Test #1. Null requirements.
+	ar = nil
+	aTest.MustBeEqual(ar.IsSatisfiedBy(nil, nil), true)
+
+	// Test #2. Empty requirements.
+	ar = NewAccessRequirements(nil, nil)
+	aTest.MustBeEqual(ar.IsSatisfiedBy(nil, nil), true)
+
+	// Test #3. Any of the roles is enough.
+	ar = NewAccessRequirements([]string{"admin", "operator"}, nil)
+	aTest.MustBeEqual(ar.IsSatisfiedBy([]string{"operator"}, nil), true)
+	aTest.MustBeEqual(ar.IsSatisfiedBy([]string{"guest"}, nil), false)
+	aTest.MustBeEqual(ar.IsSatisfiedBy(nil, nil), false)
+
+	// Test #4. All the scopes are required.
+	ar = NewAccessRequirements(nil, []string{"read", "write"})
+	aTest.MustBeEqual(ar.IsSatisfiedBy(nil, []string{"write", "read"}), true)
+	aTest.MustBeEqual(ar.IsSatisfiedBy(nil, []string{"read"}), false)
+
+	// Test #5. Roles and scopes.
+	ar = NewAccessRequirements([]string{"admin"}, []string{"read"})
+	aTest.MustBeEqual(ar.IsSatisfiedBy([]string{"admin"}, []string{"read"}), true)
+	aTest.MustBeEqual(ar.IsSatisfiedBy([]string{"admin"}, nil), false)
+	aTest.MustBeEqual(ar.IsSatisfiedBy(nil, []string{"read"}), false)
+}
+
+func Test_containsString(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	aTest.MustBeEqual(containsString([]string{"a", "b"}, "b"), true)
+	aTest.MustBeEqual(containsString([]string{"a", "b"}, "c"), false)
+	aTest.MustBeEqual(containsString(nil, "a"), false)
+}
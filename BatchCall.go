@@ -0,0 +1,33 @@
+package jrm1
+
+// BatchCall is a single function call of a batch made by the client.
+type BatchCall struct {
+	// Name of the called RPC function (method, procedure).
+	Method string
+
+	// Parameters of the function call. They are encoded using JSON format.
+	Params any
+
+	// Result of the function call. It must be a pointer to an initialised
+	// (empty) object, the result is decoded into it.
+	Result any
+
+	// RPC error returned by the RPC server for this function call.
+	Error *RpcError
+
+	// Error which happened while processing this function call on the client
+	// side, e.g. when the result can not be decoded.
+	Err error
+
+	// Identifier of request assigned by the client.
+	id string
+}
+
+// NewBatchCall is a constructor of a single function call of a batch.
+func NewBatchCall(method string, params any, result any) (bc *BatchCall) {
+	return &BatchCall{
+		Method: method,
+		Params: params,
+		Result: result,
+	}
+}
@@ -0,0 +1,20 @@
+package jrm1
+
+import (
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_NewBatchCall(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	result := new(SumResult)
+	bc := NewBatchCall("m", SumParams{A: 1}, result)
+	aTest.MustBeEqual(bc, &BatchCall{
+		Method: "m",
+		Params: SumParams{A: 1},
+		Result: result,
+	})
+}
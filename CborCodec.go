@@ -0,0 +1,43 @@
+package jrm1
+
+// CborContentType is the type of content of messages encoded using the CBOR
+// format.
+const CborContentType = "application/cbor"
+
+// CborCodec is a codec of the CBOR format. Values are encoded with the same
+// field names as in JSON. Byte slices are encoded as binary strings, while
+// binary strings are decoded as Base64 text, in the same way as JSON decodes
+// byte slices. Tags are ignored.
+type CborCodec struct{}
+
+// NewCborCodec is a constructor of a CBOR codec.
+func NewCborCodec() (cc *CborCodec) {
+	return new(CborCodec)
+}
+
+// ContentType returns the type of content of encoded messages.
+func (cc *CborCodec) ContentType() string {
+	return CborContentType
+}
+
+// Marshal encodes the value.
+func (cc *CborCodec) Marshal(v any) (data []byte, err error) {
+	var tree any
+	tree, err = newCodecTree(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendCbor(nil, tree)
+}
+
+// Unmarshal decodes the data into the value.
+func (cc *CborCodec) Unmarshal(data []byte, v any) (err error) {
+	var tree any
+	tree, err = decodeCbor(data)
+	if err != nil {
+		return err
+	}
+
+	return unmarshalJsonTree(tree, v)
+}
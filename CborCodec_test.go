@@ -0,0 +1,16 @@
+package jrm1
+
+import (
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_NewCborCodec(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	codec := NewCborCodec()
+	aTest.MustBeEqual(codec.ContentType(), CborContentType)
+	_checkCodec(t, codec)
+}
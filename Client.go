@@ -6,9 +6,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"math/big"
 	"net/http"
+	"strconv"
 	"sync"
+	"time"
 
 	mime "github.com/vault-thirteen/auxie/MIME"
 	ae "github.com/vault-thirteen/auxie/errors"
@@ -16,7 +20,17 @@ import (
 )
 
 const (
-	ErrInternalSelfCheck = "internal self check error"
+	DefaultJobPollInterval = time.Second
+)
+
+const (
+	ErrInternalSelfCheck            = "internal self check error"
+	ErrBatchResponseIsMissing       = "response is missing in the batch"
+	ErrBatchResponseIsDuplicate     = "duplicate response in the batch"
+	ErrBatchIsRejected              = "batch is rejected"
+	ErrNotificationIsRefused        = "notification is refused"
+	ErrNotificationsAreNotSupported = "server does not support notifications"
+	ErrFUnexpectedHttpStatus        = "unexpected HTTP status code: %v"
 )
 
 // Client is an RPC client.
@@ -25,6 +39,7 @@ type Client struct {
 	guard    *sync.RWMutex
 
 	// Request counters.
+	countersGuard    *sync.Mutex
 	requestsCount    *big.Int
 	requestsCountOne *big.Int
 }
@@ -39,6 +54,7 @@ func NewClient(settings *ClientSettings) (c *Client, err error) {
 	c = &Client{
 		settings:         settings,
 		guard:            new(sync.RWMutex),
+		countersGuard:    new(sync.Mutex),
 		requestsCount:    big.NewInt(0),
 		requestsCountOne: big.NewInt(1),
 	}
@@ -49,39 +65,67 @@ func NewClient(settings *ClientSettings) (c *Client, err error) {
 // Call performs a function call and puts result into the 'result' argument.
 // The 'result' argument must be a pointer to an initialised (empty) object.
 func (c *Client) Call(ctx context.Context, method string, params any, result any) (re *RpcError, err error) {
-	c.guard.Lock()
-	defer c.guard.Unlock()
+	defer c.lockCall()()
+
+	var rpcReq *RpcRequest
+	rpcReq, err = c.newRpcRequest(method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var rawRpcResp *RpcResponseRaw
+	rawRpcResp, err = c.call(ctx, rpcReq)
+	if err != nil {
+		return nil, err
+	}
 
+	return c.decodeResult(rawRpcResp, result)
+}
+
+// Invoke performs a function call like the 'Call' method, while the RPC error
+// is returned as an ordinary Go error. The error is converted by the error
+// registry of the client settings, so that callers can check it by the
+// 'errors.Is' function against registered sentinel errors, or get the RPC
+// error by the 'errors.As' function as an 'RpcErrorStd' error.
+func (c *Client) Invoke(ctx context.Context, method string, params any, result any) (err error) {
+	var re *RpcError
+	re, err = c.Call(ctx, method, params, result)
+	if err != nil {
+		return err
+	}
+
+	return c.settings.errorRegistry.ToError(re)
+}
+
+// newRpcRequest prepares a request for a function call. It assigns a new
+// request ID and encodes the parameters.
+func (c *Client) newRpcRequest(method string, params any) (rpcReq *RpcRequest, err error) {
 	// Prepare protocol name and request ID.
 	pn := ProtocolNameM1
-	c.incRequestsCount()
-	var rid = c.GetRequestsCount()
+	var rid = c.incRequestsCount()
 
-	// Encode parameters.
+	// Encode parameters. They are encoded only once as compact text, which
+	// is then embedded into the request as is.
 	var buf bytes.Buffer
-	enc := json.NewEncoder(&buf)
-	enc.SetIndent("", "\t")
-	enc.SetEscapeHTML(c.settings.useHtmlEscaping)
-	err = enc.Encode(params)
+	err = appendJsonValue(&buf, params, c.settings.useHtmlEscaping)
 	if err != nil {
 		return nil, err
 	}
 	var paramsJRM = json.RawMessage(buf.Bytes())
 
-	// Make a function call.
-	rpcReq := &RpcRequest{
+	rpcReq = &RpcRequest{
 		ProtocolName: &pn,
 		Id:           &rid,
 		Method:       &method,
 		Parameters:   &paramsJRM,
 	}
 
-	var rawRpcResp *RpcResponseRaw
-	rawRpcResp, err = c.call(ctx, rpcReq)
-	if err != nil {
-		return nil, err
-	}
+	return rpcReq, nil
+}
 
+// decodeResult checks a raw response and puts its result into the 'result'
+// argument.
+func (c *Client) decodeResult(rawRpcResp *RpcResponseRaw, result any) (re *RpcError, err error) {
 	// Internal self-check.
 	// Error flag and success flag must have opposite values.
 	if rawRpcResp.hasError() == rawRpcResp.OK {
@@ -91,7 +135,9 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 	// Get response result.
 	if rawRpcResp.Result != nil {
 		decoder := json.NewDecoder(bytes.NewReader(*rawRpcResp.Result))
-		decoder.DisallowUnknownFields()
+		if !c.settings.isResultLenient {
+			decoder.DisallowUnknownFields()
+		}
 		decoder.UseNumber()
 		err = decoder.Decode(result)
 		if err != nil {
@@ -102,10 +148,458 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 	return rawRpcResp.Error, nil
 }
 
+// Notify sends a notification, i.e. a function call whose result is not
+// awaited. This is an extension of the protocol which must be enabled on the
+// RPC server. When the server accepts the notification, no errors are
+// returned. When the notification fails the checks on the server, e.g. the
+// requested function is unknown, the RPC error is returned. When the server is
+// overloaded, the notification is refused and an error is returned.
+func (c *Client) Notify(ctx context.Context, method string, params any) (re *RpcError, err error) {
+	defer c.lockCall()()
+
+	var rpcReq *RpcRequest
+	rpcReq, err = c.newRpcRequest(method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var httpReq *http.Request
+	httpReq, err = c.newHttpRequest(ctx, rpcReq)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set(HttpHeaderNotification, strconv.FormatBool(true))
+
+	var httpResp *http.Response
+	httpResp, err = c.doHttpRequest(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		derr := httpResp.Body.Close()
+		if derr != nil {
+			err = ae.Combine(err, derr)
+		}
+	}()
+
+	switch httpResp.StatusCode {
+	case http.StatusAccepted:
+		return nil, nil
+
+	case http.StatusOK:
+		// Notification has not passed the checks.
+		var rpcResp *RpcResponseRaw
+		rpcResp, err = c.readRpcResponse(httpResp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if !rpcResp.hasError() {
+			// Server without notifications executes the call as usual.
+			return nil, errors.New(ErrNotificationsAreNotSupported)
+		}
+		return rpcResp.Error, nil
+
+	case http.StatusServiceUnavailable:
+		return nil, errors.New(ErrNotificationIsRefused)
+
+	default:
+		return nil, fmt.Errorf(ErrFUnexpectedHttpStatus, httpResp.StatusCode)
+	}
+}
+
+// CallStream calls a streaming function and returns the stream of its result
+// items. The stream must be closed after use. When the call fails before the
+// stream starts, e.g. the requested function is unknown, the RPC error is
+// returned and the stream is not created.
+func (c *Client) CallStream(ctx context.Context, method string, params any) (rs *ResponseStream, re *RpcError, err error) {
+	defer c.lockCall()()
+
+	var rpcReq *RpcRequest
+	rpcReq, err = c.newRpcRequest(method, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var httpReq *http.Request
+	httpReq, err = c.newHttpRequest(ctx, rpcReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	httpReq.Header.Set(header.HttpHeaderAccept, StreamContentType+", "+mime.TypeApplicationJson)
+
+	var httpResp *http.Response
+	httpResp, err = c.doHttpRequest(httpReq)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if httpResp.Header.Get(header.HttpHeaderContentType) == StreamContentType {
+		rs, err = newResponseStream(httpResp.Body)
+		if err != nil {
+			return nil, nil, ae.Combine(err, httpResp.Body.Close())
+		}
+		rs.isLenient = c.settings.isResultLenient
+
+		return rs, nil, nil
+	}
+
+	defer func() {
+		derr := httpResp.Body.Close()
+		if derr != nil {
+			err = ae.Combine(err, derr)
+		}
+	}()
+
+	var rpcResp *RpcResponseRaw
+	rpcResp, err = c.readRpcResponse(httpResp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !rpcResp.hasError() {
+		return nil, nil, errors.New(ErrInternalSelfCheck)
+	}
+
+	return nil, rpcResp.Error, nil
+}
+
+// SubmitJob calls an asynchronous function, which is started as a job on the
+// RPC server, and returns information about the job. This is an extension of
+// the protocol which must be enabled on the RPC server.
+func (c *Client) SubmitJob(ctx context.Context, method string, params any) (ji *JobInfo, re *RpcError, err error) {
+	ji = new(JobInfo)
+	re, err = c.Call(ctx, method, params, ji)
+	if (re != nil) || (err != nil) {
+		return nil, re, err
+	}
+
+	return ji, nil, nil
+}
+
+// GetErrorCatalogue returns the catalogue of user-generated error codes of
+// the RPC server, so that codes can be mapped to names. The catalogue must be
+// set in settings of the server.
+func (c *Client) GetErrorCatalogue(ctx context.Context) (ec *ErrorCatalogue, re *RpcError, err error) {
+	var entries []*ErrorCatalogueEntry
+	re, err = c.Call(ctx, CatalogueMethod_GetErrorCatalogue, struct{}{}, &entries)
+	if (re != nil) || (err != nil) {
+		return nil, re, err
+	}
+
+	ec = NewErrorCatalogue()
+	for _, entry := range entries {
+		err = ec.add(entry)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return ec, nil, nil
+}
+
+// GetJobStatus returns information about the job.
+func (c *Client) GetJobStatus(ctx context.Context, jobId string) (ji *JobInfo, re *RpcError, err error) {
+	ji = new(JobInfo)
+	re, err = c.Call(ctx, JobMethod_GetStatus, JobParams{JobId: jobId}, ji)
+	if (re != nil) || (err != nil) {
+		return nil, re, err
+	}
+
+	return ji, nil, nil
+}
+
+// GetJobResult puts the result of the finished job into the 'result'
+// argument. The RPC error returned by the function of the job is returned as
+// is.
+func (c *Client) GetJobResult(ctx context.Context, jobId string, result any) (re *RpcError, err error) {
+	return c.Call(ctx, JobMethod_GetResult, JobParams{JobId: jobId}, result)
+}
+
+// CancelJob cancels the running job and returns information about it.
+func (c *Client) CancelJob(ctx context.Context, jobId string) (ji *JobInfo, re *RpcError, err error) {
+	ji = new(JobInfo)
+	re, err = c.Call(ctx, JobMethod_Cancel, JobParams{JobId: jobId}, ji)
+	if (re != nil) || (err != nil) {
+		return nil, re, err
+	}
+
+	return ji, nil, nil
+}
+
+// WaitForJob polls the status of the job with the specified interval until
+// the job finishes, then puts its result into the 'result' argument. When the
+// interval is not set, the default interval is used. Waiting stops when the
+// context is done.
+func (c *Client) WaitForJob(ctx context.Context, jobId string, pollInterval time.Duration, result any) (re *RpcError, err error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultJobPollInterval
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var ji *JobInfo
+	for {
+		ji, re, err = c.GetJobStatus(ctx, jobId)
+		if (re != nil) || (err != nil) {
+			return re, err
+		}
+
+		if ji.Status.IsFinished() {
+			return c.GetJobResult(ctx, jobId, result)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// CallAsync calls an asynchronous function and waits for the result of its
+// job. See the 'SubmitJob' and 'WaitForJob' methods for details.
+func (c *Client) CallAsync(ctx context.Context, method string, params any, result any, pollInterval time.Duration) (re *RpcError, err error) {
+	var ji *JobInfo
+	ji, re, err = c.SubmitJob(ctx, method, params)
+	if (re != nil) || (err != nil) {
+		return re, err
+	}
+
+	return c.WaitForJob(ctx, ji.JobId, pollInterval, result)
+}
+
+// CallBatch performs several function calls in a single request. This is an
+// extension of the protocol which must be enabled on the RPC server. Each
+// call of the batch gets its own result, RPC error and client-side error.
+// Responses are mapped back to the calls by request IDs. The returned error
+// is set when the whole batch fails.
+func (c *Client) CallBatch(ctx context.Context, calls []*BatchCall) (err error) {
+	defer c.lockCall()()
+
+	rpcReqs := make([]*RpcRequest, 0, len(calls))
+	callsById := make(map[string]*BatchCall, len(calls))
+	for _, bc := range calls {
+		var rpcReq *RpcRequest
+		rpcReq, err = c.newRpcRequest(bc.Method, bc.Params)
+		if err != nil {
+			return err
+		}
+
+		bc.id = *rpcReq.Id
+		rpcReqs = append(rpcReqs, rpcReq)
+		callsById[bc.id] = bc
+	}
+
+	var rawRpcResps []*RpcResponseRaw
+	rawRpcResps, err = c.callBatch(ctx, rpcReqs)
+	if err != nil {
+		return err
+	}
+
+	isAnswered := make(map[string]bool, len(calls))
+	for _, rawRpcResp := range rawRpcResps {
+		if rawRpcResp.Id == nil {
+			continue
+		}
+
+		bc, ok := callsById[*rawRpcResp.Id]
+		if !ok {
+			continue
+		}
+
+		if isAnswered[bc.id] {
+			bc.Err = errors.New(ErrBatchResponseIsDuplicate)
+			continue
+		}
+		isAnswered[bc.id] = true
+
+		bc.Error, bc.Err = c.decodeResult(rawRpcResp, bc.Result)
+	}
+
+	for _, bc := range calls {
+		if !isAnswered[bc.id] {
+			bc.Err = errors.New(ErrBatchResponseIsMissing)
+		}
+	}
+
+	return nil
+}
+
+// callBatch makes a batch request to the RPC server, receives responses and
+// returns them. Each function call of the batch is journaled and measured as
+// a separate call, while the batch has a single span, because the trace
+// context is propagated by the whole request.
+func (c *Client) callBatch(ctx context.Context, rpcReqs []*RpcRequest) (rpcResps []*RpcResponseRaw, err error) {
+	tStart := time.Now()
+	respSizes := make(map[string]int64, len(rpcReqs))
+	if (c.settings.logger != nil) || (c.settings.metrics != nil) {
+		if c.settings.metrics != nil {
+			for _, rpcReq := range rpcReqs {
+				c.settings.metrics.beginClientCall(getRequestMethod(rpcReq))
+			}
+		}
+		defer func() {
+			c.finishBatchCalls(ctx, rpcReqs, tStart, rpcResps, respSizes, err)
+		}()
+	}
+
+	if c.settings.tracer != nil {
+		var span Span
+		ctx, span = c.startBatchSpan(ctx, len(rpcReqs))
+		defer func() {
+			c.endBatchSpan(span, err)
+		}()
+	}
+
+	for _, rpcReq := range rpcReqs {
+		if !rpcReq.HasAllRootFields() {
+			return nil, errors.New(ErrRpcRequestIsMalformed)
+		}
+
+		err = rpcReq.CheckProtocolVersion()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var httpReq *http.Request
+	httpReq, err = c.newHttpRequestWithBody(ctx, rpcReqs)
+	if err != nil {
+		return nil, err
+	}
+
+	var httpResp *http.Response
+	httpResp, err = c.doHttpRequest(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		derr := httpResp.Body.Close()
+		if derr != nil {
+			err = ae.Combine(err, derr)
+		}
+	}()
+
+	// A server rejecting the whole batch responds with a single object.
+	var body json.RawMessage
+	err = json.NewDecoder(httpResp.Body).Decode(&body)
+	if err != nil {
+		return nil, err
+	}
+
+	isArray, _ := peekIsJsonArray(io.NopCloser(bytes.NewReader(body)))
+	if !isArray {
+		var rpcResp *RpcResponseRaw
+		rpcResp, err = c.decodeRpcResponse(body)
+		if err != nil {
+			return nil, err
+		}
+		if rpcResp.hasError() {
+			return nil, fmt.Errorf("%s: %w", ErrBatchIsRejected, rpcResp.Error.AsError())
+		}
+		return nil, errors.New(ErrBatchIsRejected)
+	}
+
+	var items []json.RawMessage
+	err = json.Unmarshal(body, &items)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcResps = make([]*RpcResponseRaw, 0, len(items))
+	for _, item := range items {
+		var rpcResp *RpcResponseRaw
+		rpcResp, err = c.decodeRpcResponse(item)
+		if err != nil {
+			return nil, err
+		}
+		rpcResps = append(rpcResps, rpcResp)
+
+		if rpcResp.Id != nil {
+			respSizes[*rpcResp.Id] = int64(len(item))
+		}
+	}
+
+	return rpcResps, nil
+}
+
+// finishBatchCalls journals and measures each function call of the batch as a
+// separate call. Sizes of a call are sizes of its JSON texts in the batch.
+func (c *Client) finishBatchCalls(ctx context.Context, rpcReqs []*RpcRequest, tStart time.Time, rpcResps []*RpcResponseRaw, respSizes map[string]int64, err error) {
+	respsById := make(map[string]*RpcResponseRaw, len(rpcResps))
+	for _, rpcResp := range rpcResps {
+		if rpcResp.Id != nil {
+			respsById[*rpcResp.Id] = rpcResp
+		}
+	}
+
+	for _, rpcReq := range rpcReqs {
+		var rpcResp *RpcResponseRaw
+		var respSize int64
+		if rpcReq.Id != nil {
+			rpcResp = respsById[*rpcReq.Id]
+			respSize = respSizes[*rpcReq.Id]
+		}
+
+		callErr := err
+		if (callErr == nil) && (rpcResp == nil) {
+			callErr = errors.New(ErrBatchResponseIsMissing)
+		}
+
+		if c.settings.logger != nil {
+			c.writeLog(ctx, rpcReq, tStart, &rpcResp, &callErr)
+		}
+
+		if c.settings.metrics != nil {
+			var reqSize int64
+			data, merr := json.Marshal(rpcReq)
+			if merr == nil {
+				reqSize = int64(len(data))
+			}
+
+			c.settings.metrics.endClientCall(getRequestMethod(rpcReq), getCallOutcome(rpcResp, callErr), time.Since(tStart), reqSize, respSize)
+		}
+	}
+}
+
+// readRpcResponse reads a single raw response.
+func (c *Client) readRpcResponse(r io.Reader) (rpcResp *RpcResponseRaw, err error) {
+	var body json.RawMessage
+	err = json.NewDecoder(r).Decode(&body)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.decodeRpcResponse(body)
+}
+
+// decodeRpcResponse decodes a single raw response. Unknown fields are an
+// error unless the client is in the lenient envelope mode, where they are
+// captured.
+func (c *Client) decodeRpcResponse(data []byte) (rpcResp *RpcResponseRaw, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if !c.settings.isEnvelopeLenient {
+		decoder.DisallowUnknownFields()
+	}
+	decoder.UseNumber()
+	err = decoder.Decode(&rpcResp)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.settings.isEnvelopeLenient && (rpcResp != nil) {
+		err = rpcResp.captureUnknownFields(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rpcResp, nil
+}
+
 // CallRaw takes a raw request, performs the request, returns a raw response.
 func (c *Client) CallRaw(ctx context.Context, rpcReq *RpcRequest) (rpcResp *RpcResponseRaw, err error) {
-	c.guard.Lock()
-	defer c.guard.Unlock()
+	defer c.lockCall()()
 
 	c.incRequestsCount()
 	return c.call(ctx, rpcReq)
@@ -114,6 +608,33 @@ func (c *Client) CallRaw(ctx context.Context, rpcReq *RpcRequest) (rpcResp *RpcR
 // call makes a request to the RPC server, receives a response and returns it.
 // Result object is set as an empty interface ('any' type).
 func (c *Client) call(ctx context.Context, rpcReq *RpcRequest) (rpcResp *RpcResponseRaw, err error) {
+	tStart := time.Now()
+	if c.settings.logger != nil {
+		defer c.writeLog(ctx, rpcReq, tStart, &rpcResp, &err)
+	}
+
+	var reqSize int64
+	var respBodyCounter *countingReadCloser
+	if c.settings.metrics != nil {
+		method := getRequestMethod(rpcReq)
+		c.settings.metrics.beginClientCall(method)
+		defer func() {
+			var respSize int64
+			if respBodyCounter != nil {
+				respSize = respBodyCounter.n
+			}
+			c.settings.metrics.endClientCall(method, getCallOutcome(rpcResp, err), time.Since(tStart), reqSize, respSize)
+		}()
+	}
+
+	if c.settings.tracer != nil {
+		var span Span
+		ctx, span = c.startSpan(ctx, rpcReq)
+		defer func() {
+			c.endSpan(span, rpcResp, err)
+		}()
+	}
+
 	if !rpcReq.HasAllRootFields() {
 		return nil, errors.New(ErrRpcRequestIsMalformed)
 	}
@@ -129,15 +650,10 @@ func (c *Client) call(ctx context.Context, rpcReq *RpcRequest) (rpcResp *RpcResp
 		return nil, err
 	}
 
-	var httpClient *http.Client
-	if c.settings.httpClient != nil {
-		httpClient = c.settings.httpClient
-	} else {
-		httpClient = new(http.Client)
-	}
+	reqSize = httpReq.ContentLength
 
 	var httpResp *http.Response
-	httpResp, err = httpClient.Do(httpReq)
+	httpResp, err = c.doHttpRequest(httpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -148,10 +664,9 @@ func (c *Client) call(ctx context.Context, rpcReq *RpcRequest) (rpcResp *RpcResp
 		}
 	}()
 
-	decoder := json.NewDecoder(httpResp.Body)
-	decoder.DisallowUnknownFields()
-	decoder.UseNumber()
-	err = decoder.Decode(&rpcResp)
+	respBodyCounter = newCountingReadCloser(httpResp.Body)
+
+	rpcResp, err = c.readRpcResponse(respBodyCounter)
 	if err != nil {
 		return nil, err
 	}
@@ -159,50 +674,303 @@ func (c *Client) call(ctx context.Context, rpcReq *RpcRequest) (rpcResp *RpcResp
 	return rpcResp, nil
 }
 
+// getHttpClient returns the HTTP client used for requests.
+func (c *Client) getHttpClient() *http.Client {
+	if c.settings.httpClient != nil {
+		return c.settings.httpClient
+	}
+
+	return new(http.Client)
+}
+
+// doHttpRequest sends the HTTP request and returns the HTTP response.
+// Compressed body of the response is decompressed. Body encoded by a binary
+// codec is transcoded into JSON.
+func (c *Client) doHttpRequest(httpReq *http.Request) (httpResp *http.Response, err error) {
+	httpResp, err = c.getHttpClient().Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.ReadCloser
+	body, err = newDecodedBody(httpResp.Header.Get(header.HttpHeaderContentEncoding), httpResp.Body, c.settings.getMaxDecompressedResponseSize())
+	if err != nil {
+		return nil, ae.Combine(err, httpResp.Body.Close())
+	}
+	httpResp.Body = body
+
+	codec := c.settings.getCodec()
+	if !isJsonCodec(codec) && (httpResp.Header.Get(header.HttpHeaderContentType) == codec.ContentType()) {
+		httpResp.Body, err = transcodeToJson(codec, httpResp.Body)
+		if err != nil {
+			return nil, err
+		}
+		httpResp.Header.Set(header.HttpHeaderContentType, mime.TypeApplicationJson)
+	}
+
+	return httpResp, nil
+}
+
 // newHttpRequest takes an RPC request object and creates an HTTP request for
 // the RPC server.
 func (c *Client) newHttpRequest(ctx context.Context, rpcReq *RpcRequest) (hr *http.Request, err error) {
+	return c.newHttpRequestWithBody(ctx, rpcReq)
+}
+
+// encodeJson encodes the body object as JSON using the encoding profile of
+// the client. RPC requests are written without reflection and their
+// parameters are embedded as is.
+func (c *Client) encodeJson(body any) (data []byte, err error) {
 	var buf bytes.Buffer
-	enc := json.NewEncoder(&buf)
-	enc.SetIndent("", "\t")
-	enc.SetEscapeHTML(c.settings.useHtmlEscaping)
-	err = enc.Encode(rpcReq)
+	escapeHtml := c.settings.useHtmlEscaping
+
+	switch x := body.(type) {
+	case *RpcRequest:
+		err = appendRpcRequest(&buf, x, escapeHtml)
+
+	case []*RpcRequest:
+		buf.WriteByte('[')
+		for i, rr := range x {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			err = appendRpcRequest(&buf, rr, escapeHtml)
+			if err != nil {
+				break
+			}
+		}
+		buf.WriteByte(']')
+
+	default:
+		err = appendJsonValue(&buf, body, escapeHtml)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return c.settings.getJsonProfile().finish(&buf)
+}
+
+// newHttpRequestWithBody creates an HTTP request for the RPC server. The body
+// object is encoded using the codec of the client. It may be a single RPC
+// request or a batch of them.
+func (c *Client) newHttpRequestWithBody(ctx context.Context, body any) (hr *http.Request, err error) {
+	codec := c.settings.getCodec()
+
+	var data []byte
+	if isJsonCodec(codec) {
+		data, err = c.encodeJson(body)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		data, err = codec.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	isCompressed := (len(c.settings.requestEncoding) > 0) &&
+		(uint(len(data)) >= c.settings.requestCompressionThreshold)
+	if isCompressed {
+		data, err = compress(c.settings.requestEncoding, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hr, err = http.NewRequestWithContext(ctx, http.MethodPost, c.getUrl(), bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
 
-	dsn := fmt.Sprintf(
+	hr.Header.Set(header.HttpHeaderContentType, codec.ContentType())
+	hr.Header.Set(header.HttpHeaderAccept, codec.ContentType())
+	if isCompressed {
+		hr.Header.Set(header.HttpHeaderContentEncoding, c.settings.requestEncoding)
+	}
+	if c.settings.isResponseCompressionEnabled {
+		hr.Header.Set(header.HttpHeaderAcceptEncoding, acceptedContentEncodings)
+	}
+
+	// Propagate the trace context.
+	sc, ok := SpanContextFromContext(ctx)
+	if ok && sc.IsValid() {
+		hr.Header.Set(HttpHeaderTraceParent, sc.TraceParent())
+		if len(sc.TraceState) > 0 {
+			hr.Header.Set(HttpHeaderTraceState, sc.TraceState)
+		}
+	}
+
+	// Propagate the locales.
+	locales, ok := LocaleFromContext(ctx)
+	if ok && (len(locales) > 0) {
+		hr.Header.Set(header.HttpHeaderAcceptLanguage, locales)
+	}
+
+	// Set the custom HTTP headers for those who need them.
+	for hdrName, hdrValue := range c.settings.httpHeaders {
+		hr.Header.Set(hdrName, hdrValue)
+	}
+
+	return hr, nil
+}
+
+// getUrl returns the URL of the RPC server.
+func (c *Client) getUrl() string {
+	return fmt.Sprintf(
 		"%s://%s:%d%s",
 		c.settings.schema,
 		c.settings.host,
 		c.settings.port,
 		c.settings.path,
 	)
+}
+
+// writeLog journals a function call. It is called after the call finishes,
+// so the response and the error are passed by reference.
+func (c *Client) writeLog(ctx context.Context, rpcReq *RpcRequest, tStart time.Time, rpcResp **RpcResponseRaw, err *error) {
+	attrs := make([]slog.Attr, 0, 6)
+	if rpcReq.Id != nil {
+		attrs = append(attrs, slog.String(LogAttr_RequestId, *rpcReq.Id))
+	}
+	if rpcReq.Method != nil {
+		attrs = append(attrs, slog.String(LogAttr_Method, *rpcReq.Method))
+	}
+	attrs = append(attrs,
+		slog.String(LogAttr_Url, c.getUrl()),
+		slog.Duration(LogAttr_Duration, time.Since(tStart)),
+	)
+
+	var level slog.Level
+	switch {
+	case *err != nil:
+		level = slog.LevelError
+		attrs = append(attrs,
+			slog.String(LogAttr_Outcome, AccessLogOutcomeFailure),
+			slog.String(LogAttr_Error, (*err).Error()),
+		)
+	case (*rpcResp).hasError():
+		level = slog.LevelWarn
+		attrs = append(attrs,
+			slog.String(LogAttr_Outcome, AccessLogOutcomeFailure),
+			slog.Int(LogAttr_ErrorCode, (*rpcResp).Error.Code.Int()),
+		)
+	default:
+		level = slog.LevelInfo
+		attrs = append(attrs, slog.String(LogAttr_Outcome, AccessLogOutcomeSuccess))
+	}
+
+	if (*err == nil) && (len((*rpcResp).UnknownFields) > 0) {
+		attrs = append(attrs, slog.Any(LogAttr_UnknownFields, (*rpcResp).getUnknownFieldNames()))
+	}
+
+	c.settings.logger.LogAttrs(ctx, level, AccessLogMessage, attrs...)
+}
+
+// startSpan starts a span of the function call. The parent span context is
+// taken from the context. The returned context contains the new span context
+// which is propagated to the RPC server.
+func (c *Client) startSpan(ctx context.Context, rpcReq *RpcRequest) (context.Context, Span) {
+	parent, _ := SpanContextFromContext(ctx)
+
+	name := getRequestMethod(rpcReq)
+	span := c.settings.tracer.StartSpan(name, parent)
+	span.SetAttribute(SpanAttr_RpcSystem, SpanAttrValue_RpcSystem)
+	span.SetAttribute(SpanAttr_Method, name)
+	if rpcReq.Id != nil {
+		span.SetAttribute(SpanAttr_RequestId, *rpcReq.Id)
+	}
+
+	return ContextWithSpanContext(ctx, span.SpanContext()), span
+}
+
+// startBatchSpan starts a span of the batch of function calls. The returned
+// context contains the new span context which is propagated to the RPC
+// server.
+func (c *Client) startBatchSpan(ctx context.Context, callsCount int) (context.Context, Span) {
+	parent, _ := SpanContextFromContext(ctx)
+
+	span := c.settings.tracer.StartSpan(SpanNameBatch, parent)
+	span.SetAttribute(SpanAttr_RpcSystem, SpanAttrValue_RpcSystem)
+	span.SetAttribute(SpanAttr_BatchSize, callsCount)
+
+	return ContextWithSpanContext(ctx, span.SpanContext()), span
+}
 
-	hr, err = http.NewRequestWithContext(ctx, http.MethodPost, dsn, &buf)
+// endBatchSpan sets the outcome of the batch of function calls and ends the
+// span. Outcomes of single calls are not known to the span.
+func (c *Client) endBatchSpan(span Span, err error) {
+	outcome := MetricOutcome_Success
 	if err != nil {
-		return nil, err
+		outcome = MetricOutcome_TransportError
 	}
+	span.SetAttribute(SpanAttr_Outcome, outcome)
 
-	hr.Header.Set(header.HttpHeaderContentType, mime.TypeApplicationJson)
-	hr.Header.Set(header.HttpHeaderAccept, mime.TypeApplicationJson)
+	span.End()
+}
 
-	// Set the custom HTTP headers for those who need them.
-	for hdrName, hdrValue := range c.settings.httpHeaders {
-		hr.Header.Set(hdrName, hdrValue)
+// endSpan sets the outcome of the function call and ends the span.
+func (c *Client) endSpan(span Span, rpcResp *RpcResponseRaw, err error) {
+	span.SetAttribute(SpanAttr_Outcome, getCallOutcome(rpcResp, err))
+	if (err == nil) && rpcResp.hasError() {
+		span.SetAttribute(SpanAttr_ErrorCode, rpcResp.Error.Code.Int())
 	}
 
-	return hr, nil
+	span.End()
+}
+
+// getRequestMethod returns the name of the requested function used in
+// metrics and traces.
+func getRequestMethod(rpcReq *RpcRequest) string {
+	if rpcReq.Method == nil {
+		return MetricsUnknownMethod
+	}
+
+	return *rpcReq.Method
+}
+
+// getCallOutcome returns the outcome of a function call used in metrics and
+// traces.
+func getCallOutcome(rpcResp *RpcResponseRaw, err error) string {
+	switch {
+	case err != nil:
+		return MetricOutcome_TransportError
+	case rpcResp.hasError():
+		return MetricOutcome_Failure
+	default:
+		return MetricOutcome_Success
+	}
 }
 
 // GetRequestsCount returns the counter of performed calls (requests) to the RPC
 // server.
 func (c *Client) GetRequestsCount() (requestsCount string) {
+	c.countersGuard.Lock()
+	defer c.countersGuard.Unlock()
+
 	return c.requestsCount.String()
 }
 
 // incRequestsCount increases the counter of performed calls (requests) to the
-// RPC server by one.
-func (c *Client) incRequestsCount() {
+// RPC server by one and returns its new value.
+func (c *Client) incRequestsCount() (requestsCount string) {
+	c.countersGuard.Lock()
+	defer c.countersGuard.Unlock()
+
 	c.requestsCount.Add(c.requestsCount, c.requestsCountOne)
+	return c.requestsCount.String()
+}
+
+// lockCall locks the client for a function call and returns the function
+// unlocking it. Calls over HTTP are serialised, while calls over a message
+// transport are multiplexed on its connection, so they are not locked and
+// may be in flight at the same time.
+func (c *Client) lockCall() (unlock func()) {
+	if c.settings.isMessageTransport {
+		return func() {}
+	}
+
+	c.guard.Lock()
+	return c.guard.Unlock
 }
@@ -2,6 +2,8 @@ package jrm1
 
 import (
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 )
 
@@ -9,6 +11,10 @@ const (
 	ErrClientSettingsError = "error is client settings"
 )
 
+// acceptedContentEncodings is a value of the 'Accept-Encoding' HTTP header
+// sent by the client accepting compressed responses.
+const acceptedContentEncodings = ContentEncoding_Gzip + ", " + ContentEncoding_Deflate
+
 // ClientSettings are settings of an RPC client.
 type ClientSettings struct {
 	// Target server URL schema.
@@ -33,6 +39,51 @@ type ClientSettings struct {
 
 	// If enabled, some of HTML entities will be escaped during JSON encoding.
 	useHtmlEscaping bool
+
+	// Structured logger. When set, the client journals every function call.
+	logger *slog.Logger
+
+	// Set of metrics. When set, the client collects metrics of function calls.
+	metrics *Metrics
+
+	// Tracer. When set, the client creates a span for every function call.
+	tracer Tracer
+
+	// Content encoding used to compress bodies of requests. When empty,
+	// requests are not compressed.
+	requestEncoding string
+
+	// Minimum size of a request body in bytes which is compressed.
+	requestCompressionThreshold uint
+
+	// If enabled, the client accepts compressed responses.
+	isResponseCompressionEnabled bool
+
+	// Maximum size of a decompressed response body in bytes. When not set,
+	// the default size is used.
+	maxDecompressedResponseSize uint
+
+	// Codec of requests and responses. When not set, JSON is used.
+	codec Codec
+
+	// Profile of JSON encoding of requests. When not set, compact JSON is
+	// used.
+	jsonProfile JsonProfile
+
+	// If enabled, unknown fields of response envelopes are not an error.
+	// They are captured into the 'UnknownFields' map of a raw response.
+	isEnvelopeLenient bool
+
+	// If enabled, unknown fields of results are not an error.
+	isResultLenient bool
+
+	// Registry of Go errors used to convert RPC errors received by the
+	// 'Invoke' method.
+	errorRegistry *ErrorRegistry
+
+	// Flag showing that function calls are sent over a message transport,
+	// e.g. WebSocket, which uses JSON regardless of the codec.
+	isMessageTransport bool
 }
 
 // NewClientSettings is a constructor of an RPC client settings.
@@ -66,3 +117,152 @@ func (cs *ClientSettings) Check() (err error) {
 
 	return nil
 }
+
+// SetLogger sets the structured logger which journals every function call.
+// Null logger disables the journal.
+func (cs *ClientSettings) SetLogger(logger *slog.Logger) {
+	cs.logger = logger
+}
+
+// SetMetrics sets the set of metrics where the client collects metrics of
+// function calls. Null set disables the collection.
+func (cs *ClientSettings) SetMetrics(metrics *Metrics) {
+	cs.metrics = metrics
+}
+
+// SetTracer sets the tracer which creates a span for every function call.
+// Null tracer disables creation of spans, while the trace context passed to
+// the client in a context is still propagated to the RPC server.
+func (cs *ClientSettings) SetTracer(tracer Tracer) {
+	cs.tracer = tracer
+}
+
+// SetRequestCompression makes the client compress bodies of requests using
+// the specified content encoding, 'gzip' or 'deflate', when their size
+// reaches the threshold. Empty encoding disables compression. Message
+// transports, e.g. WebSocket, send requests without compression.
+func (cs *ClientSettings) SetRequestCompression(encoding string, threshold uint) (err error) {
+	if (len(encoding) > 0) && !isContentEncodingSupported(encoding) {
+		return fmt.Errorf(ErrFContentEncodingIsNotSupported, encoding)
+	}
+
+	cs.requestEncoding = encoding
+	cs.requestCompressionThreshold = threshold
+
+	return nil
+}
+
+// SetResponseCompression makes the client advertise the supported content
+// encodings, 'gzip' and 'deflate', and decompress compressed responses.
+func (cs *ClientSettings) SetResponseCompression(isEnabled bool) {
+	cs.isResponseCompressionEnabled = isEnabled
+}
+
+// SetMaxDecompressedResponseSize sets the maximum size of a decompressed
+// response body in bytes. Responses exceeding it are not readable, which
+// protects from decompression bombs. Zero size restores the default one.
+func (cs *ClientSettings) SetMaxDecompressedResponseSize(size uint) {
+	cs.maxDecompressedResponseSize = size
+}
+
+// getMaxDecompressedResponseSize returns the maximum size of a decompressed
+// response body in bytes.
+func (cs *ClientSettings) getMaxDecompressedResponseSize() int64 {
+	if cs.maxDecompressedResponseSize == 0 {
+		return DefaultMaxDecompressedSize
+	}
+
+	return int64(cs.maxDecompressedResponseSize)
+}
+
+// SetCodec sets the codec of requests and responses, e.g. MessagePack or CBOR.
+// The codec must be supported by the RPC server. Null codec restores the
+// default one, i.e. JSON. Message transports, e.g. WebSocket, send requests
+// using JSON. Streamed responses use JSON regardless of the codec.
+func (cs *ClientSettings) SetCodec(codec Codec) {
+	cs.codec = codec
+}
+
+// getCodec returns the codec of requests and responses.
+func (cs *ClientSettings) getCodec() Codec {
+	if (cs.codec == nil) || cs.isMessageTransport {
+		return NewJsonCodec()
+	}
+
+	return cs.codec
+}
+
+// SetJsonProfile sets the profile of JSON encoding of requests. Requests are
+// compact by default, while the pretty profile indents them, which is useful
+// for debugging.
+func (cs *ClientSettings) SetJsonProfile(profile JsonProfile) (err error) {
+	err = profile.Check()
+	if err != nil {
+		return err
+	}
+
+	cs.jsonProfile = profile
+
+	return nil
+}
+
+// getJsonProfile returns the profile of JSON encoding of requests.
+func (cs *ClientSettings) getJsonProfile() JsonProfile {
+	if len(cs.jsonProfile) == 0 {
+		return JsonProfile_Default
+	}
+
+	return cs.jsonProfile
+}
+
+// SetCompatibilityMode sets the strictness of decoding of responses. By
+// default, the client fails when a response has a field unknown to it. This
+// protects from mistakes, while a new field added by the RPC server breaks
+// deployed clients. In the lenient envelope mode, unknown fields of response
+// envelopes are captured into the 'UnknownFields' map of a raw response and
+// are written into the journal of the client. In the lenient result mode,
+// unknown fields of results, including items of streamed results, are
+// ignored.
+func (cs *ClientSettings) SetCompatibilityMode(isEnvelopeLenient bool, isResultLenient bool) {
+	cs.isEnvelopeLenient = isEnvelopeLenient
+	cs.isResultLenient = isResultLenient
+}
+
+// SetErrorRegistry sets the registry of Go errors. RPC errors returned by the
+// 'Invoke' method of the client match the registered sentinel errors. Null
+// registry has no registered errors.
+func (cs *ClientSettings) SetErrorRegistry(er *ErrorRegistry) {
+	cs.errorRegistry = er
+}
+
+// SetWebSocketTransport makes the client send function calls over a
+// persistent WebSocket connection using the specified transport. Custom HTTP
+// client is replaced.
+func (cs *ClientSettings) SetWebSocketTransport(wst *WebSocketTransport) {
+	cs.httpClient = &http.Client{Transport: wst}
+	cs.isMessageTransport = true
+}
+
+// SetSocketTransport makes the client send function calls over a persistent
+// socket connection using the specified transport. Custom HTTP client is
+// replaced.
+func (cs *ClientSettings) SetSocketTransport(st *SocketTransport) {
+	cs.httpClient = &http.Client{Transport: st}
+	cs.isMessageTransport = true
+}
+
+// SetStdioTransport makes the client send function calls over a pair of
+// streams, e.g. standard input and output of a child process, using the
+// specified transport. Custom HTTP client is replaced.
+func (cs *ClientSettings) SetStdioTransport(st *StdioTransport) {
+	cs.httpClient = &http.Client{Transport: st}
+	cs.isMessageTransport = true
+}
+
+// SetInProcessTransport makes the client pass function calls directly to an
+// RPC processor of the same program using the specified transport. Custom
+// HTTP client is replaced.
+func (cs *ClientSettings) SetInProcessTransport(ipt *InProcessTransport) {
+	cs.httpClient = &http.Client{Transport: ipt}
+	cs.isMessageTransport = false
+}
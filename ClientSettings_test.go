@@ -1,6 +1,10 @@
 package jrm1
 
 import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/vault-thirteen/auxie/tester"
@@ -52,3 +56,208 @@ func Test_ClientSettings_Check(t *testing.T) {
 	err = cs.Check()
 	aTest.MustBeNoError(err)
 }
+
+func Test_ClientSettings_SetLogger(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	cs, err := NewClientSettings("http", "localhost", 80, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(cs.logger, (*slog.Logger)(nil))
+	logger := slog.New(slog.DiscardHandler)
+	cs.SetLogger(logger)
+	aTest.MustBeEqual(cs.logger, logger)
+}
+
+func Test_ClientSettings_SetMetrics(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	cs, err := NewClientSettings("http", "localhost", 80, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(cs.metrics, (*Metrics)(nil))
+	m := NewMetrics(nil, nil)
+	cs.SetMetrics(m)
+	aTest.MustBeEqual(cs.metrics, m)
+}
+
+func Test_ClientSettings_SetTracer(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	cs, err := NewClientSettings("http", "localhost", 80, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(cs.tracer, nil)
+	tracer := NewMemoryTracer()
+	cs.SetTracer(tracer)
+	aTest.MustBeEqual(cs.tracer, Tracer(tracer))
+}
+
+func Test_ClientSettings_SetWebSocketTransport(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	cs, err := NewClientSettings("http", "localhost", 80, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+	wst := NewWebSocketTransport()
+	cs.SetWebSocketTransport(wst)
+	aTest.MustBeEqual(cs.httpClient.Transport, http.RoundTripper(wst))
+}
+
+func Test_ClientSettings_SetSocketTransport(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	cs, err := NewClientSettings("http", "localhost", 80, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+	st := NewSocketTransport("tcp", "localhost:2000")
+	cs.SetSocketTransport(st)
+	aTest.MustBeEqual(cs.httpClient.Transport, http.RoundTripper(st))
+}
+
+func Test_ClientSettings_SetInProcessTransport(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	cs, err := NewClientSettings("http", "localhost", 80, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+	p, err := NewProcessor(&ProcessorSettings{})
+	aTest.MustBeNoError(err)
+	ipt := NewInProcessTransport(p)
+	cs.SetInProcessTransport(ipt)
+	aTest.MustBeEqual(cs.httpClient.Transport, http.RoundTripper(ipt))
+}
+
+func Test_ClientSettings_SetStdioTransport(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	cs, err := NewClientSettings("http", "localhost", 80, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+	st := NewStdioTransport(strings.NewReader(""), io.Discard)
+	cs.SetStdioTransport(st)
+	aTest.MustBeEqual(cs.httpClient.Transport, http.RoundTripper(st))
+}
+
+func Test_ClientSettings_SetRequestCompression(t *testing.T) {
+	aTest := tester.New(t)
+
+	cs, err := NewClientSettings("http", "localhost", 80, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Supported encoding.
+	err = cs.SetRequestCompression(ContentEncoding_Gzip, 100)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(cs.requestEncoding, ContentEncoding_Gzip)
+	aTest.MustBeEqual(cs.requestCompressionThreshold, uint(100))
+
+	// Test #2. Unsupported encoding.
+	err = cs.SetRequestCompression("br", 0)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(cs.requestEncoding, ContentEncoding_Gzip)
+
+	// Test #3. Disabled compression.
+	err = cs.SetRequestCompression("", 0)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(cs.requestEncoding, "")
+}
+
+func Test_ClientSettings_SetResponseCompression(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	cs, err := NewClientSettings("http", "localhost", 80, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+	cs.SetResponseCompression(true)
+	aTest.MustBeEqual(cs.isResponseCompressionEnabled, true)
+}
+
+func Test_ClientSettings_SetMaxDecompressedResponseSize(t *testing.T) {
+	aTest := tester.New(t)
+
+	cs, err := NewClientSettings("http", "localhost", 80, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Default size.
+	aTest.MustBeEqual(cs.getMaxDecompressedResponseSize(), int64(DefaultMaxDecompressedSize))
+
+	// Test #2. Custom size.
+	cs.SetMaxDecompressedResponseSize(10)
+	aTest.MustBeEqual(cs.getMaxDecompressedResponseSize(), int64(10))
+
+	// Test #3. Default size is restored.
+	cs.SetMaxDecompressedResponseSize(0)
+	aTest.MustBeEqual(cs.getMaxDecompressedResponseSize(), int64(DefaultMaxDecompressedSize))
+}
+
+func Test_ClientSettings_SetJsonProfile(t *testing.T) {
+	aTest := tester.New(t)
+
+	cs, err := NewClientSettings("http", "localhost", 80, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Default profile.
+	aTest.MustBeEqual(cs.getJsonProfile(), JsonProfile_Compact)
+
+	// Test #2. Pretty profile.
+	err = cs.SetJsonProfile(JsonProfile_Pretty)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(cs.getJsonProfile(), JsonProfile_Pretty)
+
+	// Test #3. Negative.
+	err = cs.SetJsonProfile("indented")
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(cs.getJsonProfile(), JsonProfile_Pretty)
+}
+
+func Test_ClientSettings_SetCompatibilityMode(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	cs, err := NewClientSettings("http", "localhost", 80, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(cs.isEnvelopeLenient, false)
+	aTest.MustBeEqual(cs.isResultLenient, false)
+	cs.SetCompatibilityMode(true, false)
+	aTest.MustBeEqual(cs.isEnvelopeLenient, true)
+	aTest.MustBeEqual(cs.isResultLenient, false)
+}
+
+func Test_ClientSettings_SetErrorRegistry(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	cs, err := NewClientSettings("http", "localhost", 80, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+	er := NewErrorRegistry()
+	cs.SetErrorRegistry(er)
+	aTest.MustBeEqual(cs.errorRegistry, er)
+}
+
+func Test_ClientSettings_SetCodec(t *testing.T) {
+	aTest := tester.New(t)
+
+	cs, err := NewClientSettings("http", "localhost", 80, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Default codec.
+	aTest.MustBeEqual(cs.getCodec(), Codec(NewJsonCodec()))
+
+	// Test #2. Binary codec.
+	cs.SetCodec(NewCborCodec())
+	aTest.MustBeEqual(cs.getCodec(), Codec(NewCborCodec()))
+
+	// Test #3. Message transport uses JSON.
+	cs.SetSocketTransport(NewSocketTransport("tcp", "localhost:2000"))
+	aTest.MustBeEqual(cs.getCodec(), Codec(NewJsonCodec()))
+
+	// Test #4. In-process transport uses the codec.
+	p, err := NewProcessor(&ProcessorSettings{})
+	aTest.MustBeNoError(err)
+	cs.SetInProcessTransport(NewInProcessTransport(p))
+	aTest.MustBeEqual(cs.getCodec(), Codec(NewCborCodec()))
+
+	// Test #5. Default codec is restored.
+	cs.SetCodec(nil)
+	aTest.MustBeEqual(cs.getCodec(), Codec(NewJsonCodec()))
+}
@@ -1,11 +1,17 @@
 package jrm1
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	mime "github.com/vault-thirteen/auxie/MIME"
 	"github.com/vault-thirteen/auxie/header"
@@ -66,7 +72,46 @@ func Test_Client_newHttpRequest(t *testing.T) {
 	var reqBody []byte
 	reqBody, err = io.ReadAll(hr.Body)
 	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(reqBody), "{\"jsonrpc\":\"M1\",\"id\":\"123\",\"method\":\"m\",\"params\":{}}\n")
+
+	// Test #2. Pretty profile.
+	aTest.MustBeNoError(cs.SetJsonProfile(JsonProfile_Pretty))
+	hr, err = c.newHttpRequest(context.Background(), rpcReq)
+	aTest.MustBeNoError(err)
+	reqBody, err = io.ReadAll(hr.Body)
+	aTest.MustBeNoError(err)
 	aTest.MustBeEqual(string(reqBody), "{\n\t\"jsonrpc\": \"M1\",\n\t\"id\": \"123\",\n\t\"method\": \"m\",\n\t\"params\": {}\n}\n")
+
+	// Test #3. Parameters are embedded as compact text.
+	aTest.MustBeNoError(cs.SetJsonProfile(JsonProfile_Compact))
+	p = json.RawMessage("{ \"a\" : [1, \"<b>\"] }")
+	var rpcReqs = []*RpcRequest{rpcReq, {Method: &m}}
+	hr, err = c.newHttpRequestWithBody(context.Background(), rpcReqs)
+	aTest.MustBeNoError(err)
+	reqBody, err = io.ReadAll(hr.Body)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(reqBody), `[{"jsonrpc":"M1","id":"123","method":"m","params":{"a":[1,"\u003cb\u003e"]}},{"jsonrpc":null,"id":null,"method":"m","params":null}]`+"\n")
+}
+
+func Test_Client_newRpcRequest(t *testing.T) {
+	aTest := tester.New(t)
+	var cs *ClientSettings
+	var c *Client
+	var rpcReq *RpcRequest
+	var err error
+
+	// Test #1. Parameters are compact.
+	cs, err = NewClientSettings("http", "localhost", 80, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+	c, err = NewClient(cs)
+	aTest.MustBeNoError(err)
+	rpcReq, err = c.newRpcRequest("m", map[string]any{"a": []int{1, 2}, "b": "<c>"})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(*rpcReq.Parameters), `{"a":[1,2],"b":"<c>"}`)
+
+	// Test #2. Parameters which can not be encoded.
+	_, err = c.newRpcRequest("m", func() {})
+	aTest.MustBeAnError(err)
 }
 
 func Test_Client_call(t *testing.T) {
@@ -163,3 +208,793 @@ func Test_Client_incRequestsCount(t *testing.T) {
 	c.incRequestsCount()
 	aTest.MustBeEqual(c.requestsCount.String(), "1")
 }
+
+func Test_Client_writeLog(t *testing.T) {
+	aTest := tester.New(t)
+	var cs *ClientSettings
+	var c *Client
+	var err error
+	var logBuf bytes.Buffer
+	var record map[string]any
+
+	cs, err = NewClientSettings("http", "localhost", 80, "/", nil, nil, true)
+	aTest.MustBeNoError(err)
+	cs.SetLogger(slog.New(slog.NewJSONHandler(&logBuf, nil)))
+	c, err = NewClient(cs)
+	aTest.MustBeNoError(err)
+
+	pn := ProtocolNameM1
+	id := "123"
+	m := "m"
+	rpcReq := &RpcRequest{ProtocolName: &pn, Id: &id, Method: &m}
+	var rpcResp *RpcResponseRaw
+
+	// Test #1. Error.
+	err = errors.New("transport error")
+	c.writeLog(context.Background(), rpcReq, time.Now(), &rpcResp, &err)
+	err = json.Unmarshal(logBuf.Bytes(), &record)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(record["level"], "ERROR")
+	aTest.MustBeEqual(record[LogAttr_RequestId], "123")
+	aTest.MustBeEqual(record[LogAttr_Method], "m")
+	aTest.MustBeEqual(record[LogAttr_Url], "http://localhost:80/")
+	aTest.MustBeEqual(record[LogAttr_Outcome], AccessLogOutcomeFailure)
+	aTest.MustBeEqual(record[LogAttr_Error], "transport error")
+
+	// Test #2. RPC error.
+	logBuf.Reset()
+	record = nil
+	err = nil
+	rpcResp = &RpcResponseRaw{Error: &RpcError{Code: 5}}
+	c.writeLog(context.Background(), rpcReq, time.Now(), &rpcResp, &err)
+	err = json.Unmarshal(logBuf.Bytes(), &record)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(record["level"], "WARN")
+	aTest.MustBeEqual(record[LogAttr_ErrorCode], float64(5))
+
+	// Test #3. Success.
+	logBuf.Reset()
+	record = nil
+	rpcResp = &RpcResponseRaw{OK: true}
+	c.writeLog(context.Background(), rpcReq, time.Now(), &rpcResp, &err)
+	err = json.Unmarshal(logBuf.Bytes(), &record)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(record["level"], "INFO")
+	aTest.MustBeEqual(record[LogAttr_Outcome], AccessLogOutcomeSuccess)
+}
+
+func Test_Client_startSpan(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	serverTracer := NewMemoryTracer()
+	ps := &ProcessorSettings{
+		Tracer:        serverTracer,
+		SpanFieldName: &_metaFieldName_Span,
+	}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionExampleTraced)
+	aTest.MustBeNoError(err)
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	// Test #1. Client without a tracer propagates the context.
+	cs, err := _newClientSettingsForUrl(srv.URL)
+	aTest.MustBeNoError(err)
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+	parent, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "")
+	aTest.MustBeNoError(err)
+	ctx := ContextWithSpanContext(context.Background(), parent)
+	var result string
+	_, err = c.Call(ctx, "RpcFunctionExampleTraced", struct{}{}, &result)
+	aTest.MustBeNoError(err)
+	serverSpans := serverTracer.FinishedSpans()
+	aTest.MustBeEqual(len(serverSpans), 1)
+	aTest.MustBeEqual(serverSpans[0].Parent.TraceParent(), parent.TraceParent())
+
+	// Test #2. Client with a tracer creates its own span.
+	clientTracer := NewMemoryTracer()
+	cs.SetTracer(clientTracer)
+	_, err = c.Call(ctx, "RpcFunctionExampleTraced", struct{}{}, &result)
+	aTest.MustBeNoError(err)
+	clientSpans := clientTracer.FinishedSpans()
+	aTest.MustBeEqual(len(clientSpans), 1)
+	aTest.MustBeEqual(clientSpans[0].Parent, parent)
+	aTest.MustBeEqual(clientSpans[0].Attributes[SpanAttr_Outcome], MetricOutcome_Success)
+	aTest.MustBeEqual(clientSpans[0].Attributes[SpanAttr_RequestId], "2")
+	serverSpans = serverTracer.FinishedSpans()
+	aTest.MustBeEqual(len(serverSpans), 2)
+	aTest.MustBeEqual(serverSpans[1].Parent.TraceParent(), clientSpans[0].Context.TraceParent())
+	aTest.MustBeEqual(serverSpans[1].Context.TraceId, parent.TraceId)
+}
+
+func Test_Client_CallBatch(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	ps := &ProcessorSettings{EnableBatches: true}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	cs, err := _newClientSettingsForUrl(srv.URL)
+	aTest.MustBeNoError(err)
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Independent results.
+	calls := []*BatchCall{
+		NewBatchCall("RpcFunctionSum", SumParams{A: 1, B: 2}, new(SumResult)),
+		NewBatchCall("NoSuchFunction", struct{}{}, new(any)),
+		NewBatchCall("RpcFunctionSum", SumParams{A: 200, B: 100}, new(SumResult)),
+		NewBatchCall("RpcFunctionSum", SumParams{A: 5, B: 5}, new(string)),
+	}
+	err = c.CallBatch(context.Background(), calls)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(c.GetRequestsCount(), "4")
+	//
+	aTest.MustBeNoError(calls[0].Err)
+	aTest.MustBeEqual(calls[0].Error, (*RpcError)(nil))
+	aTest.MustBeEqual(calls[0].Result, &SumResult{C: 3})
+	//
+	aTest.MustBeNoError(calls[1].Err)
+	aTest.MustBeEqual(calls[1].Error.Code, RpcErrorCode(RpcErrorCode_UnknownMethod))
+	//
+	aTest.MustBeNoError(calls[2].Err)
+	aTest.MustBeEqual(calls[2].Error.Code, RpcErrorCode(1))
+	//
+	aTest.MustBeAnError(calls[3].Err)
+
+	// Test #2. Batches are disabled on the server.
+	ps = &ProcessorSettings{}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	srv2 := httptest.NewServer(p)
+	defer srv2.Close()
+	cs, err = _newClientSettingsForUrl(srv2.URL)
+	aTest.MustBeNoError(err)
+	c, err = NewClient(cs)
+	aTest.MustBeNoError(err)
+	err = c.CallBatch(context.Background(), []*BatchCall{NewBatchCall("RpcFunctionSum", SumParams{}, new(SumResult))})
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), "batch is rejected: Request is not readable")
+}
+
+func Test_Client_CallBatch_instrumentation(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	metrics := NewMetrics(nil, []float64{10})
+	serverTracer := NewMemoryTracer()
+	ps := &ProcessorSettings{EnableBatches: true, Metrics: metrics, Tracer: serverTracer}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	cs, err := _newClientSettingsForUrl(srv.URL)
+	aTest.MustBeNoError(err)
+	var logBuf bytes.Buffer
+	cs.SetLogger(slog.New(slog.NewJSONHandler(&logBuf, nil)))
+	cs.SetMetrics(metrics)
+	clientTracer := NewMemoryTracer()
+	cs.SetTracer(clientTracer)
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+
+	calls := []*BatchCall{
+		NewBatchCall("RpcFunctionSum", SumParams{A: 1, B: 2}, new(SumResult)),
+		NewBatchCall("NoSuchFunction", struct{}{}, new(any)),
+	}
+	err = c.CallBatch(context.Background(), calls)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Each call is journaled.
+	lines := strings.Split(strings.TrimSpace(logBuf.String()), "\n")
+	aTest.MustBeEqual(len(lines), 2)
+	var record map[string]any
+	err = json.Unmarshal([]byte(lines[1]), &record)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(record[LogAttr_Method], "NoSuchFunction")
+	aTest.MustBeEqual(record[LogAttr_Outcome], AccessLogOutcomeFailure)
+
+	// Test #2. Each call is measured with its own sizes on both sides.
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	exposition := recorder.Body.String()
+	for _, line := range []string{
+		`jrm1_client_calls_total{method="RpcFunctionSum",outcome="success"} 1`,
+		`jrm1_client_calls_total{method="NoSuchFunction",outcome="failure"} 1`,
+		`jrm1_client_calls_in_flight{method="RpcFunctionSum"} 0`,
+		`jrm1_client_request_size_bytes_bucket{method="RpcFunctionSum",le="10"} 0`,
+		`jrm1_client_response_size_bytes_bucket{method="RpcFunctionSum",le="10"} 0`,
+		`jrm1_server_request_size_bytes_bucket{method="RpcFunctionSum",le="10"} 0`,
+		`jrm1_server_response_size_bytes_bucket{method="RpcFunctionSum",le="10"} 0`,
+	} {
+		aTest.MustBeEqual(strings.Contains(exposition, line+"\n"), true)
+	}
+
+	// Test #3. Batch has a single span, which is the parent of the calls.
+	clientSpans := clientTracer.FinishedSpans()
+	aTest.MustBeEqual(len(clientSpans), 1)
+	aTest.MustBeEqual(clientSpans[0].Name, SpanNameBatch)
+	aTest.MustBeEqual(clientSpans[0].Attributes[SpanAttr_BatchSize], 2)
+	aTest.MustBeEqual(clientSpans[0].Attributes[SpanAttr_Outcome], MetricOutcome_Success)
+	serverSpans := serverTracer.FinishedSpans()
+	aTest.MustBeEqual(len(serverSpans), 2)
+	aTest.MustBeEqual(serverSpans[0].Parent.TraceParent(), clientSpans[0].Context.TraceParent())
+}
+
+func Test_Client_Notify(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+	var re *RpcError
+
+	ps := &ProcessorSettings{EnableNotifications: true}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionNotified)
+	aTest.MustBeNoError(err)
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	cs, err := _newClientSettingsForUrl(srv.URL)
+	aTest.MustBeNoError(err)
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Notification is accepted.
+	re, err = c.Notify(context.Background(), "RpcFunctionNotified", "hello")
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+
+	// Test #2. Notification fails the checks.
+	re, err = c.Notify(context.Background(), "NoSuchFunction", struct{}{})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(RpcErrorCode_UnknownMethod))
+
+	p.Stop()
+	aTest.MustBeEqual(strings.TrimSpace(<-_notifiedParams), `"hello"`)
+
+	// Test #3. Notification is refused.
+	re, err = c.Notify(context.Background(), "RpcFunctionNotified", "hello")
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrNotificationIsRefused)
+	aTest.MustBeEqual(c.GetRequestsCount(), "3")
+
+	// Test #4. Notifications are not supported.
+	p, err = NewProcessor(&ProcessorSettings{})
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	srv2 := httptest.NewServer(p)
+	defer srv2.Close()
+	cs, err = _newClientSettingsForUrl(srv2.URL)
+	aTest.MustBeNoError(err)
+	c, err = NewClient(cs)
+	aTest.MustBeNoError(err)
+	re, err = c.Notify(context.Background(), "RpcFunctionSum", SumParams{A: 1, B: 2})
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrNotificationsAreNotSupported)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+}
+
+func Test_Client_CallAsync(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+	var re *RpcError
+
+	ps := &ProcessorSettings{
+		CatchExceptions:     true,
+		EnableJobs:          true,
+		JobContextFieldName: &_metaFieldName_JobContext,
+	}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	defer p.Stop()
+	err = p.AddAsyncFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	err = p.AddAsyncFunc(RpcFunctionWaiter)
+	aTest.MustBeNoError(err)
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	cs, err := _newClientSettingsForUrl(srv.URL)
+	aTest.MustBeNoError(err)
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Job succeeds.
+	result := new(SumResult)
+	re, err = c.CallAsync(context.Background(), "RpcFunctionSum", SumParams{A: 1, B: 2}, result, time.Millisecond)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeEqual(result, &SumResult{C: 3})
+
+	// Test #2. Job fails.
+	re, err = c.CallAsync(context.Background(), "RpcFunctionSum", SumParams{A: 255, B: 2}, new(SumResult), time.Millisecond)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(1))
+
+	// Test #3. Waiting is interrupted.
+	ji, re, err := c.SubmitJob(context.Background(), "RpcFunctionWaiter", struct{}{})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeEqual(ji.Status, JobStatus_Running)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	re, err = c.WaitForJob(ctx, ji.JobId, time.Millisecond, new(any))
+	aTest.MustBeAnError(err)
+
+	// Test #4. Job is cancelled.
+	ji, re, err = c.CancelJob(context.Background(), ji.JobId)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeEqual(ji.Status, JobStatus_Cancelled)
+	re, err = c.WaitForJob(context.Background(), ji.JobId, 0, new(any))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re.Data, ErrJobIsCancelled)
+
+	// Test #5. Job is not found.
+	ji, re, err = c.GetJobStatus(context.Background(), "x")
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(ji, (*JobInfo)(nil))
+	aTest.MustBeEqual(re.Data, ErrJobIsNotFound)
+}
+
+func Test_Client_CallStream(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+	var re *RpcError
+	var rs *ResponseStream
+
+	ps := &ProcessorSettings{}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddStreamFunc(RpcFunctionCounter)
+	aTest.MustBeNoError(err)
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	cs, err := _newClientSettingsForUrl(srv.URL)
+	aTest.MustBeNoError(err)
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+
+	// Test #1. All clear.
+	rs, re, err = c.CallStream(context.Background(), "RpcFunctionCounter", 3)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeEqual(*rs.Id, "1")
+	items := make([]int, 0)
+	for item := range DecodeStreamItems[int](rs) {
+		items = append(items, item)
+	}
+	aTest.MustBeEqual(items, []int{1, 2, 3})
+	re, _, err = rs.Result()
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeNoError(rs.Close())
+
+	// Test #2. Error after items.
+	rs, re, err = c.CallStream(context.Background(), "RpcFunctionCounter", 4)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	re, _, err = rs.Result()
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(1))
+	aTest.MustBeNoError(rs.Close())
+
+	// Test #3. Error before the stream.
+	rs, re, err = c.CallStream(context.Background(), "NoSuchFunction", 1)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(rs, (*ResponseStream)(nil))
+	aTest.MustBeEqual(re.Code, RpcErrorCode(RpcErrorCode_UnknownMethod))
+}
+
+func Test_Client_compression(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	ps := &ProcessorSettings{
+		EnableCompression:    true,
+		CompressionThreshold: 1,
+	}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	err = p.AddStreamFunc(RpcFunctionCounter)
+	aTest.MustBeNoError(err)
+
+	// Encodings seen by the server.
+	encodings := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		encodings <- req.Header.Get(header.HttpHeaderContentEncoding) + "|" + req.Header.Get(header.HttpHeaderAcceptEncoding)
+		p.ServeHTTP(rw, req)
+	}))
+	defer srv.Close()
+
+	cs, err := _newClientSettingsForUrl(srv.URL)
+	aTest.MustBeNoError(err)
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+	var result SumResult
+
+	// Test #1. Compression is disabled, while the standard HTTP transport
+	// accepts "gzip" on its own.
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 1, B: 2}, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(<-encodings, "|gzip")
+	aTest.MustBeEqual(result.C, byte(3))
+
+	// Test #2. Compressed request and response.
+	err = cs.SetRequestCompression(ContentEncoding_Deflate, 0)
+	aTest.MustBeNoError(err)
+	cs.SetResponseCompression(true)
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 2, B: 2}, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(<-encodings, "deflate|gzip, deflate")
+	aTest.MustBeEqual(result.C, byte(4))
+
+	// Test #3. Request below the threshold.
+	err = cs.SetRequestCompression(ContentEncoding_Gzip, 1000)
+	aTest.MustBeNoError(err)
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 3, B: 2}, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(<-encodings, "|gzip, deflate")
+	aTest.MustBeEqual(result.C, byte(5))
+
+	// Test #4. Compressed stream.
+	rs, re, err := c.CallStream(context.Background(), "RpcFunctionCounter", 3)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	<-encodings
+	var items []int
+	for item := range DecodeStreamItems[int](rs) {
+		items = append(items, item)
+	}
+	aTest.MustBeEqual(items, []int{1, 2, 3})
+	aTest.MustBeNoError(rs.Close())
+
+	// Test #5. Compressed request over the in-process transport.
+	cs.SetInProcessTransport(NewInProcessTransport(p))
+	err = cs.SetRequestCompression(ContentEncoding_Gzip, 0)
+	aTest.MustBeNoError(err)
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 4, B: 2}, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(result.C, byte(6))
+
+	// Test #6. Decompressed response is too large.
+	cs.SetMaxDecompressedResponseSize(10)
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 5, B: 2}, &result)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrDecompressedDataIsTooLarge)
+}
+
+func Test_Client_codecs(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	ps := &ProcessorSettings{
+		EnableBatches: true,
+		Codecs:        []Codec{NewMessagePackCodec(), NewCborCodec()},
+	}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+
+	// Types of content seen by the server.
+	contentTypes := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		p.ServeHTTP(rw, req)
+		contentTypes <- req.Header.Get(header.HttpHeaderContentType) + "|" + rw.Header().Get(header.HttpHeaderContentType)
+	}))
+	defer srv.Close()
+
+	cs, err := _newClientSettingsForUrl(srv.URL)
+	aTest.MustBeNoError(err)
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+	var result SumResult
+	var re *RpcError
+
+	for _, codec := range []Codec{NewMessagePackCodec(), NewCborCodec()} {
+		cs.SetCodec(codec)
+
+		// Test #1. Successful call.
+		re, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 1, B: 2}, &result)
+		aTest.MustBeNoError(err)
+		aTest.MustBeEqual(re, (*RpcError)(nil))
+		aTest.MustBeEqual(result.C, byte(3))
+		aTest.MustBeEqual(<-contentTypes, codec.ContentType()+"|"+codec.ContentType())
+
+		// Test #2. Error with details.
+		re, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 200, B: 100}, &result)
+		aTest.MustBeNoError(err)
+		aTest.MustBeEqual(re.Code, RpcErrorCode(1))
+		aTest.MustBeEqual(re.Message, RpcErrorMessage("overflow"))
+		<-contentTypes
+
+		// Test #3. Batch.
+		calls := []*BatchCall{
+			NewBatchCall("RpcFunctionSum", SumParams{A: 5, B: 5}, new(SumResult)),
+			NewBatchCall("NoSuchFunction", struct{}{}, new(any)),
+		}
+		err = c.CallBatch(context.Background(), calls)
+		aTest.MustBeNoError(err)
+		aTest.MustBeEqual(calls[0].Result, &SumResult{C: 10})
+		aTest.MustBeEqual(calls[1].Error.Code, RpcErrorCode(RpcErrorCode_UnknownMethod))
+		aTest.MustBeEqual(<-contentTypes, codec.ContentType()+"|"+codec.ContentType())
+	}
+
+	// Test #4. Binary request with a JSON response.
+	data, err := NewCborCodec().Marshal(map[string]any{"jsonrpc": "M1", "id": "1", "method": "RpcFunctionSum", "params": SumParams{A: 1, B: 1}})
+	aTest.MustBeNoError(err)
+	req, err := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader(data))
+	aTest.MustBeNoError(err)
+	req.Header.Set(header.HttpHeaderContentType, CborContentType)
+	req.Header.Set(header.HttpHeaderAccept, mime.TypeApplicationJson)
+	resp, err := http.DefaultClient.Do(req)
+	aTest.MustBeNoError(err)
+	body, err := io.ReadAll(resp.Body)
+	aTest.MustBeNoError(err)
+	aTest.MustBeNoError(resp.Body.Close())
+	aTest.MustBeEqual(string(body), `{"jsonrpc":"M1","id":"1","result":{"c":2},"error":null,"ok":true}`+"\n")
+	<-contentTypes
+
+	// Test #5. Malformed binary request.
+	req, err = http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(`{}`))
+	aTest.MustBeNoError(err)
+	req.Header.Set(header.HttpHeaderContentType, MessagePackContentType)
+	req.Header.Set(header.HttpHeaderAccept, mime.TypeAny)
+	resp, err = http.DefaultClient.Do(req)
+	aTest.MustBeNoError(err)
+	body, err = io.ReadAll(resp.Body)
+	aTest.MustBeNoError(err)
+	aTest.MustBeNoError(resp.Body.Close())
+	var rpcResp RpcResponseRaw
+	err = NewMessagePackCodec().Unmarshal(body, &rpcResp)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(rpcResp.Error.Code, RpcErrorCode(RpcErrorCode_RequestIsNotReadable))
+	aTest.MustBeEqual(<-contentTypes, MessagePackContentType+"|"+MessagePackContentType)
+
+	// Test #6. Codec over the in-process transport.
+	cs.SetInProcessTransport(NewInProcessTransport(p))
+	cs.SetCodec(NewCborCodec())
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 4, B: 2}, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(result.C, byte(6))
+
+	// Test #7. Codec is not supported by the server.
+	p2, err := NewProcessor(&ProcessorSettings{})
+	aTest.MustBeNoError(err)
+	cs.SetInProcessTransport(NewInProcessTransport(p2))
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 4, B: 2}, &result)
+	aTest.MustBeAnError(err)
+
+	// Test #8. Byte slices are sent as binary strings.
+	p3, err := NewProcessor(&ProcessorSettings{CatchExceptions: true, Codecs: []Codec{NewMessagePackCodec()}})
+	aTest.MustBeNoError(err)
+	err = p3.addNamedFunc("RpcFunctionBytes", func(_ *json.RawMessage, _ *ResponseMetaData) (result any, re *RpcError) {
+		return []byte{0, 1, 255}, nil
+	}, nil, false, false)
+	aTest.MustBeNoError(err)
+	data, err = NewMessagePackCodec().Marshal(map[string]any{"jsonrpc": "M1", "id": "1", "method": "RpcFunctionBytes", "params": map[string]any{}})
+	aTest.MustBeNoError(err)
+	httpReq := httptest.NewRequest(http.MethodPost, "http://localhost", bytes.NewReader(data))
+	httpReq.Header.Set(header.HttpHeaderContentType, MessagePackContentType)
+	httpReq.Header.Set(header.HttpHeaderAccept, MessagePackContentType)
+	recorder := httptest.NewRecorder()
+	p3.ServeHTTP(recorder, httpReq)
+	aTest.MustBeEqual(bytes.Contains(recorder.Body.Bytes(), []byte{0xc4, 3, 0, 1, 255}), true)
+	cs.SetInProcessTransport(NewInProcessTransport(p3))
+	cs.SetCodec(NewMessagePackCodec())
+	var bytesResult []byte
+	_, err = c.Call(context.Background(), "RpcFunctionBytes", struct{}{}, &bytesResult)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(bytesResult, []byte{0, 1, 255})
+}
+
+func Test_Client_compatibilityMode(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	// Server of a newer version adds fields to responses and results.
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		reqBody, _ := io.ReadAll(req.Body)
+		var body string
+		if strings.HasPrefix(req.Header.Get(header.HttpHeaderAccept), StreamContentType) {
+			rw.Header().Set(header.HttpHeaderContentType, StreamContentType)
+			body = `{"jsonrpc":"M1","id":"1"}` + "\n" + `{"item":{"c":3,"d":4}}` + "\n" + `{"ok":true}` + "\n"
+		} else if bytes.HasPrefix(reqBody, []byte("[")) {
+			var rpcReqs []*RpcRequest
+			_ = json.Unmarshal(reqBody, &rpcReqs)
+			body = `[{"jsonrpc":"M1","id":"` + *rpcReqs[0].Id + `","result":{"c":3},"error":null,"ok":true,"x":1}]`
+		} else {
+			body = `{"jsonrpc":"M1","id":"1","result":{"c":3,"d":4},"error":null,"ok":true,"x":1,"Y":"z"}`
+		}
+		_, _ = rw.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	cs, err := _newClientSettingsForUrl(srv.URL)
+	aTest.MustBeNoError(err)
+	var logBuf bytes.Buffer
+	cs.SetLogger(slog.New(slog.NewJSONHandler(&logBuf, nil)))
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+	var result SumResult
+
+	// Test #1. Strict mode.
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 1, B: 2}, &result)
+	aTest.MustBeAnError(err)
+
+	// Test #2. Lenient envelope with a strict result.
+	cs.SetCompatibilityMode(true, false)
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 1, B: 2}, &result)
+	aTest.MustBeAnError(err)
+
+	// Test #3. Lenient envelope and result.
+	cs.SetCompatibilityMode(true, true)
+	logBuf.Reset()
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 1, B: 2}, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(result.C, byte(3))
+	var record map[string]any
+	err = json.Unmarshal(logBuf.Bytes(), &record)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(record[LogAttr_UnknownFields], []any{"Y", "x"})
+
+	// Test #4. Unknown fields are captured.
+	pn, id, m := ProtocolNameM1, "1", "RpcFunctionSum"
+	p := json.RawMessage(`{}`)
+	rpcResp, err := c.CallRaw(context.Background(), &RpcRequest{ProtocolName: &pn, Id: &id, Method: &m, Parameters: &p})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(rpcResp.UnknownFields, map[string]json.RawMessage{"x": json.RawMessage(`1`), "Y": json.RawMessage(`"z"`)})
+
+	// Test #5. Batch.
+	calls := []*BatchCall{NewBatchCall("RpcFunctionSum", SumParams{A: 1, B: 2}, new(SumResult))}
+	err = c.CallBatch(context.Background(), calls)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(calls[0].Result, &SumResult{C: 3})
+
+	// Test #6. Stream.
+	rs, _, err := c.CallStream(context.Background(), "RpcFunctionCounter", 1)
+	aTest.MustBeNoError(err)
+	var items []SumResult
+	for item := range DecodeStreamItems[SumResult](rs) {
+		items = append(items, item)
+	}
+	aTest.MustBeNoError(rs.Close())
+	aTest.MustBeEqual(items, []SumResult{{C: 3}})
+}
+
+func Test_Client_Invoke(t *testing.T) {
+	aTest := tester.New(t)
+
+	er, err := _newErrorRegistry()
+	aTest.MustBeNoError(err)
+	p, err := NewProcessor(&ProcessorSettings{ErrorRegistry: er})
+	aTest.MustBeNoError(err)
+	err = p.AddFuncWithError(RpcFunctionFind)
+	aTest.MustBeNoError(err)
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	cs, err := _newClientSettingsForUrl(srv.URL)
+	aTest.MustBeNoError(err)
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+	var result string
+	var res *RpcErrorStd
+
+	// Test #1. Success.
+	err = c.Invoke(context.Background(), "RpcFunctionFind", 1, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(result, "one")
+
+	// Test #2. Registry is not set.
+	err = c.Invoke(context.Background(), "RpcFunctionFind", 2, &result)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(errors.Is(err, _errNotFound), false)
+	aTest.MustBeEqual(errors.As(err, &res), true)
+	aTest.MustBeEqual(res.Code, RpcErrorCode(404))
+
+	// Test #3. Sentinel error across the wire.
+	cs.SetErrorRegistry(er)
+	err = c.Invoke(context.Background(), "RpcFunctionFind", 2, &result)
+	aTest.MustBeEqual(errors.Is(err, _errNotFound), true)
+
+	// Test #4. Other errors.
+	err = c.Invoke(context.Background(), "RpcFunctionFind", 4, &result)
+	aTest.MustBeEqual(errors.Is(err, NewRpcErrorStd(RpcErrorCode_InternalRpcError, "", nil)), true)
+	err = c.Invoke(context.Background(), "NoSuchFunction", 1, &result)
+	aTest.MustBeEqual(errors.Is(err, NewRpcErrorStd(RpcErrorCode_UnknownMethod, "", nil)), true)
+
+	// Test #5. Transport error.
+	srv.Close()
+	err = c.Invoke(context.Background(), "RpcFunctionFind", 1, &result)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(errors.As(err, &res), false)
+}
+
+func Test_Client_GetErrorCatalogue(t *testing.T) {
+	aTest := tester.New(t)
+
+	ec, err := _newErrorCatalogue()
+	aTest.MustBeNoError(err)
+	p, err := NewProcessor(&ProcessorSettings{ErrorCatalogue: ec})
+	aTest.MustBeNoError(err)
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	cs, err := _newClientSettingsForUrl(srv.URL)
+	aTest.MustBeNoError(err)
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Catalogue is set.
+	catalogue, re, err := c.GetErrorCatalogue(context.Background())
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeEqual(catalogue.Entries(), ec.Entries())
+	entry, ok := catalogue.GetEntry(429)
+	aTest.MustBeEqual(ok, true)
+	aTest.MustBeEqual(entry.Name, "QuotaExceeded")
+
+	// Test #2. Catalogue is not set.
+	p, err = NewProcessor(&ProcessorSettings{})
+	aTest.MustBeNoError(err)
+	srv2 := httptest.NewServer(p)
+	defer srv2.Close()
+	cs, err = _newClientSettingsForUrl(srv2.URL)
+	aTest.MustBeNoError(err)
+	c, err = NewClient(cs)
+	aTest.MustBeNoError(err)
+	catalogue, re, err = c.GetErrorCatalogue(context.Background())
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(RpcErrorCode_UnknownMethod))
+	aTest.MustBeEqual(catalogue, (*ErrorCatalogue)(nil))
+}
+
+func Test_Client_locale(t *testing.T) {
+	aTest := tester.New(t)
+
+	er, err := _newErrorRegistry()
+	aTest.MustBeNoError(err)
+	el, err := _newErrorLocalization()
+	aTest.MustBeNoError(err)
+	p, err := NewProcessor(&ProcessorSettings{ErrorRegistry: er, ErrorLocalization: el})
+	aTest.MustBeNoError(err)
+	err = p.AddFuncWithError(RpcFunctionFind)
+	aTest.MustBeNoError(err)
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	cs, err := _newClientSettingsForUrl(srv.URL)
+	aTest.MustBeNoError(err)
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+	var result string
+
+	// Test #1. Locale is propagated, code is kept.
+	ctx := ContextWithLocale(context.Background(), "de-AT, en;q=0.5")
+	re, err := c.Call(ctx, "RpcFunctionFind", 2, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(404))
+	aTest.MustBeEqual(re.Message, RpcErrorMessage("Nicht gefunden (AT)"))
+
+	// Test #2. No locale.
+	re, err = c.Call(context.Background(), "RpcFunctionFind", 2, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re.Message, RpcErrorMessage("Not found"))
+}
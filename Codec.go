@@ -0,0 +1,18 @@
+package jrm1
+
+// Codec encodes and decodes messages of the protocol: requests, responses and
+// batches. JSON is the default codec, while binary codecs may be enabled on
+// the RPC processor (server) and selected by the RPC client. Codecs keep the
+// same envelope fields as JSON, so a message has the same structure with
+// every codec. Codec must be safe for concurrent use.
+type Codec interface {
+	// ContentType returns the type of content of encoded messages, which is
+	// used in the 'Content-Type' and 'Accept' HTTP headers.
+	ContentType() string
+
+	// Marshal encodes the value.
+	Marshal(v any) (data []byte, err error)
+
+	// Unmarshal decodes the data into the value.
+	Unmarshal(data []byte, v any) (err error)
+}
@@ -0,0 +1,67 @@
+package jrm1
+
+import (
+	"fmt"
+	"time"
+)
+
+// Formats of durations stored in meta-data.
+const (
+	DurationFormat_Nanoseconds  = DurationFormat("ns")
+	DurationFormat_Microseconds = DurationFormat("us")
+	DurationFormat_Milliseconds = DurationFormat("ms")
+	DurationFormat_Seconds      = DurationFormat("s")
+	DurationFormat_String       = DurationFormat("string")
+
+	// DurationFormat_Default is used when the format is not set.
+	DurationFormat_Default = DurationFormat_Milliseconds
+)
+
+// Names of phases of request processing.
+const (
+	DurationPhase_Decode = "decode"
+	DurationPhase_Run    = "run"
+	DurationPhase_Encode = "encode"
+)
+
+const (
+	ErrFUnsupportedDurationFormat = "unsupported duration format: %v"
+)
+
+// DurationFormat is a format of a duration stored in meta-data.
+// Nanoseconds, microseconds and milliseconds are stored as integer numbers,
+// seconds are stored as a floating point number, string format is the format
+// of the 'String' method of the 'time.Duration' type, e.g. "1.5ms".
+type DurationFormat string
+
+// Check ensures that the duration format is supported. Empty format is
+// supported and means the default format.
+func (df DurationFormat) Check() (err error) {
+	switch df {
+	case "",
+		DurationFormat_Nanoseconds,
+		DurationFormat_Microseconds,
+		DurationFormat_Milliseconds,
+		DurationFormat_Seconds,
+		DurationFormat_String:
+		return nil
+	default:
+		return fmt.Errorf(ErrFUnsupportedDurationFormat, df)
+	}
+}
+
+// Format converts a duration into a value stored in meta-data.
+func (df DurationFormat) Format(d time.Duration) any {
+	switch df {
+	case DurationFormat_Nanoseconds:
+		return d.Nanoseconds()
+	case DurationFormat_Microseconds:
+		return d.Microseconds()
+	case DurationFormat_Seconds:
+		return d.Seconds()
+	case DurationFormat_String:
+		return d.String()
+	default:
+		return d.Milliseconds()
+	}
+}
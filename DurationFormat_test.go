@@ -0,0 +1,34 @@
+package jrm1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_DurationFormat_Check(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	aTest.MustBeNoError(DurationFormat("").Check())
+	aTest.MustBeNoError(DurationFormat_Nanoseconds.Check())
+	aTest.MustBeNoError(DurationFormat_Microseconds.Check())
+	aTest.MustBeNoError(DurationFormat_Milliseconds.Check())
+	aTest.MustBeNoError(DurationFormat_Seconds.Check())
+	aTest.MustBeNoError(DurationFormat_String.Check())
+	aTest.MustBeAnError(DurationFormat("h").Check())
+}
+
+func Test_DurationFormat_Format(t *testing.T) {
+	aTest := tester.New(t)
+	d := 1500 * time.Microsecond
+
+	// Test.
+	aTest.MustBeEqual(DurationFormat("").Format(d), int64(1))
+	aTest.MustBeEqual(DurationFormat_Nanoseconds.Format(d), int64(1500000))
+	aTest.MustBeEqual(DurationFormat_Microseconds.Format(d), int64(1500))
+	aTest.MustBeEqual(DurationFormat_Milliseconds.Format(d), int64(1))
+	aTest.MustBeEqual(DurationFormat_Seconds.Format(d), 0.0015)
+	aTest.MustBeEqual(DurationFormat_String.Format(d), "1.5ms")
+}
@@ -0,0 +1,269 @@
+package jrm1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Name of the built-in method returning the error catalogue.
+const CatalogueMethod_GetErrorCatalogue = "M1_GetErrorCatalogue"
+
+const (
+	ErrErrorNameIsEmpty                = "error name is empty"
+	ErrErrorDataSchemaIsNotValid       = "error data schema is not valid JSON"
+	ErrFErrorNameIsAlreadyRegistered   = "error name is already registered: %v"
+	ErrFErrorCodeIsNotInCatalogue      = "error code is not in catalogue: %v"
+	ErrFErrorNameIsNotInCatalogue      = "error name is not in catalogue: %v"
+	ErrFErrorMessageDiffersInCatalogue = "error message differs from the catalogue: %v"
+)
+
+// ErrorCatalogue is a catalogue of user-generated error codes of an RPC
+// processor (server). Each code is registered with a unique name, a default
+// message and an optional schema of the data of errors, so that different
+// parts of a program can not reuse a code for different meanings. Clients
+// get the catalogue using the built-in 'M1_GetErrorCatalogue' method and map
+// codes to names.
+type ErrorCatalogue struct {
+	guard *sync.RWMutex
+
+	// Registered errors by their codes and names.
+	entriesByCode map[RpcErrorCode]*ErrorCatalogueEntry
+	entriesByName map[string]*ErrorCatalogueEntry
+}
+
+// ErrorCatalogueEntry is an error code registered in the error catalogue.
+type ErrorCatalogueEntry struct {
+	// Error code.
+	Code RpcErrorCode `json:"code"`
+
+	// Unique name of the error, e.g. 'NotFound'.
+	Name string `json:"name"`
+
+	// Default error message.
+	Message RpcErrorMessage `json:"message"`
+
+	// Schema of the data of errors, e.g. a JSON Schema. It describes the data
+	// for clients and is not enforced.
+	DataSchema json.RawMessage `json:"dataSchema,omitempty"`
+}
+
+// NewErrorCatalogue is a constructor of an empty error catalogue.
+func NewErrorCatalogue() (ec *ErrorCatalogue) {
+	return &ErrorCatalogue{
+		guard:         new(sync.RWMutex),
+		entriesByCode: make(map[RpcErrorCode]*ErrorCatalogueEntry),
+		entriesByName: make(map[string]*ErrorCatalogueEntry),
+	}
+}
+
+// Register registers the user-generated error code with the name, the
+// default message and the optional schema of data. Codes and names must be
+// unique.
+func (ec *ErrorCatalogue) Register(code int, name string, message string, dataSchema json.RawMessage) (err error) {
+	return ec.add(&ErrorCatalogueEntry{
+		Code:       RpcErrorCode(code),
+		Name:       name,
+		Message:    RpcErrorMessage(message),
+		DataSchema: dataSchema,
+	})
+}
+
+// RegisterFast registers the user-generated error code. It panics on error,
+// which suits registration at the start of a program.
+func (ec *ErrorCatalogue) RegisterFast(code int, name string, message string, dataSchema json.RawMessage) {
+	err := ec.Register(code, name, message, dataSchema)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Include registers all the error codes of another catalogue, e.g. the one of
+// another part of the program. If any code or name is already registered,
+// nothing is registered and an error is returned.
+func (ec *ErrorCatalogue) Include(other *ErrorCatalogue) (err error) {
+	entries := other.Entries()
+
+	ec.guard.Lock()
+	defer ec.guard.Unlock()
+
+	for _, entry := range entries {
+		err = ec.checkUniqueness(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range entries {
+		ec.entriesByCode[entry.Code] = entry
+		ec.entriesByName[entry.Name] = entry
+	}
+
+	return nil
+}
+
+// add checks the entry and adds it to the catalogue.
+func (ec *ErrorCatalogue) add(entry *ErrorCatalogueEntry) (err error) {
+	if !entry.Code.IsGeneratedByUser() {
+		return errors.New(ErrUserGeneratedErrorsHaveSpecialCodes)
+	}
+
+	if len(entry.Name) == 0 {
+		return errors.New(ErrErrorNameIsEmpty)
+	}
+
+	err = entry.Message.Check()
+	if err != nil {
+		return err
+	}
+
+	if (entry.DataSchema != nil) && !json.Valid(entry.DataSchema) {
+		return errors.New(ErrErrorDataSchemaIsNotValid)
+	}
+
+	ec.guard.Lock()
+	defer ec.guard.Unlock()
+
+	err = ec.checkUniqueness(entry)
+	if err != nil {
+		return err
+	}
+
+	ec.entriesByCode[entry.Code] = entry
+	ec.entriesByName[entry.Name] = entry
+
+	return nil
+}
+
+// checkUniqueness ensures that neither the code nor the name of the entry is
+// registered. The caller must hold the guard.
+func (ec *ErrorCatalogue) checkUniqueness(entry *ErrorCatalogueEntry) (err error) {
+	_, isRegistered := ec.entriesByCode[entry.Code]
+	if isRegistered {
+		return fmt.Errorf(ErrFErrorCodeIsAlreadyRegistered, entry.Code)
+	}
+
+	_, isRegistered = ec.entriesByName[entry.Name]
+	if isRegistered {
+		return fmt.Errorf(ErrFErrorNameIsAlreadyRegistered, entry.Name)
+	}
+
+	return nil
+}
+
+// GetEntry returns the registered error code. If the code is not registered,
+// 'False' is returned.
+func (ec *ErrorCatalogue) GetEntry(code RpcErrorCode) (entry *ErrorCatalogueEntry, ok bool) {
+	ec.guard.RLock()
+	defer ec.guard.RUnlock()
+
+	entry, ok = ec.entriesByCode[code]
+	return entry, ok
+}
+
+// GetEntryByName returns the error code registered with the name. If the
+// name is not registered, 'False' is returned.
+func (ec *ErrorCatalogue) GetEntryByName(name string) (entry *ErrorCatalogueEntry, ok bool) {
+	ec.guard.RLock()
+	defer ec.guard.RUnlock()
+
+	entry, ok = ec.entriesByName[name]
+	return entry, ok
+}
+
+// Entries returns all the registered error codes sorted by code.
+func (ec *ErrorCatalogue) Entries() (entries []*ErrorCatalogueEntry) {
+	ec.guard.RLock()
+	defer ec.guard.RUnlock()
+
+	entries = make([]*ErrorCatalogueEntry, 0, len(ec.entriesByCode))
+	for _, entry := range ec.entriesByCode {
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b *ErrorCatalogueEntry) int {
+		return a.Code.Int() - b.Code.Int()
+	})
+
+	return entries
+}
+
+// NewError creates an RPC error having the registered code and its default
+// message. It is a variant of the 'NewRpcErrorByUser' constructor which
+// validates the code against the catalogue.
+func (ec *ErrorCatalogue) NewError(code int, data any) (re *RpcError, err error) {
+	entry, ok := ec.GetEntry(RpcErrorCode(code))
+	if !ok {
+		return nil, fmt.Errorf(ErrFErrorCodeIsNotInCatalogue, code)
+	}
+
+	return entry.newError(entry.Message, data), nil
+}
+
+// NewErrorWithMessage creates an RPC error having the registered code and a
+// custom message.
+func (ec *ErrorCatalogue) NewErrorWithMessage(code int, message string, data any) (re *RpcError, err error) {
+	entry, ok := ec.GetEntry(RpcErrorCode(code))
+	if !ok {
+		return nil, fmt.Errorf(ErrFErrorCodeIsNotInCatalogue, code)
+	}
+
+	msg := RpcErrorMessage(message)
+	err = msg.Check()
+	if err != nil {
+		return nil, err
+	}
+
+	return entry.newError(msg, data), nil
+}
+
+// NewErrorByName creates an RPC error having the code registered with the
+// name and its default message.
+func (ec *ErrorCatalogue) NewErrorByName(name string, data any) (re *RpcError, err error) {
+	entry, ok := ec.GetEntryByName(name)
+	if !ok {
+		return nil, fmt.Errorf(ErrFErrorNameIsNotInCatalogue, name)
+	}
+
+	return entry.newError(entry.Message, data), nil
+}
+
+// NewErrorFast creates an RPC error having the registered code and its
+// default message. It panics if the code is not registered.
+func (ec *ErrorCatalogue) NewErrorFast(code int, data any) (re *RpcError) {
+	re, err := ec.NewError(code, data)
+	if err != nil {
+		panic(err)
+	}
+
+	return re
+}
+
+// newError creates an RPC error of the entry.
+func (e *ErrorCatalogueEntry) newError(message RpcErrorMessage, data any) (re *RpcError) {
+	return &RpcError{
+		Code:    e.Code,
+		Message: message,
+		Data:    data,
+	}
+}
+
+// checkErrorRegistry ensures that all the codes of the error registry are in
+// the catalogue and have the same messages.
+func (ec *ErrorCatalogue) checkErrorRegistry(er *ErrorRegistry) (err error) {
+	er.guard.RLock()
+	defer er.guard.RUnlock()
+
+	for _, registryEntry := range er.entries {
+		entry, ok := ec.GetEntry(registryEntry.code)
+		if !ok {
+			return fmt.Errorf(ErrFErrorCodeIsNotInCatalogue, registryEntry.code)
+		}
+		if entry.Message != registryEntry.message {
+			return fmt.Errorf(ErrFErrorMessageDiffersInCatalogue, registryEntry.code)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package jrm1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+// _newErrorCatalogue creates an error catalogue used by tests. It has the
+// codes of the error registry used by tests.
+func _newErrorCatalogue() (ec *ErrorCatalogue, err error) {
+	ec = NewErrorCatalogue()
+
+	err = ec.Register(404, "NotFound", "Not found", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ec.Register(429, "QuotaExceeded", "Quota is exceeded", json.RawMessage(`{"type":"integer"}`))
+	if err != nil {
+		return nil, err
+	}
+
+	return ec, nil
+}
+
+func Test_ErrorCatalogue_Register(t *testing.T) {
+	aTest := tester.New(t)
+
+	ec, err := _newErrorCatalogue()
+	aTest.MustBeNoError(err)
+
+	// Test #1. Code is not generated by user.
+	err = ec.Register(RpcErrorCode_InvalidRequest, "Invalid", "x", nil)
+	aTest.MustBeAnError(err)
+
+	// Test #2. Name is empty.
+	err = ec.Register(409, "", "Conflict", nil)
+	aTest.MustBeAnError(err)
+
+	// Test #3. Message is empty.
+	err = ec.Register(409, "Conflict", "", nil)
+	aTest.MustBeAnError(err)
+
+	// Test #4. Data schema is not valid.
+	err = ec.Register(409, "Conflict", "Conflict", json.RawMessage(`{`))
+	aTest.MustBeAnError(err)
+
+	// Test #5. Code or name is already registered.
+	err = ec.Register(404, "Conflict", "Conflict", nil)
+	aTest.MustBeAnError(err)
+	err = ec.Register(409, "NotFound", "Conflict", nil)
+	aTest.MustBeAnError(err)
+
+	// Test #6. All clear.
+	err = ec.Register(409, "Conflict", "Conflict", nil)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(len(ec.Entries()), 3)
+
+	// Test #7. Panic.
+	func() {
+		defer func() {
+			aTest.MustBeDifferent(recover(), nil)
+		}()
+		ec.RegisterFast(409, "Conflict", "Conflict", nil)
+	}()
+}
+
+func Test_ErrorCatalogue_Include(t *testing.T) {
+	aTest := tester.New(t)
+
+	ec, err := _newErrorCatalogue()
+	aTest.MustBeNoError(err)
+
+	// Test #1. Duplicate name, nothing is included.
+	other := NewErrorCatalogue()
+	other.RegisterFast(409, "Conflict", "Conflict", nil)
+	other.RegisterFast(410, "NotFound", "Gone", nil)
+	err = ec.Include(other)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(len(ec.Entries()), 2)
+
+	// Test #2. All clear.
+	other = NewErrorCatalogue()
+	other.RegisterFast(409, "Conflict", "Conflict", nil)
+	err = ec.Include(other)
+	aTest.MustBeNoError(err)
+	entry, ok := ec.GetEntryByName("Conflict")
+	aTest.MustBeEqual(ok, true)
+	aTest.MustBeEqual(entry.Code, RpcErrorCode(409))
+}
+
+func Test_ErrorCatalogue_Entries(t *testing.T) {
+	aTest := tester.New(t)
+
+	ec := NewErrorCatalogue()
+	ec.RegisterFast(500, "C", "C", nil)
+	ec.RegisterFast(3, "A", "A", nil)
+	ec.RegisterFast(7, "B", "B", nil)
+
+	var codes []RpcErrorCode
+	for _, entry := range ec.Entries() {
+		codes = append(codes, entry.Code)
+	}
+	aTest.MustBeEqual(codes, []RpcErrorCode{3, 7, 500})
+}
+
+func Test_ErrorCatalogue_NewError(t *testing.T) {
+	aTest := tester.New(t)
+	var re *RpcError
+
+	ec, err := _newErrorCatalogue()
+	aTest.MustBeNoError(err)
+
+	// Test #1. Default message.
+	re, err = ec.NewError(404, "x")
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(*re, RpcError{Code: 404, Message: "Not found", Data: "x"})
+	re, err = ec.NewErrorByName("QuotaExceeded", 10)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(*re, RpcError{Code: 429, Message: "Quota is exceeded", Data: 10})
+	aTest.MustBeEqual(*ec.NewErrorFast(404, nil), RpcError{Code: 404, Message: "Not found"})
+
+	// Test #2. Custom message.
+	re, err = ec.NewErrorWithMessage(404, "No such user", nil)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(*re, RpcError{Code: 404, Message: "No such user"})
+	_, err = ec.NewErrorWithMessage(404, "", nil)
+	aTest.MustBeAnError(err)
+
+	// Test #3. Not in catalogue.
+	_, err = ec.NewError(409, nil)
+	aTest.MustBeAnError(err)
+	_, err = ec.NewErrorWithMessage(409, "Conflict", nil)
+	aTest.MustBeAnError(err)
+	_, err = ec.NewErrorByName("Conflict", nil)
+	aTest.MustBeAnError(err)
+	func() {
+		defer func() {
+			aTest.MustBeDifferent(recover(), nil)
+		}()
+		ec.NewErrorFast(409, nil)
+	}()
+}
+
+func Test_ErrorCatalogue_checkErrorRegistry(t *testing.T) {
+	aTest := tester.New(t)
+
+	ec, err := _newErrorCatalogue()
+	aTest.MustBeNoError(err)
+	er, err := _newErrorRegistry()
+	aTest.MustBeNoError(err)
+
+	// Test #1. All clear.
+	err = ec.checkErrorRegistry(er)
+	aTest.MustBeNoError(err)
+
+	// Test #2. Code is not in catalogue.
+	err = er.RegisterError(_errConflict, 409, "Conflict")
+	aTest.MustBeNoError(err)
+	err = ec.checkErrorRegistry(er)
+	aTest.MustBeAnError(err)
+
+	// Test #3. Message differs.
+	ec.RegisterFast(409, "Conflict", "Conflict of versions", nil)
+	err = ec.checkErrorRegistry(er)
+	aTest.MustBeAnError(err)
+}
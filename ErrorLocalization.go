@@ -0,0 +1,219 @@
+package jrm1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	ErrLocaleIsEmpty = "locale is empty"
+)
+
+// ErrorLocalization is a set of catalogs of RPC error messages per locale. It
+// translates messages of both built-in and user-generated error codes. The
+// code of an error is never changed, so clients still match errors by their
+// codes, while the message is shown to people. The locale of a function call
+// is selected by the 'ContextWithLocale' function or by the 'Accept-Language'
+// HTTP header of the request.
+type ErrorLocalization struct {
+	guard *sync.RWMutex
+
+	// Messages by locales and error codes. Locales are stored in lower case.
+	catalogs map[string]map[RpcErrorCode]RpcErrorMessage
+}
+
+// NewErrorLocalization is a constructor of an empty error localization.
+func NewErrorLocalization() (el *ErrorLocalization) {
+	return &ErrorLocalization{
+		guard:    new(sync.RWMutex),
+		catalogs: make(map[string]map[RpcErrorCode]RpcErrorMessage),
+	}
+}
+
+// AddMessages adds messages of the locale, e.g. 'de' or 'pt-BR', by their
+// error codes. Messages of the same locale may be added several times, the
+// last message of a code wins. If any message is not correct, nothing is
+// added.
+func (el *ErrorLocalization) AddMessages(locale string, messages map[RpcErrorCode]string) (err error) {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if len(locale) == 0 {
+		return errors.New(ErrLocaleIsEmpty)
+	}
+
+	for code, message := range messages {
+		if !code.IsGeneratedByUser() {
+			err = code.Check()
+			if err != nil {
+				return fmt.Errorf(ErrFUnknownErrorCode, code)
+			}
+		}
+
+		err = RpcErrorMessage(message).Check()
+		if err != nil {
+			return err
+		}
+	}
+
+	el.guard.Lock()
+	defer el.guard.Unlock()
+
+	catalog, ok := el.catalogs[locale]
+	if !ok {
+		catalog = make(map[RpcErrorCode]RpcErrorMessage, len(messages))
+		el.catalogs[locale] = catalog
+	}
+
+	for code, message := range messages {
+		catalog[code] = RpcErrorMessage(message)
+	}
+
+	return nil
+}
+
+// GetMessage returns the message of the error code for the most preferred of
+// the locales, which are written in the format of the 'Accept-Language' HTTP
+// header, e.g. 'de-AT, en;q=0.8'. When the exact locale has no message, the
+// message of its language is used, e.g. 'de' for 'de-AT'. If no locale has a
+// message, 'False' is returned.
+func (el *ErrorLocalization) GetMessage(code RpcErrorCode, locales string) (message RpcErrorMessage, ok bool) {
+	el.guard.RLock()
+	defer el.guard.RUnlock()
+
+	for _, locale := range parseAcceptLanguage(locales) {
+		message, ok = el.catalogs[locale][code]
+		if ok {
+			return message, true
+		}
+
+		language, _, isRegional := strings.Cut(locale, "-")
+		if isRegional {
+			message, ok = el.catalogs[language][code]
+			if ok {
+				return message, true
+			}
+		}
+	}
+
+	return RpcErrorMsg_Empty, false
+}
+
+// Localize returns a copy of the RPC error having the message of the most
+// preferred of the locales. The code and the data are kept as is. If no
+// locale has a message, the RPC error is returned as is.
+func (el *ErrorLocalization) Localize(re *RpcError, locales string) *RpcError {
+	if (re == nil) || (len(locales) == 0) {
+		return re
+	}
+
+	message, ok := el.GetMessage(re.Code, locales)
+	if !ok {
+		return re
+	}
+
+	return &RpcError{
+		Code:    re.Code,
+		Message: message,
+		Data:    re.Data,
+	}
+}
+
+// checkErrorCatalogue ensures that all the user-generated error codes of the
+// localization are in the error catalogue.
+func (el *ErrorLocalization) checkErrorCatalogue(ec *ErrorCatalogue) (err error) {
+	el.guard.RLock()
+	defer el.guard.RUnlock()
+
+	for _, catalog := range el.catalogs {
+		for code := range catalog {
+			if !code.IsGeneratedByUser() {
+				continue
+			}
+
+			_, ok := ec.GetEntry(code)
+			if !ok {
+				return fmt.Errorf(ErrFErrorCodeIsNotInCatalogue, code)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseAcceptLanguage reads locales of the 'Accept-Language' HTTP header in
+// the order of preference. Locales are returned in lower case. Locales having
+// zero quality and the wildcard are skipped.
+func parseAcceptLanguage(value string) (locales []string) {
+	type weightedLocale struct {
+		locale  string
+		quality float64
+	}
+
+	var wls []weightedLocale
+	for _, part := range strings.Split(value, ",") {
+		locale, params, _ := strings.Cut(part, ";")
+		locale = strings.ToLower(strings.TrimSpace(locale))
+		if (len(locale) == 0) || (locale == "*") {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if name != "q" {
+				continue
+			}
+
+			q, err := strconv.ParseFloat(v, 64)
+			if err == nil {
+				quality = q
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+
+		wls = append(wls, weightedLocale{locale: locale, quality: quality})
+	}
+
+	slices.SortStableFunc(wls, func(a, b weightedLocale) int {
+		switch {
+		case a.quality > b.quality:
+			return -1
+		case a.quality < b.quality:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	locales = make([]string, 0, len(wls))
+	for _, wl := range wls {
+		locales = append(locales, wl.locale)
+	}
+
+	return locales
+}
+
+// localeKey is a key of the locale stored in a context.
+type localeKey struct{}
+
+// ContextWithLocale returns a copy of the context containing the locales of a
+// function call, written in the format of the 'Accept-Language' HTTP header,
+// e.g. 'de' or 'de-AT, en;q=0.8'. On the RPC server, the locales of the
+// context take precedence over the HTTP header, so that a middleware may set
+// them, e.g. from a profile of the user. The client sends the locales of the
+// context in the HTTP header.
+func ContextWithLocale(ctx context.Context, locales string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locales)
+}
+
+// LocaleFromContext reads the locales from the context.
+func LocaleFromContext(ctx context.Context) (locales string, ok bool) {
+	locales, ok = ctx.Value(localeKey{}).(string)
+	return locales, ok
+}
@@ -0,0 +1,164 @@
+package jrm1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+// _newErrorLocalization creates an error localization used by tests.
+func _newErrorLocalization() (el *ErrorLocalization, err error) {
+	el = NewErrorLocalization()
+
+	err = el.AddMessages("de", map[RpcErrorCode]string{
+		RpcErrorCode_UnknownMethod: "Unbekannte Methode",
+		404:                        "Nicht gefunden",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = el.AddMessages("de-AT", map[RpcErrorCode]string{
+		404: "Nicht gefunden (AT)",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return el, nil
+}
+
+func Test_ErrorLocalization_AddMessages(t *testing.T) {
+	aTest := tester.New(t)
+
+	el, err := _newErrorLocalization()
+	aTest.MustBeNoError(err)
+
+	// Test #1. Locale is empty.
+	err = el.AddMessages(" ", map[RpcErrorCode]string{404: "x"})
+	aTest.MustBeAnError(err)
+
+	// Test #2. Unknown built-in code, nothing is added.
+	err = el.AddMessages("fr", map[RpcErrorCode]string{404: "Introuvable", -3: "x"})
+	aTest.MustBeAnError(err)
+	_, ok := el.GetMessage(404, "fr")
+	aTest.MustBeEqual(ok, false)
+
+	// Test #3. Message is empty.
+	err = el.AddMessages("fr", map[RpcErrorCode]string{404: ""})
+	aTest.MustBeAnError(err)
+
+	// Test #4. All clear, the last message wins.
+	err = el.AddMessages("DE", map[RpcErrorCode]string{404: "Nicht da"})
+	aTest.MustBeNoError(err)
+	message, ok := el.GetMessage(404, "de")
+	aTest.MustBeEqual(ok, true)
+	aTest.MustBeEqual(message, RpcErrorMessage("Nicht da"))
+}
+
+func Test_ErrorLocalization_GetMessage(t *testing.T) {
+	aTest := tester.New(t)
+	var message RpcErrorMessage
+	var ok bool
+
+	el, err := _newErrorLocalization()
+	aTest.MustBeNoError(err)
+
+	// Test #1. Exact locale.
+	message, ok = el.GetMessage(404, "de-AT")
+	aTest.MustBeEqual(ok, true)
+	aTest.MustBeEqual(message, RpcErrorMessage("Nicht gefunden (AT)"))
+
+	// Test #2. Language of the locale.
+	message, ok = el.GetMessage(RpcErrorCode_UnknownMethod, "de-at")
+	aTest.MustBeEqual(ok, true)
+	aTest.MustBeEqual(message, RpcErrorMessage("Unbekannte Methode"))
+
+	// Test #3. Order of preference.
+	message, ok = el.GetMessage(404, "fr, de;q=0.5, de-AT;q=0.9")
+	aTest.MustBeEqual(ok, true)
+	aTest.MustBeEqual(message, RpcErrorMessage("Nicht gefunden (AT)"))
+
+	// Test #4. No message.
+	_, ok = el.GetMessage(404, "fr, *, de;q=0")
+	aTest.MustBeEqual(ok, false)
+	_, ok = el.GetMessage(409, "de")
+	aTest.MustBeEqual(ok, false)
+}
+
+func Test_ErrorLocalization_Localize(t *testing.T) {
+	aTest := tester.New(t)
+
+	el, err := _newErrorLocalization()
+	aTest.MustBeNoError(err)
+	re := &RpcError{Code: 404, Message: "Not found", Data: "x"}
+
+	// Test #1. Localized copy.
+	aTest.MustBeEqual(*el.Localize(re, "de"), RpcError{Code: 404, Message: "Nicht gefunden", Data: "x"})
+	aTest.MustBeEqual(re.Message, RpcErrorMessage("Not found"))
+
+	// Test #2. No message.
+	aTest.MustBeEqual(el.Localize(re, "fr"), re)
+	aTest.MustBeEqual(el.Localize(re, ""), re)
+	aTest.MustBeEqual(el.Localize(nil, "de"), (*RpcError)(nil))
+}
+
+func Test_ErrorLocalization_checkErrorCatalogue(t *testing.T) {
+	aTest := tester.New(t)
+
+	el, err := _newErrorLocalization()
+	aTest.MustBeNoError(err)
+	ec, err := _newErrorCatalogue()
+	aTest.MustBeNoError(err)
+
+	// Test #1. All clear.
+	err = el.checkErrorCatalogue(ec)
+	aTest.MustBeNoError(err)
+
+	// Test #2. Code is not in catalogue.
+	err = el.AddMessages("de", map[RpcErrorCode]string{409: "Konflikt"})
+	aTest.MustBeNoError(err)
+	err = el.checkErrorCatalogue(ec)
+	aTest.MustBeAnError(err)
+}
+
+func Test_parseAcceptLanguage(t *testing.T) {
+	aTest := tester.New(t)
+
+	aTest.MustBeEqual(parseAcceptLanguage(""), []string{})
+	aTest.MustBeEqual(parseAcceptLanguage("de-AT"), []string{"de-at"})
+	aTest.MustBeEqual(parseAcceptLanguage("fr;q=0.5, de ; q=0.9, en, *;q=0.1, ru;q=0"), []string{"en", "de", "fr"})
+	aTest.MustBeEqual(parseAcceptLanguage("a;q=x, b"), []string{"a", "b"})
+}
+
+func Test_LocaleFromContext(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test #1. No locale.
+	_, ok := LocaleFromContext(context.Background())
+	aTest.MustBeEqual(ok, false)
+
+	// Test #2. Locale.
+	locales, ok := LocaleFromContext(ContextWithLocale(context.Background(), "de"))
+	aTest.MustBeEqual(ok, true)
+	aTest.MustBeEqual(locales, "de")
+}
+
+func Test_Processor_errorLocalization(t *testing.T) {
+	aTest := tester.New(t)
+
+	el, err := _newErrorLocalization()
+	aTest.MustBeNoError(err)
+	p, err := NewProcessor(&ProcessorSettings{ErrorLocalization: el})
+	aTest.MustBeNoError(err)
+
+	// Test #1. Locale of the context.
+	ctx := ContextWithLocale(context.Background(), "de")
+	resp := p.Handle(ctx, []byte(`{"jsonrpc":"M1","id":"1","method":"NoSuchFunction","params":{}}`))
+	aTest.MustBeEqual(string(resp), `{"jsonrpc":"M1","id":"1","result":null,"error":{"code":-8,"message":"Unbekannte Methode","data":null},"ok":false}`)
+
+	// Test #2. No locale.
+	resp = p.Handle(context.Background(), []byte(`{"jsonrpc":"M1","id":"1","method":"NoSuchFunction","params":{}}`))
+	aTest.MustBeEqual(string(resp), `{"jsonrpc":"M1","id":"1","result":null,"error":{"code":-8,"message":"Unknown method","data":null},"ok":false}`)
+}
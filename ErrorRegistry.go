@@ -0,0 +1,189 @@
+package jrm1
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	ErrErrorIsNotSet                 = "error is not set"
+	ErrFErrorCodeIsAlreadyRegistered = "error code is already registered: %v"
+)
+
+// ErrorRegistry maps Go errors to RPC errors having user-generated codes. It
+// is a bridge between ordinary Go errors and RPC errors. On the server, an
+// error returned by a function is converted into the RPC error registered for
+// it. On the client, an RPC error is converted back into a Go error which
+// matches the registered sentinel error, so that callers can write
+// 'errors.Is(err, ErrNotFound)' across the wire.
+type ErrorRegistry struct {
+	guard *sync.RWMutex
+
+	// Registered errors in the order of registration.
+	entries []*errorRegistryEntry
+
+	// Registered errors by their codes.
+	entriesByCode map[RpcErrorCode]*errorRegistryEntry
+}
+
+// errorRegistryEntry is a Go error registered with an RPC error code.
+type errorRegistryEntry struct {
+	code    RpcErrorCode
+	message RpcErrorMessage
+
+	// Sentinel error. It is null for registered types of errors.
+	sentinel error
+
+	// Function telling whether an error matches the entry.
+	match func(err error) bool
+}
+
+// NewErrorRegistry is a constructor of an empty error registry.
+func NewErrorRegistry() (er *ErrorRegistry) {
+	return &ErrorRegistry{
+		guard:         new(sync.RWMutex),
+		entriesByCode: make(map[RpcErrorCode]*errorRegistryEntry),
+	}
+}
+
+// RegisterError registers the sentinel error with the user-generated code and
+// message. Errors wrapping the sentinel error match it as well.
+func (er *ErrorRegistry) RegisterError(sentinel error, code int, message string) (err error) {
+	if sentinel == nil {
+		return errors.New(ErrErrorIsNotSet)
+	}
+
+	return er.register(&errorRegistryEntry{
+		code:     RpcErrorCode(code),
+		message:  RpcErrorMessage(message),
+		sentinel: sentinel,
+		match: func(err error) bool {
+			return errors.Is(err, sentinel)
+		},
+	})
+}
+
+// RegisterErrorType registers the type of errors with the user-generated code
+// and message. Errors of this type and errors wrapping them match it. On the
+// client, an RPC error having this code is returned as an 'RpcErrorStd'
+// error, since the original error can not be restored.
+func RegisterErrorType[T error](er *ErrorRegistry, code int, message string) (err error) {
+	return er.register(&errorRegistryEntry{
+		code:    RpcErrorCode(code),
+		message: RpcErrorMessage(message),
+		match: func(err error) bool {
+			var target T
+			return errors.As(err, &target)
+		},
+	})
+}
+
+// register adds the entry to the registry.
+func (er *ErrorRegistry) register(entry *errorRegistryEntry) (err error) {
+	if !entry.code.IsGeneratedByUser() {
+		return errors.New(ErrUserGeneratedErrorsHaveSpecialCodes)
+	}
+
+	err = entry.message.Check()
+	if err != nil {
+		return err
+	}
+
+	er.guard.Lock()
+	defer er.guard.Unlock()
+
+	_, isRegistered := er.entriesByCode[entry.code]
+	if isRegistered {
+		return fmt.Errorf(ErrFErrorCodeIsAlreadyRegistered, entry.code)
+	}
+
+	er.entries = append(er.entries, entry)
+	er.entriesByCode[entry.code] = entry
+
+	return nil
+}
+
+// ToRpcError converts the Go error into an RPC error. An RPC error wrapped
+// into the Go error, i.e. 'RpcErrorStd', is returned as is when its code is
+// generated by user or is the code of invalid parameters, which the
+// 'ParseParameters' function returns. Other errors are matched against the
+// registered ones in the order of registration. If the error is not
+// registered or it is an RPC error having another code reserved by the
+// protocol, 'False' is returned. Null error is converted into null RPC error.
+// Null registry has no registered errors.
+func (er *ErrorRegistry) ToRpcError(err error) (re *RpcError, ok bool) {
+	if err == nil {
+		return nil, true
+	}
+
+	var res *RpcErrorStd
+	if errors.As(err, &res) {
+		if !res.Code.IsGeneratedByUser() && (res.Code != RpcErrorCode_InvalidParameters) {
+			return nil, false
+		}
+
+		return &RpcError{
+			Code:    res.Code,
+			Message: res.Message,
+			Data:    res.Data,
+		}, true
+	}
+
+	if er == nil {
+		return nil, false
+	}
+
+	er.guard.RLock()
+	defer er.guard.RUnlock()
+
+	for _, entry := range er.entries {
+		if entry.match(err) {
+			return &RpcError{
+				Code:    entry.code,
+				Message: entry.message,
+			}, true
+		}
+	}
+
+	return nil, false
+}
+
+// ToError converts the RPC error into a Go error. If the code of the RPC
+// error is registered with a sentinel error, the returned error matches the
+// sentinel error, i.e. 'errors.Is' reports it. The returned error is always
+// an 'RpcErrorStd' error for the 'errors.As' function. Null RPC error is
+// converted into null error. Null registry has no registered errors.
+func (er *ErrorRegistry) ToError(re *RpcError) (err error) {
+	if re == nil {
+		return nil
+	}
+
+	res := re.AsError()
+	if er == nil {
+		return res
+	}
+
+	er.guard.RLock()
+	entry, isRegistered := er.entriesByCode[re.Code]
+	er.guard.RUnlock()
+
+	if !isRegistered || (entry.sentinel == nil) {
+		return res
+	}
+
+	return &registeredRpcError{RpcErrorStd: res, sentinel: entry.sentinel}
+}
+
+// registeredRpcError is an RPC error received by the client whose code is
+// registered with a sentinel error.
+type registeredRpcError struct {
+	*RpcErrorStd
+	sentinel error
+}
+
+// Unwrap returns the RPC error and the sentinel error, so that the standard
+// 'errors.Is' and 'errors.As' functions find both of them.
+func (e *registeredRpcError) Unwrap() []error {
+	return []error{e.RpcErrorStd, e.sentinel}
+}
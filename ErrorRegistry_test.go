@@ -0,0 +1,152 @@
+package jrm1
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+// Errors used by tests of the error registry.
+var (
+	_errNotFound = errors.New("not found")
+	_errConflict = errors.New("conflict")
+)
+
+// _quotaError is a type of errors used by tests of the error registry.
+type _quotaError struct {
+	limit int
+}
+
+func (e *_quotaError) Error() string {
+	return fmt.Sprintf("quota of %d is exceeded", e.limit)
+}
+
+// _newErrorRegistry creates an error registry used by tests.
+func _newErrorRegistry() (er *ErrorRegistry, err error) {
+	er = NewErrorRegistry()
+
+	err = er.RegisterError(_errNotFound, 404, "Not found")
+	if err != nil {
+		return nil, err
+	}
+
+	err = RegisterErrorType[*_quotaError](er, 429, "Quota is exceeded")
+	if err != nil {
+		return nil, err
+	}
+
+	return er, nil
+}
+
+func Test_ErrorRegistry_RegisterError(t *testing.T) {
+	aTest := tester.New(t)
+
+	er, err := _newErrorRegistry()
+	aTest.MustBeNoError(err)
+
+	// Test #1. Error is not set.
+	err = er.RegisterError(nil, 1, "x")
+	aTest.MustBeAnError(err)
+
+	// Test #2. Code is not generated by user.
+	err = er.RegisterError(_errConflict, RpcErrorCode_InvalidRequest, "x")
+	aTest.MustBeAnError(err)
+
+	// Test #3. Message is empty.
+	err = er.RegisterError(_errConflict, 409, "")
+	aTest.MustBeAnError(err)
+
+	// Test #4. Code is already registered.
+	err = er.RegisterError(_errConflict, 404, "Conflict")
+	aTest.MustBeAnError(err)
+	err = RegisterErrorType[*_quotaError](er, 429, "Quota")
+	aTest.MustBeAnError(err)
+
+	// Test #5. All clear.
+	err = er.RegisterError(_errConflict, 409, "Conflict")
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(len(er.entries), 3)
+}
+
+func Test_ErrorRegistry_ToRpcError(t *testing.T) {
+	aTest := tester.New(t)
+	var re *RpcError
+	var ok bool
+
+	er, err := _newErrorRegistry()
+	aTest.MustBeNoError(err)
+
+	// Test #1. Null error.
+	re, ok = er.ToRpcError(nil)
+	aTest.MustBeEqual(ok, true)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+
+	// Test #2. Wrapped sentinel error.
+	re, ok = er.ToRpcError(fmt.Errorf("user 5: %w", _errNotFound))
+	aTest.MustBeEqual(ok, true)
+	aTest.MustBeEqual(re, &RpcError{Code: 404, Message: "Not found"})
+
+	// Test #3. Type of errors.
+	re, ok = er.ToRpcError(fmt.Errorf("upload: %w", &_quotaError{limit: 10}))
+	aTest.MustBeEqual(ok, true)
+	aTest.MustBeEqual(re, &RpcError{Code: 429, Message: "Quota is exceeded"})
+
+	// Test #4. RPC error.
+	re, ok = er.ToRpcError(fmt.Errorf("x: %w", NewRpcErrorByUser(7, "Seven", 1).AsError()))
+	aTest.MustBeEqual(ok, true)
+	aTest.MustBeEqual(re, &RpcError{Code: 7, Message: "Seven", Data: 1})
+
+	// Test #5. RPC errors having codes reserved by the protocol. Only errors
+	// of parameters are allowed.
+	re, ok = er.ToRpcError(NewRpcErrorFast(RpcErrorCode_InvalidParameters).AsError())
+	aTest.MustBeEqual(ok, true)
+	aTest.MustBeEqual(re, NewRpcErrorFast(RpcErrorCode_InvalidParameters))
+	re, ok = er.ToRpcError(NewRpcErrorFast(RpcErrorCode_AccessDenied).AsError())
+	aTest.MustBeEqual(ok, false)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+
+	// Test #6. Error is not registered.
+	re, ok = er.ToRpcError(_errConflict)
+	aTest.MustBeEqual(ok, false)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+
+	// Test #7. Null registry.
+	er = nil
+	_, ok = er.ToRpcError(_errNotFound)
+	aTest.MustBeEqual(ok, false)
+	_, ok = er.ToRpcError(NewRpcErrorByUser(7, "Seven", nil).AsError())
+	aTest.MustBeEqual(ok, true)
+}
+
+func Test_ErrorRegistry_ToError(t *testing.T) {
+	aTest := tester.New(t)
+	var res *RpcErrorStd
+
+	er, err := _newErrorRegistry()
+	aTest.MustBeNoError(err)
+
+	// Test #1. Null RPC error.
+	aTest.MustBeNoError(er.ToError(nil))
+
+	// Test #2. Registered sentinel error.
+	err = er.ToError(&RpcError{Code: 404, Message: "Not found", Data: "x"})
+	aTest.MustBeEqual(errors.Is(err, _errNotFound), true)
+	aTest.MustBeEqual(errors.Is(err, _errConflict), false)
+	aTest.MustBeEqual(err.Error(), "Not found")
+	aTest.MustBeEqual(errors.As(err, &res), true)
+	aTest.MustBeEqual(res.Data, "x")
+
+	// Test #3. Registered type of errors.
+	err = er.ToError(&RpcError{Code: 429, Message: "Quota is exceeded"})
+	aTest.MustBeEqual(errors.Is(err, _errNotFound), false)
+	aTest.MustBeEqual(errors.As(err, &res), true)
+	aTest.MustBeEqual(res.Code, RpcErrorCode(429))
+
+	// Test #4. Null registry.
+	er = nil
+	err = er.ToError(&RpcError{Code: 404, Message: "Not found"})
+	aTest.MustBeEqual(errors.Is(err, _errNotFound), false)
+	aTest.MustBeEqual(errors.Is(err, NewRpcErrorStd(404, "", nil)), true)
+}
@@ -0,0 +1,117 @@
+package jrm1
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Modes of details of exceptions shown to clients.
+const (
+	// No details, the data of the internal RPC error is null.
+	ExceptionDetails_None = ExceptionDetails("none")
+
+	// Only the incident ID, which is suitable for production environments.
+	ExceptionDetails_IncidentId = ExceptionDetails("incident")
+
+	// The incident ID, the value passed to the 'panic' function and a trimmed
+	// stack trace, which is suitable for development environments only.
+	ExceptionDetails_Debug = ExceptionDetails("debug")
+
+	// ExceptionDetails_Default is used when the mode is not set.
+	ExceptionDetails_Default = ExceptionDetails_None
+)
+
+const (
+	ErrFUnsupportedExceptionDetails     = "unsupported exception details: %v"
+	ErrEnableExceptionCaptureToShowThem = "enable exception capture to show their details"
+	ErrEnableExceptionLogToShowThem     = "enable exception log to show their details"
+)
+
+// maxIncidentStackFrames is the maximum number of frames of the stack trace
+// shown to clients.
+const maxIncidentStackFrames = 16
+
+// ExceptionDetails is a mode of details of an exception (panic) caught during
+// the function call, which are put into the 'Data' field of the internal RPC
+// error, so that reports of clients can be matched to the journal of the
+// server.
+type ExceptionDetails string
+
+// Check ensures that the mode is supported. Empty mode is supported and means
+// the default mode.
+func (ed ExceptionDetails) Check() (err error) {
+	switch ed {
+	case "",
+		ExceptionDetails_None,
+		ExceptionDetails_IncidentId,
+		ExceptionDetails_Debug:
+		return nil
+	default:
+		return fmt.Errorf(ErrFUnsupportedExceptionDetails, ed)
+	}
+}
+
+// isEnabled tells whether any details are shown.
+func (ed ExceptionDetails) isEnabled() bool {
+	return (ed == ExceptionDetails_IncidentId) || (ed == ExceptionDetails_Debug)
+}
+
+// newIncidentDetails creates details of the exception according to the mode.
+func (ed ExceptionDetails) newIncidentDetails(pi *panicInfo) (id *IncidentDetails) {
+	id = &IncidentDetails{IncidentId: pi.incidentId}
+	if ed == ExceptionDetails_Debug {
+		id.Panic = fmt.Sprint(pi.value)
+		id.Stack = trimStack(pi.stack)
+	}
+
+	return id
+}
+
+// IncidentDetails are details of an exception (panic) caught during the
+// function call. They are the data of the internal RPC error.
+type IncidentDetails struct {
+	// Identifier of the incident, which is written into the journal of the
+	// server as well.
+	IncidentId string `json:"incidentId"`
+
+	// Value passed to the 'panic' function.
+	Panic string `json:"panic,omitempty"`
+
+	// Frames of the stack trace, starting from the place of the exception.
+	// Each frame is a function and its location in source code.
+	Stack []string `json:"stack,omitempty"`
+}
+
+// trimStack converts the stack trace of the goroutine where the exception
+// happened into frames. Frames of catching the exception and of the framework
+// calling the function or the encoder are removed, the number of frames is
+// limited.
+func trimStack(stack []byte) (frames []string) {
+	lines := strings.Split(strings.TrimSpace(string(stack)), "\n")
+
+	// The first line is the header of the goroutine, then each frame is a
+	// line with the function and a line with its location.
+	var isPanicPassed bool
+	for i := 1; i+1 < len(lines); i += 2 {
+		function := lines[i]
+		location := strings.TrimSpace(lines[i+1])
+		location, _, _ = strings.Cut(location, " +0x")
+
+		if !isPanicPassed {
+			isPanicPassed = strings.HasPrefix(function, "panic(")
+			continue
+		}
+
+		if strings.Contains(function, ".(*Processor).callFunc(") ||
+			strings.Contains(function, ".(*Processor).marshalJson(") {
+			break
+		}
+
+		frames = append(frames, function+" at "+location)
+		if len(frames) == maxIncidentStackFrames {
+			break
+		}
+	}
+
+	return frames
+}
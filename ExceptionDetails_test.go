@@ -0,0 +1,118 @@
+package jrm1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_ExceptionDetails_Check(t *testing.T) {
+	aTest := tester.New(t)
+
+	aTest.MustBeNoError(ExceptionDetails("").Check())
+	aTest.MustBeNoError(ExceptionDetails_None.Check())
+	aTest.MustBeNoError(ExceptionDetails_IncidentId.Check())
+	aTest.MustBeNoError(ExceptionDetails_Debug.Check())
+	aTest.MustBeAnError(ExceptionDetails("stack").Check())
+}
+
+func Test_ExceptionDetails_newIncidentDetails(t *testing.T) {
+	aTest := tester.New(t)
+	pi := &panicInfo{value: "boom", incidentId: "abc"}
+
+	// Test #1. Incident ID.
+	aTest.MustBeEqual(*ExceptionDetails_IncidentId.newIncidentDetails(pi), IncidentDetails{IncidentId: "abc"})
+
+	// Test #2. Debug.
+	id := ExceptionDetails_Debug.newIncidentDetails(pi)
+	aTest.MustBeEqual(id.IncidentId, "abc")
+	aTest.MustBeEqual(id.Panic, "boom")
+}
+
+func Test_trimStack(t *testing.T) {
+	aTest := tester.New(t)
+
+	stack := []byte(`goroutine 7 [running]:
+runtime/debug.Stack()
+	/usr/local/go/src/runtime/debug/stack.go:26 +0x5e
+github.com/vault-thirteen/JSON-RPC-M1.(*Processor).callFunc.func1()
+	/root/module/Processor.go:449 +0x4b
+panic({0x6b2f40?, 0xa1b2c0?})
+	/usr/local/go/src/runtime/panic.go:792 +0x132
+main.divide(...)
+	/app/main.go:10
+main.handler(0x0?, 0x0?)
+	/app/main.go:20 +0x1d
+github.com/vault-thirteen/JSON-RPC-M1.(*Processor).callFunc(0xc000100000, {0x0, 0x0}, 0x0, 0x0, 0x0)
+	/root/module/Processor.go:480 +0x1a5
+github.com/vault-thirteen/JSON-RPC-M1.(*Processor).runFunc(0xc000100000, {0x0, 0x0}, 0x0, 0x0)
+	/root/module/Processor.go:420 +0x9d
+`)
+
+	// Test #1. Frames of the function.
+	aTest.MustBeEqual(trimStack(stack), []string{
+		"main.divide(...) at /app/main.go:10",
+		"main.handler(0x0?, 0x0?) at /app/main.go:20",
+	})
+
+	// Test #2. Not a stack trace.
+	aTest.MustBeEqual(len(trimStack([]byte("abc"))), 0)
+}
+
+func Test_Processor_exceptionDetails(t *testing.T) {
+	aTest := tester.New(t)
+	var logBuf bytes.Buffer
+	var resp RpcResponse
+	var id IncidentDetails
+
+	ps := &ProcessorSettings{
+		CatchExceptions:  true,
+		LogExceptions:    true,
+		Logger:           slog.New(slog.NewJSONHandler(&logBuf, nil)),
+		ExceptionDetails: ExceptionDetails_IncidentId,
+	}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionExampleCrasher)
+	aTest.MustBeNoError(err)
+	request := []byte(`{"jsonrpc":"M1","id":"1","method":"RpcFunctionExampleCrasher","params":{}}`)
+
+	// Test #1. Incident ID is shown and journaled.
+	err = json.Unmarshal(p.Handle(context.Background(), request), &resp)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(resp.Error.Code, RpcErrorCode(RpcErrorCode_InternalRpcError))
+	buf, err := json.Marshal(resp.Error.Data)
+	aTest.MustBeNoError(err)
+	err = json.Unmarshal(buf, &id)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(len(id.IncidentId), 32)
+	aTest.MustBeEqual(len(id.Panic), 0)
+	aTest.MustBeEqual(len(id.Stack), 0)
+	aTest.MustBeEqual(strings.Contains(logBuf.String(), `"incident_id":"`+id.IncidentId+`"`), true)
+
+	// Test #2. Debug details.
+	ps.ExceptionDetails = ExceptionDetails_Debug
+	err = json.Unmarshal(p.Handle(context.Background(), request), &resp)
+	aTest.MustBeNoError(err)
+	buf, err = json.Marshal(resp.Error.Data)
+	aTest.MustBeNoError(err)
+	id = IncidentDetails{}
+	err = json.Unmarshal(buf, &id)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(len(id.IncidentId), 32)
+	aTest.MustBeEqual(id.Panic, "runtime error: integer divide by zero")
+	aTest.MustBeEqual(len(id.Stack) > 0, true)
+	aTest.MustBeEqual(strings.HasPrefix(id.Stack[0], "github.com/vault-thirteen/JSON-RPC-M1.RpcFunctionExampleCrasher("), true)
+
+	// Test #3. No details.
+	ps.ExceptionDetails = ExceptionDetails_None
+	resp = RpcResponse{}
+	err = json.Unmarshal(p.Handle(context.Background(), request), &resp)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(resp.Error.Data, nil)
+}
@@ -0,0 +1,77 @@
+package jrm1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+const (
+	ErrFInProcessServerHasPanicked = "RPC server has panicked: %v"
+)
+
+// InProcessRemoteAddress is a network address of the client which is seen by
+// the RPC server when the in-process transport is used.
+const InProcessRemoteAddress = "in-process"
+
+// InProcessTransport is a transport of the client which passes function calls
+// directly to the RPC processor of the same program, without sockets. It
+// implements the 'http.RoundTripper' interface, so the client works without
+// changes, while the URL of the RPC server set in the client settings is
+// ignored. Requests and responses are encoded and decoded in the same way as
+// with HTTP, so the transport is useful for testing of clients against real
+// processors and for running several services in a single program.
+type InProcessTransport struct {
+	processor *Processor
+}
+
+// NewInProcessTransport is a constructor of the in-process transport of the
+// client connected to the specified RPC processor.
+func NewInProcessTransport(p *Processor) (ipt *InProcessTransport) {
+	return &InProcessTransport{
+		processor: p,
+	}
+}
+
+// RoundTrip passes the function call to the RPC processor and waits for the
+// start of its response. The body of the response is read while the processor
+// writes it, so streamed results are received lazily.
+// 'RoundTrip' is a required method of the 'http.RoundTripper' interface.
+func (ipt *InProcessTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	ctx := req.Context()
+
+	srvReq := req.Clone(ctx)
+	srvReq.RemoteAddr = InProcessRemoteAddress
+	srvReq.RequestURI = req.URL.RequestURI()
+	srvReq.Host = req.URL.Host
+
+	// When the client stops waiting, the processor stops writing the response.
+	rw := newInProcessResponseWriter()
+	stopCancel := context.AfterFunc(ctx, func() {
+		rw.cancel(ctx.Err())
+	})
+
+	go func() {
+		defer stopCancel()
+		defer func() {
+			x := recover()
+			if x != nil {
+				rw.fail(fmt.Errorf(ErrFInProcessServerHasPanicked, x))
+				return
+			}
+			rw.finish()
+		}()
+
+		ipt.processor.ServeHTTP(rw, srvReq)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-rw.headerCh:
+		if rw.err != nil {
+			return nil, rw.err
+		}
+		return rw.newHttpResponse(req), nil
+	}
+}
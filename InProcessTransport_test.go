@@ -0,0 +1,104 @@
+package jrm1
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_NewInProcessTransport(t *testing.T) {
+	aTest := tester.New(t)
+
+	p, err := NewProcessor(&ProcessorSettings{})
+	aTest.MustBeNoError(err)
+
+	// Test.
+	ipt := NewInProcessTransport(p)
+	aTest.MustBeEqual(ipt.processor, p)
+}
+
+func Test_InProcessTransport_RoundTrip(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	remoteAddresses := make(chan string, 1)
+	ps := &ProcessorSettings{
+		EnableNotifications: true,
+		AccessPolicy: func(ac *AccessCheck) bool {
+			select {
+			case remoteAddresses <- ac.HttpRequest.RemoteAddr:
+			default:
+			}
+			return true
+		},
+	}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	err = p.AddStreamFunc(RpcFunctionCounter)
+	aTest.MustBeNoError(err)
+	err = p.AddNotificationFunc(RpcFunctionNotified)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionExampleCrasher)
+	aTest.MustBeNoError(err)
+
+	// The URL is not used.
+	cs, err := NewClientSettings("http", "localhost", 1, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+	cs.SetInProcessTransport(NewInProcessTransport(p))
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Successful call.
+	var result SumResult
+	re, err := c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 1, B: 2}, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeEqual(result.C, byte(3))
+	aTest.MustBeEqual(<-remoteAddresses, InProcessRemoteAddress)
+
+	// Test #2. Error of a function.
+	re, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 255, B: 1}, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(1))
+
+	// Test #3. Streaming function.
+	rs, re, err := c.CallStream(context.Background(), "RpcFunctionCounter", 3)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	var items []int
+	for item := range DecodeStreamItems[int](rs) {
+		items = append(items, item)
+	}
+	aTest.MustBeEqual(items, []int{1, 2, 3})
+	aTest.MustBeNoError(rs.Close())
+
+	// Test #4. Stream closed before the end.
+	rs, _, err = c.CallStream(context.Background(), "RpcFunctionCounter", 3)
+	aTest.MustBeNoError(err)
+	for range rs.Items() {
+		break
+	}
+	aTest.MustBeNoError(rs.Close())
+
+	// Test #5. Panic of the processor.
+	_, err = c.Call(context.Background(), "RpcFunctionExampleCrasher", struct{}{}, &result)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), fmt.Sprintf(`Post "http://localhost:1/": `+ErrFInProcessServerHasPanicked, "runtime error: integer divide by zero"))
+
+	// Test #6. Cancelled context.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.Call(ctx, "RpcFunctionSum", SumParams{A: 1, B: 2}, &result)
+	aTest.MustBeAnError(err)
+
+	// Test #7. Notification.
+	re, err = c.Notify(context.Background(), "RpcFunctionNotified", "x")
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	p.Stop()
+	aTest.MustBeEqual(<-_notifiedParams, `"x"`)
+}
@@ -0,0 +1,116 @@
+package jrm1
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Names of built-in job methods.
+const (
+	JobMethod_GetStatus = "M1_GetJobStatus"
+	JobMethod_GetResult = "M1_GetJobResult"
+	JobMethod_Cancel    = "M1_CancelJob"
+)
+
+// Details of job errors.
+const (
+	ErrJobIsNotFound    = "job is not found"
+	ErrJobIsNotFinished = "job is not finished"
+	ErrJobIsFinished    = "job is finished"
+	ErrJobIsCancelled   = "job is cancelled"
+	ErrJobsAreStopped   = "jobs are stopped"
+	ErrJobsAreDisabled  = "jobs are disabled"
+)
+
+// Statuses of an asynchronous job.
+const (
+	JobStatus_Running   = JobStatus("running")
+	JobStatus_Succeeded = JobStatus("succeeded")
+	JobStatus_Failed    = JobStatus("failed")
+	JobStatus_Cancelled = JobStatus("cancelled")
+)
+
+// Job is an asynchronous execution of an RPC function.
+type Job struct {
+	// Identifier of the job.
+	Id string `json:"jobId"`
+
+	// Name of the executed function.
+	Method string `json:"method"`
+
+	// Status of the job.
+	Status JobStatus `json:"status"`
+
+	// Encoded result of the function. It is set when the job succeeds.
+	Result json.RawMessage `json:"result,omitempty"`
+
+	// Error returned by the function. It is set when the job fails.
+	Error *RpcError `json:"error,omitempty"`
+
+	// Time of the job creation.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Time of the job finishing. It is not set while the job is running.
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// JobStatus is a status of an asynchronous job.
+type JobStatus string
+
+// JobParams are parameters of built-in job methods.
+type JobParams struct {
+	JobId string `json:"jobId"`
+}
+
+// JobInfo is a result of built-in job methods and of calls of asynchronous
+// functions. It describes the job without its result.
+type JobInfo struct {
+	JobId      string     `json:"jobId"`
+	Method     string     `json:"method"`
+	Status     JobStatus  `json:"status"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// NewJob is a constructor of a running job with a random identifier.
+func NewJob(method string) (job *Job) {
+	return &Job{
+		Id:        newRandomId(),
+		Method:    method,
+		Status:    JobStatus_Running,
+		CreatedAt: time.Now(),
+	}
+}
+
+// IsFinished tells whether the job status is final.
+func (js JobStatus) IsFinished() bool {
+	return js != JobStatus_Running
+}
+
+// Info returns the description of the job.
+func (j *Job) Info() (ji *JobInfo) {
+	return &JobInfo{
+		JobId:      j.Id,
+		Method:     j.Method,
+		Status:     j.Status,
+		CreatedAt:  j.CreatedAt,
+		FinishedAt: j.FinishedAt,
+	}
+}
+
+// finish sets the final status of the job and its outcome.
+func (j *Job) finish(status JobStatus, result json.RawMessage, re *RpcError) {
+	t := time.Now()
+	j.Status = status
+	j.Result = result
+	j.Error = re
+	j.FinishedAt = &t
+}
+
+// newJobError creates an RPC error of a job with the specified details. Jobs
+// do not have an error code of their own: the error is an invalid request,
+// while its data tells the problem with the job.
+func newJobError(details string) (re *RpcError) {
+	re, _ = NewRpcError(RpcErrorCode_InvalidRequest, details)
+	return re
+}
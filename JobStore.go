@@ -0,0 +1,18 @@
+package jrm1
+
+import "time"
+
+// JobStore keeps asynchronous jobs. It is a small interface which may be
+// implemented on top of any database. Store must be safe for concurrent use.
+type JobStore interface {
+	// SaveJob creates or updates the job.
+	SaveJob(job *Job) (err error)
+
+	// GetJob reads the job. If the job is not found, nil is returned without
+	// an error.
+	GetJob(id string) (job *Job, err error)
+
+	// DeleteJobsFinishedBefore deletes jobs which have finished before the
+	// specified time. Running jobs are kept.
+	DeleteJobsFinishedBefore(t time.Time) (err error)
+}
@@ -0,0 +1,69 @@
+package jrm1
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_NewJob(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	job := NewJob("Method")
+	aTest.MustBeEqual(len(job.Id), 32)
+	aTest.MustBeEqual(job.Method, "Method")
+	aTest.MustBeEqual(job.Status, JobStatus_Running)
+	aTest.MustBeEqual(job.CreatedAt.IsZero(), false)
+	aTest.MustBeEqual(job.FinishedAt, (*time.Time)(nil))
+	aTest.MustBeDifferent(NewJob("Method").Id, job.Id)
+}
+
+func Test_JobStatus_IsFinished(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	aTest.MustBeEqual(JobStatus_Running.IsFinished(), false)
+	aTest.MustBeEqual(JobStatus_Succeeded.IsFinished(), true)
+	aTest.MustBeEqual(JobStatus_Failed.IsFinished(), true)
+	aTest.MustBeEqual(JobStatus_Cancelled.IsFinished(), true)
+}
+
+func Test_Job_Info(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	job := NewJob("Method")
+	job.finish(JobStatus_Succeeded, json.RawMessage(`1`), nil)
+	aTest.MustBeEqual(job.Info(), &JobInfo{
+		JobId:      job.Id,
+		Method:     "Method",
+		Status:     JobStatus_Succeeded,
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+	})
+}
+
+func Test_Job_finish(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	job := NewJob("Method")
+	re := NewRpcErrorByUser(1, "failure", nil)
+	job.finish(JobStatus_Failed, nil, re)
+	aTest.MustBeEqual(job.Status, JobStatus_Failed)
+	aTest.MustBeEqual(job.Error, re)
+	aTest.MustBeDifferent(job.FinishedAt, (*time.Time)(nil))
+}
+
+func Test_newJobError(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	re := newJobError(ErrJobIsNotFound)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(RpcErrorCode_InvalidRequest))
+	aTest.MustBeEqual(re.Message, RpcErrorMessage(RpcErrorMsg_InvalidRequest))
+	aTest.MustBeEqual(re.Data, ErrJobIsNotFound)
+}
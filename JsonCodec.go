@@ -0,0 +1,55 @@
+package jrm1
+
+import (
+	"bytes"
+	"encoding/json"
+
+	mime "github.com/vault-thirteen/auxie/MIME"
+)
+
+// JsonCodec is a codec of the JSON format. It is the default codec.
+type JsonCodec struct {
+	profile JsonProfile
+}
+
+// NewJsonCodec is a constructor of a JSON codec.
+func NewJsonCodec() (jc *JsonCodec) {
+	return new(JsonCodec)
+}
+
+// NewJsonCodecWithProfile is a constructor of a JSON codec using the
+// specified encoding profile.
+func NewJsonCodecWithProfile(profile JsonProfile) (jc *JsonCodec, err error) {
+	err = profile.Check()
+	if err != nil {
+		return nil, err
+	}
+
+	return &JsonCodec{profile: profile}, nil
+}
+
+// ContentType returns the type of content of encoded messages.
+func (jc *JsonCodec) ContentType() string {
+	return mime.TypeApplicationJson
+}
+
+// Marshal encodes the value. The encoded text is terminated with a new line
+// symbol in the same way as a JSON encoder does.
+func (jc *JsonCodec) Marshal(v any) (data []byte, err error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if jc.profile.isPretty() {
+		enc.SetIndent("", jsonIndent)
+	}
+	err = enc.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes the data into the value.
+func (jc *JsonCodec) Unmarshal(data []byte, v any) (err error) {
+	return json.Unmarshal(data, v)
+}
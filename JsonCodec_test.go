@@ -0,0 +1,41 @@
+package jrm1
+
+import (
+	"testing"
+
+	mime "github.com/vault-thirteen/auxie/MIME"
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_NewJsonCodec(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	codec := NewJsonCodec()
+	aTest.MustBeEqual(codec.ContentType(), mime.TypeApplicationJson)
+	_checkCodec(t, codec)
+}
+
+func Test_JsonCodec_Marshal(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test. Output is the same as of a JSON encoder.
+	data, err := NewJsonCodec().Marshal(map[string]string{"a": "<b>"})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(data), `{"a":"\u003cb\u003e"}`+"\n")
+}
+
+func Test_NewJsonCodecWithProfile(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test #1. Pretty profile.
+	codec, err := NewJsonCodecWithProfile(JsonProfile_Pretty)
+	aTest.MustBeNoError(err)
+	data, err := codec.Marshal(map[string]int{"a": 1})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(data), "{\n\t\"a\": 1\n}\n")
+
+	// Test #2. Negative.
+	_, err = NewJsonCodecWithProfile("indented")
+	aTest.MustBeAnError(err)
+}
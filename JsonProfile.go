@@ -0,0 +1,65 @@
+package jrm1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Profiles of JSON encoding.
+const (
+	// Compact text without white space.
+	JsonProfile_Compact = JsonProfile("compact")
+
+	// Text indented with tabulation symbols, which is useful for debugging.
+	JsonProfile_Pretty = JsonProfile("pretty")
+
+	// JsonProfile_Default is used when the profile is not set.
+	JsonProfile_Default = JsonProfile_Compact
+)
+
+const (
+	ErrFUnsupportedJsonProfile = "unsupported JSON profile: %v"
+)
+
+// jsonIndent is the indentation of the pretty JSON profile.
+const jsonIndent = "\t"
+
+// JsonProfile is a profile of JSON encoding of requests and responses.
+type JsonProfile string
+
+// Check ensures that the JSON profile is supported. Empty profile is
+// supported and means the default profile.
+func (jp JsonProfile) Check() (err error) {
+	switch jp {
+	case "",
+		JsonProfile_Compact,
+		JsonProfile_Pretty:
+		return nil
+	default:
+		return fmt.Errorf(ErrFUnsupportedJsonProfile, jp)
+	}
+}
+
+// isPretty tells whether the text is indented.
+func (jp JsonProfile) isPretty() bool {
+	return jp == JsonProfile_Pretty
+}
+
+// finish applies the profile to the compact JSON text and terminates it with
+// a new line symbol in the same way as a JSON encoder does.
+func (jp JsonProfile) finish(compact *bytes.Buffer) (data []byte, err error) {
+	if !jp.isPretty() {
+		compact.WriteByte('\n')
+		return compact.Bytes(), nil
+	}
+
+	var buf bytes.Buffer
+	err = json.Indent(&buf, compact.Bytes(), "", jsonIndent)
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+
+	return buf.Bytes(), nil
+}
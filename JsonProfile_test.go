@@ -0,0 +1,38 @@
+package jrm1
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_JsonProfile_Check(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	aTest.MustBeNoError(JsonProfile("").Check())
+	aTest.MustBeNoError(JsonProfile_Compact.Check())
+	aTest.MustBeNoError(JsonProfile_Pretty.Check())
+	aTest.MustBeAnError(JsonProfile("indented").Check())
+}
+
+func Test_JsonProfile_finish(t *testing.T) {
+	aTest := tester.New(t)
+	var data []byte
+	var err error
+
+	// Test #1. Compact profile.
+	data, err = JsonProfile_Compact.finish(bytes.NewBufferString(`{"a":[1,2]}`))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(data), "{\"a\":[1,2]}\n")
+
+	// Test #2. Pretty profile.
+	data, err = JsonProfile_Pretty.finish(bytes.NewBufferString(`{"a":[1,2]}`))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(data), "{\n\t\"a\": [\n\t\t1,\n\t\t2\n\t]\n}\n")
+
+	// Test #3. Negative.
+	_, err = JsonProfile_Pretty.finish(bytes.NewBufferString(`{`))
+	aTest.MustBeAnError(err)
+}
@@ -0,0 +1,61 @@
+package jrm1
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryJobStore is a job store which keeps jobs in memory. Jobs are lost when
+// the process exits.
+type MemoryJobStore struct {
+	guard *sync.RWMutex
+	jobs  map[string]*Job
+}
+
+// NewMemoryJobStore is a constructor of an in-memory job store.
+func NewMemoryJobStore() (mjs *MemoryJobStore) {
+	return &MemoryJobStore{
+		guard: new(sync.RWMutex),
+		jobs:  make(map[string]*Job),
+	}
+}
+
+// SaveJob creates or updates the job. A copy of the job is stored.
+func (mjs *MemoryJobStore) SaveJob(job *Job) (err error) {
+	mjs.guard.Lock()
+	defer mjs.guard.Unlock()
+
+	jobCopy := *job
+	mjs.jobs[job.Id] = &jobCopy
+
+	return nil
+}
+
+// GetJob reads a copy of the job. If the job is not found, nil is returned.
+func (mjs *MemoryJobStore) GetJob(id string) (job *Job, err error) {
+	mjs.guard.RLock()
+	defer mjs.guard.RUnlock()
+
+	storedJob, ok := mjs.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+
+	jobCopy := *storedJob
+	return &jobCopy, nil
+}
+
+// DeleteJobsFinishedBefore deletes jobs which have finished before the
+// specified time.
+func (mjs *MemoryJobStore) DeleteJobsFinishedBefore(t time.Time) (err error) {
+	mjs.guard.Lock()
+	defer mjs.guard.Unlock()
+
+	for id, job := range mjs.jobs {
+		if (job.FinishedAt != nil) && job.FinishedAt.Before(t) {
+			delete(mjs.jobs, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package jrm1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_MemoryJobStore_SaveJob(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	// Test #1. Copy is stored.
+	mjs := NewMemoryJobStore()
+	job := NewJob("Method")
+	err = mjs.SaveJob(job)
+	aTest.MustBeNoError(err)
+	job.Status = JobStatus_Cancelled
+	var storedJob *Job
+	storedJob, err = mjs.GetJob(job.Id)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(storedJob.Status, JobStatus_Running)
+
+	// Test #2. Update.
+	err = mjs.SaveJob(job)
+	aTest.MustBeNoError(err)
+	storedJob, err = mjs.GetJob(job.Id)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(storedJob.Status, JobStatus_Cancelled)
+}
+
+func Test_MemoryJobStore_GetJob(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	mjs := NewMemoryJobStore()
+	job, err := mjs.GetJob("x")
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(job, (*Job)(nil))
+}
+
+func Test_MemoryJobStore_DeleteJobsFinishedBefore(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	// Test.
+	mjs := NewMemoryJobStore()
+	runningJob := NewJob("Method")
+	oldJob := NewJob("Method")
+	oldJob.finish(JobStatus_Succeeded, nil, nil)
+	newJob := NewJob("Method")
+	newJob.finish(JobStatus_Succeeded, nil, nil)
+	t2 := newJob.FinishedAt.Add(time.Hour)
+	newJob.FinishedAt = &t2
+	for _, job := range []*Job{runningJob, oldJob, newJob} {
+		err = mjs.SaveJob(job)
+		aTest.MustBeNoError(err)
+	}
+
+	err = mjs.DeleteJobsFinishedBefore(time.Now().Add(time.Minute))
+	aTest.MustBeNoError(err)
+	var job *Job
+	job, _ = mjs.GetJob(runningJob.Id)
+	aTest.MustBeDifferent(job, (*Job)(nil))
+	job, _ = mjs.GetJob(oldJob.Id)
+	aTest.MustBeEqual(job, (*Job)(nil))
+	job, _ = mjs.GetJob(newJob.Id)
+	aTest.MustBeDifferent(job, (*Job)(nil))
+}
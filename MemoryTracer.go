@@ -0,0 +1,96 @@
+package jrm1
+
+import (
+	"crypto/rand"
+	"sync"
+	"time"
+)
+
+// MemoryTracer is a tracer which keeps finished spans in memory. It is useful
+// for tests and for environments without a trace collector.
+type MemoryTracer struct {
+	guard *sync.Mutex
+
+	// Finished spans in the order of finishing.
+	finishedSpans []*MemorySpan
+}
+
+// MemorySpan is a span created by the in-memory tracer.
+type MemorySpan struct {
+	tracer *MemoryTracer
+	guard  *sync.Mutex
+
+	Name       string
+	Context    SpanContext
+	Parent     SpanContext
+	Attributes map[string]any
+	StartTime  time.Time
+	EndTime    time.Time
+}
+
+// NewMemoryTracer is a constructor of an in-memory tracer.
+func NewMemoryTracer() (mt *MemoryTracer) {
+	return &MemoryTracer{
+		guard:         new(sync.Mutex),
+		finishedSpans: make([]*MemorySpan, 0),
+	}
+}
+
+// StartSpan starts a span. If the parent span context is not valid, a new
+// trace is started.
+func (mt *MemoryTracer) StartSpan(name string, parent SpanContext) Span {
+	ms := &MemorySpan{
+		tracer:     mt,
+		guard:      new(sync.Mutex),
+		Name:       name,
+		Parent:     parent,
+		Attributes: make(map[string]any),
+		StartTime:  time.Now(),
+	}
+
+	if parent.IsValid() {
+		ms.Context.TraceId = parent.TraceId
+		ms.Context.TraceFlags = parent.TraceFlags
+		ms.Context.TraceState = parent.TraceState
+	} else {
+		// Reading of random bytes never fails.
+		_, _ = rand.Read(ms.Context.TraceId[:])
+		ms.Context.TraceFlags = TraceFlagSampled
+	}
+	_, _ = rand.Read(ms.Context.SpanId[:])
+
+	return ms
+}
+
+// FinishedSpans returns a copy of the list of finished spans.
+func (mt *MemoryTracer) FinishedSpans() []*MemorySpan {
+	mt.guard.Lock()
+	defer mt.guard.Unlock()
+
+	return append([]*MemorySpan(nil), mt.finishedSpans...)
+}
+
+// SpanContext returns the identity of the span.
+func (ms *MemorySpan) SpanContext() SpanContext {
+	return ms.Context
+}
+
+// SetAttribute sets an attribute of the span.
+func (ms *MemorySpan) SetAttribute(key string, value any) {
+	ms.guard.Lock()
+	defer ms.guard.Unlock()
+
+	ms.Attributes[key] = value
+}
+
+// End finishes the span and passes it to the tracer.
+func (ms *MemorySpan) End() {
+	ms.guard.Lock()
+	ms.EndTime = time.Now()
+	ms.guard.Unlock()
+
+	ms.tracer.guard.Lock()
+	defer ms.tracer.guard.Unlock()
+
+	ms.tracer.finishedSpans = append(ms.tracer.finishedSpans, ms)
+}
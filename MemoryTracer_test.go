@@ -0,0 +1,43 @@
+package jrm1
+
+import (
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_MemoryTracer_StartSpan(t *testing.T) {
+	aTest := tester.New(t)
+	var mt *MemoryTracer
+
+	// Test #1. New trace.
+	mt = NewMemoryTracer()
+	root := mt.StartSpan("root", SpanContext{})
+	aTest.MustBeEqual(root.SpanContext().IsValid(), true)
+	aTest.MustBeEqual(root.SpanContext().IsSampled(), true)
+
+	// Test #2. Child span.
+	parent := root.SpanContext()
+	parent.TraceState = "a=b"
+	child := mt.StartSpan("child", parent)
+	aTest.MustBeEqual(child.SpanContext().TraceId, parent.TraceId)
+	aTest.MustBeDifferent(child.SpanContext().SpanId, parent.SpanId)
+	aTest.MustBeEqual(child.SpanContext().TraceState, "a=b")
+	aTest.MustBeEqual(child.(*MemorySpan).Parent, parent)
+}
+
+func Test_MemoryTracer_FinishedSpans(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	mt := NewMemoryTracer()
+	span := mt.StartSpan("s", SpanContext{})
+	span.SetAttribute("k", "v")
+	aTest.MustBeEqual(len(mt.FinishedSpans()), 0)
+	span.End()
+	spans := mt.FinishedSpans()
+	aTest.MustBeEqual(len(spans), 1)
+	aTest.MustBeEqual(spans[0].Name, "s")
+	aTest.MustBeEqual(spans[0].Attributes, map[string]any{"k": "v"})
+	aTest.MustBeEqual(spans[0].EndTime.IsZero(), false)
+}
@@ -0,0 +1,43 @@
+package jrm1
+
+// MessagePackContentType is the type of content of messages encoded using
+// the MessagePack format.
+const MessagePackContentType = "application/msgpack"
+
+// MessagePackCodec is a codec of the MessagePack format. Values are encoded
+// with the same field names as in JSON. Byte slices are encoded as binary
+// strings, while binary strings are decoded as Base64 text, in the same way
+// as JSON decodes byte slices. Extension types are not supported.
+type MessagePackCodec struct{}
+
+// NewMessagePackCodec is a constructor of a MessagePack codec.
+func NewMessagePackCodec() (mpc *MessagePackCodec) {
+	return new(MessagePackCodec)
+}
+
+// ContentType returns the type of content of encoded messages.
+func (mpc *MessagePackCodec) ContentType() string {
+	return MessagePackContentType
+}
+
+// Marshal encodes the value.
+func (mpc *MessagePackCodec) Marshal(v any) (data []byte, err error) {
+	var tree any
+	tree, err = newCodecTree(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendMsgpack(nil, tree)
+}
+
+// Unmarshal decodes the data into the value.
+func (mpc *MessagePackCodec) Unmarshal(data []byte, v any) (err error) {
+	var tree any
+	tree, err = decodeMsgpack(data)
+	if err != nil {
+		return err
+	}
+
+	return unmarshalJsonTree(tree, v)
+}
@@ -0,0 +1,16 @@
+package jrm1
+
+import (
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_NewMessagePackCodec(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	codec := NewMessagePackCodec()
+	aTest.MustBeEqual(codec.ContentType(), MessagePackContentType)
+	_checkCodec(t, codec)
+}
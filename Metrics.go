@@ -0,0 +1,168 @@
+package jrm1
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"github.com/vault-thirteen/auxie/header"
+)
+
+const (
+	MetricsContentType   = "text/plain; version=0.0.4; charset=utf-8"
+	MetricsUnknownMethod = "(unknown)"
+)
+
+// Labels of metrics.
+const (
+	MetricLabel_Method  = "method"
+	MetricLabel_Outcome = "outcome"
+)
+
+// Outcomes of function calls used in metrics.
+const (
+	MetricOutcome_Success        = AccessLogOutcomeSuccess
+	MetricOutcome_Failure        = AccessLogOutcomeFailure
+	MetricOutcome_TransportError = "transport error"
+)
+
+// Default upper bounds of histogram buckets.
+var (
+	DefaultMetricsDurationBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	DefaultMetricsSizeBuckets     = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216}
+)
+
+// Metrics is a set of metrics of RPC processors (servers) and RPC clients.
+// The same set may be shared by several processors and clients. Metrics are
+// served in the text exposition format of Prometheus, thus the set is an HTTP
+// handler which can be registered at a metrics endpoint.
+type Metrics struct {
+	// Server-side metrics.
+	serverCalls         *metricFamily
+	serverCallDuration  *metricFamily
+	serverCallsInFlight *metricFamily
+	serverPanics        *metricFamily
+	serverRequestSize   *metricFamily
+	serverResponseSize  *metricFamily
+
+	// Client-side metrics.
+	clientCalls         *metricFamily
+	clientCallDuration  *metricFamily
+	clientCallsInFlight *metricFamily
+	clientRequestSize   *metricFamily
+	clientResponseSize  *metricFamily
+}
+
+// NewMetrics is a constructor of a set of metrics. Null buckets are replaced
+// with default buckets. Bucket bounds must be sorted in increasing order.
+func NewMetrics(durationBuckets []float64, sizeBuckets []float64) (m *Metrics) {
+	if durationBuckets == nil {
+		durationBuckets = DefaultMetricsDurationBuckets
+	}
+	if sizeBuckets == nil {
+		sizeBuckets = DefaultMetricsSizeBuckets
+	}
+
+	return &Metrics{
+		serverCalls: newMetricFamily("jrm1_server_calls_total",
+			"Number of function calls served by the RPC server.",
+			MetricTypeCounter, nil, MetricLabel_Method, MetricLabel_Outcome),
+		serverCallDuration: newMetricFamily("jrm1_server_call_duration_seconds",
+			"Time taken by the RPC server to serve a function call.",
+			MetricTypeHistogram, durationBuckets, MetricLabel_Method),
+		serverCallsInFlight: newMetricFamily("jrm1_server_calls_in_flight",
+			"Number of functions being executed by the RPC server.",
+			MetricTypeGauge, nil, MetricLabel_Method),
+		serverPanics: newMetricFamily("jrm1_server_panics_total",
+			"Number of exceptions caught by the RPC server.",
+			MetricTypeCounter, nil, MetricLabel_Method),
+		serverRequestSize: newMetricFamily("jrm1_server_request_size_bytes",
+			"Size of requests received by the RPC server.",
+			MetricTypeHistogram, sizeBuckets, MetricLabel_Method),
+		serverResponseSize: newMetricFamily("jrm1_server_response_size_bytes",
+			"Size of responses sent by the RPC server.",
+			MetricTypeHistogram, sizeBuckets, MetricLabel_Method),
+
+		clientCalls: newMetricFamily("jrm1_client_calls_total",
+			"Number of function calls made by the RPC client.",
+			MetricTypeCounter, nil, MetricLabel_Method, MetricLabel_Outcome),
+		clientCallDuration: newMetricFamily("jrm1_client_call_duration_seconds",
+			"Time taken by the RPC client to make a function call.",
+			MetricTypeHistogram, durationBuckets, MetricLabel_Method),
+		clientCallsInFlight: newMetricFamily("jrm1_client_calls_in_flight",
+			"Number of function calls being made by the RPC client.",
+			MetricTypeGauge, nil, MetricLabel_Method),
+		clientRequestSize: newMetricFamily("jrm1_client_request_size_bytes",
+			"Size of requests sent by the RPC client.",
+			MetricTypeHistogram, sizeBuckets, MetricLabel_Method),
+		clientResponseSize: newMetricFamily("jrm1_client_response_size_bytes",
+			"Size of responses received by the RPC client.",
+			MetricTypeHistogram, sizeBuckets, MetricLabel_Method),
+	}
+}
+
+// ServeHTTP writes all the metrics in the text exposition format.
+// 'ServeHTTP' is a required method of the 'http.Handler' interface.
+func (m *Metrics) ServeHTTP(rw http.ResponseWriter, _ *http.Request) {
+	var buf bytes.Buffer
+	for _, mf := range m.families() {
+		// Writing into a buffer can not fail.
+		_ = mf.write(&buf)
+	}
+
+	rw.Header().Set(header.HttpHeaderContentType, MetricsContentType)
+	_, _ = rw.Write(buf.Bytes())
+}
+
+// families lists all the metric families in the order of exposition.
+func (m *Metrics) families() []*metricFamily {
+	return []*metricFamily{
+		m.serverCalls,
+		m.serverCallDuration,
+		m.serverCallsInFlight,
+		m.serverPanics,
+		m.serverRequestSize,
+		m.serverResponseSize,
+		m.clientCalls,
+		m.clientCallDuration,
+		m.clientCallsInFlight,
+		m.clientRequestSize,
+		m.clientResponseSize,
+	}
+}
+
+// beginServerCall registers the start of a function execution.
+func (m *Metrics) beginServerCall(method string) {
+	m.serverCallsInFlight.add(+1, method)
+}
+
+// endServerCall registers the end of a function execution.
+func (m *Metrics) endServerCall(method string) {
+	m.serverCallsInFlight.add(-1, method)
+}
+
+// recordServerCall registers a function call served by the RPC server.
+func (m *Metrics) recordServerCall(method string, outcome string, duration time.Duration, hasPanic bool, requestSize int64, responseSize int64) {
+	m.serverCalls.add(1, method, outcome)
+	m.serverCallDuration.observe(duration.Seconds(), method)
+	if hasPanic {
+		m.serverPanics.add(1, method)
+	}
+	m.serverRequestSize.observe(float64(requestSize), method)
+	m.serverResponseSize.observe(float64(responseSize), method)
+}
+
+// beginClientCall registers the start of a function call made by the RPC
+// client.
+func (m *Metrics) beginClientCall(method string) {
+	m.clientCallsInFlight.add(+1, method)
+}
+
+// endClientCall registers a function call made by the RPC client.
+func (m *Metrics) endClientCall(method string, outcome string, duration time.Duration, requestSize int64, responseSize int64) {
+	m.clientCallsInFlight.add(-1, method)
+	m.clientCalls.add(1, method, outcome)
+	m.clientCallDuration.observe(duration.Seconds(), method)
+	m.clientRequestSize.observe(float64(requestSize), method)
+	m.clientResponseSize.observe(float64(responseSize), method)
+}
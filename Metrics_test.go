@@ -0,0 +1,123 @@
+package jrm1
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/header"
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_NewMetrics(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test #1. Default buckets.
+	m := NewMetrics(nil, nil)
+	aTest.MustBeEqual(m.serverCallDuration.buckets, DefaultMetricsDurationBuckets)
+	aTest.MustBeEqual(m.serverRequestSize.buckets, DefaultMetricsSizeBuckets)
+
+	// Test #2. Custom buckets.
+	m = NewMetrics([]float64{1}, []float64{2})
+	aTest.MustBeEqual(m.clientCallDuration.buckets, []float64{1})
+	aTest.MustBeEqual(m.clientResponseSize.buckets, []float64{2})
+}
+
+func Test_Metrics_ServeHTTP(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	metrics := NewMetrics([]float64{10}, []float64{1000})
+
+	ps := &ProcessorSettings{
+		CatchExceptions: true,
+		Metrics:         metrics,
+	}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionExampleCrasher)
+	aTest.MustBeNoError(err)
+
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	cs, err := _newClientSettingsForUrl(srv.URL)
+	aTest.MustBeNoError(err)
+	cs.SetMetrics(metrics)
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+
+	// Calls.
+	var result SumResult
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 1, B: 2}, &result)
+	aTest.MustBeNoError(err)
+	_, err = c.Call(context.Background(), "RpcFunctionExampleCrasher", struct{}{}, new(any))
+	aTest.MustBeNoError(err)
+	_, err = c.Call(context.Background(), "NoSuchFunction", struct{}{}, new(any))
+	aTest.MustBeNoError(err)
+
+	// Exposition.
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	aTest.MustBeEqual(recorder.Header().Get(header.HttpHeaderContentType), MetricsContentType)
+	body, err := io.ReadAll(recorder.Body)
+	aTest.MustBeNoError(err)
+	exposition := string(body)
+
+	expectedLines := []string{
+		"# TYPE jrm1_server_calls_total counter",
+		`jrm1_server_calls_total{method="(unknown)",outcome="failure"} 1`,
+		`jrm1_server_calls_total{method="RpcFunctionExampleCrasher",outcome="failure"} 1`,
+		`jrm1_server_calls_total{method="RpcFunctionSum",outcome="success"} 1`,
+		`jrm1_server_call_duration_seconds_count{method="RpcFunctionSum"} 1`,
+		`jrm1_server_calls_in_flight{method="RpcFunctionSum"} 0`,
+		`jrm1_server_panics_total{method="RpcFunctionExampleCrasher"} 1`,
+		`jrm1_server_request_size_bytes_bucket{method="RpcFunctionSum",le="1000"} 1`,
+		`jrm1_server_response_size_bytes_bucket{method="RpcFunctionSum",le="1000"} 1`,
+		`jrm1_client_calls_total{method="NoSuchFunction",outcome="failure"} 1`,
+		`jrm1_client_calls_total{method="RpcFunctionSum",outcome="success"} 1`,
+		`jrm1_client_call_duration_seconds_count{method="RpcFunctionSum"} 1`,
+		`jrm1_client_calls_in_flight{method="RpcFunctionSum"} 0`,
+		`jrm1_client_request_size_bytes_count{method="RpcFunctionSum"} 1`,
+		`jrm1_client_response_size_bytes_bucket{method="RpcFunctionSum",le="1000"} 1`,
+	}
+	for _, line := range expectedLines {
+		aTest.MustBeEqual(strings.Contains(exposition, line+"\n"), true)
+	}
+
+	// Transport error.
+	srv.Close()
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 1, B: 2}, &result)
+	aTest.MustBeAnError(err)
+	recorder = httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	aTest.MustBeEqual(strings.Contains(recorder.Body.String(),
+		`jrm1_client_calls_total{method="RpcFunctionSum",outcome="transport error"} 1`+"\n"), true)
+}
+
+func Test_Metrics_escapedException(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	metrics := NewMetrics(nil, nil)
+	p, err := NewProcessor(&ProcessorSettings{Metrics: metrics})
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionExampleCrasher)
+	aTest.MustBeNoError(err)
+
+	// Exception escapes from the function, while the call is finished.
+	func() {
+		defer func() { _ = recover() }()
+		_ = p.Handle(context.Background(), []byte(`{"jsonrpc":"M1","id":"1","method":"RpcFunctionExampleCrasher","params":{}}`))
+	}()
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	aTest.MustBeEqual(strings.Contains(recorder.Body.String(),
+		`jrm1_server_calls_in_flight{method="RpcFunctionExampleCrasher"} 0`+"\n"), true)
+}
@@ -0,0 +1,13 @@
+package jrm1
+
+// PanicHandler represents a signature for a handler of exceptions (panics)
+// caught by the RPC processor (server) in functions and in encoding of
+// responses. It receives the name of the function, the ID of the request, the
+// ID of the incident, the value passed to the 'panic' function and the stack
+// trace. The request ID is empty when it is not known, e.g. for asynchronous
+// jobs. The incident ID is empty when details of exceptions are not shown.
+// The handler may report the exception to an error tracker and may return a
+// custom RPC error for the client. When the custom error has no data, details
+// of the incident are put into its data. When null is returned, the client
+// gets an internal RPC error.
+type PanicHandler func(method string, requestId string, incidentId string, value any, stack []byte) (re *RpcError)
@@ -0,0 +1,147 @@
+package jrm1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+// _panickingValue is a value whose encoding into JSON panics.
+type _panickingValue struct{}
+
+func (v _panickingValue) MarshalJSON() ([]byte, error) {
+	panic("encoding is broken")
+}
+
+func RpcFunctionPanickingResult(_ *json.RawMessage, _ *ResponseMetaData) (result any, re *RpcError) {
+	return _panickingValue{}, nil
+}
+
+func RpcFunctionPanickingMetaData(_ *json.RawMessage, metaData *ResponseMetaData) (result any, re *RpcError) {
+	metaData.AddFieldFast("value", _panickingValue{})
+	return 1, nil
+}
+
+func RpcFunctionDuplicateMetaData(_ *json.RawMessage, metaData *ResponseMetaData) (result any, re *RpcError) {
+	metaData.AddFieldFast("a", 1)
+	metaData.AddFieldFast("a", 2)
+	return 1, nil
+}
+
+func Test_Processor_PanicHandler(t *testing.T) {
+	aTest := tester.New(t)
+
+	type panicEvent struct {
+		method    string
+		requestId string
+		value     any
+		hasStack  bool
+	}
+	var events []panicEvent
+	var isHandlerBroken bool
+
+	ps := &ProcessorSettings{
+		CatchExceptions: true,
+		PanicHandler: func(method string, requestId string, incidentId string, value any, stack []byte) (re *RpcError) {
+			events = append(events, panicEvent{method: method, requestId: requestId, value: value, hasStack: len(stack) > 0})
+			if isHandlerBroken {
+				panic("handler is broken")
+			}
+			if method == "RpcFunctionDuplicateMetaData" {
+				return nil
+			}
+			return NewRpcErrorByUser(500, "Crash is reported", requestId)
+		},
+	}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	for _, f := range []RpcFunction{
+		RpcFunctionExampleCrasher,
+		RpcFunctionPanickingResult,
+		RpcFunctionPanickingMetaData,
+		RpcFunctionDuplicateMetaData,
+	} {
+		err = p.AddFunc(f)
+		aTest.MustBeNoError(err)
+	}
+
+	call := func(method string) string {
+		request := `{"jsonrpc":"M1","id":"7","method":"` + method + `","params":{}}`
+		return string(p.Handle(context.Background(), []byte(request)))
+	}
+
+	// Test #1. Exception in function.
+	aTest.MustBeEqual(call("RpcFunctionExampleCrasher"), `{"jsonrpc":"M1","id":"7","result":null,"error":{"code":500,"message":"Crash is reported","data":"7"},"ok":false}`)
+	aTest.MustBeEqual(events[0].method, "RpcFunctionExampleCrasher")
+	aTest.MustBeEqual(events[0].requestId, "7")
+	aTest.MustBeEqual(events[0].hasStack, true)
+
+	// Test #2. Exception in encoding of result.
+	aTest.MustBeEqual(call("RpcFunctionPanickingResult"), `{"jsonrpc":"M1","id":"7","result":null,"error":{"code":500,"message":"Crash is reported","data":"7"},"ok":false}`)
+	aTest.MustBeEqual(events[1].value, "encoding is broken")
+
+	// Test #3. Exception in encoding of meta-data.
+	aTest.MustBeEqual(call("RpcFunctionPanickingMetaData"), `{"jsonrpc":"M1","id":"7","result":null,"error":{"code":500,"message":"Crash is reported","data":"7"},"ok":false}`)
+	aTest.MustBeEqual(events[2].method, "RpcFunctionPanickingMetaData")
+
+	// Test #4. Exception in manipulation of meta-data, handler returns null.
+	aTest.MustBeEqual(call("RpcFunctionDuplicateMetaData"), `{"jsonrpc":"M1","id":"7","result":null,"error":{"code":-32,"message":"Internal RPC error","data":null},"meta":{"a":1},"ok":false}`)
+	aTest.MustBeEqual(events[3].value.(error).Error(), fmt.Sprintf(ErrFDuplicateMetaDataField, "a"))
+
+	// Test #5. Exception in handler.
+	isHandlerBroken = true
+	aTest.MustBeEqual(call("RpcFunctionExampleCrasher"), `{"jsonrpc":"M1","id":"7","result":null,"error":{"code":-32,"message":"Internal RPC error","data":null},"ok":false}`)
+	aTest.MustBeEqual(len(events), 5)
+}
+
+func Test_Processor_PanicHandler_incident(t *testing.T) {
+	aTest := tester.New(t)
+	var resp RpcResponse
+	var id IncidentDetails
+
+	var logBuf bytes.Buffer
+	incidentIds := make(chan string, 1)
+	ps := &ProcessorSettings{
+		CatchExceptions:  true,
+		LogExceptions:    true,
+		Logger:           slog.New(slog.NewJSONHandler(&logBuf, nil)),
+		ExceptionDetails: ExceptionDetails_IncidentId,
+		PanicHandler: func(method string, requestId string, incidentId string, value any, stack []byte) (re *RpcError) {
+			incidentIds <- incidentId
+			if requestId == "2" {
+				return NewRpcErrorByUser(500, "Crash is reported", "custom")
+			}
+			return NewRpcErrorByUser(500, "Crash is reported", nil)
+		},
+	}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionExampleCrasher)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Custom error without data gets details of the incident.
+	request := []byte(`{"jsonrpc":"M1","id":"1","method":"RpcFunctionExampleCrasher","params":{}}`)
+	err = json.Unmarshal(p.Handle(context.Background(), request), &resp)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(resp.Error.Code, RpcErrorCode(500))
+	buf, err := json.Marshal(resp.Error.Data)
+	aTest.MustBeNoError(err)
+	err = json.Unmarshal(buf, &id)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(<-incidentIds, id.IncidentId)
+	aTest.MustBeEqual(len(id.IncidentId), 32)
+	aTest.MustBeEqual(strings.Contains(logBuf.String(), `"incident_id":"`+id.IncidentId+`"`), true)
+
+	// Test #2. Data of a custom error is kept.
+	request = []byte(`{"jsonrpc":"M1","id":"2","method":"RpcFunctionExampleCrasher","params":{}}`)
+	err = json.Unmarshal(p.Handle(context.Background(), request), &resp)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(resp.Error.Data, "custom")
+	aTest.MustBeEqual(len(<-incidentIds), 32)
+}
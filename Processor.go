@@ -1,19 +1,30 @@
 package jrm1
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"math/big"
 	"net/http"
 	"runtime/debug"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
-	ErrDuplicateFunction  = "duplicate function"
-	ErrFunctionIsNotFound = "function is not found"
+	ErrDuplicateFunction   = "duplicate function"
+	ErrFunctionIsNotFound  = "function is not found"
+	ErrFunctionIsBuiltIn   = "built-in function can not be removed"
+	ErrExceptionInFunction = "exception in RPC function"
+	ErrExceptionInEncoding = "exception in encoding of RPC response"
+	ErrExceptionInHandler  = "exception in panic handler"
+	ErrExceptionEscaped    = "exception escaped from RPC call"
+	ErrUnregisteredError   = "unregistered error in RPC function"
 )
 
 // Processor is an RPC processor (server).
@@ -24,10 +35,50 @@ type Processor struct {
 	// List of RPC functions.
 	funcs map[string]RpcFunction
 
+	// List of streaming RPC functions.
+	streamFuncs map[string]RpcStreamFunction
+
+	// Access requirements of RPC functions.
+	funcsAccess map[string]*AccessRequirements
+
+	// Supported codecs. JSON codec is the first one.
+	codecs []Codec
+
 	// Request counters.
+	countersGuard           *sync.Mutex
 	requestsCountAll        *big.Int
 	requestsCountSuccessful *big.Int
 	requestsCountOne        *big.Int
+
+	// Counter of successful function calls used for sampling of the access
+	// log.
+	accessLogCounter atomic.Uint64
+
+	// Names of functions which are always called as notifications.
+	notificationOnlyFuncs map[string]bool
+
+	// Queue of notifications and its workers.
+	notificationsGuard  *sync.RWMutex
+	notificationQueue   chan *rpcCall
+	notificationWorkers *sync.WaitGroup
+	isStopped           bool
+
+	// Names of functions which are executed as asynchronous jobs.
+	asyncFuncs map[string]bool
+
+	// Names of built-in functions, which can not be removed.
+	builtInFuncs map[string]bool
+
+	// Asynchronous jobs. Running jobs are cancelled using their cancellation
+	// functions. Background activities of jobs, including the cleaner of old
+	// jobs, are stopped when the common context is cancelled.
+	jobsGuard     *sync.Mutex
+	jobStore      JobStore
+	jobCancels    map[string]context.CancelFunc
+	jobsCtx       context.Context
+	jobsCtxCancel context.CancelFunc
+	jobWorkers    *sync.WaitGroup
+	isJobsStopped bool
 }
 
 // NewProcessor is a constructor of an empty RPC processor (server).
@@ -41,20 +92,251 @@ func NewProcessor(settings *ProcessorSettings) (p *Processor, err error) {
 		settings:                settings,
 		guard:                   new(sync.RWMutex),
 		funcs:                   make(map[string]RpcFunction),
+		streamFuncs:             make(map[string]RpcStreamFunction),
+		funcsAccess:             make(map[string]*AccessRequirements),
+		codecs:                  settings.getCodecs(),
+		countersGuard:           new(sync.Mutex),
 		requestsCountAll:        big.NewInt(0),
 		requestsCountSuccessful: big.NewInt(0),
 		requestsCountOne:        big.NewInt(1),
+		notificationOnlyFuncs:   make(map[string]bool),
+		notificationsGuard:      new(sync.RWMutex),
+		notificationWorkers:     new(sync.WaitGroup),
+		asyncFuncs:              make(map[string]bool),
+		builtInFuncs:            make(map[string]bool),
+		jobsGuard:               new(sync.Mutex),
+		jobCancels:              make(map[string]context.CancelFunc),
+		jobWorkers:              new(sync.WaitGroup),
 	}
 
 	if errorMessages == nil {
 		initErrorMessages()
 	}
 
+	err = p.addBuiltInFuncs()
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.EnableNotifications {
+		p.startNotificationWorkers()
+	}
+
+	if settings.EnableJobs {
+		p.startJobs()
+	}
+
 	return p, nil
 }
 
+// addBuiltInFuncs registers built-in methods enabled in settings: methods of
+// jobs and the method of the error catalogue. Built-in methods are checked
+// like other functions, declare the access requirements set in settings and
+// can not be removed.
+func (p *Processor) addBuiltInFuncs() (err error) {
+	builtInFuncs := make(map[string]RpcFunction)
+	if p.settings.EnableJobs {
+		builtInFuncs[JobMethod_GetStatus] = p.getJobStatus
+		builtInFuncs[JobMethod_GetResult] = p.getJobResult
+		builtInFuncs[JobMethod_Cancel] = p.cancelJob
+	}
+	if p.settings.ErrorCatalogue != nil {
+		builtInFuncs[CatalogueMethod_GetErrorCatalogue] = p.getErrorCatalogue
+	}
+
+	for funcName, f := range builtInFuncs {
+		err = p.addNamedFunc(funcName, f, p.settings.BuiltInFuncsAccess, false, false)
+		if err != nil {
+			return err
+		}
+
+		p.builtInFuncs[funcName] = true
+	}
+
+	return nil
+}
+
+// Stop stops background activities of the RPC processor (server). Queued
+// notifications are executed before this method returns. Notifications
+// received after the stop are refused. Running jobs are cancelled and this
+// method waits for their functions to return.
+func (p *Processor) Stop() {
+	p.notificationsGuard.Lock()
+	if p.isStopped {
+		p.notificationsGuard.Unlock()
+		return
+	}
+	p.isStopped = true
+	if p.notificationQueue != nil {
+		close(p.notificationQueue)
+	}
+	p.notificationsGuard.Unlock()
+
+	p.stopJobs()
+
+	p.notificationWorkers.Wait()
+	p.jobWorkers.Wait()
+}
+
 // AddFunc tries to add a function to the RPC processor (server).
 func (p *Processor) AddFunc(f RpcFunction) (err error) {
+	return p.addFunc(f, nil, false, false)
+}
+
+// AddFuncWithAccess tries to add a function to the RPC processor (server)
+// together with requirements which a caller must satisfy to call it.
+func (p *Processor) AddFuncWithAccess(f RpcFunction, ar *AccessRequirements) (err error) {
+	return p.addFunc(f, ar, false, false)
+}
+
+// AddNotificationFunc tries to add a function to the RPC processor (server)
+// which is always called as a notification when notifications are enabled.
+// Calls of such a function are acknowledged before the function is executed,
+// so its result is never returned to the client.
+func (p *Processor) AddNotificationFunc(f RpcFunction) (err error) {
+	return p.addFunc(f, nil, true, false)
+}
+
+// AddAsyncFunc tries to add a function to the RPC processor (server) which
+// is executed as an asynchronous job. Jobs must be enabled in settings. A call
+// of such a function returns information about the started job ('JobInfo').
+func (p *Processor) AddAsyncFunc(f RpcFunction) (err error) {
+	if !p.settings.EnableJobs {
+		return errors.New(ErrJobsAreDisabled)
+	}
+
+	return p.addFunc(f, nil, false, true)
+}
+
+// AddAsyncFuncWithAccess tries to add a function to the RPC processor (server)
+// which is executed as an asynchronous job, together with requirements which
+// a caller must satisfy to start the job.
+func (p *Processor) AddAsyncFuncWithAccess(f RpcFunction, ar *AccessRequirements) (err error) {
+	if !p.settings.EnableJobs {
+		return errors.New(ErrJobsAreDisabled)
+	}
+
+	return p.addFunc(f, ar, false, true)
+}
+
+// AddFuncWithError tries to add a function returning an ordinary Go error to
+// the RPC processor (server). The error is converted into an RPC error by the
+// error registry set in settings.
+func (p *Processor) AddFuncWithError(f RpcFunctionWithError) (err error) {
+	return p.addFuncWithError(f, nil, false, false)
+}
+
+// AddFuncWithErrorAndAccess tries to add a function returning an ordinary Go
+// error to the RPC processor (server) together with requirements which a
+// caller must satisfy to call it.
+func (p *Processor) AddFuncWithErrorAndAccess(f RpcFunctionWithError, ar *AccessRequirements) (err error) {
+	return p.addFuncWithError(f, ar, false, false)
+}
+
+// AddNotificationFuncWithError tries to add a function returning an ordinary
+// Go error to the RPC processor (server) which is always called as a
+// notification when notifications are enabled.
+func (p *Processor) AddNotificationFuncWithError(f RpcFunctionWithError) (err error) {
+	return p.addFuncWithError(f, nil, true, false)
+}
+
+// AddAsyncFuncWithError tries to add a function returning an ordinary Go
+// error to the RPC processor (server) which is executed as an asynchronous
+// job. Jobs must be enabled in settings. The error is the RPC error of the
+// job.
+func (p *Processor) AddAsyncFuncWithError(f RpcFunctionWithError) (err error) {
+	return p.AddAsyncFuncWithErrorAndAccess(f, nil)
+}
+
+// AddAsyncFuncWithErrorAndAccess tries to add a function returning an
+// ordinary Go error to the RPC processor (server) which is executed as an
+// asynchronous job, together with requirements which a caller must satisfy to
+// start the job.
+func (p *Processor) AddAsyncFuncWithErrorAndAccess(f RpcFunctionWithError, ar *AccessRequirements) (err error) {
+	if !p.settings.EnableJobs {
+		return errors.New(ErrJobsAreDisabled)
+	}
+
+	return p.addFuncWithError(f, ar, false, true)
+}
+
+// addFuncWithError tries to add a function returning an ordinary Go error to
+// the RPC processor (server).
+func (p *Processor) addFuncWithError(f RpcFunctionWithError, ar *AccessRequirements, isNotificationOnly bool, isAsync bool) (err error) {
+	funcName := f.GetName()
+
+	return p.addNamedFunc(funcName, p.convertFuncWithError(funcName, f), ar, isNotificationOnly, isAsync)
+}
+
+// convertFuncWithError converts the function returning a Go error into an
+// ordinary RPC function.
+func (p *Processor) convertFuncWithError(funcName string, f RpcFunctionWithError) RpcFunction {
+	return func(params *json.RawMessage, metaData *ResponseMetaData) (result any, re *RpcError) {
+		result, err := f(params, metaData)
+		if err != nil {
+			return nil, p.toRpcError(funcName, err)
+		}
+
+		return result, nil
+	}
+}
+
+// toRpcError converts the error returned by a function into an RPC error.
+// Errors which are not registered are journaled and are reported to the
+// client as an internal RPC error.
+func (p *Processor) toRpcError(funcName string, err error) (re *RpcError) {
+	re, ok := p.settings.ErrorRegistry.ToRpcError(err)
+	if ok {
+		return re
+	}
+
+	p.settings.getLogger().Error(ErrUnregisteredError,
+		slog.String(LogAttr_Method, funcName),
+		slog.String(LogAttr_Error, err.Error()),
+	)
+
+	return NewRpcErrorFast(RpcErrorCode_InternalRpcError)
+}
+
+// addFunc tries to add a function to the RPC processor (server).
+func (p *Processor) addFunc(f RpcFunction, ar *AccessRequirements, isNotificationOnly bool, isAsync bool) (err error) {
+	return p.addNamedFunc(f.GetName(), f, ar, isNotificationOnly, isAsync)
+}
+
+// addNamedFunc tries to add a function with the specified name to the RPC
+// processor (server).
+func (p *Processor) addNamedFunc(funcName string, f RpcFunction, ar *AccessRequirements, isNotificationOnly bool, isAsync bool) (err error) {
+	p.guard.Lock()
+	defer p.guard.Unlock()
+
+	err = CheckFunctionName(funcName)
+	if err != nil {
+		return err
+	}
+
+	if p.isFuncRegistered(funcName) {
+		return errors.New(ErrDuplicateFunction)
+	}
+
+	p.funcs[funcName] = f
+	if ar != nil {
+		p.funcsAccess[funcName] = ar
+	}
+	if isNotificationOnly {
+		p.notificationOnlyFuncs[funcName] = true
+	}
+	if isAsync {
+		p.asyncFuncs[funcName] = true
+	}
+
+	return nil
+}
+
+// AddStreamFunc tries to add a streaming function to the RPC processor
+// (server). Result items of such a function are written to the client as
+// newline-delimited JSON: the header, items and the trailer containing the
+// outcome of the call.
+func (p *Processor) AddStreamFunc(f RpcStreamFunction) (err error) {
 	p.guard.Lock()
 	defer p.guard.Unlock()
 
@@ -65,16 +347,24 @@ func (p *Processor) AddFunc(f RpcFunction) (err error) {
 		return err
 	}
 
-	_, alreadyExists := p.funcs[funcName]
-	if alreadyExists {
+	if p.isFuncRegistered(funcName) {
 		return errors.New(ErrDuplicateFunction)
 	}
 
-	p.funcs[funcName] = f
+	p.streamFuncs[funcName] = f
 
 	return nil
 }
 
+// isFuncRegistered tells whether a function of any kind with the specified
+// name is registered. The caller must hold the guard.
+func (p *Processor) isFuncRegistered(funcName string) bool {
+	_, isFunc := p.funcs[funcName]
+	_, isStreamFunc := p.streamFuncs[funcName]
+
+	return isFunc || isStreamFunc
+}
+
 // AddFuncFast tries to add a function to the RPC processor (server).
 // It panics on error.
 func (p *Processor) AddFuncFast(f RpcFunction) {
@@ -85,16 +375,24 @@ func (p *Processor) AddFuncFast(f RpcFunction) {
 }
 
 // RemoveFunc tries to remove a function from the RPC processor (server).
+// Built-in functions can not be removed.
 func (p *Processor) RemoveFunc(funcName string) (err error) {
 	p.guard.Lock()
 	defer p.guard.Unlock()
 
-	_, exists := p.funcs[funcName]
-	if !exists {
+	if !p.isFuncRegistered(funcName) {
 		return errors.New(ErrFunctionIsNotFound)
 	}
 
+	if p.builtInFuncs[funcName] {
+		return errors.New(ErrFunctionIsBuiltIn)
+	}
+
 	delete(p.funcs, funcName)
+	delete(p.streamFuncs, funcName)
+	delete(p.funcsAccess, funcName)
+	delete(p.notificationOnlyFuncs, funcName)
+	delete(p.asyncFuncs, funcName)
 
 	return nil
 }
@@ -104,62 +402,642 @@ func (p *Processor) FindFunc(funcName string) (err error) {
 	p.guard.RLock()
 	defer p.guard.RUnlock()
 
-	_, exists := p.funcs[funcName]
-	if !exists {
+	if !p.isFuncRegistered(funcName) {
 		return errors.New(ErrFunctionIsNotFound)
 	}
 
 	return nil
 }
 
+// isStreamFunc tells whether the function is a streaming function.
+func (p *Processor) isStreamFunc(funcName string) bool {
+	p.guard.RLock()
+	defer p.guard.RUnlock()
+
+	_, isStreamFunc := p.streamFuncs[funcName]
+	return isStreamFunc
+}
+
+// isAccessAllowed tells whether the call of a function is allowed by the
+// authorisation policy.
+func (p *Processor) isAccessAllowed(ctx context.Context, funcName string, requestId string, req *http.Request) bool {
+	p.guard.RLock()
+	ar := p.funcsAccess[funcName]
+	p.guard.RUnlock()
+
+	if p.settings.AccessPolicy == nil {
+		return ar == nil
+	}
+
+	ac := NewAccessCheck(funcName, requestId, ar, req)
+	ac.Context = ctx
+
+	return p.settings.AccessPolicy(ac)
+}
+
+// isNotificationOnly tells whether the function is always called as a
+// notification.
+func (p *Processor) isNotificationOnly(funcName string) bool {
+	p.guard.RLock()
+	defer p.guard.RUnlock()
+
+	return p.notificationOnlyFuncs[funcName]
+}
+
+// startNotificationWorkers creates the queue of notifications and starts the
+// workers executing them.
+func (p *Processor) startNotificationWorkers() {
+	p.notificationQueue = make(chan *rpcCall, p.settings.getNotificationQueueSize())
+
+	for i := 0; i < p.settings.getNotificationWorkersCount(); i++ {
+		p.notificationWorkers.Add(1)
+		go p.runNotificationWorker()
+	}
+}
+
+// runNotificationWorker executes notifications from the queue until the queue
+// is closed.
+func (p *Processor) runNotificationWorker() {
+	defer p.notificationWorkers.Done()
+
+	for c := range p.notificationQueue {
+		c.runNotification()
+	}
+}
+
+// enqueueNotification puts a notification into the queue. If the queue is
+// full or the processor is stopped, 'False' is returned.
+func (p *Processor) enqueueNotification(c *rpcCall) (ok bool) {
+	p.notificationsGuard.RLock()
+	defer p.notificationsGuard.RUnlock()
+
+	if p.isStopped || (p.notificationQueue == nil) {
+		return false
+	}
+
+	select {
+	case p.notificationQueue <- c:
+		return true
+	default:
+		return false
+	}
+}
+
 // RunFunc executes a function of the RPC processor (server) specified by its
 // name. If enabled in settings, it also catches any exception (panic) which
 // may happen during the function execution.
 func (p *Processor) RunFunc(funcName string, params *json.RawMessage, metaData *ResponseMetaData) (result any, re *RpcError) {
+	result, re, _ = p.runFunc(funcName, "", params, metaData)
+	return result, re
+}
+
+// runFunc executes a function of the RPC processor (server) specified by its
+// name. If an exception is caught, information about it is returned.
+// Asynchronous functions are started as jobs. The request ID is passed to the
+// panic handler and may be empty. The function is called without holding the
+// lock of functions, so that long calls do not block changes of the list of
+// functions and, thus, other calls.
+func (p *Processor) runFunc(funcName string, requestId string, params *json.RawMessage, metaData *ResponseMetaData) (result any, re *RpcError, pi *panicInfo) {
 	p.guard.RLock()
-	defer p.guard.RUnlock()
+	f, ok := p.funcs[funcName]
+	isAsync := p.asyncFuncs[funcName]
+	p.guard.RUnlock()
+
+	if !ok {
+		return nil, NewRpcErrorFast(RpcErrorCode_UnknownMethod), nil
+	}
+
+	if isAsync {
+		result, re = p.startJob(funcName, f, params)
+		return result, re, nil
+	}
+
+	return p.callFunc(funcName, requestId, f, params, metaData)
+}
+
+// runStreamFunc executes a streaming function of the RPC processor (server)
+// specified by its name. Result items are passed to the 'yield' function. If
+// an exception is caught, information about it is returned. The function is
+// called without holding the lock of functions.
+func (p *Processor) runStreamFunc(funcName string, requestId string, params *json.RawMessage, metaData *ResponseMetaData, yield func(item any) bool) (re *RpcError, pi *panicInfo) {
+	p.guard.RLock()
+	sf, ok := p.streamFuncs[funcName]
+	p.guard.RUnlock()
 
+	if !ok {
+		return NewRpcErrorFast(RpcErrorCode_UnknownMethod), nil
+	}
+
+	f := func(params *json.RawMessage, metaData *ResponseMetaData) (result any, re *RpcError) {
+		return nil, sf(params, metaData, yield)
+	}
+
+	_, re, pi = p.callFunc(funcName, requestId, f, params, metaData)
+	return re, pi
+}
+
+// callFunc calls the function. If enabled in settings, it also catches any
+// exception (panic) which may happen during the function execution, including
+// manipulation of meta-data, e.g. by the 'AddFieldFast' method.
+func (p *Processor) callFunc(funcName string, requestId string, f RpcFunction, params *json.RawMessage, metaData *ResponseMetaData) (result any, re *RpcError, pi *panicInfo) {
 	if p.settings.CatchExceptions {
 		defer func() {
 			x := recover()
 			if x != nil {
-				if p.settings.LogExceptions {
-					log.Println(fmt.Sprintf("%v, %s", x, string(debug.Stack())))
-				}
+				result = nil
+				re, pi = p.handlePanic(ErrExceptionInFunction, funcName, requestId, x, debug.Stack())
+			}
+		}()
+	}
 
-				re = NewRpcErrorFast(RpcErrorCode_InternalRpcError)
+	result, re = f(params, metaData)
+	return result, re, nil
+}
+
+// handlePanic journals the caught exception if enabled in settings and
+// returns the RPC error for the client. The error is created by the panic
+// handler set in settings or, when the handler is not set or returns null, it
+// is an internal RPC error.
+func (p *Processor) handlePanic(logMessage string, funcName string, requestId string, x any, stack []byte) (re *RpcError, pi *panicInfo) {
+	pi = newPanicInfo(x, stack)
+	if p.settings.ExceptionDetails.isEnabled() {
+		pi.incidentId = newRandomId()
+	}
+
+	if p.settings.LogExceptions {
+		attrs := []any{
+			slog.String(LogAttr_Method, funcName),
+			slog.String(LogAttr_Panic, fmt.Sprint(pi.value)),
+			slog.String(LogAttr_Stack, string(pi.stack)),
+		}
+		if len(requestId) > 0 {
+			attrs = append(attrs, slog.String(LogAttr_RequestId, requestId))
+		}
+		if len(pi.incidentId) > 0 {
+			attrs = append(attrs, slog.String(LogAttr_IncidentId, pi.incidentId))
+		}
+		p.settings.getLogger().Error(logMessage, attrs...)
+	}
+
+	if p.settings.PanicHandler != nil {
+		re = p.callPanicHandler(funcName, requestId, pi)
+	}
+	if re == nil {
+		re = NewRpcErrorFast(RpcErrorCode_InternalRpcError)
+	}
+
+	if p.settings.ExceptionDetails.isEnabled() && (re.Data == nil) {
+		// The error of the handler may be shared, so it is copied.
+		re = &RpcError{
+			Code:    re.Code,
+			Message: re.Message,
+			Data:    p.settings.ExceptionDetails.newIncidentDetails(pi),
+		}
+	}
+
+	return re, pi
+}
+
+// logEscapedPanic journals an exception (panic) which escaped from serving of
+// a function call, e.g. because exceptions are not caught, and is caught in a
+// goroutine started by the RPC processor (server). Such an exception would
+// otherwise crash the whole program, so it is always journaled.
+func (p *Processor) logEscapedPanic(funcName string, requestId string, x any, stack []byte) {
+	attrs := []any{
+		slog.String(LogAttr_Method, funcName),
+		slog.String(LogAttr_Panic, fmt.Sprint(x)),
+		slog.String(LogAttr_Stack, string(stack)),
+	}
+	if len(requestId) > 0 {
+		attrs = append(attrs, slog.String(LogAttr_RequestId, requestId))
+	}
+
+	p.settings.getLogger().Error(ErrExceptionEscaped, attrs...)
+}
+
+// callPanicHandler calls the panic handler set in settings. An exception in
+// the handler itself is journaled and null is returned.
+func (p *Processor) callPanicHandler(funcName string, requestId string, pi *panicInfo) (re *RpcError) {
+	defer func() {
+		x := recover()
+		if x != nil {
+			re = nil
+			p.settings.getLogger().Error(ErrExceptionInHandler,
+				slog.String(LogAttr_Method, funcName),
+				slog.String(LogAttr_Panic, fmt.Sprint(x)),
+			)
+		}
+	}()
+
+	return p.settings.PanicHandler(funcName, requestId, pi.incidentId, pi.value, pi.stack)
+}
+
+// marshalJson encodes the value into JSON. If enabled in settings, it also
+// catches any exception (panic) which may happen during encoding, e.g. in a
+// 'MarshalJSON' method of the value. If the value can not be encoded, the
+// problem is journaled and an RPC error is returned.
+func (p *Processor) marshalJson(funcName string, requestId string, v any) (data json.RawMessage, re *RpcError, pi *panicInfo) {
+	if p.settings.CatchExceptions {
+		defer func() {
+			x := recover()
+			if x != nil {
+				data = nil
+				re, pi = p.handlePanic(ErrExceptionInEncoding, funcName, requestId, x, debug.Stack())
 			}
 		}()
 	}
 
-	f, ok := p.funcs[funcName]
-	if !ok {
-		return nil, NewRpcErrorFast(RpcErrorCode_UnknownMethod)
+	buf, err := json.Marshal(v)
+	if err != nil {
+		p.settings.getLogger().Error(err.Error())
+		return nil, NewRpcErrorFast(RpcErrorCode_InternalRpcError), nil
 	}
 
-	return f(params, metaData)
+	return buf, nil, nil
+}
+
+// marshalCodecTree converts the value into a tree of generic values written
+// by binary codecs. Exceptions and problems are handled in the same way as
+// by the 'marshalJson' method.
+func (p *Processor) marshalCodecTree(funcName string, requestId string, v any) (tree any, re *RpcError, pi *panicInfo) {
+	if p.settings.CatchExceptions {
+		defer func() {
+			x := recover()
+			if x != nil {
+				tree = nil
+				re, pi = p.handlePanic(ErrExceptionInEncoding, funcName, requestId, x, debug.Stack())
+			}
+		}()
+	}
+
+	tree, err := newCodecTree(v)
+	if err != nil {
+		p.settings.getLogger().Error(err.Error())
+		return nil, NewRpcErrorFast(RpcErrorCode_InternalRpcError), nil
+	}
+
+	return tree, nil, nil
+}
+
+// getErrorCatalogue is a built-in method returning entries of the error
+// catalogue.
+func (p *Processor) getErrorCatalogue(_ *json.RawMessage, _ *ResponseMetaData) (result any, re *RpcError) {
+	return p.settings.ErrorCatalogue.Entries(), nil
+}
+
+// startJobs prepares the store of jobs and starts the cleaner of old jobs.
+func (p *Processor) startJobs() {
+	p.jobStore = p.settings.JobStore
+	if p.jobStore == nil {
+		p.jobStore = NewMemoryJobStore()
+	}
+
+	p.jobsCtx, p.jobsCtxCancel = context.WithCancel(context.Background())
+
+	p.jobWorkers.Add(1)
+	go p.runJobCleaner()
+}
+
+// stopJobs cancels running jobs and stops the cleaner of old jobs. New jobs
+// are refused after this.
+func (p *Processor) stopJobs() {
+	p.jobsGuard.Lock()
+	defer p.jobsGuard.Unlock()
+
+	if !p.settings.EnableJobs || p.isJobsStopped {
+		return
+	}
+	p.isJobsStopped = true
+
+	for jobId := range p.jobCancels {
+		_, re := p.cancelJobById(jobId)
+		if re != nil {
+			p.settings.getLogger().Error(re.Message.String(), slog.Any(LogAttr_Error, re.Data))
+		}
+	}
+
+	p.jobsCtxCancel()
+}
+
+// runJobCleaner periodically deletes finished jobs which are older than their
+// time to live.
+func (p *Processor) runJobCleaner() {
+	defer p.jobWorkers.Done()
+
+	ttl := p.settings.getJobTTL()
+	ticker := time.NewTicker(ttl / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.jobsCtx.Done():
+			return
+		case <-ticker.C:
+			p.deleteOldJobs(ttl)
+		}
+	}
+}
+
+// deleteOldJobs deletes finished jobs which are older than their time to
+// live. An exception in the job store is journaled, so that it does not crash
+// the whole program.
+func (p *Processor) deleteOldJobs(ttl time.Duration) {
+	defer func() {
+		x := recover()
+		if x != nil {
+			p.logEscapedPanic("", "", x, debug.Stack())
+		}
+	}()
+
+	err := p.jobStore.DeleteJobsFinishedBefore(time.Now().Add(-ttl))
+	if err != nil {
+		p.settings.getLogger().Error(err.Error())
+	}
+}
+
+// startJob starts an asynchronous execution of the function and returns
+// information about the job.
+func (p *Processor) startJob(funcName string, f RpcFunction, params *json.RawMessage) (result any, re *RpcError) {
+	p.jobsGuard.Lock()
+	defer p.jobsGuard.Unlock()
+
+	if p.isJobsStopped {
+		return nil, newJobError(ErrJobsAreStopped)
+	}
+
+	job := NewJob(funcName)
+	err := p.jobStore.SaveJob(job)
+	if err != nil {
+		p.settings.getLogger().Error(err.Error())
+		return nil, NewRpcErrorFast(RpcErrorCode_InternalRpcError)
+	}
+
+	ctx, cancel := context.WithCancel(p.jobsCtx)
+	p.jobCancels[job.Id] = cancel
+
+	p.jobWorkers.Add(1)
+	go p.runJob(ctx, job, f, params)
+
+	return job.Info(), nil
+}
+
+// runJob executes the function of the job and saves its outcome. An exception
+// which escaped from the function is journaled and the job fails with an
+// internal RPC error, so that it does not crash the whole program.
+func (p *Processor) runJob(ctx context.Context, job *Job, f RpcFunction, params *json.RawMessage) {
+	defer p.jobWorkers.Done()
+
+	var isFinishing bool
+	defer func() {
+		x := recover()
+		if x != nil {
+			p.logEscapedPanic(job.Method, "", x, debug.Stack())
+			if !isFinishing {
+				p.finishJob(job, JobStatus_Failed, nil, NewRpcErrorFast(RpcErrorCode_InternalRpcError))
+			}
+		}
+	}()
+
+	md := make(ResponseMetaData)
+	if p.settings.isJobContextShown() {
+		md[*p.settings.JobContextFieldName] = ctx
+	}
+
+	result, re, _ := p.callFunc(job.Method, "", f, params, &md)
+
+	status := JobStatus_Failed
+	var rawResult json.RawMessage
+	if re == nil {
+		rawResult, re, _ = p.marshalJson(job.Method, "", result)
+		if re == nil {
+			status = JobStatus_Succeeded
+		}
+	}
+
+	isFinishing = true
+	p.finishJob(job, status, rawResult, re)
+}
+
+// finishJob saves the outcome of the job unless the job has been cancelled.
+func (p *Processor) finishJob(job *Job, status JobStatus, result json.RawMessage, re *RpcError) {
+	p.jobsGuard.Lock()
+	defer p.jobsGuard.Unlock()
+
+	cancel, isRunning := p.jobCancels[job.Id]
+	if !isRunning {
+		return
+	}
+	cancel()
+	delete(p.jobCancels, job.Id)
+
+	job.finish(status, result, re)
+	err := p.jobStore.SaveJob(job)
+	if err != nil {
+		p.settings.getLogger().Error(err.Error())
+	}
+}
+
+// findJob reads the job specified in parameters of a built-in job method.
+func (p *Processor) findJob(params *json.RawMessage) (job *Job, re *RpcError) {
+	var jp JobParams
+	re = ParseParametersWith(params, &jp, p.GetParametersDecoding())
+	if re != nil {
+		return nil, re
+	}
+
+	return p.findJobById(jp.JobId)
+}
+
+// findJobById reads the job from the store.
+func (p *Processor) findJobById(jobId string) (job *Job, re *RpcError) {
+	job, err := p.jobStore.GetJob(jobId)
+	if err != nil {
+		p.settings.getLogger().Error(err.Error())
+		return nil, NewRpcErrorFast(RpcErrorCode_InternalRpcError)
+	}
+	if job == nil {
+		return nil, newJobError(ErrJobIsNotFound)
+	}
+
+	return job, nil
+}
+
+// getJobStatus is a built-in job method returning information about the job.
+func (p *Processor) getJobStatus(params *json.RawMessage, _ *ResponseMetaData) (result any, re *RpcError) {
+	job, re := p.findJob(params)
+	if re != nil {
+		return nil, re
+	}
+
+	return job.Info(), nil
+}
+
+// getJobResult is a built-in job method returning the outcome of the finished
+// job as if the function was called synchronously.
+func (p *Processor) getJobResult(params *json.RawMessage, _ *ResponseMetaData) (result any, re *RpcError) {
+	job, re := p.findJob(params)
+	if re != nil {
+		return nil, re
+	}
+
+	switch job.Status {
+	case JobStatus_Running:
+		return nil, newJobError(ErrJobIsNotFinished)
+	case JobStatus_Cancelled:
+		return nil, newJobError(ErrJobIsCancelled)
+	case JobStatus_Failed:
+		return nil, job.Error
+	default:
+		return job.Result, nil
+	}
+}
+
+// cancelJob is a built-in job method cancelling the running job.
+func (p *Processor) cancelJob(params *json.RawMessage, _ *ResponseMetaData) (result any, re *RpcError) {
+	var jp JobParams
+	re = ParseParametersWith(params, &jp, p.GetParametersDecoding())
+	if re != nil {
+		return nil, re
+	}
+
+	p.jobsGuard.Lock()
+	defer p.jobsGuard.Unlock()
+
+	var job *Job
+	job, re = p.cancelJobById(jp.JobId)
+	if re != nil {
+		return nil, re
+	}
+
+	return job.Info(), nil
+}
+
+// cancelJobById cancels the running job. The caller must hold the guard of
+// jobs.
+func (p *Processor) cancelJobById(jobId string) (job *Job, re *RpcError) {
+	job, re = p.findJobById(jobId)
+	if re != nil {
+		return nil, re
+	}
+
+	if job.Status.IsFinished() {
+		return nil, newJobError(ErrJobIsFinished)
+	}
+
+	cancel, isRunning := p.jobCancels[jobId]
+	if isRunning {
+		cancel()
+		delete(p.jobCancels, jobId)
+	}
+
+	job.finish(JobStatus_Cancelled, nil, nil)
+	err := p.jobStore.SaveJob(job)
+	if err != nil {
+		p.settings.getLogger().Error(err.Error())
+		return nil, NewRpcErrorFast(RpcErrorCode_InternalRpcError)
+	}
+
+	return job, nil
 }
 
 // ServeHTTP handles an HTTP request and responds to it.
 // 'ServeHTTP' is a required method of the 'http.Handler' interface.
 func (p *Processor) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	rhr := NewRpcHttpRequest(p, p.settings, req, rw)
+	defer rhr.finish()
 
 	if !rhr.init() {
 		return
 	}
 
-	if !rhr.run() {
-		return
+	rhr.acknowledge(rhr.serve())
+}
+
+// Handle serves a message containing an RPC request, or a batch of requests
+// when batches are enabled, and returns the encoded response. It does not
+// depend on a transport, so it may be used to serve messages of queues, pipes
+// or custom servers. Response of a notification is empty. Streamed responses
+// are returned as newline-delimited records. The parent span of a call is
+// taken from the context, which is also passed to the access policy.
+func (p *Processor) Handle(ctx context.Context, data []byte) (response []byte) {
+	return p.handle(ctx, nil, data, false)
+}
+
+// HandleRequest serves a decoded RPC request and returns the response. It does
+// not depend on a transport. Notification-only functions are executed as
+// ordinary functions, while streaming functions are not supported.
+func (p *Processor) HandleRequest(ctx context.Context, rr *RpcRequest) (resp *RpcResponse) {
+	if rr == nil {
+		rr = new(RpcRequest)
 	}
 
-	rhr.respond()
-	return
+	c := newRpcCall(p, p.settings, ctx, nil, nil)
+	c.startTimer()
+	c.p.incAllRequestsCounter()
+	c.resp = NewRpcResponse()
+	c.rr = rr
+	c.startSpan()
+
+	return c.serveDecoded()
+}
+
+// handle serves a message received by a transport and returns the encoded
+// response. The HTTP request, if it is set, describes the origin of the
+// message, e.g. the upgrade request of a WebSocket connection. When
+// acknowledgement is requested, the response of a notification is a message
+// telling whether the notification is accepted, otherwise it is empty.
+func (p *Processor) handle(ctx context.Context, req *http.Request, data []byte, isAckRequested bool) (response []byte) {
+	var buf bytes.Buffer
+	c := newRpcCall(p, p.settings, ctx, req, &buf)
+	c.startTimer()
+	if req != nil {
+		c.isNotificationRequested = isNotificationRequested(req)
+	}
+
+	if !c.read(io.NopCloser(bytes.NewReader(data))) {
+		return bytes.TrimRight(buf.Bytes(), "\n")
+	}
+
+	outcome := c.serve()
+	if outcome != callOutcome_Responded {
+		if !isAckRequested {
+			return nil
+		}
+
+		var err error
+		response, err = newNotificationAck(c.rr.Id, outcome)
+		if err != nil {
+			p.settings.getLogger().Error(err.Error())
+			return nil
+		}
+		return response
+	}
+
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
+
+// GetParametersDecoding returns options of decoding of parameters matching
+// strict decoding enabled in settings. Functions pass them to the
+// 'ParseParametersWith' function, so that numbers may be decoded into
+// json.Number values and errors of parameters have details of the problem.
+// When strict decoding is disabled, null is returned, i.e. default options.
+func (p *Processor) GetParametersDecoding() (pd *ParametersDecoding) {
+	if !p.settings.isStrictDecodingEnabled() {
+		return nil
+	}
+
+	return &ParametersDecoding{
+		UseNumber:   p.settings.UseNumbersInParameters,
+		ShowDetails: true,
+	}
 }
 
 // GetRequestsCount returns the number of all (received) and successful
 // function calls.
 func (p *Processor) GetRequestsCount() (all, successful string) {
+	p.countersGuard.Lock()
+	defer p.countersGuard.Unlock()
+
 	return p.requestsCountAll.String(), p.requestsCountSuccessful.String()
 }
 
@@ -167,6 +1045,9 @@ func (p *Processor) GetRequestsCount() (all, successful string) {
 // calls if the request counter is enabled.
 func (p *Processor) incAllRequestsCounter() {
 	if p.settings.CountRequests {
+		p.countersGuard.Lock()
+		defer p.countersGuard.Unlock()
+
 		p.requestsCountAll.Add(p.requestsCountAll, p.requestsCountOne)
 	}
 }
@@ -175,6 +1056,9 @@ func (p *Processor) incAllRequestsCounter() {
 // calls if the request counter is enabled.
 func (p *Processor) incSuccessfulRequestsCounter() {
 	if p.settings.CountRequests {
+		p.countersGuard.Lock()
+		defer p.countersGuard.Unlock()
+
 		p.requestsCountSuccessful.Add(p.requestsCountSuccessful, p.requestsCountOne)
 	}
 }
@@ -1,10 +1,29 @@
 package jrm1
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	mime "github.com/vault-thirteen/auxie/MIME"
+)
+
+const (
+	DefaultNotificationWorkersCount = 4
+	DefaultNotificationQueueSize    = 1000
+	DefaultJobTTL                   = time.Hour
+	DefaultStreamFlushPeriod        = 1
+	DefaultCompressionThreshold     = 1024
+	DefaultMaxDecompressedSize      = 16 * 1024 * 1024
+)
 
 const (
-	ErrEnableExceptionCaptureToLogThem = "enable exception capture to log them"
-	ErrMetaDataFieldNameConflict       = "meta data field name conflict"
+	ErrEnableExceptionCaptureToLogThem    = "enable exception capture to log them"
+	ErrEnableExceptionCaptureToHandleThem = "enable exception capture to handle them"
+	ErrMetaDataFieldNameConflict          = "meta data field name conflict"
+	ErrCodecIsNotSet                      = "codec is not set"
+	ErrFCodecContentTypeConflict          = "codec content type conflict: %v"
 )
 
 // ProcessorSettings are settings of the RPC processor (server).
@@ -15,21 +34,222 @@ type ProcessorSettings struct {
 	// When enabled, RPC processor (server) will journal exceptions.
 	LogExceptions bool
 
+	// Structured logger used by RPC processor (server). When not set, the
+	// default logger of the 'slog' package is used.
+	Logger *slog.Logger
+
+	// Settings of the access log. When set, RPC processor (server) will
+	// journal every function call using the structured logger.
+	AccessLog *AccessLogSettings
+
+	// Set of metrics. When set, RPC processor (server) will collect metrics
+	// of function calls. The set is an HTTP handler serving the metrics.
+	Metrics *Metrics
+
 	// When enabled, RPC processor (server) will count requests.
 	CountRequests bool
 
 	// Name of a meta-data field where to store request duration.
 	// When enabled, RPC processor (server) will measure time taken to run
-	// functions. Duration is shown only for successful function calls.
+	// functions. Duration is shown for both successful and failed function
+	// calls.
 	// To enable this feature, set the field name as non-null value.
 	DurationFieldName *string
 
+	// Format of durations stored in meta-data. Milliseconds are used by
+	// default.
+	DurationFormat DurationFormat
+
+	// Name of a meta-data field where to store durations of separate phases
+	// of request processing: decoding of the request, execution of the
+	// function and encoding of the result. The field is an object having a
+	// field for each finished phase.
+	// To enable this feature, set the field name as non-null value.
+	PhaseDurationsFieldName *string
+
 	// Name of a meta-data field where to store ID of the current request.
 	// When enabled, RPC processor (server) will add ID of the current request
 	// as a meta-data field, so that user function will be able to read it.
 	// This field is automatically removed when function call finishes.
 	// To enable this feature, set the field name as non-null value.
 	RequestIdFieldName *string
+
+	// Tracer creating a span for every function call. When set, RPC processor
+	// (server) extracts the W3C trace context from HTTP headers of a request
+	// and uses it as a parent of the span.
+	Tracer Tracer
+
+	// Name of a meta-data field where to store the span of the current
+	// function call. When enabled, RPC processor (server) will add the span as
+	// a meta-data field, so that user function will be able to read it. This
+	// field is automatically removed when function call finishes.
+	// To enable this feature, set the field name as non-null value and set
+	// the tracer.
+	SpanFieldName *string
+
+	// When enabled, RPC processor (server) accepts batches of function calls.
+	// A batch is a JSON array of requests, it is responded with a JSON array
+	// of responses. Each function call of a batch is processed independently.
+	// This is an extension of the protocol which is disabled by default.
+	EnableBatches bool
+
+	// Maximum number of function calls in a batch. When not set, the default
+	// maximum is used.
+	MaxBatchSize uint
+
+	// When enabled, function calls of a batch are executed in parallel.
+	// Do note that exceptions in parallel function calls can not be recovered
+	// by the HTTP server, so enable exception capture when using this mode.
+	RunBatchesInParallel bool
+
+	// When enabled, RPC processor (server) accepts notifications. A
+	// notification is a function call whose result is not awaited by the
+	// client. It is marked with a special HTTP header or sent to a function
+	// registered as a notification-only function. Notification is checked,
+	// acknowledged with the HTTP status code 202 and executed asynchronously.
+	// This is an extension of the protocol which is disabled by default.
+	// Stop the processor to finish queued notifications.
+	EnableNotifications bool
+
+	// Number of workers executing notifications. When not set, the default
+	// number is used.
+	NotificationWorkersCount uint
+
+	// Maximum number of notifications waiting for execution. When the queue
+	// is full, new notifications are refused with the HTTP status code 503.
+	// When not set, the default size is used.
+	NotificationQueueSize uint
+
+	// When enabled, RPC processor (server) executes asynchronous functions as
+	// jobs. A call of a function registered as an asynchronous function
+	// immediately returns information about the started job, while the
+	// function is executed in background. Built-in job methods let clients
+	// poll the status of a job, get its result and cancel it. This is an
+	// extension of the protocol which is disabled by default. Stop the
+	// processor to cancel running jobs. Jobs are executed outside of the HTTP
+	// server, so enable exception capture when using jobs.
+	EnableJobs bool
+
+	// Store of jobs. When not set, jobs are stored in memory.
+	JobStore JobStore
+
+	// Time during which a finished job is kept in the store. When not set,
+	// the default time is used.
+	JobTTL time.Duration
+
+	// Name of the meta-data field containing a context of the job. The
+	// context is cancelled when the job is cancelled, so long-running
+	// functions may stop early. When not set, the context is not passed.
+	JobContextFieldName *string
+
+	// Number of result items of a streaming function written between flushes
+	// of the HTTP response. Larger values reduce overhead, while smaller
+	// values reduce latency. When not set, every item is flushed.
+	StreamFlushPeriod uint
+
+	// When enabled, RPC processor (server) compresses HTTP responses using an
+	// encoding accepted by the client, 'gzip' or 'deflate', when the size of
+	// a response reaches the compression threshold. Streamed responses are
+	// compressed since their first flush. Compressed requests are accepted
+	// regardless of this setting.
+	EnableCompression bool
+
+	// Minimum size of a response in bytes which is compressed. When not set,
+	// the default threshold is used.
+	CompressionThreshold uint
+
+	// Maximum size of a decompressed request body in bytes. Requests
+	// exceeding it are not readable, which protects from decompression
+	// bombs. When not set, the default size is used.
+	MaxDecompressedRequestSize uint
+
+	// Authorisation policy deciding whether a function call is allowed.
+	// When set, the policy is asked before every function call. When not set,
+	// calls of functions declaring access requirements are denied, while
+	// calls of other functions are allowed.
+	AccessPolicy AccessPolicy
+
+	// Requirements which a caller must satisfy to call built-in methods,
+	// i.e. methods of jobs and the method of the error catalogue. When not
+	// set, built-in methods declare no requirements.
+	BuiltInFuncsAccess *AccessRequirements
+
+	// Codecs supported in addition to JSON, e.g. MessagePack or CBOR. A
+	// request is decoded by the codec of its 'Content-Type' HTTP header,
+	// while the response is encoded by a codec accepted by the client. JSON
+	// is always supported and is used by default. Streamed responses and
+	// message transports use JSON. Binary codecs make messages smaller,
+	// especially the ones with byte slices, but not faster: requests are
+	// converted into JSON, and results which are not simple values, slices,
+	// maps or byte slices, e.g. structures, are encoded as JSON before they
+	// are converted, so a binary call costs more than a JSON call.
+	Codecs []Codec
+
+	// When enabled, RPC processor (server) uses a performance-focused path:
+	// requests are decoded by a scanner of the envelope without reflection,
+	// responses are encoded into pooled buffers, and pre-encoded results,
+	// i.e. raw messages and values implementing the json.Marshaler
+	// interface, are written without reflection. Encoded messages are the
+	// same as without this setting. Unusual input, e.g. strings with escape
+	// sequences, is passed to the standard JSON decoder.
+	EnableFastPath bool
+
+	// Profile of JSON encoding of responses. Responses are compact by
+	// default, while the pretty profile indents them, which is useful for
+	// debugging. Streamed responses are always compact.
+	JsonProfile JsonProfile
+
+	// When enabled, RPC processor (server) rejects requests having fields
+	// unknown to the protocol, in the same way as the client rejects such
+	// responses. Otherwise, unknown fields are ignored.
+	StrictEnvelope bool
+
+	// When enabled, RPC processor (server) rejects requests in which a key
+	// is repeated within an object, either in the envelope or in
+	// parameters. Otherwise, the last value of the key wins.
+	RejectDuplicateKeys bool
+
+	// When enabled, RPC processor (server) rejects function calls whose
+	// parameters are not an object, e.g. an array or null.
+	RequireObjectParameters bool
+
+	// When enabled, options of decoding returned by the
+	// 'GetParametersDecoding' method of the processor make the
+	// 'ParseParametersWith' function decode numbers into json.Number values
+	// instead of float64 values when the destination is an interface, so that
+	// large integers do not lose precision.
+	UseNumbersInParameters bool
+
+	// Registry of Go errors which functions added by the 'AddFuncWithError'
+	// method may return. A registered error is converted into the RPC error
+	// registered for it, while other errors are reported to the client as an
+	// internal RPC error.
+	ErrorRegistry *ErrorRegistry
+
+	// Catalogue of user-generated error codes. When set, the catalogue is
+	// returned to clients by the built-in 'M1_GetErrorCatalogue' method, and
+	// all the codes of the error registry must be in the catalogue.
+	ErrorCatalogue *ErrorCatalogue
+
+	// Catalogs of error messages per locale. When set, messages of RPC errors
+	// are translated into the locale of the function call, while codes are
+	// kept as is. When the error catalogue is set as well, all the
+	// user-generated codes of the localization must be in the catalogue.
+	ErrorLocalization *ErrorLocalization
+
+	// Details of exceptions put into the data of the internal RPC error:
+	// none, the incident ID for production environments, or the incident ID
+	// with the value of the exception and a trimmed stack trace for
+	// development environments. The incident ID is journaled with the
+	// exception. Exceptions must be caught and journaled to show their
+	// details, so that each incident shown to a client is in the journal.
+	ExceptionDetails ExceptionDetails
+
+	// Handler of caught exceptions. It may report them to an error tracker
+	// and may return a custom RPC error for the client. When the handler is
+	// not set or returns null, the client gets an internal RPC error.
+	// Exceptions must be caught to be handled.
+	PanicHandler PanicHandler
 }
 
 // Check verifies processor's settings.
@@ -38,20 +258,191 @@ func (ps *ProcessorSettings) Check() (err error) {
 		return errors.New(ErrEnableExceptionCaptureToLogThem)
 	}
 
-	if ps.DurationFieldName != nil && ps.RequestIdFieldName != nil {
-		if *ps.DurationFieldName == *ps.RequestIdFieldName {
+	err = ps.DurationFormat.Check()
+	if err != nil {
+		return err
+	}
+
+	err = ps.JsonProfile.Check()
+	if err != nil {
+		return err
+	}
+
+	err = ps.ExceptionDetails.Check()
+	if err != nil {
+		return err
+	}
+
+	if ps.ExceptionDetails.isEnabled() && (!ps.CatchExceptions) {
+		return errors.New(ErrEnableExceptionCaptureToShowThem)
+	}
+
+	if ps.ExceptionDetails.isEnabled() && (!ps.LogExceptions) {
+		return errors.New(ErrEnableExceptionLogToShowThem)
+	}
+
+	if (ps.PanicHandler != nil) && (!ps.CatchExceptions) {
+		return errors.New(ErrEnableExceptionCaptureToHandleThem)
+	}
+
+	fieldNames := make(map[string]bool)
+	for _, fieldName := range []*string{ps.DurationFieldName, ps.PhaseDurationsFieldName, ps.RequestIdFieldName, ps.SpanFieldName, ps.JobContextFieldName} {
+		if fieldName == nil {
+			continue
+		}
+		if fieldNames[*fieldName] {
 			return errors.New(ErrMetaDataFieldNameConflict)
 		}
+		fieldNames[*fieldName] = true
+	}
+
+	if (ps.ErrorCatalogue != nil) && (ps.ErrorRegistry != nil) {
+		err = ps.ErrorCatalogue.checkErrorRegistry(ps.ErrorRegistry)
+		if err != nil {
+			return err
+		}
+	}
+
+	if (ps.ErrorCatalogue != nil) && (ps.ErrorLocalization != nil) {
+		err = ps.ErrorLocalization.checkErrorCatalogue(ps.ErrorCatalogue)
+		if err != nil {
+			return err
+		}
+	}
+
+	contentTypes := map[string]bool{mime.TypeApplicationJson: true}
+	for _, codec := range ps.Codecs {
+		if codec == nil {
+			return errors.New(ErrCodecIsNotSet)
+		}
+		if contentTypes[codec.ContentType()] {
+			return fmt.Errorf(ErrFCodecContentTypeConflict, codec.ContentType())
+		}
+		contentTypes[codec.ContentType()] = true
 	}
 
 	return nil
 }
 
+// isEnvelopeCheckEnabled tells whether fields of request envelopes are
+// checked.
+func (ps *ProcessorSettings) isEnvelopeCheckEnabled() bool {
+	return ps.StrictEnvelope || ps.RejectDuplicateKeys
+}
+
+// isParametersCheckEnabled tells whether parameters are checked before the
+// function call.
+func (ps *ProcessorSettings) isParametersCheckEnabled() bool {
+	return ps.RequireObjectParameters || ps.RejectDuplicateKeys
+}
+
+// isStrictDecodingEnabled tells whether any option of strict decoding is
+// enabled. In this mode, errors of parameters returned by the
+// 'ParseParametersWith' function with options of the processor have details
+// of the problem.
+func (ps *ProcessorSettings) isStrictDecodingEnabled() bool {
+	return ps.isEnvelopeCheckEnabled() || ps.isParametersCheckEnabled() || ps.UseNumbersInParameters
+}
+
 // isDurationEnabled tells whether time measurement is enabled.
 func (ps *ProcessorSettings) isDurationEnabled() bool {
 	return ps.DurationFieldName != nil
 }
 
+// isAccessLogEnabled tells whether function calls are journaled.
+func (ps *ProcessorSettings) isAccessLogEnabled() bool {
+	return ps.AccessLog != nil
+}
+
+// isMetricsEnabled tells whether metrics are collected.
+func (ps *ProcessorSettings) isMetricsEnabled() bool {
+	return ps.Metrics != nil
+}
+
+// isTimerEnabled tells whether the time of request processing is measured.
+func (ps *ProcessorSettings) isTimerEnabled() bool {
+	return ps.isDurationEnabled() || ps.isPhaseDurationEnabled() || ps.isAccessLogEnabled() || ps.isMetricsEnabled()
+}
+
+// isTracingEnabled tells whether function calls are traced.
+func (ps *ProcessorSettings) isTracingEnabled() bool {
+	return ps.Tracer != nil
+}
+
+// isSpanShown tells whether the span is added to the meta-data set.
+func (ps *ProcessorSettings) isSpanShown() bool {
+	return ps.isTracingEnabled() && (ps.SpanFieldName != nil)
+}
+
+// getLogger returns the structured logger.
+func (ps *ProcessorSettings) getLogger() *slog.Logger {
+	if ps.Logger != nil {
+		return ps.Logger
+	}
+
+	return slog.Default()
+}
+
+// isPhaseDurationEnabled tells whether durations of processing phases are
+// measured.
+func (ps *ProcessorSettings) isPhaseDurationEnabled() bool {
+	return ps.PhaseDurationsFieldName != nil
+}
+
+// getMaxBatchSize returns the maximum number of function calls in a batch.
+func (ps *ProcessorSettings) getMaxBatchSize() int {
+	if ps.MaxBatchSize == 0 {
+		return DefaultMaxBatchSize
+	}
+
+	return int(ps.MaxBatchSize)
+}
+
+// getNotificationWorkersCount returns the number of workers executing
+// notifications.
+func (ps *ProcessorSettings) getNotificationWorkersCount() int {
+	if ps.NotificationWorkersCount == 0 {
+		return DefaultNotificationWorkersCount
+	}
+
+	return int(ps.NotificationWorkersCount)
+}
+
+// getNotificationQueueSize returns the maximum number of notifications
+// waiting for execution.
+func (ps *ProcessorSettings) getNotificationQueueSize() int {
+	if ps.NotificationQueueSize == 0 {
+		return DefaultNotificationQueueSize
+	}
+
+	return int(ps.NotificationQueueSize)
+}
+
+// getJobTTL returns the time during which a finished job is kept in the store.
+func (ps *ProcessorSettings) getJobTTL() time.Duration {
+	if ps.JobTTL <= 0 {
+		return DefaultJobTTL
+	}
+
+	return ps.JobTTL
+}
+
+// getStreamFlushPeriod returns the number of result items of a streaming
+// function written between flushes of the HTTP response.
+func (ps *ProcessorSettings) getStreamFlushPeriod() uint {
+	if ps.StreamFlushPeriod == 0 {
+		return DefaultStreamFlushPeriod
+	}
+
+	return ps.StreamFlushPeriod
+}
+
+// isJobContextShown tells whether the job context is added to the meta-data
+// set of an asynchronous function.
+func (ps *ProcessorSettings) isJobContextShown() bool {
+	return ps.JobContextFieldName != nil
+}
+
 // isRequestIdShown tells whether request ID is added to the meta-data set.
 // Note that request ID is added to the meta-data set only for the duration of
 // the function call. When the requested function returns, the ID is removed
@@ -59,3 +450,30 @@ func (ps *ProcessorSettings) isDurationEnabled() bool {
 func (ps *ProcessorSettings) isRequestIdShown() bool {
 	return ps.RequestIdFieldName != nil
 }
+
+// getCompressionThreshold returns the minimum size of a compressed response.
+func (ps *ProcessorSettings) getCompressionThreshold() int {
+	if ps.CompressionThreshold == 0 {
+		return DefaultCompressionThreshold
+	}
+
+	return int(ps.CompressionThreshold)
+}
+
+// getMaxDecompressedRequestSize returns the maximum size of a decompressed
+// request body.
+func (ps *ProcessorSettings) getMaxDecompressedRequestSize() int64 {
+	if ps.MaxDecompressedRequestSize == 0 {
+		return DefaultMaxDecompressedSize
+	}
+
+	return int64(ps.MaxDecompressedRequestSize)
+}
+
+// getCodecs returns the supported codecs. JSON codec is the first one.
+func (ps *ProcessorSettings) getCodecs() []Codec {
+	codecs := make([]Codec, 0, len(ps.Codecs)+1)
+	codecs = append(codecs, &JsonCodec{profile: ps.JsonProfile})
+
+	return append(codecs, ps.Codecs...)
+}
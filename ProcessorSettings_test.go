@@ -1,7 +1,9 @@
 package jrm1
 
 import (
+	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/vault-thirteen/auxie/tester"
 )
@@ -28,7 +30,113 @@ func Test_ProcessorSettings_Check(t *testing.T) {
 	err = ps.Check()
 	aTest.MustBeAnError(err)
 
-	// Test #3. All clear.
+	// Test #3. Span field conflicts with duration field.
+	someFieldC := "cc"
+	ps = &ProcessorSettings{
+		DurationFieldName: &someFieldC,
+		SpanFieldName:     &someFieldC,
+	}
+	err = ps.Check()
+	aTest.MustBeAnError(err)
+
+	// Test #4. Unsupported duration format.
+	ps = &ProcessorSettings{
+		DurationFormat: DurationFormat("minutes"),
+	}
+	err = ps.Check()
+	aTest.MustBeAnError(err)
+
+	// Test #5. Phase durations field conflicts with request ID field.
+	ps = &ProcessorSettings{
+		PhaseDurationsFieldName: &someFieldC,
+		RequestIdFieldName:      &someFieldC,
+	}
+	err = ps.Check()
+	aTest.MustBeAnError(err)
+
+	// Test #6. Job context field conflicts with request ID field.
+	ps = &ProcessorSettings{
+		JobContextFieldName: &someFieldC,
+		RequestIdFieldName:  &someFieldC,
+	}
+	err = ps.Check()
+	aTest.MustBeAnError(err)
+
+	// Test #7. Codec is not set.
+	ps = &ProcessorSettings{
+		Codecs: []Codec{NewCborCodec(), nil},
+	}
+	err = ps.Check()
+	aTest.MustBeAnError(err)
+
+	// Test #8. Codec conflicts with JSON.
+	ps = &ProcessorSettings{
+		Codecs: []Codec{NewJsonCodec()},
+	}
+	err = ps.Check()
+	aTest.MustBeAnError(err)
+
+	// Test #9. Unsupported JSON profile.
+	ps = &ProcessorSettings{
+		JsonProfile: "indented",
+	}
+	err = ps.Check()
+	aTest.MustBeAnError(err)
+
+	// Test #10. Code of error registry is not in error catalogue.
+	er, err := _newErrorRegistry()
+	aTest.MustBeNoError(err)
+	ps = &ProcessorSettings{
+		ErrorRegistry:  er,
+		ErrorCatalogue: NewErrorCatalogue(),
+	}
+	err = ps.Check()
+	aTest.MustBeAnError(err)
+
+	// Test #11. Code of error localization is not in error catalogue.
+	el, err := _newErrorLocalization()
+	aTest.MustBeNoError(err)
+	ps = &ProcessorSettings{
+		ErrorLocalization: el,
+		ErrorCatalogue:    NewErrorCatalogue(),
+	}
+	err = ps.Check()
+	aTest.MustBeAnError(err)
+
+	// Test #12. Unsupported exception details.
+	ps = &ProcessorSettings{
+		CatchExceptions:  true,
+		ExceptionDetails: "stack",
+	}
+	err = ps.Check()
+	aTest.MustBeAnError(err)
+
+	// Test #13. Exception details without catching.
+	ps = &ProcessorSettings{
+		ExceptionDetails: ExceptionDetails_Debug,
+	}
+	err = ps.Check()
+	aTest.MustBeAnError(err)
+
+	// Test #14. Exception details without journaling.
+	ps = &ProcessorSettings{
+		CatchExceptions:  true,
+		ExceptionDetails: ExceptionDetails_IncidentId,
+	}
+	err = ps.Check()
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrEnableExceptionLogToShowThem)
+
+	// Test #15. Panic handler without catching.
+	ps = &ProcessorSettings{
+		PanicHandler: func(string, string, string, any, []byte) *RpcError { return nil },
+	}
+	err = ps.Check()
+	aTest.MustBeAnError(err)
+
+	// Test #16. All clear.
+	ec, err := _newErrorCatalogue()
+	aTest.MustBeNoError(err)
 	someFieldA := "aa"
 	someFieldB := "bb"
 	ps = &ProcessorSettings{
@@ -37,11 +145,47 @@ func Test_ProcessorSettings_Check(t *testing.T) {
 		CountRequests:      true,
 		DurationFieldName:  &someFieldA,
 		RequestIdFieldName: &someFieldB,
+		Codecs:             []Codec{NewMessagePackCodec(), NewCborCodec()},
+		JsonProfile:        JsonProfile_Pretty,
+		ErrorRegistry:      er,
+		ErrorCatalogue:     ec,
+		ErrorLocalization:  el,
+		ExceptionDetails:   ExceptionDetails_IncidentId,
 	}
 	err = ps.Check()
 	aTest.MustBeNoError(err)
 }
 
+func Test_ProcessorSettings_isStrictDecodingEnabled(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+
+	// Test #1. Disabled.
+	ps = &ProcessorSettings{}
+	aTest.MustBeEqual(ps.isEnvelopeCheckEnabled(), false)
+	aTest.MustBeEqual(ps.isParametersCheckEnabled(), false)
+	aTest.MustBeEqual(ps.isStrictDecodingEnabled(), false)
+
+	// Test #2. Duplicate keys.
+	ps = &ProcessorSettings{RejectDuplicateKeys: true}
+	aTest.MustBeEqual(ps.isEnvelopeCheckEnabled(), true)
+	aTest.MustBeEqual(ps.isParametersCheckEnabled(), true)
+	aTest.MustBeEqual(ps.isStrictDecodingEnabled(), true)
+
+	// Test #3. Strict envelope.
+	ps = &ProcessorSettings{StrictEnvelope: true}
+	aTest.MustBeEqual(ps.isEnvelopeCheckEnabled(), true)
+	aTest.MustBeEqual(ps.isParametersCheckEnabled(), false)
+
+	// Test #4. Parameters.
+	ps = &ProcessorSettings{RequireObjectParameters: true}
+	aTest.MustBeEqual(ps.isEnvelopeCheckEnabled(), false)
+	aTest.MustBeEqual(ps.isParametersCheckEnabled(), true)
+	ps = &ProcessorSettings{UseNumbersInParameters: true}
+	aTest.MustBeEqual(ps.isParametersCheckEnabled(), false)
+	aTest.MustBeEqual(ps.isStrictDecodingEnabled(), true)
+}
+
 func Test_ProcessorSettings_isDurationEnabled(t *testing.T) {
 	aTest := tester.New(t)
 	var ps *ProcessorSettings
@@ -77,3 +221,245 @@ func Test_ProcessorSettings_isRequestIdShown(t *testing.T) {
 	}
 	aTest.MustBeEqual(ps.isRequestIdShown(), false)
 }
+
+func Test_ProcessorSettings_isAccessLogEnabled(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+
+	// Test #1.
+	ps = &ProcessorSettings{
+		AccessLog: &AccessLogSettings{},
+	}
+	aTest.MustBeEqual(ps.isAccessLogEnabled(), true)
+
+	// Test #2.
+	ps = &ProcessorSettings{
+		AccessLog: nil,
+	}
+	aTest.MustBeEqual(ps.isAccessLogEnabled(), false)
+}
+
+func Test_ProcessorSettings_getLogger(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+
+	// Test #1. Custom logger.
+	logger := slog.New(slog.DiscardHandler)
+	ps = &ProcessorSettings{
+		Logger: logger,
+	}
+	aTest.MustBeEqual(ps.getLogger(), logger)
+
+	// Test #2. Default logger.
+	ps = &ProcessorSettings{}
+	aTest.MustBeEqual(ps.getLogger(), slog.Default())
+}
+
+func Test_ProcessorSettings_isMetricsEnabled(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+
+	// Test #1.
+	ps = &ProcessorSettings{
+		Metrics: NewMetrics(nil, nil),
+	}
+	aTest.MustBeEqual(ps.isMetricsEnabled(), true)
+
+	// Test #2.
+	ps = &ProcessorSettings{
+		Metrics: nil,
+	}
+	aTest.MustBeEqual(ps.isMetricsEnabled(), false)
+}
+
+func Test_ProcessorSettings_isTimerEnabled(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+	durField := "dur"
+
+	// Test #1. Nothing needs the timer.
+	ps = &ProcessorSettings{}
+	aTest.MustBeEqual(ps.isTimerEnabled(), false)
+
+	// Test #2. Duration.
+	ps = &ProcessorSettings{DurationFieldName: &durField}
+	aTest.MustBeEqual(ps.isTimerEnabled(), true)
+
+	// Test #3. Access log.
+	ps = &ProcessorSettings{AccessLog: &AccessLogSettings{}}
+	aTest.MustBeEqual(ps.isTimerEnabled(), true)
+
+	// Test #4. Metrics.
+	ps = &ProcessorSettings{Metrics: NewMetrics(nil, nil)}
+	aTest.MustBeEqual(ps.isTimerEnabled(), true)
+}
+
+func Test_ProcessorSettings_isTracingEnabled(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+
+	// Test #1.
+	ps = &ProcessorSettings{Tracer: NewMemoryTracer()}
+	aTest.MustBeEqual(ps.isTracingEnabled(), true)
+
+	// Test #2.
+	ps = &ProcessorSettings{}
+	aTest.MustBeEqual(ps.isTracingEnabled(), false)
+}
+
+func Test_ProcessorSettings_isSpanShown(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+	spanField := "span"
+
+	// Test #1. Tracer and field name are set.
+	ps = &ProcessorSettings{Tracer: NewMemoryTracer(), SpanFieldName: &spanField}
+	aTest.MustBeEqual(ps.isSpanShown(), true)
+
+	// Test #2. Tracer is not set.
+	ps = &ProcessorSettings{SpanFieldName: &spanField}
+	aTest.MustBeEqual(ps.isSpanShown(), false)
+
+	// Test #3. Field name is not set.
+	ps = &ProcessorSettings{Tracer: NewMemoryTracer()}
+	aTest.MustBeEqual(ps.isSpanShown(), false)
+}
+
+func Test_ProcessorSettings_isPhaseDurationEnabled(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+
+	// Test #1.
+	phasesField := "phases"
+	ps = &ProcessorSettings{
+		PhaseDurationsFieldName: &phasesField,
+	}
+	aTest.MustBeEqual(ps.isPhaseDurationEnabled(), true)
+
+	// Test #2.
+	ps = &ProcessorSettings{
+		PhaseDurationsFieldName: nil,
+	}
+	aTest.MustBeEqual(ps.isPhaseDurationEnabled(), false)
+}
+
+func Test_ProcessorSettings_getMaxBatchSize(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+
+	// Test #1. Default.
+	ps = &ProcessorSettings{}
+	aTest.MustBeEqual(ps.getMaxBatchSize(), DefaultMaxBatchSize)
+
+	// Test #2. Custom.
+	ps = &ProcessorSettings{MaxBatchSize: 5}
+	aTest.MustBeEqual(ps.getMaxBatchSize(), 5)
+}
+
+func Test_ProcessorSettings_getNotificationWorkersCount(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+
+	// Test #1. Default.
+	ps = &ProcessorSettings{}
+	aTest.MustBeEqual(ps.getNotificationWorkersCount(), DefaultNotificationWorkersCount)
+
+	// Test #2. Custom.
+	ps = &ProcessorSettings{NotificationWorkersCount: 2}
+	aTest.MustBeEqual(ps.getNotificationWorkersCount(), 2)
+}
+
+func Test_ProcessorSettings_getNotificationQueueSize(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+
+	// Test #1. Default.
+	ps = &ProcessorSettings{}
+	aTest.MustBeEqual(ps.getNotificationQueueSize(), DefaultNotificationQueueSize)
+
+	// Test #2. Custom.
+	ps = &ProcessorSettings{NotificationQueueSize: 7}
+	aTest.MustBeEqual(ps.getNotificationQueueSize(), 7)
+}
+
+func Test_ProcessorSettings_getJobTTL(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+
+	// Test #1. Default.
+	ps = &ProcessorSettings{}
+	aTest.MustBeEqual(ps.getJobTTL(), DefaultJobTTL)
+
+	// Test #2. Custom.
+	ps = &ProcessorSettings{JobTTL: time.Minute}
+	aTest.MustBeEqual(ps.getJobTTL(), time.Minute)
+}
+
+func Test_ProcessorSettings_isJobContextShown(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+
+	// Test #1. Negative.
+	ps = &ProcessorSettings{}
+	aTest.MustBeEqual(ps.isJobContextShown(), false)
+
+	// Test #2. Positive.
+	ps = &ProcessorSettings{JobContextFieldName: &_metaFieldName_JobContext}
+	aTest.MustBeEqual(ps.isJobContextShown(), true)
+}
+
+func Test_ProcessorSettings_getStreamFlushPeriod(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+
+	// Test #1. Default.
+	ps = &ProcessorSettings{}
+	aTest.MustBeEqual(ps.getStreamFlushPeriod(), uint(DefaultStreamFlushPeriod))
+
+	// Test #2. Custom.
+	ps = &ProcessorSettings{StreamFlushPeriod: 10}
+	aTest.MustBeEqual(ps.getStreamFlushPeriod(), uint(10))
+}
+
+func Test_ProcessorSettings_getCompressionThreshold(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+
+	// Test #1. Default.
+	ps = &ProcessorSettings{}
+	aTest.MustBeEqual(ps.getCompressionThreshold(), DefaultCompressionThreshold)
+
+	// Test #2. Custom.
+	ps = &ProcessorSettings{CompressionThreshold: 10}
+	aTest.MustBeEqual(ps.getCompressionThreshold(), 10)
+}
+
+func Test_ProcessorSettings_getMaxDecompressedRequestSize(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+
+	// Test #1. Default.
+	ps = &ProcessorSettings{}
+	aTest.MustBeEqual(ps.getMaxDecompressedRequestSize(), int64(DefaultMaxDecompressedSize))
+
+	// Test #2. Custom.
+	ps = &ProcessorSettings{MaxDecompressedRequestSize: 10}
+	aTest.MustBeEqual(ps.getMaxDecompressedRequestSize(), int64(10))
+}
+
+func Test_ProcessorSettings_getCodecs(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+
+	// Test #1. Default codec.
+	ps = &ProcessorSettings{}
+	aTest.MustBeEqual(ps.getCodecs(), []Codec{NewJsonCodec()})
+
+	// Test #2. Additional codecs.
+	ps = &ProcessorSettings{Codecs: []Codec{NewCborCodec()}}
+	aTest.MustBeEqual(ps.getCodecs(), []Codec{NewJsonCodec(), NewCborCodec()})
+
+	// Test #3. JSON profile.
+	ps = &ProcessorSettings{JsonProfile: JsonProfile_Pretty}
+	aTest.MustBeEqual(ps.getCodecs(), []Codec{&JsonCodec{profile: JsonProfile_Pretty}})
+}
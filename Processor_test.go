@@ -2,10 +2,16 @@ package jrm1
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	mime "github.com/vault-thirteen/auxie/MIME"
 	"github.com/vault-thirteen/auxie/header"
@@ -66,6 +72,177 @@ func Test_Processor_AddFunc(t *testing.T) {
 	aTest.MustBeEqual(err.Error(), `duplicate function`)
 }
 
+func Test_Processor_AddFuncWithAccess(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+	var p *Processor
+	var err error
+
+	// Test #1. Function with requirements.
+	ps = &ProcessorSettings{}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	ar := NewAccessRequirements([]string{"admin"}, nil)
+	err = p.AddFuncWithAccess(RpcFunctionExampleOne, ar)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.funcsAccess["RpcFunctionExampleOne"], ar)
+	err = p.RemoveFunc("RpcFunctionExampleOne")
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(len(p.funcsAccess), 0)
+
+	// Test #2. Function without requirements.
+	ps = &ProcessorSettings{}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFuncWithAccess(RpcFunctionExampleOne, nil)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(len(p.funcsAccess), 0)
+}
+
+func Test_Processor_isAccessAllowed(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+	var p *Processor
+	var err error
+
+	// Test #1. No policy.
+	ps = &ProcessorSettings{}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionExampleOne)
+	aTest.MustBeNoError(err)
+	err = p.AddFuncWithAccess(RpcFunctionExampleFive, NewAccessRequirements([]string{"admin"}, nil))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.isAccessAllowed(context.Background(), "RpcFunctionExampleOne", "1", nil), true)
+	aTest.MustBeEqual(p.isAccessAllowed(context.Background(), "RpcFunctionExampleFive", "1", nil), false)
+
+	// Test #2. Policy.
+	var lastCheck *AccessCheck
+	ps = &ProcessorSettings{
+		AccessPolicy: func(ac *AccessCheck) bool {
+			lastCheck = ac
+			return ac.Requirements.IsSatisfiedBy([]string{"user"}, nil)
+		},
+	}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionExampleOne)
+	aTest.MustBeNoError(err)
+	err = p.AddFuncWithAccess(RpcFunctionExampleFive, NewAccessRequirements([]string{"admin"}, nil))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.isAccessAllowed(context.Background(), "RpcFunctionExampleOne", "1", nil), true)
+	aTest.MustBeEqual(lastCheck.Method, "RpcFunctionExampleOne")
+	aTest.MustBeEqual(lastCheck.RequestId, "1")
+	aTest.MustBeEqual(p.isAccessAllowed(context.Background(), "RpcFunctionExampleFive", "2", nil), false)
+	aTest.MustBeEqual(lastCheck.Method, "RpcFunctionExampleFive")
+	aTest.MustBeEqual(lastCheck.RequestId, "2")
+}
+
+func Test_Processor_AddFuncWithError(t *testing.T) {
+	aTest := tester.New(t)
+
+	er, err := _newErrorRegistry()
+	aTest.MustBeNoError(err)
+	var logBuf bytes.Buffer
+	ps := &ProcessorSettings{
+		ErrorRegistry: er,
+		Logger:        slog.New(slog.NewJSONHandler(&logBuf, nil)),
+	}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+
+	// Test #1. All clear.
+	err = p.AddFuncWithError(RpcFunctionFind)
+	aTest.MustBeNoError(err)
+	aTest.MustBeNoError(p.FindFunc("RpcFunctionFind"))
+
+	// Test #2. Duplicate function.
+	err = p.AddFuncWithError(RpcFunctionFind)
+	aTest.MustBeAnError(err)
+
+	// Test #3. Errors are converted.
+	type testCase struct {
+		params string
+		result any
+		re     *RpcError
+	}
+	tests := []testCase{
+		{params: `1`, result: "one"},
+		{params: `2`, re: &RpcError{Code: 404, Message: "Not found"}},
+		{params: `3`, re: &RpcError{Code: 429, Message: "Quota is exceeded"}},
+		{params: `"x"`, re: NewRpcErrorFast(RpcErrorCode_InvalidParameters)},
+		{params: `4`, re: NewRpcErrorFast(RpcErrorCode_InternalRpcError)},
+	}
+	for _, test := range tests {
+		params := json.RawMessage(test.params)
+		result, re := p.RunFunc("RpcFunctionFind", &params, nil)
+		aTest.MustBeEqual(result, test.result)
+		aTest.MustBeEqual(re, test.re)
+	}
+
+	// Test #4. Unregistered error is journaled.
+	var record map[string]any
+	err = json.Unmarshal(logBuf.Bytes(), &record)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(record["msg"], ErrUnregisteredError)
+	aTest.MustBeEqual(record[LogAttr_Method], "RpcFunctionFind")
+	aTest.MustBeEqual(record[LogAttr_Error], "disk failure")
+}
+
+func Test_Processor_addFuncWithError(t *testing.T) {
+	aTest := tester.New(t)
+	var p *Processor
+	var err error
+	ar := NewAccessRequirements([]string{"admin"}, nil)
+
+	// Test #1. Function with requirements.
+	p, err = NewProcessor(&ProcessorSettings{})
+	aTest.MustBeNoError(err)
+	err = p.AddFuncWithErrorAndAccess(RpcFunctionFind, ar)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.funcsAccess["RpcFunctionFind"], ar)
+	aTest.MustBeEqual(p.isAccessAllowed(context.Background(), "RpcFunctionFind", "1", nil), false)
+
+	// Test #2. Notification-only function.
+	p, err = NewProcessor(&ProcessorSettings{})
+	aTest.MustBeNoError(err)
+	err = p.AddNotificationFuncWithError(RpcFunctionFind)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.isNotificationOnly("RpcFunctionFind"), true)
+
+	// Test #3. Asynchronous function, jobs are disabled.
+	err = p.AddAsyncFuncWithError(RpcFunctionFind)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrJobsAreDisabled)
+
+	// Test #4. Asynchronous function with requirements.
+	er, err := _newErrorRegistry()
+	aTest.MustBeNoError(err)
+	p, err = NewProcessor(&ProcessorSettings{EnableJobs: true, ErrorRegistry: er})
+	aTest.MustBeNoError(err)
+	defer p.Stop()
+	err = p.AddAsyncFuncWithErrorAndAccess(RpcFunctionFind, ar)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.asyncFuncs["RpcFunctionFind"], true)
+	aTest.MustBeEqual(p.funcsAccess["RpcFunctionFind"], ar)
+
+	// Test #5. Error of the job is converted.
+	params := json.RawMessage(`2`)
+	result, re := p.RunFunc("RpcFunctionFind", &params, nil)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	jobParams := json.RawMessage(fmt.Sprintf(`{"jobId":"%s"}`, result.(*JobInfo).JobId))
+	for {
+		result, re = p.RunFunc(JobMethod_GetStatus, &jobParams, nil)
+		aTest.MustBeEqual(re, (*RpcError)(nil))
+		if result.(*JobInfo).Status.IsFinished() {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	_, re = p.RunFunc(JobMethod_GetResult, &jobParams, nil)
+	aTest.MustBeEqual(re, &RpcError{Code: 404, Message: "Not found"})
+}
+
 func Test_Processor_AddFuncFast(t *testing.T) {
 	aTest := tester.New(t)
 	var ps *ProcessorSettings
@@ -125,6 +302,429 @@ func Test_Processor_RemoveFunc(t *testing.T) {
 	err = p.RemoveFunc("RpcFunctionExampleOne")
 	aTest.MustBeAnError(err)
 	aTest.MustBeEqual(err.Error(), `function is not found`)
+
+	// Test #3. Built-in function.
+	ec, err := _newErrorCatalogue()
+	aTest.MustBeNoError(err)
+	ps = &ProcessorSettings{EnableJobs: true, ErrorCatalogue: ec}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	defer p.Stop()
+	for _, funcName := range []string{JobMethod_GetStatus, JobMethod_GetResult, JobMethod_Cancel, CatalogueMethod_GetErrorCatalogue} {
+		err = p.RemoveFunc(funcName)
+		aTest.MustBeAnError(err)
+		aTest.MustBeEqual(err.Error(), ErrFunctionIsBuiltIn)
+		aTest.MustBeNoError(p.FindFunc(funcName))
+	}
+}
+
+func Test_Processor_addBuiltInFuncs(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+	var p *Processor
+	var err error
+
+	// Test #1. Built-in functions are disabled.
+	ps = &ProcessorSettings{}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(len(p.funcs), 0)
+	aTest.MustBeEqual(len(p.builtInFuncs), 0)
+
+	// Test #2. Built-in functions declare access requirements and are
+	// subject to the access policy.
+	ec, err := _newErrorCatalogue()
+	aTest.MustBeNoError(err)
+	ar := NewAccessRequirements([]string{"admin"}, nil)
+	ps = &ProcessorSettings{
+		EnableJobs:         true,
+		ErrorCatalogue:     ec,
+		BuiltInFuncsAccess: ar,
+		AccessPolicy: func(ac *AccessCheck) bool {
+			return ac.Requirements.IsSatisfiedBy([]string{"user"}, nil)
+		},
+	}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	defer p.Stop()
+	aTest.MustBeEqual(len(p.builtInFuncs), 4)
+	for funcName := range p.builtInFuncs {
+		aTest.MustBeEqual(p.funcsAccess[funcName], ar)
+		aTest.MustBeEqual(p.isAccessAllowed(context.Background(), funcName, "1", nil), false)
+	}
+
+	// Test #3. Function can not take the name of a built-in function.
+	err = p.addNamedFunc(CatalogueMethod_GetErrorCatalogue, RpcFunctionExampleOne, nil, false, false)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrDuplicateFunction)
+}
+
+func Test_Processor_AddNotificationFunc(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+	var p *Processor
+	var err error
+
+	// Test #1. Function is marked.
+	ps = &ProcessorSettings{}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddNotificationFunc(RpcFunctionNotified)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.isNotificationOnly("RpcFunctionNotified"), true)
+	aTest.MustBeEqual(p.isNotificationOnly("RpcFunctionSum"), false)
+
+	// Test #2. Mark is removed together with the function.
+	err = p.RemoveFunc("RpcFunctionNotified")
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.isNotificationOnly("RpcFunctionNotified"), false)
+
+	// Test #3. Duplicate function.
+	err = p.AddNotificationFunc(RpcFunctionSum)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(p.isNotificationOnly("RpcFunctionSum"), false)
+}
+
+func Test_Processor_AddStreamFunc(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+	var p *Processor
+	var err error
+
+	// Test #1. All clear.
+	ps = &ProcessorSettings{}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddStreamFunc(RpcFunctionCounter)
+	aTest.MustBeNoError(err)
+	err = p.FindFunc("RpcFunctionCounter")
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.isStreamFunc("RpcFunctionCounter"), true)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.isStreamFunc("RpcFunctionSum"), false)
+
+	// Test #2. Duplicate function.
+	err = p.AddStreamFunc(RpcFunctionCounter)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrDuplicateFunction)
+
+	// Test #3. Removal.
+	err = p.RemoveFunc("RpcFunctionCounter")
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.isStreamFunc("RpcFunctionCounter"), false)
+	err = p.FindFunc("RpcFunctionCounter")
+	aTest.MustBeAnError(err)
+}
+
+func Test_Processor_runStreamFunc(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+	var p *Processor
+	var err error
+	var re *RpcError
+	var pi *panicInfo
+
+	items := make([]any, 0)
+	yield := func(item any) bool {
+		items = append(items, item)
+		return len(items) < 2
+	}
+
+	ps = &ProcessorSettings{CatchExceptions: true}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Function is not found.
+	params := json.RawMessage(`3`)
+	re, pi = p.runStreamFunc("RpcFunctionCounter", "1", &params, nil, yield)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(RpcErrorCode_UnknownMethod))
+	aTest.MustBeEqual(pi, (*panicInfo)(nil))
+
+	// Test #2. Client is gone.
+	err = p.AddStreamFunc(RpcFunctionCounter)
+	aTest.MustBeNoError(err)
+	re, pi = p.runStreamFunc("RpcFunctionCounter", "1", &params, nil, yield)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeEqual(pi, (*panicInfo)(nil))
+	aTest.MustBeEqual(items, []any{1, 2})
+
+	// Test #3. Exception.
+	re, pi = p.runStreamFunc("RpcFunctionCounter", "1", &params, nil, nil)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(RpcErrorCode_InternalRpcError))
+	aTest.MustBeDifferent(pi, (*panicInfo)(nil))
+
+	// Test #4. Functions may be changed while a function is streaming. The
+	// function is removed by the first item, so the second item stops it.
+	items = items[:0]
+	yield = func(item any) bool {
+		items = append(items, item)
+		return p.RemoveFunc("RpcFunctionCounter") == nil
+	}
+	re, pi = p.runStreamFunc("RpcFunctionCounter", "1", &params, nil, yield)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeEqual(pi, (*panicInfo)(nil))
+	aTest.MustBeEqual(items, []any{1, 2})
+}
+
+func Test_Processor_AddAsyncFunc(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+	var p *Processor
+	var err error
+
+	// Test #1. Jobs are disabled.
+	ps = &ProcessorSettings{}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddAsyncFunc(RpcFunctionSum)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrJobsAreDisabled)
+
+	// Test #2. Jobs are enabled.
+	ps = &ProcessorSettings{EnableJobs: true}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	defer p.Stop()
+	err = p.AddAsyncFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.asyncFuncs["RpcFunctionSum"], true)
+	err = p.FindFunc(JobMethod_GetStatus)
+	aTest.MustBeNoError(err)
+
+	// Test #3. Mark is removed together with the function.
+	err = p.RemoveFunc("RpcFunctionSum")
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.asyncFuncs["RpcFunctionSum"], false)
+}
+
+func Test_Processor_AddAsyncFuncWithAccess(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+	var p *Processor
+	var err error
+	ar := NewAccessRequirements([]string{"admin"}, nil)
+
+	// Test #1. Jobs are disabled.
+	ps = &ProcessorSettings{}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddAsyncFuncWithAccess(RpcFunctionSum, ar)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrJobsAreDisabled)
+
+	// Test #2. Jobs are enabled.
+	ps = &ProcessorSettings{EnableJobs: true}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	defer p.Stop()
+	err = p.AddAsyncFuncWithAccess(RpcFunctionSum, ar)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.asyncFuncs["RpcFunctionSum"], true)
+	aTest.MustBeEqual(p.funcsAccess["RpcFunctionSum"], ar)
+	aTest.MustBeEqual(p.isAccessAllowed(context.Background(), "RpcFunctionSum", "1", nil), false)
+}
+
+func Test_Processor_jobs(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+	var p *Processor
+	var err error
+	var result any
+	var re *RpcError
+
+	jobParams := func(jobId string) *json.RawMessage {
+		params := json.RawMessage(fmt.Sprintf(`{"jobId":"%s"}`, jobId))
+		return &params
+	}
+	waitForJob := func(jobId string) *JobInfo {
+		for {
+			result, re = p.RunFunc(JobMethod_GetStatus, jobParams(jobId), nil)
+			aTest.MustBeEqual(re, (*RpcError)(nil))
+			ji := result.(*JobInfo)
+			if ji.Status.IsFinished() {
+				return ji
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	ps = &ProcessorSettings{
+		CatchExceptions:     true,
+		EnableJobs:          true,
+		JobTTL:              time.Hour,
+		JobContextFieldName: &_metaFieldName_JobContext,
+	}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddAsyncFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	err = p.AddAsyncFunc(RpcFunctionWaiter)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Job succeeds.
+	params := json.RawMessage(`{"a":1,"b":2}`)
+	result, re = p.RunFunc("RpcFunctionSum", &params, nil)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	ji := result.(*JobInfo)
+	aTest.MustBeEqual(ji.Method, "RpcFunctionSum")
+	aTest.MustBeEqual(waitForJob(ji.JobId).Status, JobStatus_Succeeded)
+	result, re = p.RunFunc(JobMethod_GetResult, jobParams(ji.JobId), nil)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeEqual(string(result.(json.RawMessage)), `{"c":3}`)
+	_, re = p.RunFunc(JobMethod_Cancel, jobParams(ji.JobId), nil)
+	aTest.MustBeEqual(re.Data, ErrJobIsFinished)
+
+	// Test #2. Job fails.
+	params = json.RawMessage(`{"a":255,"b":2}`)
+	result, re = p.RunFunc("RpcFunctionSum", &params, nil)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	ji = result.(*JobInfo)
+	aTest.MustBeEqual(waitForJob(ji.JobId).Status, JobStatus_Failed)
+	result, re = p.RunFunc(JobMethod_GetResult, jobParams(ji.JobId), nil)
+	aTest.MustBeEqual(result, nil)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(1))
+
+	// Test #3. Job is cancelled.
+	result, re = p.RunFunc("RpcFunctionWaiter", &params, nil)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	ji = result.(*JobInfo)
+	_, re = p.RunFunc(JobMethod_GetResult, jobParams(ji.JobId), nil)
+	aTest.MustBeEqual(re.Data, ErrJobIsNotFinished)
+	result, re = p.RunFunc(JobMethod_Cancel, jobParams(ji.JobId), nil)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeEqual(result.(*JobInfo).Status, JobStatus_Cancelled)
+	_, re = p.RunFunc(JobMethod_GetResult, jobParams(ji.JobId), nil)
+	aTest.MustBeEqual(re.Data, ErrJobIsCancelled)
+
+	// Test #4. Job is not found.
+	_, re = p.RunFunc(JobMethod_GetStatus, jobParams("x"), nil)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(RpcErrorCode_InvalidRequest))
+	aTest.MustBeEqual(re.Data, ErrJobIsNotFound)
+	_, re = p.RunFunc(JobMethod_GetStatus, nil, nil)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(RpcErrorCode_InvalidParameters))
+
+	// Test #5. Running jobs are cancelled by the stop.
+	result, re = p.RunFunc("RpcFunctionWaiter", &params, nil)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	ji = result.(*JobInfo)
+	p.Stop()
+	aTest.MustBeEqual(waitForJob(ji.JobId).Status, JobStatus_Cancelled)
+	_, re = p.RunFunc("RpcFunctionSum", &params, nil)
+	aTest.MustBeEqual(re.Data, ErrJobsAreStopped)
+
+	// Test #6. Exception escaped from the function of a job.
+	var logBuf bytes.Buffer
+	ps = &ProcessorSettings{
+		EnableJobs: true,
+		JobTTL:     time.Hour,
+		Logger:     slog.New(slog.NewJSONHandler(&logBuf, nil)),
+	}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddAsyncFunc(RpcFunctionExampleCrasher)
+	aTest.MustBeNoError(err)
+	result, re = p.RunFunc("RpcFunctionExampleCrasher", nil, nil)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	ji = result.(*JobInfo)
+	aTest.MustBeEqual(waitForJob(ji.JobId).Status, JobStatus_Failed)
+	_, re = p.RunFunc(JobMethod_GetResult, jobParams(ji.JobId), nil)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(RpcErrorCode_InternalRpcError))
+	p.Stop()
+	aTest.MustBeEqual(strings.Contains(logBuf.String(), ErrExceptionEscaped), true)
+}
+
+func Test_Processor_runJobCleaner(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	ps := &ProcessorSettings{EnableJobs: true, JobTTL: 10 * time.Millisecond}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	defer p.Stop()
+	err = p.AddAsyncFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+
+	params := json.RawMessage(`{"a":1,"b":2}`)
+	result, re := p.RunFunc("RpcFunctionSum", &params, nil)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	jobId := result.(*JobInfo).JobId
+	for {
+		job, _ := p.jobStore.GetJob(jobId)
+		if job == nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func Test_Processor_Stop(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+	var p *Processor
+	var err error
+
+	// Test #1. Notifications are disabled.
+	ps = &ProcessorSettings{}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.enqueueNotification(nil), false)
+	p.Stop()
+
+	// Test #2. Queued notifications are executed before the stop.
+	ps = &ProcessorSettings{EnableNotifications: true, NotificationWorkersCount: 1}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionNotified)
+	aTest.MustBeNoError(err)
+	for i := 1; i <= 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "http://example.org",
+			strings.NewReader(fmt.Sprintf(`{"jsonrpc":"M1","id":"%d","method":"RpcFunctionNotified","params":%d}`, i, i)))
+		req.Header.Add(header.HttpHeaderContentType, mime.TypeApplicationJson)
+		req.Header.Add(header.HttpHeaderAccept, mime.TypeAny)
+		req.Header.Add(HttpHeaderNotification, "true")
+		recorder := httptest.NewRecorder()
+		p.ServeHTTP(recorder, req)
+		aTest.MustBeEqual(recorder.Code, http.StatusAccepted)
+	}
+	p.Stop()
+	aTest.MustBeEqual(len(_notifiedParams), 3)
+	aTest.MustBeEqual(<-_notifiedParams, "1")
+	aTest.MustBeEqual(<-_notifiedParams, "2")
+	aTest.MustBeEqual(<-_notifiedParams, "3")
+
+	// Test #3. Notifications are refused after the stop.
+	aTest.MustBeEqual(p.enqueueNotification(nil), false)
+	p.Stop()
+
+	// Test #4. Exception in a notification does not stop the worker.
+	var logBuf bytes.Buffer
+	ps = &ProcessorSettings{
+		EnableNotifications:      true,
+		NotificationWorkersCount: 1,
+		Logger:                   slog.New(slog.NewJSONHandler(&logBuf, nil)),
+	}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionNotified)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionExampleCrasher)
+	aTest.MustBeNoError(err)
+	for _, method := range []string{"RpcFunctionExampleCrasher", "RpcFunctionNotified"} {
+		req := httptest.NewRequest(http.MethodPost, "http://example.org",
+			strings.NewReader(fmt.Sprintf(`{"jsonrpc":"M1","id":"1","method":"%s","params":4}`, method)))
+		req.Header.Add(header.HttpHeaderContentType, mime.TypeApplicationJson)
+		req.Header.Add(header.HttpHeaderAccept, mime.TypeAny)
+		req.Header.Add(HttpHeaderNotification, "true")
+		recorder := httptest.NewRecorder()
+		p.ServeHTTP(recorder, req)
+		aTest.MustBeEqual(recorder.Code, http.StatusAccepted)
+	}
+	p.Stop()
+	aTest.MustBeEqual(<-_notifiedParams, "4")
+	aTest.MustBeEqual(strings.Contains(logBuf.String(), ErrExceptionEscaped), true)
 }
 
 func Test_Processor_FindFunc(t *testing.T) {
@@ -199,6 +799,50 @@ func Test_Processor_RunFunc(t *testing.T) {
 	}
 }
 
+func Test_Processor_runFunc(t *testing.T) {
+	aTest := tester.New(t)
+	var ps *ProcessorSettings
+	var p *Processor
+	var err error
+	var result any
+	var re *RpcError
+	var pi *panicInfo
+
+	// Test #1. No exception.
+	ps = &ProcessorSettings{CatchExceptions: true}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionExampleFive)
+	aTest.MustBeNoError(err)
+	result, re, pi = p.runFunc("RpcFunctionExampleFive", "1", nil, nil)
+	aTest.MustBeEqual(result, 2024)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeEqual(pi, (*panicInfo)(nil))
+
+	// Test #2. Exception.
+	ps = &ProcessorSettings{CatchExceptions: true}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionExampleCrasher)
+	aTest.MustBeNoError(err)
+	result, re, pi = p.runFunc("RpcFunctionExampleCrasher", "1", nil, nil)
+	aTest.MustBeEqual(result, nil)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(RpcErrorCode_InternalRpcError))
+	aTest.MustBeDifferent(pi, (*panicInfo)(nil))
+	aTest.MustBeEqual(fmt.Sprint(pi.value), "runtime error: integer divide by zero")
+	aTest.MustBeEqual(len(pi.stack) > 0, true)
+
+	// Test #3. Functions may be changed while a function is running.
+	err = p.addNamedFunc("RpcFunctionAdder", func(_ *json.RawMessage, _ *ResponseMetaData) (result any, re *RpcError) {
+		return p.AddFunc(RpcFunctionSum) == nil, nil
+	}, nil, false, false)
+	aTest.MustBeNoError(err)
+	result, re, pi = p.runFunc("RpcFunctionAdder", "1", nil, nil)
+	aTest.MustBeEqual(result, true)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeEqual(pi, (*panicInfo)(nil))
+}
+
 func Test_Processor_ServeHTTP(t *testing.T) {
 	const TestUrl = "http://example.org"
 	aTest := tester.New(t)
@@ -313,6 +957,27 @@ func Test_Processor_ServeHTTP(t *testing.T) {
 
 }
 
+func Test_Processor_GetParametersDecoding(t *testing.T) {
+	aTest := tester.New(t)
+	var p *Processor
+	var err error
+
+	// Test #1. Strict decoding is disabled.
+	p, err = NewProcessor(&ProcessorSettings{})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.GetParametersDecoding(), (*ParametersDecoding)(nil))
+
+	// Test #2. Strict decoding without numbers.
+	p, err = NewProcessor(&ProcessorSettings{RejectDuplicateKeys: true})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.GetParametersDecoding(), &ParametersDecoding{ShowDetails: true})
+
+	// Test #3. Strict decoding with numbers.
+	p, err = NewProcessor(&ProcessorSettings{UseNumbersInParameters: true})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(p.GetParametersDecoding(), &ParametersDecoding{UseNumber: true, ShowDetails: true})
+}
+
 func Test_Processor_GetRequestsCount(t *testing.T) {
 	aTest := tester.New(t)
 	ps := &ProcessorSettings{}
@@ -348,3 +1013,203 @@ func Test_Processor_incSuccessfulRequestsCounter(t *testing.T) {
 	aTest.MustBeEqual(a, "0")
 	aTest.MustBeEqual(b, "1")
 }
+
+func Test_Processor_Handle(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	type ctxKey struct{}
+	var lastCheck *AccessCheck
+	ps := &ProcessorSettings{
+		EnableBatches:       true,
+		EnableNotifications: true,
+		AccessPolicy: func(ac *AccessCheck) bool {
+			lastCheck = ac
+			return true
+		},
+	}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	err = p.AddStreamFunc(RpcFunctionCounter)
+	aTest.MustBeNoError(err)
+	err = p.AddNotificationFunc(RpcFunctionNotified)
+	aTest.MustBeNoError(err)
+	ctx := context.WithValue(context.Background(), ctxKey{}, "caller")
+
+	// Test #1. Single call.
+	response := p.Handle(ctx, []byte(`{"jsonrpc":"M1","id":"1","method":"RpcFunctionSum","params":{"a":1,"b":2}}`))
+	aTest.MustBeEqual(string(response), `{"jsonrpc":"M1","id":"1","result":{"c":3},"error":null,"ok":true}`)
+	aTest.MustBeEqual(lastCheck.HttpRequest, (*http.Request)(nil))
+	aTest.MustBeEqual(lastCheck.Context.Value(ctxKey{}), "caller")
+
+	// Test #2. Malformed message.
+	response = p.Handle(ctx, []byte(`{`))
+	aTest.MustBeEqual(string(response),
+		`{"jsonrpc":"M1","id":null,"result":null,"error":{"code":-1,"message":"Request is not readable","data":null},"ok":false}`)
+
+	// Test #3. Batch.
+	response = p.Handle(ctx, []byte(`[{"jsonrpc":"M1","id":"3","method":"RpcFunctionSum","params":{"a":2,"b":2}}]`))
+	aTest.MustBeEqual(string(response), `[{"jsonrpc":"M1","id":"3","result":{"c":4},"error":null,"ok":true}]`)
+
+	// Test #4. Streaming function.
+	response = p.Handle(ctx, []byte(`{"jsonrpc":"M1","id":"4","method":"RpcFunctionCounter","params":1}`))
+	aTest.MustBeEqual(string(response), `{"jsonrpc":"M1","id":"4"}`+"\n"+`{"item":1}`+"\n"+`{"error":null,"ok":true}`)
+
+	// Test #5. Notification.
+	response = p.Handle(ctx, []byte(`{"jsonrpc":"M1","id":"5","method":"RpcFunctionNotified","params":"y"}`))
+	aTest.MustBeEqual(len(response), 0)
+	p.Stop()
+	aTest.MustBeEqual(<-_notifiedParams, `"y"`)
+}
+
+func Test_Processor_HandleRequest(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	ps := &ProcessorSettings{RequestIdFieldName: &_metaFieldName_RID}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	err = p.AddStreamFunc(RpcFunctionCounter)
+	aTest.MustBeNoError(err)
+
+	newRequest := func(id string, method string, params string) *RpcRequest {
+		protocol := ProtocolNameM1
+		rawParams := json.RawMessage(params)
+		return &RpcRequest{ProtocolName: &protocol, Id: &id, Method: &method, Parameters: &rawParams}
+	}
+
+	// Test #1. Successful call.
+	resp := p.HandleRequest(context.Background(), newRequest("1", "RpcFunctionSum", `{"a":1,"b":2}`))
+	aTest.MustBeEqual(resp.OK, true)
+	aTest.MustBeEqual(*resp.Id, "1")
+	aTest.MustBeEqual(resp.Result, any(&SumResult{C: 3}))
+	aTest.MustBeEqual(resp.Meta, (*ResponseMetaData)(nil))
+
+	// Test #2. Unknown method.
+	resp = p.HandleRequest(context.Background(), newRequest("2", "NoSuchFunction", `{}`))
+	aTest.MustBeEqual(resp.OK, false)
+	aTest.MustBeEqual(resp.Error.Code, RpcErrorCode(RpcErrorCode_UnknownMethod))
+
+	// Test #3. Streaming function.
+	resp = p.HandleRequest(context.Background(), newRequest("3", "RpcFunctionCounter", `1`))
+	aTest.MustBeEqual(resp.Error.Code, RpcErrorCode(RpcErrorCode_InvalidRequest))
+	aTest.MustBeEqual(resp.Error.Data, any(ErrStreamIsNotSupportedWithoutOutput))
+
+	// Test #4. Null request.
+	resp = p.HandleRequest(context.Background(), nil)
+	aTest.MustBeEqual(resp.Error.Code, RpcErrorCode(RpcErrorCode_InvalidRequest))
+}
+
+func Test_Processor_ServeHTTP_compression(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	ps := &ProcessorSettings{
+		EnableCompression:          true,
+		CompressionThreshold:       10,
+		MaxDecompressedRequestSize: 100,
+	}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+
+	newRequest := func(body []byte, contentEncoding string, acceptEncoding string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://example.org", bytes.NewReader(body))
+		req.Header.Set(header.HttpHeaderContentType, mime.TypeApplicationJson)
+		req.Header.Set(header.HttpHeaderAccept, mime.TypeApplicationJson)
+		if len(contentEncoding) > 0 {
+			req.Header.Set(header.HttpHeaderContentEncoding, contentEncoding)
+		}
+		if len(acceptEncoding) > 0 {
+			req.Header.Set(header.HttpHeaderAcceptEncoding, acceptEncoding)
+		}
+		return req
+	}
+	reqStr := `{"jsonrpc":"M1","id":"1","method":"RpcFunctionSum","params":{"a":1,"b":2}}`
+	respStr := `{"jsonrpc":"M1","id":"1","result":{"c":3},"error":null,"ok":true}` + "\n"
+
+	// Test #1. Compressed request and response.
+	compressed, err := compress(ContentEncoding_Gzip, []byte(reqStr))
+	aTest.MustBeNoError(err)
+	recorder := httptest.NewRecorder()
+	p.ServeHTTP(recorder, newRequest(compressed, ContentEncoding_Gzip, "deflate"))
+	resp := recorder.Result()
+	aTest.MustBeEqual(resp.StatusCode, http.StatusOK)
+	aTest.MustBeEqual(resp.Header.Get(header.HttpHeaderContentEncoding), ContentEncoding_Deflate)
+	aTest.MustBeEqual(resp.Header.Get(header.HttpHeaderVary), header.HttpHeaderAcceptEncoding)
+	aTest.MustBeEqual(_decompressBody(t, resp), respStr)
+
+	// Test #2. Client not accepting compression.
+	recorder = httptest.NewRecorder()
+	p.ServeHTTP(recorder, newRequest([]byte(reqStr), "", ""))
+	aTest.MustBeEqual(recorder.Header().Get(header.HttpHeaderContentEncoding), "")
+	aTest.MustBeEqual(recorder.Body.String(), respStr)
+
+	// Test #3. Unsupported encoding of the request.
+	recorder = httptest.NewRecorder()
+	p.ServeHTTP(recorder, newRequest([]byte(reqStr), "br", ""))
+	aTest.MustBeEqual(recorder.Code, http.StatusUnsupportedMediaType)
+
+	// Test #4. Decompression bomb.
+	bomb, err := compress(ContentEncoding_Gzip, []byte(reqStr+strings.Repeat(" ", 1000)))
+	aTest.MustBeNoError(err)
+	recorder = httptest.NewRecorder()
+	p.ServeHTTP(recorder, newRequest(bomb, ContentEncoding_Gzip, ""))
+	aTest.MustBeEqual(recorder.Body.String(),
+		`{"jsonrpc":"M1","id":null,"result":null,"error":{"code":-1,"message":"Request is not readable","data":null},"ok":false}`+"\n")
+
+	// Test #5. Request without compression is not limited by the size of
+	// decompressed requests.
+	recorder = httptest.NewRecorder()
+	p.ServeHTTP(recorder, newRequest([]byte(reqStr+strings.Repeat(" ", 1000)), ContentEncoding_Identity, ""))
+	aTest.MustBeEqual(recorder.Body.String(), respStr)
+
+	// Test #6. Disabled compression of responses.
+	p, err = NewProcessor(&ProcessorSettings{CompressionThreshold: 1})
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	recorder = httptest.NewRecorder()
+	p.ServeHTTP(recorder, newRequest(compressed, ContentEncoding_Gzip, "gzip"))
+	aTest.MustBeEqual(recorder.Header().Get(header.HttpHeaderContentEncoding), "")
+	aTest.MustBeEqual(recorder.Body.String(), respStr)
+}
+
+func Benchmark_Processor_ServeHTTP(b *testing.B) {
+	const body = `{"jsonrpc":"M1","id":"12345","method":"RpcFunctionSum","params":{"a":1,"b":2}}`
+
+	for _, isFast := range []bool{false, true} {
+		name := "Standard"
+		if isFast {
+			name = "FastPath"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			p, err := NewProcessor(&ProcessorSettings{EnableFastPath: isFast})
+			if err != nil {
+				b.Fatal(err)
+			}
+			err = p.AddFunc(RpcFunctionSum)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "http://example.org", nil)
+			req.Header.Set(header.HttpHeaderContentType, mime.TypeApplicationJson)
+			req.Header.Set(header.HttpHeaderAccept, mime.TypeApplicationJson)
+			rw := httptest.NewRecorder()
+
+			b.ReportAllocs()
+			for b.Loop() {
+				req.Body = io.NopCloser(strings.NewReader(body))
+				rw.Body.Reset()
+				p.ServeHTTP(rw, req)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package jrm1
+
+// PushMessage is a message sent by the RPC server to the client without a
+// request. Push messages are available in connection-oriented transports,
+// e.g. WebSocket.
+type PushMessage struct {
+	// RPC protocol name.
+	ProtocolName string `json:"jsonrpc"`
+
+	// Topic of the message. It lets the client distinguish kinds of messages.
+	Topic string `json:"push"`
+
+	// Contents of the message.
+	Data any `json:"data"`
+}
+
+// NewPushMessage is a constructor of a push message.
+func NewPushMessage(topic string, data any) (pm *PushMessage) {
+	return &PushMessage{
+		ProtocolName: ProtocolNameM1,
+		Topic:        topic,
+		Data:         data,
+	}
+}
@@ -0,0 +1,17 @@
+package jrm1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_NewPushMessage(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	buf, err := json.Marshal(NewPushMessage("news", 42))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(buf), `{"jsonrpc":"M1","push":"news","data":42}`)
+}
@@ -0,0 +1,153 @@
+package jrm1
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+)
+
+// ResponseStream is a streamed response received by the client. Items are
+// read lazily while iterating, so the stream must be closed after use. The
+// outcome of the function call is known when all items are read.
+type ResponseStream struct {
+	body    io.ReadCloser
+	decoder *json.Decoder
+
+	// Identifier of request and its response.
+	Id *string
+
+	// Outcome of the function call.
+	trailer *StreamTrailer
+
+	// Error which has stopped reading of the stream.
+	err error
+
+	// If enabled, unknown fields of result items are not an error.
+	isLenient bool
+}
+
+// newResponseStream reads the header of a streamed response and prepares
+// the stream for reading of items.
+func newResponseStream(body io.ReadCloser) (rs *ResponseStream, err error) {
+	rs = &ResponseStream{
+		body:    body,
+		decoder: json.NewDecoder(body),
+	}
+	rs.decoder.UseNumber()
+
+	var header StreamHeader
+	err = rs.decoder.Decode(&header)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.ProtocolName != ProtocolNameM1 {
+		return nil, errors.New(ErrStreamRecordIsUnexpected)
+	}
+	rs.Id = header.Id
+
+	return rs, nil
+}
+
+// Items returns an iterator over raw result items. Iteration stops at the end
+// of the stream or on error, which is then returned by the 'Result' method.
+func (rs *ResponseStream) Items() iter.Seq[json.RawMessage] {
+	return func(yield func(item json.RawMessage) bool) {
+		for {
+			item, ok := rs.next()
+			if !ok {
+				return
+			}
+
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// DecodeStreamItems returns an iterator over result items decoded into values
+// of the specified type. Iteration stops at the end of the stream or on error,
+// which is then returned by the 'Result' method of the stream.
+func DecodeStreamItems[T any](rs *ResponseStream) iter.Seq[T] {
+	return func(yield func(item T) bool) {
+		for rawItem := range rs.Items() {
+			var item T
+			decoder := json.NewDecoder(bytes.NewReader(rawItem))
+			if !rs.isLenient {
+				decoder.DisallowUnknownFields()
+			}
+			decoder.UseNumber()
+			err := decoder.Decode(&item)
+			if err != nil {
+				rs.err = err
+				return
+			}
+
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// next reads the next result item. When there are no more items, 'False' is
+// returned.
+func (rs *ResponseStream) next() (item json.RawMessage, ok bool) {
+	if (rs.trailer != nil) || (rs.err != nil) {
+		return nil, false
+	}
+
+	var record streamRecordRaw
+	err := rs.decoder.Decode(&record)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = errors.New(ErrStreamIsTruncated)
+		}
+		rs.err = err
+		return nil, false
+	}
+
+	if record.isTrailer() {
+		rs.trailer = &StreamTrailer{
+			Error: record.Error,
+			Meta:  record.Meta,
+			OK:    *record.OK,
+		}
+		return nil, false
+	}
+
+	if len(record.Item) == 0 {
+		rs.err = errors.New(ErrStreamRecordIsUnexpected)
+		return nil, false
+	}
+
+	return record.Item, true
+}
+
+// Result returns the outcome of the function call. Items which are not read
+// yet are skipped. If the stream can not be read till the end, an error is
+// returned.
+func (rs *ResponseStream) Result() (re *RpcError, meta *ResponseMetaData, err error) {
+	for range rs.Items() {
+	}
+
+	if rs.err != nil {
+		return nil, nil, rs.err
+	}
+
+	// Internal self-check.
+	// Error flag and success flag must have opposite values.
+	if (rs.trailer.Error != nil) == rs.trailer.OK {
+		return rs.trailer.Error, rs.trailer.Meta, errors.New(ErrInternalSelfCheck)
+	}
+
+	return rs.trailer.Error, rs.trailer.Meta, nil
+}
+
+// Close closes the stream.
+func (rs *ResponseStream) Close() (err error) {
+	return rs.body.Close()
+}
@@ -0,0 +1,112 @@
+package jrm1
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_newResponseStream(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+	var rs *ResponseStream
+
+	// Test #1. Header is not readable.
+	rs, err = newResponseStream(io.NopCloser(strings.NewReader(`{`)))
+	aTest.MustBeAnError(err)
+
+	// Test #2. Unexpected header.
+	rs, err = newResponseStream(io.NopCloser(strings.NewReader(`{"jsonrpc":"X","id":"1"}`)))
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrStreamRecordIsUnexpected)
+
+	// Test #3. All clear.
+	rs, err = newResponseStream(io.NopCloser(strings.NewReader(`{"jsonrpc":"M1","id":"1"}`)))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(*rs.Id, "1")
+}
+
+func Test_ResponseStream_Items(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+	var rs *ResponseStream
+	var re *RpcError
+	var meta *ResponseMetaData
+
+	newStream := func(body string) *ResponseStream {
+		rs, err = newResponseStream(io.NopCloser(strings.NewReader(`{"jsonrpc":"M1","id":"1"}` + "\n" + body)))
+		aTest.MustBeNoError(err)
+		return rs
+	}
+
+	// Test #1. All clear.
+	rs = newStream(`{"item":1}` + "\n" + `{"item":{"a":"b"}}` + "\n" + `{"error":null,"meta":{"x":"y"},"ok":true}` + "\n")
+	items := make([]string, 0)
+	for item := range rs.Items() {
+		items = append(items, string(item))
+	}
+	aTest.MustBeEqual(items, []string{`1`, `{"a":"b"}`})
+	re, meta, err = rs.Result()
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeEqual(*meta, ResponseMetaData{"x": "y"})
+	aTest.MustBeNoError(rs.Close())
+
+	// Test #2. Error after items. Unread items are skipped.
+	rs = newStream(`{"item":1}` + "\n" + `{"item":2}` + "\n" + `{"error":{"code":1,"message":"too many","data":null},"ok":false}`)
+	for range rs.Items() {
+		break
+	}
+	re, _, err = rs.Result()
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(1))
+
+	// Test #3. Stream is truncated.
+	rs = newStream(`{"item":1}` + "\n")
+	re, _, err = rs.Result()
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrStreamIsTruncated)
+
+	// Test #4. Unexpected record.
+	rs = newStream(`{"something":1}` + "\n")
+	_, _, err = rs.Result()
+	aTest.MustBeAnError(err)
+
+	// Test #5. Internal self-check.
+	rs = newStream(`{"error":null,"ok":false}`)
+	_, _, err = rs.Result()
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrInternalSelfCheck)
+}
+
+func Test_DecodeStreamItems(t *testing.T) {
+	aTest := tester.New(t)
+
+	newStream := func(body string) *ResponseStream {
+		rs, err := newResponseStream(io.NopCloser(strings.NewReader(`{"jsonrpc":"M1","id":"1"}` + "\n" + body)))
+		aTest.MustBeNoError(err)
+		return rs
+	}
+
+	// Test #1. All clear.
+	rs := newStream(`{"item":{"c":1}}` + "\n" + `{"item":{"c":2}}` + "\n" + `{"error":null,"ok":true}`)
+	items := make([]SumResult, 0)
+	for item := range DecodeStreamItems[SumResult](rs) {
+		items = append(items, item)
+	}
+	aTest.MustBeEqual(items, []SumResult{{C: 1}, {C: 2}})
+	_, _, err := rs.Result()
+	aTest.MustBeNoError(err)
+
+	// Test #2. Item is not decodable.
+	rs = newStream(`{"item":{"c":1}}` + "\n" + `{"item":{"d":2}}` + "\n" + `{"error":null,"ok":true}`)
+	items = make([]SumResult, 0)
+	for item := range DecodeStreamItems[SumResult](rs) {
+		items = append(items, item)
+	}
+	aTest.MustBeEqual(items, []SumResult{{C: 1}})
+	_, _, err = rs.Result()
+	aTest.MustBeAnError(err)
+}
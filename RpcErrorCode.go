@@ -10,8 +10,8 @@ const (
 	RpcErrorCode_UnknownMethod        = -8
 	RpcErrorCode_InvalidParameters    = -16
 	RpcErrorCode_InternalRpcError     = -32
+	RpcErrorCode_AccessDenied         = -64
 	//
-	RpcErrorCode_ReservedForFuture_1 = -64
 	RpcErrorCode_ReservedForFuture_2 = -128
 	RpcErrorCode_ReservedForFuture_3 = -256
 
@@ -19,6 +19,12 @@ const (
 	RpcErrorCode_UGEC_Minimal = 1
 )
 
+// RpcErrorCode_ReservedForFuture_1 is the former name of the error code which
+// is now used for denied access.
+//
+// Deprecated: use RpcErrorCode_AccessDenied instead.
+const RpcErrorCode_ReservedForFuture_1 = RpcErrorCode_AccessDenied
+
 const (
 	ErrUnsupportedErrorCode = "unsupported error code"
 	ErrFUnknownErrorCode    = "unknown error code: %v"
@@ -38,7 +44,7 @@ func (rec RpcErrorCode) Check() (err error) {
 		RpcErrorCode_UnknownMethod,
 		RpcErrorCode_InvalidParameters,
 		RpcErrorCode_InternalRpcError,
-		RpcErrorCode_ReservedForFuture_1,
+		RpcErrorCode_AccessDenied,
 		RpcErrorCode_ReservedForFuture_2,
 		RpcErrorCode_ReservedForFuture_3:
 		return nil
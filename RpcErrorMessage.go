@@ -10,14 +10,20 @@ const (
 	RpcErrorMsg_UnknownMethod        = "Unknown method"
 	RpcErrorMsg_InvalidParameters    = "Invalid parameters"
 	RpcErrorMsg_InternalRpcError     = "Internal RPC error"
+	RpcErrorMsg_AccessDenied         = "Access denied"
 	//
-	RpcErrorMsg_ReservedForFuture_1 = "Reserved for future (1)"
 	RpcErrorMsg_ReservedForFuture_2 = "Reserved for future (2)"
 	RpcErrorMsg_ReservedForFuture_3 = "Reserved for future (3)"
 
 	RpcErrorMsg_Empty = ""
 )
 
+// RpcErrorMsg_ReservedForFuture_1 is the former message of the error code
+// which is now used for denied access.
+//
+// Deprecated: use RpcErrorMsg_AccessDenied instead.
+const RpcErrorMsg_ReservedForFuture_1 = RpcErrorMsg_AccessDenied
+
 const (
 	ErrErrorMessageIsNotSet = "error message is not set"
 )
@@ -20,3 +20,14 @@ func NewRpcErrorStd(code RpcErrorCode, msg RpcErrorMessage, data any) (res *RpcE
 func (res *RpcErrorStd) Error() string {
 	return res.Message.String()
 }
+
+// Is tells whether the target is an RPC error having the same code. It is used
+// by the 'errors.Is' function.
+func (res *RpcErrorStd) Is(target error) bool {
+	t, ok := target.(*RpcErrorStd)
+	if !ok || (t == nil) || (res == nil) {
+		return false
+	}
+
+	return res.Code == t.Code
+}
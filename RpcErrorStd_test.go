@@ -1,6 +1,7 @@
 package jrm1
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/vault-thirteen/auxie/tester"
@@ -30,3 +31,14 @@ func Test_RpcErrorStd_Error(t *testing.T) {
 	aTest.MustBeEqual(ok, true)
 	aTest.MustBeEqual(res.Code, RpcErrorCode(1))
 }
+
+func Test_RpcErrorStd_Is(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	var err error = NewRpcErrorStd(1, "One", nil)
+	aTest.MustBeEqual(errors.Is(err, NewRpcErrorStd(1, "", true)), true)
+	aTest.MustBeEqual(errors.Is(err, NewRpcErrorStd(2, "One", nil)), false)
+	aTest.MustBeEqual(errors.Is(err, errors.New("One")), false)
+	aTest.MustBeEqual(errors.Is(err, (*RpcErrorStd)(nil)), false)
+}
@@ -45,8 +45,8 @@ func Test_NewRpcError(t *testing.T) {
 	re, err = NewRpcError(-64, nil)
 	aTest.MustBeNoError(err)
 	reExpected = &RpcError{
-		Code:    RpcErrorCode_ReservedForFuture_1,
-		Message: RpcErrorMsg_ReservedForFuture_1,
+		Code:    RpcErrorCode_AccessDenied,
+		Message: RpcErrorMsg_AccessDenied,
 	}
 	aTest.MustBeEqual(re, reExpected)
 }
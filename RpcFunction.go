@@ -35,6 +35,11 @@ type RpcFunction func(params *json.RawMessage, metaData *ResponseMetaData) (resu
 // describes Go’s stable ABI, known as ABI0.
 // -----------------------------------------------------------------------------
 func (f RpcFunction) GetName() string {
+	return getFunctionName(f)
+}
+
+// getFunctionName reads name of a Go function.
+func getFunctionName(f any) string {
 	fullName := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
 	parts := strings.Split(fullName, ".")
 	stageOneName := parts[len(parts)-1]
@@ -0,0 +1,16 @@
+package jrm1
+
+import "encoding/json"
+
+// RpcFunctionWithError represents a signature for an RPC function (method,
+// procedure) returning an ordinary Go error. The error is converted into an
+// RPC error by the error registry set in settings of the RPC processor
+// (server). Errors which are not registered are reported to the client as an
+// internal RPC error.
+type RpcFunctionWithError func(params *json.RawMessage, metaData *ResponseMetaData) (result any, err error)
+
+// GetName reads name of the RPC function (method, procedure).
+// See the 'GetName' method of the 'RpcFunction' type for details.
+func (f RpcFunctionWithError) GetName() string {
+	return getFunctionName(f)
+}
@@ -0,0 +1,15 @@
+package jrm1
+
+import (
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_RpcFunctionWithError_GetName(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	f := RpcFunctionWithError(RpcFunctionFind)
+	aTest.MustBeEqual(f.GetName(), "RpcFunctionFind")
+}
@@ -1,182 +1,128 @@
 package jrm1
 
 import (
-	"encoding/json"
-	"log"
+	"io"
 	"net/http"
-	"time"
 
-	mime "github.com/vault-thirteen/auxie/MIME"
 	"github.com/vault-thirteen/auxie/header"
 )
 
-// RpcHttpRequest is an RPC request originated from an HTTP request.
+// RpcHttpRequest is an RPC request originated from an HTTP request. It is an
+// adapter of the transport-neutral RPC call to HTTP.
 type RpcHttpRequest struct {
-	// Processor.
-	p *Processor
-
-	// Processor settings.
-	settings *ProcessorSettings
-
-	// HTTP request from which the RPC request was received.
-	req *http.Request
+	*rpcCall
 
 	// HTTP response writer used for communication with client.
 	rw http.ResponseWriter
 
-	// Time of start of the request processing.
-	tStart time.Time
-
-	// Time spent on processing a successful function call.
-	// Is set in milliseconds.
-	tDuration int64
-
-	// RPC request.
-	rr *RpcRequest
-
-	// RPC response.
-	resp *RpcResponse
+	// Writer compressing the response. It is null when the response is not
+	// compressed.
+	crw *compressingResponseWriter
 }
 
 // NewRpcHttpRequest is a simple constructor of an RPC request originated from
 // an HTTP request.
 func NewRpcHttpRequest(p *Processor, settings *ProcessorSettings, req *http.Request, rw http.ResponseWriter) (rhr *RpcHttpRequest) {
 	rhr = &RpcHttpRequest{
-		p:        p,
-		settings: settings,
-		req:      req,
-		rw:       rw,
+		rw: rw,
 	}
 
-	return rhr
-}
-
-// init reads a request from HTTP body, checks it and searches for the
-// requested function. If error occurs, it responds to the client via HTTP.
-// If request is correct and ready to be processed further, 'True' is returned.
-// When 'False' is returned, the caller must stop serving the request.
-func (r *RpcHttpRequest) init() (proceed bool) {
-	r.startTimer()
-
-	r.p.incAllRequestsCounter()
-
-	if !checkHttpRequest(r.rw, r.req) {
-		return false
+	var out io.Writer
+	if rw != nil {
+		out = rw
 	}
+	if (rw != nil) && (req != nil) && settings.EnableCompression {
+		rw.Header().Add(header.HttpHeaderVary, header.HttpHeaderAcceptEncoding)
 
-	r.resp = NewRpcResponse()
+		encoding := negotiateContentEncoding(req.Header.Get(header.HttpHeaderAcceptEncoding))
+		if len(encoding) > 0 {
+			rhr.crw = newCompressingResponseWriter(rw, encoding, settings.getCompressionThreshold())
+			out = rhr.crw
+		}
+	}
 
-	var err error
-	r.rr, err = NewRpcRequest(r.req.Body)
-	if err != nil {
-		r.resp.Error = NewRpcErrorFast(RpcErrorCode_RequestIsNotReadable)
-		r.respond()
-		return false
+	rhr.rpcCall = newRpcCall(p, settings, nil, req, out)
+	if rw != nil {
+		rhr.setContentType = rhr.setHttpContentType
+		rhr.flush = rhr.flushHttpResponse
+	}
+	if req != nil {
+		rhr.isNotificationRequested = isNotificationRequested(req)
 	}
 
-	r.resp.Id = r.rr.Id
+	return rhr
+}
+
+// init checks the HTTP request, reads the RPC request from HTTP body, checks
+// it, searches for the requested function and checks access to it. Compressed
+// body is decompressed. Codecs of the request and of the response are selected
+// by the HTTP headers. If error occurs, it responds to the client via HTTP. If
+// request is correct and ready to be processed further, 'True' is returned.
+// When 'False' is returned, the caller must stop serving the request. If the
+// request is a batch of function calls, it is only detected here and is served
+// by the 'serve' method.
+func (r *RpcHttpRequest) init() (proceed bool) {
+	r.startTimer()
 
 	var ok bool
-	ok = r.rr.HasAllRootFields()
+	r.requestCodec, r.responseCodec, ok = checkHttpRequest(r.rw, r.req, r.p.codecs)
 	if !ok {
-		r.resp.Error = NewRpcErrorFast(RpcErrorCode_InvalidRequest)
-		r.respond()
+		r.p.incAllRequestsCounter()
 		return false
 	}
 
-	err = r.rr.CheckProtocolVersion()
+	body, err := newContentDecoder(r.req.Header.Get(header.HttpHeaderContentEncoding), r.req.Body)
 	if err != nil {
-		r.resp.Error = NewRpcErrorFast(RpcErrorCode_UnsupportedProtocol)
-		r.respond()
+		r.p.incAllRequestsCounter()
+		r.rw.WriteHeader(http.StatusUnsupportedMediaType)
 		return false
 	}
-
-	err = r.p.FindFunc(*r.rr.Method)
-	if err != nil {
-		r.resp.Error = NewRpcErrorFast(RpcErrorCode_UnknownMethod)
-		r.respond()
-		return false
+	if isDecompressing(body) {
+		body = newLimitedReadCloser(body, r.settings.getMaxDecompressedRequestSize())
 	}
 
-	return true
+	return r.read(body)
 }
 
-// startTimer starts the timer.
-func (r *RpcHttpRequest) startTimer() {
-	if r.settings.isDurationEnabled() {
-		r.tStart = time.Now()
+// acknowledge translates the outcome of serving a notification into an HTTP
+// status code. If the queue of notifications is full, the client is asked to
+// retry later.
+func (r *RpcHttpRequest) acknowledge(outcome callOutcome) {
+	switch outcome {
+	case callOutcome_Accepted:
+		r.rw.WriteHeader(http.StatusAccepted)
+	case callOutcome_Refused:
+		r.rw.WriteHeader(http.StatusServiceUnavailable)
 	}
 }
 
-// run calls the requested function and collects the result. If error occurs,
-// it responds to the client via HTTP. If everything is correct and ready to be
-// processed further, 'True' is returned. When 'False' is returned, the caller
-// must stop serving the request.
-func (r *RpcHttpRequest) run() (proceed bool) {
-	var err error
-	if r.settings.isRequestIdShown() {
-		err = r.resp.Meta.AddField(*r.settings.RequestIdFieldName, *r.rr.Id)
-		if err != nil {
-			r.resp.Error = NewRpcErrorFast(RpcErrorCode_InternalRpcError)
-			r.respond()
-			return false
-		}
-	}
-
-	r.resp.Result, r.resp.Error = r.p.RunFunc(*r.rr.Method, r.rr.Parameters, r.resp.Meta)
-
-	if r.settings.isRequestIdShown() {
-		err = r.resp.Meta.RemoveField(*r.settings.RequestIdFieldName)
-		if err != nil {
-			r.resp.Error = NewRpcErrorFast(RpcErrorCode_InternalRpcError)
-			r.respond()
-			return false
-		}
+// finish finishes writing of the response. It must be called when the
+// request is served.
+func (r *RpcHttpRequest) finish() {
+	if r.crw == nil {
+		return
 	}
 
-	if !r.stopTimer() {
-		return false
+	err := r.crw.close()
+	if err != nil {
+		r.settings.getLogger().Error(err.Error())
 	}
-
-	return true
 }
 
-// stopTimer stops the timer and saves the duration as a meta-data field. If
-// error occurs, it responds to the client via HTTP. If everything is correct
-// and ready to be processed further, 'True' is returned. When 'False' is
-// returned, the caller must stop serving the request.
-func (r *RpcHttpRequest) stopTimer() (proceed bool) {
-	if r.settings.isDurationEnabled() {
-		r.tDuration = time.Now().Sub(r.tStart).Milliseconds()
+// setHttpContentType sets the HTTP header with the type of content.
+func (r *RpcHttpRequest) setHttpContentType(contentType string) {
+	r.rw.Header().Set(header.HttpHeaderContentType, contentType)
+}
 
-		err := r.resp.Meta.AddField(*r.p.settings.DurationFieldName, r.tDuration)
+// flushHttpResponse sends the written part of the response to the client.
+func (r *RpcHttpRequest) flushHttpResponse() {
+	if r.crw != nil {
+		err := r.crw.flush()
 		if err != nil {
-			r.resp.Error = NewRpcErrorFast(RpcErrorCode_InternalRpcError)
-			r.respond()
-			return false
+			r.settings.getLogger().Error(err.Error())
 		}
 	}
 
-	return true
-}
-
-// respond analyses the result and responds to the client via HTTP. The caller
-// must stop serving the request after this function returns.
-func (r *RpcHttpRequest) respond() {
-	// Empty meta-data set must not be shown.
-	if len(*r.resp.Meta) == 0 {
-		r.resp.Meta = nil
-	}
-
-	if !r.resp.hasError() {
-		r.resp.OK = true
-		r.p.incSuccessfulRequestsCounter()
-	}
-
-	r.rw.Header().Set(header.HttpHeaderContentType, mime.TypeApplicationJson)
-
-	err := json.NewEncoder(r.rw).Encode(r.resp)
-	if err != nil {
-		log.Println(err)
-	}
+	// Writers without flushing support are written without flushes.
+	_ = http.NewResponseController(r.rw).Flush()
 }
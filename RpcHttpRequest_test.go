@@ -1,8 +1,12 @@
 package jrm1
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -16,7 +20,7 @@ import (
 
 func Test_NewRpcHttpRequest(t *testing.T) {
 	aTest := tester.New(t)
-	var rhr, rhrExpected *RpcHttpRequest
+	var rhr *RpcHttpRequest
 
 	var (
 		p        *Processor
@@ -25,21 +29,24 @@ func Test_NewRpcHttpRequest(t *testing.T) {
 		rw       http.ResponseWriter
 	)
 
-	// Test.
+	// Test #1. HTTP request with a response writer.
 	p = new(Processor)
 	settings = new(ProcessorSettings)
 	req = new(http.Request)
 	rw = new(httptest.ResponseRecorder)
 
-	rhrExpected = &RpcHttpRequest{
-		p:        p,
-		settings: settings,
-		req:      req,
-		rw:       rw,
-	}
-
 	rhr = NewRpcHttpRequest(p, settings, req, rw)
-	aTest.MustBeEqual(rhr, rhrExpected)
+	aTest.MustBeEqual(rhr.p, p)
+	aTest.MustBeEqual(rhr.settings, settings)
+	aTest.MustBeEqual(rhr.req, req)
+	aTest.MustBeEqual(rhr.rw, rw)
+	aTest.MustBeEqual(rhr.out, io.Writer(rw))
+	aTest.MustBeEqual(rhr.isWriteDisabled, false)
+
+	// Test #2. No response writer.
+	rhr = NewRpcHttpRequest(p, settings, nil, nil)
+	aTest.MustBeEqual(rhr.out, nil)
+	aTest.MustBeEqual(rhr.isWriteDisabled, true)
 }
 
 func Test_RpcHttpRequest_init(t *testing.T) {
@@ -138,7 +145,32 @@ func Test_RpcHttpRequest_init(t *testing.T) {
 		aTest.MustBeEqual(proceed, false)
 	}
 
-	// Test #6. OK.
+	// Test #6. Access is denied.
+	{
+		ps = &ProcessorSettings{}
+		p, err = NewProcessor(ps)
+		aTest.MustBeNoError(err)
+		err = p.AddFuncWithAccess(RpcFunctionExampleOne, NewAccessRequirements([]string{"admin"}, nil))
+		aTest.MustBeNoError(err)
+		recorder := httptest.NewRecorder()
+		req = &http.Request{}
+		req.Method = http.MethodPost
+		req.Header = http.Header{}
+		req.Header.Add(header.HttpHeaderContentType, mime.TypeApplicationJson)
+		req.Header.Add(header.HttpHeaderAccept, mime.TypeAny)
+		req.Body = io.NopCloser(strings.NewReader(`{"jsonrpc":"M1","id":"1","method":"RpcFunctionExampleOne","params":{}}`))
+		r = NewRpcHttpRequest(p, ps, req, recorder)
+		proceed = r.init()
+		//
+		aTest.MustBeEqual(proceed, false)
+		aTest.MustBeEqual(r.resp.Error.Code, RpcErrorCode(RpcErrorCode_AccessDenied))
+		aTest.MustBeEqual(
+			strings.TrimSpace(recorder.Body.String()),
+			`{"jsonrpc":"M1","id":"1","result":null,"error":{"code":-64,"message":"Access denied","data":null},"ok":false}`,
+		)
+	}
+
+	// Test #7. OK.
 	{
 		ps = &ProcessorSettings{}
 		p, err = NewProcessor(ps)
@@ -268,7 +300,7 @@ func Test_RpcHttpRequest_stopTimer(t *testing.T) {
 	aTest.MustBeEqual(r.tStart, time.Time{})
 	proceed = r.stopTimer()
 	aTest.MustBeEqual(proceed, true)
-	aTest.MustBeEqual(r.tDuration, int64(0))
+	aTest.MustBeEqual(r.tDuration, time.Duration(0))
 
 	// Test #2. Duration is enabled, no error.
 	ps = &ProcessorSettings{DurationFieldName: &durFN}
@@ -281,7 +313,7 @@ func Test_RpcHttpRequest_stopTimer(t *testing.T) {
 	time.Sleep(time.Second)
 	proceed = r.stopTimer()
 	aTest.MustBeEqual(proceed, true)
-	aTest.MustBeDifferent(r.tDuration, int64(0))
+	aTest.MustBeDifferent(r.tDuration, time.Duration(0))
 
 	// Test #3. Duration is enabled, duplicate meta-data field.
 	ps = &ProcessorSettings{DurationFieldName: &durFN}
@@ -296,7 +328,7 @@ func Test_RpcHttpRequest_stopTimer(t *testing.T) {
 	time.Sleep(time.Second)
 	proceed = r.stopTimer()
 	aTest.MustBeEqual(proceed, false)
-	aTest.MustBeEqual(r.tDuration >= int64(1000), true) // ms.
+	aTest.MustBeEqual(r.tDuration >= time.Second, true)
 }
 
 func Test_RpcHttpRequest_respond(t *testing.T) {
@@ -325,4 +357,494 @@ func Test_RpcHttpRequest_respond(t *testing.T) {
 		strings.TrimSpace(string(respBody)),
 		`{"jsonrpc":"M1","id":null,"result":null,"error":null,"ok":true}`,
 	)
+
+	// Test #2. Response can not be encoded by the codec.
+	var logBuf bytes.Buffer
+	ps = &ProcessorSettings{
+		Codecs: []Codec{NewMessagePackCodec()},
+		Logger: slog.New(slog.NewJSONHandler(&logBuf, nil)),
+	}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	recorder = httptest.NewRecorder()
+	r = NewRpcHttpRequest(p, ps, nil, recorder)
+	r.responseCodec = NewMessagePackCodec()
+	r.resp = NewRpcResponse()
+	r.resp.Id = new(string)
+	*r.resp.Id = "1"
+	r.resp.Result = math.Inf(1)
+	r.respond()
+	//
+	aTest.MustBeEqual(recorder.Header().Get(header.HttpHeaderContentType), mime.TypeApplicationJson)
+	respBody, err = io.ReadAll(recorder.Body)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(
+		strings.TrimSpace(string(respBody)),
+		`{"jsonrpc":"M1","id":"1","result":null,"error":{"code":-32,"message":"Internal RPC error","data":null},"ok":false}`,
+	)
+	aTest.MustBeEqual(strings.Contains(logBuf.String(), ErrCodecNumberIsNotFinite), true)
+}
+
+func Test_RpcHttpRequest_writeAccessLog(t *testing.T) {
+	aTest := tester.New(t)
+	var p *Processor
+	var ps *ProcessorSettings
+	var err error
+	var logBuf bytes.Buffer
+	var record map[string]any
+
+	serve := func(funcName string, params string) {
+		logBuf.Reset()
+		req := httptest.NewRequest(http.MethodPost, "http://example.org",
+			strings.NewReader(fmt.Sprintf(`{"jsonrpc":"M1","id":"7","method":"%s","params":%s}`, funcName, params)))
+		req.Header.Add(header.HttpHeaderContentType, mime.TypeApplicationJson)
+		req.Header.Add(header.HttpHeaderAccept, mime.TypeAny)
+		p.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	ps = &ProcessorSettings{
+		CatchExceptions: true,
+		Logger:          slog.New(slog.NewJSONHandler(&logBuf, nil)),
+		AccessLog: &AccessLogSettings{
+			SamplingRate:       2,
+			LogParameters:      true,
+			RedactedParameters: []string{"b"},
+		},
+	}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionExampleCrasher)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Successful call is skipped by sampling.
+	serve("RpcFunctionSum", `{"a":1,"b":2}`)
+	aTest.MustBeEqual(logBuf.Len(), 0)
+
+	// Test #2. Successful call is sampled.
+	serve("RpcFunctionSum", `{"a":1,"b":2}`)
+	err = json.Unmarshal(logBuf.Bytes(), &record)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(record["msg"], AccessLogMessage)
+	aTest.MustBeEqual(record["level"], "INFO")
+	aTest.MustBeEqual(record[LogAttr_RequestId], "7")
+	aTest.MustBeEqual(record[LogAttr_Method], "RpcFunctionSum")
+	aTest.MustBeEqual(record[LogAttr_RemoteAddress], "192.0.2.1:1234")
+	aTest.MustBeEqual(record[LogAttr_Outcome], AccessLogOutcomeSuccess)
+	aTest.MustBeEqual(record[LogAttr_Parameters], `{"a":1,"b":"[REDACTED]"}`)
+	_, hasDuration := record[LogAttr_Duration]
+	aTest.MustBeEqual(hasDuration, true)
+
+	// Test #3. Failed call is never skipped.
+	record = nil
+	serve("RpcFunctionSum", `{"a":255,"b":2}`)
+	err = json.Unmarshal(logBuf.Bytes(), &record)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(record["level"], "WARN")
+	aTest.MustBeEqual(record[LogAttr_Outcome], AccessLogOutcomeFailure)
+	aTest.MustBeEqual(record[LogAttr_ErrorCode], float64(1))
+
+	// Test #4. Exception.
+	record = nil
+	serve("RpcFunctionExampleCrasher", `{}`)
+	err = json.Unmarshal(logBuf.Bytes(), &record)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(record["level"], "ERROR")
+	aTest.MustBeEqual(record[LogAttr_ErrorCode], float64(RpcErrorCode_InternalRpcError))
+	aTest.MustBeEqual(record[LogAttr_Panic], "runtime error: integer divide by zero")
+	_, hasStack := record[LogAttr_Stack]
+	aTest.MustBeEqual(hasStack, true)
+}
+
+func Test_RpcHttpRequest_startSpan(t *testing.T) {
+	aTest := tester.New(t)
+	var p *Processor
+	var ps *ProcessorSettings
+	var err error
+	var spans []*MemorySpan
+
+	serve := func(funcName string, traceParent string) (respBody string) {
+		req := httptest.NewRequest(http.MethodPost, "http://example.org",
+			strings.NewReader(fmt.Sprintf(`{"jsonrpc":"M1","id":"7","method":"%s","params":{}}`, funcName)))
+		req.Header.Add(header.HttpHeaderContentType, mime.TypeApplicationJson)
+		req.Header.Add(header.HttpHeaderAccept, mime.TypeAny)
+		if len(traceParent) > 0 {
+			req.Header.Add(HttpHeaderTraceParent, traceParent)
+			req.Header.Add(HttpHeaderTraceState, "k=v")
+		}
+		recorder := httptest.NewRecorder()
+		p.ServeHTTP(recorder, req)
+		return strings.TrimSpace(recorder.Body.String())
+	}
+
+	tracer := NewMemoryTracer()
+	ps = &ProcessorSettings{
+		Tracer:        tracer,
+		SpanFieldName: &_metaFieldName_Span,
+	}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionExampleTraced)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Trace context is propagated, span is shown to the function.
+	respBody := serve("RpcFunctionExampleTraced", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	spans = tracer.FinishedSpans()
+	aTest.MustBeEqual(len(spans), 1)
+	aTest.MustBeEqual(spans[0].Name, "RpcFunctionExampleTraced")
+	aTest.MustBeEqual(spans[0].Parent.TraceParent(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	aTest.MustBeEqual(spans[0].Context.TraceState, "k=v")
+	aTest.MustBeEqual(spans[0].Attributes, map[string]any{
+		SpanAttr_RpcSystem: SpanAttrValue_RpcSystem,
+		SpanAttr_Method:    "RpcFunctionExampleTraced",
+		SpanAttr_RequestId: "7",
+		SpanAttr_Outcome:   AccessLogOutcomeSuccess,
+		"user.attribute":   "value",
+	})
+	aTest.MustBeEqual(respBody,
+		fmt.Sprintf(`{"jsonrpc":"M1","id":"7","result":"%s","error":null,"ok":true}`, spans[0].Context.TraceParent()))
+
+	// Test #2. No trace context, unknown method.
+	serve("NoSuchFunction", "")
+	spans = tracer.FinishedSpans()
+	aTest.MustBeEqual(len(spans), 2)
+	aTest.MustBeEqual(spans[1].Parent.IsValid(), false)
+	aTest.MustBeEqual(spans[1].Context.IsValid(), true)
+	aTest.MustBeEqual(spans[1].Attributes[SpanAttr_Outcome], AccessLogOutcomeFailure)
+	aTest.MustBeEqual(spans[1].Attributes[SpanAttr_ErrorCode], RpcErrorCode_UnknownMethod)
+}
+
+func Test_RpcHttpRequest_saveDurations(t *testing.T) {
+	aTest := tester.New(t)
+	var p *Processor
+	var ps *ProcessorSettings
+	var err error
+	var resp map[string]any
+	phasesField := "phases"
+
+	serve := func(funcName string) {
+		req := httptest.NewRequest(http.MethodPost, "http://example.org",
+			strings.NewReader(fmt.Sprintf(`{"jsonrpc":"M1","id":"1","method":"%s","params":{"a":1,"b":2}}`, funcName)))
+		req.Header.Add(header.HttpHeaderContentType, mime.TypeApplicationJson)
+		req.Header.Add(header.HttpHeaderAccept, mime.TypeAny)
+		recorder := httptest.NewRecorder()
+		p.ServeHTTP(recorder, req)
+		resp = nil
+		err = json.Unmarshal(recorder.Body.Bytes(), &resp)
+		aTest.MustBeNoError(err)
+	}
+
+	ps = &ProcessorSettings{
+		DurationFieldName:       &_metaFieldName_Duration,
+		DurationFormat:          DurationFormat_String,
+		PhaseDurationsFieldName: &phasesField,
+	}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Successful call has all the phases.
+	serve("RpcFunctionSum")
+	aTest.MustBeEqual(resp["ok"], true)
+	aTest.MustBeEqual(resp["result"], map[string]any{"c": float64(3)})
+	meta := resp["meta"].(map[string]any)
+	_, err = time.ParseDuration(meta[_metaFieldName_Duration].(string))
+	aTest.MustBeNoError(err)
+	phases := meta[phasesField].(map[string]any)
+	aTest.MustBeEqual(len(phases), 3)
+	for _, phase := range []string{DurationPhase_Decode, DurationPhase_Run, DurationPhase_Encode} {
+		_, err = time.ParseDuration(phases[phase].(string))
+		aTest.MustBeNoError(err)
+	}
+
+	// Test #2. Failed call has a duration too.
+	serve("NoSuchFunction")
+	aTest.MustBeEqual(resp["ok"], false)
+	meta = resp["meta"].(map[string]any)
+	_, err = time.ParseDuration(meta[_metaFieldName_Duration].(string))
+	aTest.MustBeNoError(err)
+	phases = meta[phasesField].(map[string]any)
+	aTest.MustBeEqual(len(phases), 1)
+	_, err = time.ParseDuration(phases[DurationPhase_Decode].(string))
+	aTest.MustBeNoError(err)
+
+	// Test #3. Field of the function conflicts with the phases field.
+	err = p.AddFunc(RpcFunctionPhases)
+	aTest.MustBeNoError(err)
+	serve("RpcFunctionPhases")
+	aTest.MustBeEqual(resp["ok"], false)
+	aTest.MustBeEqual(resp["result"], nil)
+	aTest.MustBeEqual(resp["error"].(map[string]any)["code"], float64(RpcErrorCode_InternalRpcError))
+	meta = resp["meta"].(map[string]any)
+	aTest.MustBeEqual(meta[phasesField], "custom")
+}
+
+// RpcFunctionPhases sets a meta-data field named as the phase durations field
+// of tests.
+func RpcFunctionPhases(_ *json.RawMessage, metaData *ResponseMetaData) (result any, re *RpcError) {
+	metaData.AddFieldFast("phases", "custom")
+	return 1, nil
+}
+
+func Test_RpcHttpRequest_encodeResult(t *testing.T) {
+	aTest := tester.New(t)
+	var r *RpcHttpRequest
+	var p *Processor
+	var ps *ProcessorSettings
+	var err error
+	phasesField := "phases"
+
+	ps = &ProcessorSettings{PhaseDurationsFieldName: &phasesField}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Result is encoded.
+	r = NewRpcHttpRequest(p, ps, nil, nil)
+	r.resp = NewRpcResponse()
+	r.resp.Result = SumResult{C: 5}
+	r.encodeResult()
+	aTest.MustBeEqual(r.resp.Result, any(json.RawMessage(`{"c":5}`)))
+	_, hasEncodePhase := r.phaseDurations[DurationPhase_Encode]
+	aTest.MustBeEqual(hasEncodePhase, true)
+
+	// Test #2. Result can not be encoded.
+	r = NewRpcHttpRequest(p, ps, nil, nil)
+	r.resp = NewRpcResponse()
+	r.resp.Result = func() {}
+	r.encodeResult()
+	aTest.MustBeEqual(r.resp.Result, nil)
+	aTest.MustBeEqual(r.resp.Error.Code, RpcErrorCode(RpcErrorCode_InternalRpcError))
+}
+
+func Test_RpcHttpRequest_acknowledge(t *testing.T) {
+	aTest := tester.New(t)
+	var p *Processor
+	var ps *ProcessorSettings
+	var err error
+
+	serve := func(body string, isNotification bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "http://example.org", strings.NewReader(body))
+		req.Header.Add(header.HttpHeaderContentType, mime.TypeApplicationJson)
+		req.Header.Add(header.HttpHeaderAccept, mime.TypeAny)
+		if isNotification {
+			req.Header.Add(HttpHeaderNotification, "true")
+		}
+		recorder := httptest.NewRecorder()
+		p.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	var recorder *httptest.ResponseRecorder
+
+	// Test #1. Notifications are disabled.
+	ps = &ProcessorSettings{}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	recorder = serve(`{"jsonrpc":"M1","id":"1","method":"RpcFunctionSum","params":{"a":1,"b":2}}`, true)
+	aTest.MustBeEqual(recorder.Code, http.StatusOK)
+	aTest.MustBeEqual(strings.TrimSpace(recorder.Body.String()),
+		`{"jsonrpc":"M1","id":"1","result":{"c":3},"error":null,"ok":true}`)
+
+	// Test #2. Notification is accepted.
+	ps = &ProcessorSettings{EnableNotifications: true, CountRequests: true}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	err = p.AddNotificationFunc(RpcFunctionNotified)
+	aTest.MustBeNoError(err)
+	recorder = serve(`{"jsonrpc":"M1","id":"2","method":"RpcFunctionSum","params":{"a":1,"b":2}}`, true)
+	aTest.MustBeEqual(recorder.Code, http.StatusAccepted)
+	aTest.MustBeEqual(recorder.Body.Len(), 0)
+
+	// Test #3. Notification-only function.
+	recorder = serve(`{"jsonrpc":"M1","id":"3","method":"RpcFunctionNotified","params":"x"}`, false)
+	aTest.MustBeEqual(recorder.Code, http.StatusAccepted)
+
+	// Test #4. Ordinary call is not affected.
+	recorder = serve(`{"jsonrpc":"M1","id":"4","method":"RpcFunctionSum","params":{"a":2,"b":2}}`, false)
+	aTest.MustBeEqual(recorder.Code, http.StatusOK)
+	aTest.MustBeEqual(strings.TrimSpace(recorder.Body.String()),
+		`{"jsonrpc":"M1","id":"4","result":{"c":4},"error":null,"ok":true}`)
+
+	// Test #5. Notification fails the checks.
+	recorder = serve(`{"jsonrpc":"M1","id":"5","method":"NoSuchFunction","params":{}}`, true)
+	aTest.MustBeEqual(recorder.Code, http.StatusOK)
+	aTest.MustBeEqual(strings.TrimSpace(recorder.Body.String()),
+		`{"jsonrpc":"M1","id":"5","result":null,"error":{"code":-8,"message":"Unknown method","data":null},"ok":false}`)
+
+	p.Stop()
+	aTest.MustBeEqual(<-_notifiedParams, `"x"`)
+	all, successful := p.GetRequestsCount()
+	aTest.MustBeEqual(all, "4")
+	aTest.MustBeEqual(successful, "3")
+
+	// Test #6. Notification is refused.
+	recorder = serve(`{"jsonrpc":"M1","id":"6","method":"RpcFunctionSum","params":{"a":1,"b":2}}`, true)
+	aTest.MustBeEqual(recorder.Code, http.StatusServiceUnavailable)
+}
+
+func Test_RpcHttpRequest_writeStreamItem(t *testing.T) {
+	aTest := tester.New(t)
+	var p *Processor
+	var ps *ProcessorSettings
+	var err error
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "http://example.org", strings.NewReader(body))
+		req.Header.Add(header.HttpHeaderContentType, mime.TypeApplicationJson)
+		req.Header.Add(header.HttpHeaderAccept, mime.TypeAny)
+		recorder := httptest.NewRecorder()
+		p.ServeHTTP(recorder, req)
+		aTest.MustBeEqual(recorder.Code, http.StatusOK)
+		return recorder
+	}
+
+	var recorder *httptest.ResponseRecorder
+
+	ps = &ProcessorSettings{
+		EnableBatches:      true,
+		RequestIdFieldName: &_metaFieldName_RID,
+	}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddStreamFunc(RpcFunctionCounter)
+	aTest.MustBeNoError(err)
+
+	// Test #1. All clear.
+	recorder = serve(`{"jsonrpc":"M1","id":"1","method":"RpcFunctionCounter","params":2}`)
+	aTest.MustBeEqual(recorder.Header().Get(header.HttpHeaderContentType), StreamContentType)
+	aTest.MustBeEqual(recorder.Flushed, true)
+	aTest.MustBeEqual(recorder.Body.String(), `{"jsonrpc":"M1","id":"1"}`+"\n"+
+		`{"item":1}`+"\n"+
+		`{"item":2}`+"\n"+
+		`{"error":null,"ok":true}`+"\n")
+
+	// Test #2. Error after items.
+	recorder = serve(`{"jsonrpc":"M1","id":"2","method":"RpcFunctionCounter","params":5}`)
+	aTest.MustBeEqual(recorder.Body.String(), `{"jsonrpc":"M1","id":"2"}`+"\n"+
+		`{"item":1}`+"\n"+
+		`{"item":2}`+"\n"+
+		`{"item":3}`+"\n"+
+		`{"error":{"code":1,"message":"too many","data":null},"ok":false}`+"\n")
+
+	// Test #3. Error before the stream.
+	recorder = serve(`{"jsonrpc":"M1","id":"3","method":"RpcFunctionCounter","params":"x"}`)
+	aTest.MustBeEqual(recorder.Body.String(), `{"jsonrpc":"M1","id":"3"}`+"\n"+
+		`{"error":{"code":-16,"message":"Invalid parameters","data":null},"ok":false}`+"\n")
+
+	// Test #4. Streaming in a batch.
+	recorder = serve(`[{"jsonrpc":"M1","id":"4","method":"RpcFunctionCounter","params":1}]`)
+	aTest.MustBeEqual(strings.TrimSpace(recorder.Body.String()),
+		`[{"jsonrpc":"M1","id":"4","result":null,"error":{"code":-2,"message":"Invalid request","data":"streaming is not supported in a batch"},"ok":false}]`)
+
+	// Test #5. Flushes are rare.
+	ps = &ProcessorSettings{StreamFlushPeriod: 10}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddStreamFunc(RpcFunctionCounter)
+	aTest.MustBeNoError(err)
+	recorder = serve(`{"jsonrpc":"M1","id":"5","method":"RpcFunctionCounter","params":3}`)
+	aTest.MustBeEqual(recorder.Flushed, false)
+}
+
+func Test_RpcHttpRequest_serveBatch(t *testing.T) {
+	aTest := tester.New(t)
+	var p *Processor
+	var ps *ProcessorSettings
+	var err error
+
+	serve := func(body string) string {
+		req := httptest.NewRequest(http.MethodPost, "http://example.org", strings.NewReader(body))
+		req.Header.Add(header.HttpHeaderContentType, mime.TypeApplicationJson)
+		req.Header.Add(header.HttpHeaderAccept, mime.TypeAny)
+		recorder := httptest.NewRecorder()
+		p.ServeHTTP(recorder, req)
+		aTest.MustBeEqual(recorder.Code, http.StatusOK)
+		return strings.TrimSpace(recorder.Body.String())
+	}
+
+	batch := ` [
+		{"jsonrpc":"M1","id":"1","method":"RpcFunctionSum","params":{"a":1,"b":2}},
+		{"jsonrpc":"M1","id":"2","method":"NoSuchFunction","params":{}},
+		"junk",
+		{"jsonrpc":"M1","id":"4","method":"RpcFunctionSum","params":{"a":255,"b":1}}
+	]`
+	batchResponse := `[` +
+		`{"jsonrpc":"M1","id":"1","result":{"c":3},"error":null,"ok":true},` +
+		`{"jsonrpc":"M1","id":"2","result":null,"error":{"code":-8,"message":"Unknown method","data":null},"ok":false},` +
+		`{"jsonrpc":"M1","id":null,"result":null,"error":{"code":-1,"message":"Request is not readable","data":null},"ok":false},` +
+		`{"jsonrpc":"M1","id":"4","result":null,"error":{"code":1,"message":"overflow","data":{"a":255,"b":1}},"ok":false}` +
+		`]`
+
+	// Test #1. Batches are disabled.
+	ps = &ProcessorSettings{}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(serve(batch),
+		`{"jsonrpc":"M1","id":null,"result":null,"error":{"code":-1,"message":"Request is not readable","data":null},"ok":false}`)
+
+	// Test #2. Sequential batch.
+	ps = &ProcessorSettings{EnableBatches: true, MaxBatchSize: 4, CountRequests: true}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(serve(batch), batchResponse)
+	all, successful := p.GetRequestsCount()
+	aTest.MustBeEqual(all, "4")
+	aTest.MustBeEqual(successful, "1")
+
+	// Test #3. Single call is not affected.
+	aTest.MustBeEqual(serve(`{"jsonrpc":"M1","id":"5","method":"RpcFunctionSum","params":{"a":1,"b":1}}`),
+		`{"jsonrpc":"M1","id":"5","result":{"c":2},"error":null,"ok":true}`)
+
+	// Test #4. Parallel batch.
+	ps = &ProcessorSettings{EnableBatches: true, RunBatchesInParallel: true, CatchExceptions: true}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(serve(batch), batchResponse)
+
+	// Test #5. Empty batch.
+	aTest.MustBeEqual(serve(`[]`),
+		`{"jsonrpc":"M1","id":null,"result":null,"error":{"code":-2,"message":"Invalid request","data":"batch is empty"},"ok":false}`)
+
+	// Test #6. Batch is too large.
+	ps = &ProcessorSettings{EnableBatches: true, MaxBatchSize: 3}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(serve(batch),
+		`{"jsonrpc":"M1","id":null,"result":null,"error":{"code":-2,"message":"Invalid request","data":"batch is too large"},"ok":false}`)
+
+	// Test #7. Batch is not readable.
+	aTest.MustBeEqual(serve(`[{`),
+		`{"jsonrpc":"M1","id":null,"result":null,"error":{"code":-1,"message":"Request is not readable","data":null},"ok":false}`)
+
+	// Test #8. Exception in a parallel batch is not caught by settings.
+	var logBuf bytes.Buffer
+	ps = &ProcessorSettings{
+		EnableBatches:        true,
+		RunBatchesInParallel: true,
+		Logger:               slog.New(slog.NewJSONHandler(&logBuf, nil)),
+	}
+	p, err = NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionExampleCrasher)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(serve(`[{"jsonrpc":"M1","id":"1","method":"RpcFunctionExampleCrasher","params":{}},`+
+		`{"jsonrpc":"M1","id":"2","method":"RpcFunctionSum","params":{"a":1,"b":2}}]`), `[`+
+		`{"jsonrpc":"M1","id":"1","result":null,"error":{"code":-32,"message":"Internal RPC error","data":null},"ok":false},`+
+		`{"jsonrpc":"M1","id":"2","result":{"c":3},"error":null,"ok":true}`+
+		`]`)
+	aTest.MustBeEqual(strings.Contains(logBuf.String(), ErrExceptionEscaped), true)
 }
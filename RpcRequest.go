@@ -51,6 +51,22 @@ func NewRpcRequest(input io.ReadCloser) (rr *RpcRequest, err error) {
 	return rr, nil
 }
 
+// NewRpcRequestWithCodec is a constructor of a raw RPC request.
+// It takes an input stream of bytes and decodes it using the codec. Null codec
+// is JSON.
+func NewRpcRequestWithCodec(input io.ReadCloser, codec Codec) (rr *RpcRequest, err error) {
+	if isJsonCodec(codec) {
+		return NewRpcRequest(input)
+	}
+
+	input, err = transcodeToJson(codec, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRpcRequest(input)
+}
+
 // HasAllRootFields tells if all the root fields are set, i.e. are not null
 // pointers.
 func (r *RpcRequest) HasAllRootFields() bool {
@@ -44,6 +44,39 @@ func Test_NewRpcRequest(t *testing.T) {
 	aTest.MustBeEqual(rr, rrExpected)
 }
 
+func Test_NewRpcRequestWithCodec(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+	var rr *RpcRequest
+	var data io.ReadCloser
+
+	// Test #1. Positive. Binary codec.
+	pn, id, method := "M1", "12345", "abc"
+	params := json.RawMessage(`{"a":[1,2]}`)
+	rrExpected := &RpcRequest{ProtocolName: &pn, Id: &id, Method: &method, Parameters: &params}
+	buf, err := NewMessagePackCodec().Marshal(rrExpected)
+	aTest.MustBeNoError(err)
+	data = io.NopCloser(bytes.NewReader(buf))
+	//
+	rr, err = NewRpcRequestWithCodec(data, NewMessagePackCodec())
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(rr, rrExpected)
+
+	// Test #2. Positive. Default codec.
+	data = io.NopCloser(bytes.NewReader([]byte(`{"id": "12345"}`)))
+	//
+	rr, err = NewRpcRequestWithCodec(data, nil)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(rr, &RpcRequest{Id: &id})
+
+	// Test #3. Negative. Data is not in the format of the codec.
+	data = io.NopCloser(bytes.NewReader([]byte(`{}`)))
+	//
+	rr, err = NewRpcRequestWithCodec(data, NewCborCodec())
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(rr, (*RpcRequest)(nil))
+}
+
 func Test_RpcRequest_HasAllRootFields(t *testing.T) {
 	aTest := tester.New(t)
 	var rr *RpcRequest
@@ -1,6 +1,10 @@
 package jrm1
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
 
 // RpcResponseRaw is a raw RPC response.
 type RpcResponseRaw struct {
@@ -34,9 +38,63 @@ type RpcResponseRaw struct {
 	// having to parse the whole result JSON object. If you are familiar with
 	// the HTTP status code 200, you will understand the idea.
 	OK bool `json:"ok"`
+
+	// Fields of the response which are unknown to the client. They are
+	// captured when the client decodes responses in the lenient mode, so
+	// that a client may log them instead of failing.
+	UnknownFields map[string]json.RawMessage `json:"-"`
 }
 
+// rpcResponseRawFields are names of the known fields of a raw RPC response.
+var rpcResponseRawFields = []string{"jsonrpc", "id", "result", "error", "meta", "ok"}
+
 // hasError tells if the response finished with an error or not.
 func (r *RpcResponseRaw) hasError() bool {
 	return r.Error != nil
 }
+
+// captureUnknownFields stores fields of the encoded response which are
+// unknown. Names of fields are matched without regard to the letter case, in
+// the same way as the JSON decoder does.
+func (r *RpcResponseRaw) captureUnknownFields(data []byte) (err error) {
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range fields {
+		if isKnownRpcResponseRawField(name) {
+			continue
+		}
+		if r.UnknownFields == nil {
+			r.UnknownFields = make(map[string]json.RawMessage)
+		}
+		r.UnknownFields[name] = value
+	}
+
+	return nil
+}
+
+// isKnownRpcResponseRawField tells whether the name matches a known field of
+// a raw RPC response.
+func isKnownRpcResponseRawField(name string) bool {
+	for _, knownName := range rpcResponseRawFields {
+		if strings.EqualFold(name, knownName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getUnknownFieldNames returns sorted names of the captured unknown fields.
+func (r *RpcResponseRaw) getUnknownFieldNames() (names []string) {
+	names = make([]string, 0, len(r.UnknownFields))
+	for name := range r.UnknownFields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
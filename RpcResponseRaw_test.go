@@ -0,0 +1,32 @@
+package jrm1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_RpcResponseRaw_captureUnknownFields(t *testing.T) {
+	aTest := tester.New(t)
+	var r *RpcResponseRaw
+	var err error
+
+	// Test #1. No unknown fields.
+	r = new(RpcResponseRaw)
+	err = r.captureUnknownFields([]byte(`{"jsonrpc":"M1","ID":"1","ok":true}`))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(r.UnknownFields, map[string]json.RawMessage(nil))
+	aTest.MustBeEqual(r.getUnknownFieldNames(), []string{})
+
+	// Test #2. Unknown fields.
+	r = new(RpcResponseRaw)
+	err = r.captureUnknownFields([]byte(`{"jsonrpc":"M1","b":[1],"a":null}`))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(r.UnknownFields, map[string]json.RawMessage{"a": json.RawMessage(`null`), "b": json.RawMessage(`[1]`)})
+	aTest.MustBeEqual(r.getUnknownFieldNames(), []string{"a", "b"})
+
+	// Test #3. Negative.
+	err = r.captureUnknownFields([]byte(`[]`))
+	aTest.MustBeAnError(err)
+}
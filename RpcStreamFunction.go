@@ -0,0 +1,16 @@
+package jrm1
+
+import "encoding/json"
+
+// RpcStreamFunction represents a signature for a streaming RPC function
+// (method, procedure). Instead of returning a single result, the function
+// passes result items one by one to the 'yield' function, which writes them
+// to the client as soon as possible. When 'yield' returns 'False', the client
+// is gone and the function should stop.
+type RpcStreamFunction func(params *json.RawMessage, metaData *ResponseMetaData, yield func(item any) bool) (re *RpcError)
+
+// GetName reads name of the streaming RPC function (method, procedure).
+// See the 'GetName' method of the 'RpcFunction' type for details.
+func (f RpcStreamFunction) GetName() string {
+	return getFunctionName(f)
+}
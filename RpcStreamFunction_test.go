@@ -0,0 +1,15 @@
+package jrm1
+
+import (
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_RpcStreamFunction_GetName(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	f := RpcStreamFunction(RpcFunctionCounter)
+	aTest.MustBeEqual(f.GetName(), "RpcFunctionCounter")
+}
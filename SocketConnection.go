@@ -0,0 +1,60 @@
+package jrm1
+
+import (
+	"errors"
+	"net"
+	"net/http"
+)
+
+// errSocketConnectionIsAlreadyClosed is returned when a closed connection is
+// closed again.
+var errSocketConnectionIsAlreadyClosed = errors.New(ErrConnectionIsClosed)
+
+// SocketConnection is a socket connection served by the RPC processor
+// (server).
+type SocketConnection struct {
+	conn *serverConnection
+	sc   *socketConn
+}
+
+// newSocketConnection is a constructor of a socket connection served by the
+// RPC processor (server).
+func newSocketConnection(server *SocketServer, sc *socketConn, req *http.Request) (c *SocketConnection) {
+	return &SocketConnection{
+		conn: newServerConnection(server.p, sc, req, server.settings.getMaxConcurrentCalls(), errSocketConnectionIsAlreadyClosed, true),
+		sc:   sc,
+	}
+}
+
+// Id returns the identifier of the connection.
+func (c *SocketConnection) Id() string {
+	return c.conn.id
+}
+
+// RemoteAddr returns the network address of the client. Clients served over
+// standard streams have the 'stdio' address.
+func (c *SocketConnection) RemoteAddr() net.Addr {
+	if c.sc.conn == nil {
+		return stdioAddr{}
+	}
+
+	return c.sc.conn.RemoteAddr()
+}
+
+// Push sends a push message to the client.
+func (c *SocketConnection) Push(topic string, data any) (err error) {
+	return c.conn.push(topic, data)
+}
+
+// Close closes the connection. Calls being executed are finished, but their
+// responses are not delivered.
+func (c *SocketConnection) Close() (err error) {
+	return c.conn.close()
+}
+
+// serve reads messages and serves function calls until the connection is
+// closed. When the client stops sending messages, responses of the calls
+// being executed are sent before closing.
+func (c *SocketConnection) serve() {
+	c.conn.serve()
+}
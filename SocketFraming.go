@@ -0,0 +1,21 @@
+package jrm1
+
+// Framings of messages sent over sockets.
+const (
+	// Each message is preceded by its size in bytes as a 32-bit unsigned
+	// integer in big-endian byte order.
+	SocketFraming_LengthPrefixed = SocketFraming(0)
+
+	// Each message is terminated by a new line character. New lines which
+	// separate records of streamed responses are replaced with spaces.
+	SocketFraming_NewlineDelimited = SocketFraming(1)
+)
+
+// SocketFraming is a way of separating messages sent over sockets.
+type SocketFraming byte
+
+// IsValid tells whether the framing is known.
+func (sf SocketFraming) IsValid() bool {
+	return (sf == SocketFraming_LengthPrefixed) ||
+		(sf == SocketFraming_NewlineDelimited)
+}
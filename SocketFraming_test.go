@@ -0,0 +1,16 @@
+package jrm1
+
+import (
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_SocketFraming_IsValid(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	aTest.MustBeEqual(SocketFraming_LengthPrefixed.IsValid(), true)
+	aTest.MustBeEqual(SocketFraming_NewlineDelimited.IsValid(), true)
+	aTest.MustBeEqual(SocketFraming(2).IsValid(), false)
+}
@@ -0,0 +1,222 @@
+package jrm1
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	ae "github.com/vault-thirteen/auxie/errors"
+)
+
+const (
+	ErrSocketServerIsClosed = "socket server is closed"
+)
+
+// SocketServer serves function calls of the RPC processor (server) over raw
+// socket connections, e.g. TCP or Unix domain sockets. Each message is a
+// request or a batch of requests, while each response is sent as a separate
+// message. Several requests of a connection may be in flight at the same
+// time and responses are matched to requests by their IDs. Messages are
+// served in the same way as HTTP requests whose headers are taken from the
+// 'headers' field of the message, so the remote address of the client is
+// visible to the access policy.
+type SocketServer struct {
+	p        *Processor
+	settings *SocketSettings
+
+	guard       *sync.Mutex
+	listeners   map[net.Listener]struct{}
+	connections map[string]*SocketConnection
+	connWorkers *sync.WaitGroup
+	isClosed    bool
+}
+
+// NewSocketServer is a constructor of a socket server serving the RPC
+// processor (server).
+func NewSocketServer(p *Processor, settings *SocketSettings) (ss *SocketServer, err error) {
+	err = settings.Check()
+	if err != nil {
+		return nil, err
+	}
+
+	ss = &SocketServer{
+		p:           p,
+		settings:    settings,
+		guard:       new(sync.Mutex),
+		listeners:   make(map[net.Listener]struct{}),
+		connections: make(map[string]*SocketConnection),
+		connWorkers: new(sync.WaitGroup),
+	}
+
+	return ss, nil
+}
+
+// Serve accepts connections of the listener and serves them. It blocks until
+// the listener fails or the server is closed. Serve may be used with several
+// listeners at the same time.
+func (ss *SocketServer) Serve(listener net.Listener) (err error) {
+	ss.guard.Lock()
+	if ss.isClosed {
+		ss.guard.Unlock()
+		return errors.New(ErrSocketServerIsClosed)
+	}
+	ss.listeners[listener] = struct{}{}
+	ss.guard.Unlock()
+
+	defer func() {
+		ss.guard.Lock()
+		delete(ss.listeners, listener)
+		ss.guard.Unlock()
+	}()
+
+	for {
+		var conn net.Conn
+		conn, err = listener.Accept()
+		if err != nil {
+			ss.guard.Lock()
+			isClosed := ss.isClosed
+			ss.guard.Unlock()
+
+			if isClosed {
+				return errors.New(ErrSocketServerIsClosed)
+			}
+			return err
+		}
+
+		ss.connWorkers.Add(1)
+		go func() {
+			defer ss.connWorkers.Done()
+			ss.serveConn(conn)
+		}()
+	}
+}
+
+// ServeStdio serves function calls of a single client over a pair of streams,
+// e.g. standard input and output of a plugin process. Messages are framed in
+// the same way as with sockets. It blocks until the input ends or the server
+// is closed. Calls being executed when the input ends are finished and their
+// responses are written before the streams are closed.
+func (ss *SocketServer) ServeStdio(r io.Reader, w io.Writer) (err error) {
+	ss.connWorkers.Add(1)
+	defer ss.connWorkers.Done()
+
+	sc := newStreamSocketConn(newStdioStream(r, w), ss.settings.Framing, ss.settings.getMaxMessageSize())
+	ss.serveSocketConn(sc, stdioAddr{})
+
+	ss.guard.Lock()
+	defer ss.guard.Unlock()
+
+	if ss.isClosed {
+		return errors.New(ErrSocketServerIsClosed)
+	}
+
+	return nil
+}
+
+// Connections returns the list of open connections.
+func (ss *SocketServer) Connections() (connections []*SocketConnection) {
+	ss.guard.Lock()
+	defer ss.guard.Unlock()
+
+	connections = make([]*SocketConnection, 0, len(ss.connections))
+	for _, c := range ss.connections {
+		connections = append(connections, c)
+	}
+
+	return connections
+}
+
+// Broadcast sends a push message to all open connections.
+func (ss *SocketServer) Broadcast(topic string, data any) (err error) {
+	for _, c := range ss.Connections() {
+		err = ae.Combine(err, c.Push(topic, data))
+	}
+
+	return err
+}
+
+// Close stops accepting of connections, closes all open connections and
+// waits for their calls to finish.
+func (ss *SocketServer) Close() (err error) {
+	ss.guard.Lock()
+	ss.isClosed = true
+	for listener := range ss.listeners {
+		err = ae.Combine(err, listener.Close())
+	}
+	ss.guard.Unlock()
+
+	for _, c := range ss.Connections() {
+		cerr := c.Close()
+		if (cerr != nil) && !errors.Is(cerr, errSocketConnectionIsAlreadyClosed) {
+			err = ae.Combine(err, cerr)
+		}
+	}
+
+	ss.connWorkers.Wait()
+
+	return err
+}
+
+// serveConn serves the accepted network connection until it is closed.
+func (ss *SocketServer) serveConn(conn net.Conn) {
+	sc := newSocketConn(conn, ss.settings.Framing,
+		ss.settings.getMaxMessageSize(),
+		ss.settings.getIdleTimeout(),
+		ss.settings.getWriteTimeout(),
+	)
+
+	ss.serveSocketConn(sc, conn.RemoteAddr())
+}
+
+// serveSocketConn serves the connection transferring framed messages until it
+// is closed.
+func (ss *SocketServer) serveSocketConn(sc *socketConn, remoteAddr net.Addr) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "/", nil)
+	if err != nil {
+		_ = sc.close()
+		return
+	}
+	req.RemoteAddr = remoteAddr.String()
+
+	c := newSocketConnection(ss, sc, req)
+
+	if !ss.addConnection(c) {
+		_ = sc.close()
+		return
+	}
+	defer ss.removeConnection(c)
+
+	c.serve()
+}
+
+// addConnection registers an open connection. Connections are refused when
+// the server is closed.
+func (ss *SocketServer) addConnection(c *SocketConnection) (ok bool) {
+	ss.guard.Lock()
+	if ss.isClosed {
+		ss.guard.Unlock()
+		return false
+	}
+	ss.connections[c.Id()] = c
+	ss.guard.Unlock()
+
+	if ss.settings.OnConnect != nil {
+		ss.settings.OnConnect(c)
+	}
+
+	return true
+}
+
+// removeConnection unregisters a closed connection.
+func (ss *SocketServer) removeConnection(c *SocketConnection) {
+	ss.guard.Lock()
+	delete(ss.connections, c.Id())
+	ss.guard.Unlock()
+
+	if ss.settings.OnDisconnect != nil {
+		ss.settings.OnDisconnect(c)
+	}
+}
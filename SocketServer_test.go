@@ -0,0 +1,352 @@
+package jrm1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_NewSocketServer(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	p, err := NewProcessor(&ProcessorSettings{})
+	aTest.MustBeNoError(err)
+
+	// Test #1. Invalid settings.
+	_, err = NewSocketServer(p, &SocketSettings{Framing: SocketFraming(2)})
+	aTest.MustBeAnError(err)
+
+	// Test #2. Valid settings.
+	ss, err := NewSocketServer(p, &SocketSettings{})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(len(ss.Connections()), 0)
+}
+
+func Test_SocketServer_Serve(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test #1. TCP with length-prefixed framing.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	aTest.MustBeNoError(err)
+	_testSocketServer(t, listener, SocketFraming_LengthPrefixed)
+
+	// Test #2. Unix domain socket with newline-delimited framing.
+	listener, err = net.Listen("unix", filepath.Join(t.TempDir(), "rpc.sock"))
+	aTest.MustBeNoError(err)
+	_testSocketServer(t, listener, SocketFraming_NewlineDelimited)
+}
+
+// _testSocketServer serves function calls over the listener and checks them.
+func _testSocketServer(t *testing.T, listener net.Listener, framing SocketFraming) {
+	aTest := tester.New(t)
+	var err error
+
+	var logBuf bytes.Buffer
+	p, err := NewProcessor(&ProcessorSettings{Logger: slog.New(slog.NewJSONHandler(&logBuf, nil))})
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionExampleCrasher)
+	aTest.MustBeNoError(err)
+	err = p.AddStreamFunc(RpcFunctionCounter)
+	aTest.MustBeNoError(err)
+	err = p.addNamedFunc("RpcFunctionBarrier", _newRpcFunctionBarrier(10), nil, false, false)
+	aTest.MustBeNoError(err)
+
+	connected := make(chan *SocketConnection, 1)
+	disconnected := make(chan *SocketConnection, 1)
+	settings := &SocketSettings{
+		Framing:      framing,
+		OnConnect:    func(c *SocketConnection) { connected <- c },
+		OnDisconnect: func(c *SocketConnection) { disconnected <- c },
+	}
+	ss, err := NewSocketServer(p, settings)
+	aTest.MustBeNoError(err)
+	served := make(chan error, 1)
+	go func() { served <- ss.Serve(listener) }()
+
+	cs, err := NewClientSettings("http", "localhost", 1, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+	st := NewSocketTransport(listener.Addr().Network(), listener.Addr().String())
+	st.Framing = framing
+	pushes := make(chan string, 1)
+	st.OnPush = func(topic string, data json.RawMessage) { pushes <- topic + ":" + string(data) }
+	cs.SetSocketTransport(st)
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+	c2, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+
+	// Pipelined calls of two clients over a single connection. All the calls
+	// are in flight at the same time.
+	for _, isMet := range _callBarrierConcurrently([]*Client{c, c2}, 10) {
+		aTest.MustBeEqual(isMet, true)
+	}
+
+	// Pipelined calls with results.
+	var wg sync.WaitGroup
+	results := make([]SumResult, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: byte(i), B: 1}, &results[i])
+		}()
+	}
+	wg.Wait()
+	for i := range results {
+		aTest.MustBeEqual(results[i].C, byte(i+1))
+	}
+	conn := <-connected
+	aTest.MustBeEqual(len(ss.Connections()), 1)
+
+	// Error of a function.
+	var result SumResult
+	re, err := c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 255, B: 1}, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(1))
+
+	// Streaming function.
+	rs, re, err := c.CallStream(context.Background(), "RpcFunctionCounter", 3)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	var items []int
+	for item := range DecodeStreamItems[int](rs) {
+		items = append(items, item)
+	}
+	aTest.MustBeEqual(items, []int{1, 2, 3})
+	aTest.MustBeNoError(rs.Close())
+
+	// Push message.
+	err = ss.Broadcast("news", 42)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(<-pushes, "news:42")
+
+	// Exception escaped from a function closes the connection.
+	_, err = c.Call(context.Background(), "RpcFunctionExampleCrasher", struct{}{}, &result)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual((<-disconnected).Id(), conn.Id())
+	aTest.MustBeEqual(strings.Contains(logBuf.String(), ErrExceptionEscaped), true)
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 1, B: 2}, &result)
+	aTest.MustBeNoError(err)
+	conn = <-connected
+
+	// Closing by the client.
+	err = st.Close()
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual((<-disconnected).Id(), conn.Id())
+
+	// Connection is re-established.
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 1, B: 2}, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(result.C, byte(3))
+	<-connected
+
+	// Closing of the server.
+	err = ss.Close()
+	aTest.MustBeNoError(err)
+	<-disconnected
+	err = <-served
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrSocketServerIsClosed)
+	aTest.MustBeEqual(len(ss.Connections()), 0)
+	err = ss.Serve(listener)
+	aTest.MustBeAnError(err)
+}
+
+func Test_SocketServer_ServeStdio(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	p, err := NewProcessor(&ProcessorSettings{})
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	err = p.AddStreamFunc(RpcFunctionCounter)
+	aTest.MustBeNoError(err)
+	err = p.addNamedFunc("RpcFunctionBarrier", _newRpcFunctionBarrier(10), nil, false, false)
+	aTest.MustBeNoError(err)
+
+	connected := make(chan *SocketConnection, 1)
+	ss, err := NewSocketServer(p, &SocketSettings{OnConnect: func(c *SocketConnection) { connected <- c }})
+	aTest.MustBeNoError(err)
+
+	// Requests go from the client to the server and responses go back.
+	requestReader, requestWriter := io.Pipe()
+	responseReader, responseWriter := io.Pipe()
+	served := make(chan error, 1)
+	go func() { served <- ss.ServeStdio(requestReader, responseWriter) }()
+
+	cs, err := NewClientSettings("http", "localhost", 1, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+	st := NewStdioTransport(responseReader, requestWriter)
+	pushes := make(chan string, 1)
+	st.OnPush = func(topic string, data json.RawMessage) { pushes <- topic + ":" + string(data) }
+	cs.SetStdioTransport(st)
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+	c2, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Concurrent calls. Calls of two clients are in flight at the
+	// same time, then results are matched to calls.
+	for _, isMet := range _callBarrierConcurrently([]*Client{c, c2}, 10) {
+		aTest.MustBeEqual(isMet, true)
+	}
+
+	var wg sync.WaitGroup
+	results := make([]SumResult, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: byte(i), B: 1}, &results[i])
+		}()
+	}
+	wg.Wait()
+	for i := range results {
+		aTest.MustBeEqual(results[i].C, byte(i+1))
+	}
+	conn := <-connected
+	aTest.MustBeEqual(conn.RemoteAddr().String(), "stdio")
+
+	// Test #2. Streaming function.
+	rs, re, err := c.CallStream(context.Background(), "RpcFunctionCounter", 2)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	var items []int
+	for item := range DecodeStreamItems[int](rs) {
+		items = append(items, item)
+	}
+	aTest.MustBeEqual(items, []int{1, 2})
+	aTest.MustBeNoError(rs.Close())
+
+	// Test #3. Push message.
+	err = ss.Broadcast("news", 42)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(<-pushes, "news:42")
+
+	// Test #4. End of input.
+	err = st.Close()
+	aTest.MustBeNoError(err)
+	aTest.MustBeNoError(<-served)
+	aTest.MustBeEqual(len(ss.Connections()), 0)
+	var result SumResult
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 1, B: 2}, &result)
+	aTest.MustBeAnError(err)
+
+	// Test #5. Closed server.
+	err = ss.Close()
+	aTest.MustBeNoError(err)
+	err = ss.ServeStdio(strings.NewReader(""), io.Discard)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrSocketServerIsClosed)
+}
+
+func Test_SocketServer_callHeaders(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	er, err := _newErrorRegistry()
+	aTest.MustBeNoError(err)
+	el, err := _newErrorLocalization()
+	aTest.MustBeNoError(err)
+	tracer := NewMemoryTracer()
+	ps := &ProcessorSettings{
+		EnableNotifications: true,
+		ErrorRegistry:       er,
+		ErrorLocalization:   el,
+		Tracer:              tracer,
+		SpanFieldName:       &_metaFieldName_Span,
+	}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionNotified)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionExampleTraced)
+	aTest.MustBeNoError(err)
+	err = p.AddFuncWithError(RpcFunctionFind)
+	aTest.MustBeNoError(err)
+	entered, gate := make(chan struct{}), make(chan struct{})
+	err = p.addNamedFunc("RpcFunctionGate", func(_ *json.RawMessage, _ *ResponseMetaData) (result any, re *RpcError) {
+		close(entered)
+		<-gate
+		return "gate", nil
+	}, nil, false, false)
+	aTest.MustBeNoError(err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	aTest.MustBeNoError(err)
+	ss, err := NewSocketServer(p, &SocketSettings{})
+	aTest.MustBeNoError(err)
+	go func() { _ = ss.Serve(listener) }()
+	defer func() { _ = ss.Close() }()
+
+	cs, err := NewClientSettings("http", "localhost", 1, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+	cs.SetSocketTransport(NewSocketTransport(listener.Addr().Network(), listener.Addr().String()))
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+	c2, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Notification while a call of another client with the same ID
+	// is in flight. The call receives its own result.
+	type callOutput struct {
+		result string
+		err    error
+	}
+	called := make(chan callOutput, 1)
+	go func() {
+		var result string
+		_, err := c.Call(context.Background(), "RpcFunctionGate", struct{}{}, &result)
+		called <- callOutput{result: result, err: err}
+	}()
+	<-entered
+	re, err := c2.Notify(context.Background(), "RpcFunctionNotified", 7)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeEqual(<-_notifiedParams, "7")
+	close(gate)
+	output := <-called
+	aTest.MustBeNoError(output.err)
+	aTest.MustBeEqual(output.result, "gate")
+
+	// Test #2. Notification of an unknown function.
+	re, err = c2.Notify(context.Background(), "RpcFunctionUnknown", 7)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(RpcErrorCode_UnknownMethod))
+
+	// Test #3. Trace context of each call.
+	for _, traceParent := range []string{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+	} {
+		parent, err := ParseTraceParent(traceParent, "")
+		aTest.MustBeNoError(err)
+		var result string
+		_, err = c.Call(ContextWithSpanContext(context.Background(), parent), "RpcFunctionExampleTraced", struct{}{}, &result)
+		aTest.MustBeNoError(err)
+		spans := tracer.FinishedSpans()
+		aTest.MustBeEqual(spans[len(spans)-1].Parent.TraceParent(), traceParent)
+	}
+
+	// Test #4. Locales of each call.
+	var result string
+	re, err = c.Call(ContextWithLocale(context.Background(), "de"), "RpcFunctionFind", 2, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re.Message, RpcErrorMessage("Nicht gefunden"))
+	re, err = c.Call(context.Background(), "RpcFunctionFind", 2, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re.Message, RpcErrorMessage("Not found"))
+}
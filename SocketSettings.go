@@ -0,0 +1,92 @@
+package jrm1
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DefaultSocketMaxMessageSize     = 1024 * 1024
+	DefaultSocketMaxConcurrentCalls = 16
+	DefaultSocketIdleTimeout        = 5 * time.Minute
+	DefaultSocketWriteTimeout       = 10 * time.Second
+)
+
+const (
+	ErrSocketFramingIsUnknown = "socket framing is unknown"
+)
+
+// SocketSettings are settings of the socket transport of the RPC processor
+// (server). Zero values mean default settings.
+type SocketSettings struct {
+	// Framing of messages.
+	Framing SocketFraming
+
+	// Maximum size of a received message in bytes. Connection sending a
+	// larger message is closed.
+	MaxMessageSize uint
+
+	// Maximum number of function calls executed concurrently for a single
+	// connection. When the limit is reached, next messages are not read until
+	// some call finishes.
+	MaxConcurrentCalls uint
+
+	// Time limit of waiting for the next message from the client. When the
+	// limit is exceeded, the connection is closed.
+	IdleTimeout time.Duration
+
+	// Time limit of writing a message to the client.
+	WriteTimeout time.Duration
+
+	// Function called when a connection is established.
+	OnConnect func(c *SocketConnection)
+
+	// Function called when a connection is closed.
+	OnDisconnect func(c *SocketConnection)
+}
+
+// Check verifies the settings.
+func (ss *SocketSettings) Check() (err error) {
+	if !ss.Framing.IsValid() {
+		return errors.New(ErrSocketFramingIsUnknown)
+	}
+
+	return nil
+}
+
+// getMaxMessageSize returns the maximum size of a received message.
+func (ss *SocketSettings) getMaxMessageSize() int {
+	if ss.MaxMessageSize == 0 {
+		return DefaultSocketMaxMessageSize
+	}
+
+	return int(ss.MaxMessageSize)
+}
+
+// getMaxConcurrentCalls returns the maximum number of function calls executed
+// concurrently for a single connection.
+func (ss *SocketSettings) getMaxConcurrentCalls() int {
+	if ss.MaxConcurrentCalls == 0 {
+		return DefaultSocketMaxConcurrentCalls
+	}
+
+	return int(ss.MaxConcurrentCalls)
+}
+
+// getIdleTimeout returns the time limit of waiting for the next message.
+func (ss *SocketSettings) getIdleTimeout() time.Duration {
+	if ss.IdleTimeout <= 0 {
+		return DefaultSocketIdleTimeout
+	}
+
+	return ss.IdleTimeout
+}
+
+// getWriteTimeout returns the time limit of writing a message.
+func (ss *SocketSettings) getWriteTimeout() time.Duration {
+	if ss.WriteTimeout <= 0 {
+		return DefaultSocketWriteTimeout
+	}
+
+	return ss.WriteTimeout
+}
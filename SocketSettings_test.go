@@ -0,0 +1,47 @@
+package jrm1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_SocketSettings_Check(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	// Test #1. Default settings.
+	ss := &SocketSettings{}
+	err = ss.Check()
+	aTest.MustBeNoError(err)
+
+	// Test #2. Unknown framing.
+	ss = &SocketSettings{Framing: SocketFraming(2)}
+	err = ss.Check()
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrSocketFramingIsUnknown)
+}
+
+func Test_SocketSettings_getters(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test #1. Default values.
+	ss := &SocketSettings{}
+	aTest.MustBeEqual(ss.getMaxMessageSize(), DefaultSocketMaxMessageSize)
+	aTest.MustBeEqual(ss.getMaxConcurrentCalls(), DefaultSocketMaxConcurrentCalls)
+	aTest.MustBeEqual(ss.getIdleTimeout(), DefaultSocketIdleTimeout)
+	aTest.MustBeEqual(ss.getWriteTimeout(), DefaultSocketWriteTimeout)
+
+	// Test #2. Custom values.
+	ss = &SocketSettings{
+		MaxMessageSize:     100,
+		MaxConcurrentCalls: 2,
+		IdleTimeout:        time.Second,
+		WriteTimeout:       2 * time.Second,
+	}
+	aTest.MustBeEqual(ss.getMaxMessageSize(), 100)
+	aTest.MustBeEqual(ss.getMaxConcurrentCalls(), 2)
+	aTest.MustBeEqual(ss.getIdleTimeout(), time.Second)
+	aTest.MustBeEqual(ss.getWriteTimeout(), 2*time.Second)
+}
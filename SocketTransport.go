@@ -0,0 +1,91 @@
+package jrm1
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+)
+
+// SocketTransport is a transport of the client which sends function calls
+// over a single persistent socket connection, e.g. TCP or Unix domain socket.
+// It implements the 'http.RoundTripper' interface, so the client works without
+// changes, while the URL of the RPC server set in the client settings is
+// ignored. The connection is established on the first call and is
+// re-established after failures. Several calls may be in flight at the same
+// time, even calls of several clients sharing the transport, they are matched
+// to responses by request IDs made unique on the connection. Batches are not
+// supported. Headers describing a single call, e.g. the trace context, are
+// sent inside of its message, and notifications wait for the acknowledgement
+// of the server.
+type SocketTransport struct {
+	// Network and address of the RPC server, e.g. 'tcp' and 'localhost:2000'
+	// or 'unix' and '/run/rpc.sock'.
+	Network string
+	Address string
+
+	// Framing of messages. It must be the same as on the server.
+	Framing SocketFraming
+
+	// Maximum size of a received message in bytes. Zero means the default
+	// size.
+	MaxMessageSize uint
+
+	// Time limit of establishing a connection. Zero means no limit.
+	DialTimeout time.Duration
+
+	// Function receiving push messages of the server. Push messages are
+	// dropped when it is not set. It must not block for a long time.
+	OnPush func(topic string, data json.RawMessage)
+
+	pipeline *pipeline
+}
+
+// NewSocketTransport is a constructor of the socket transport of the client.
+func NewSocketTransport(network string, address string) (st *SocketTransport) {
+	st = &SocketTransport{
+		Network: network,
+		Address: address,
+	}
+	st.pipeline = newPipeline(st.dial, st.handlePush)
+
+	return st
+}
+
+// RoundTrip sends the function call over the socket connection and waits for
+// the response.
+// 'RoundTrip' is a required method of the 'http.RoundTripper' interface.
+func (st *SocketTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	return st.pipeline.roundTrip(req)
+}
+
+// Close closes the socket connection. Pending calls fail, while next calls
+// establish a new connection.
+func (st *SocketTransport) Close() (err error) {
+	return st.pipeline.close()
+}
+
+// handlePush passes a push message to the receiver.
+func (st *SocketTransport) handlePush(topic string, data json.RawMessage) {
+	if st.OnPush != nil {
+		st.OnPush(topic, data)
+	}
+}
+
+// dial connects to the server.
+func (st *SocketTransport) dial(req *http.Request) (mc messageConn, err error) {
+	dialer := &net.Dialer{Timeout: st.DialTimeout}
+
+	var conn net.Conn
+	conn, err = dialer.DialContext(req.Context(), st.Network, st.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	maxMessageSize := DefaultSocketMaxMessageSize
+	if st.MaxMessageSize > 0 {
+		maxMessageSize = int(st.MaxMessageSize)
+	}
+
+	return newSocketConn(conn, st.Framing, maxMessageSize, 0, DefaultSocketWriteTimeout), nil
+}
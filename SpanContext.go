@@ -0,0 +1,131 @@
+package jrm1
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// HTTP headers of the W3C Trace Context specification.
+// https://www.w3.org/TR/trace-context/
+const (
+	HttpHeaderTraceParent = "Traceparent"
+	HttpHeaderTraceState  = "Tracestate"
+)
+
+const (
+	TraceParentVersion        = "00"
+	TraceParentVersionInvalid = "ff"
+	TraceParentLength         = 55
+	TraceFlagSampled          = 0x01
+)
+
+const (
+	ErrTraceParentIsMalformed = "traceparent is malformed"
+	ErrTraceParentIsInvalid   = "traceparent is invalid"
+)
+
+// SpanContext is an identity of a span which is propagated across the RPC
+// boundary according to the W3C Trace Context specification.
+type SpanContext struct {
+	// Identifier of the trace.
+	TraceId [16]byte
+
+	// Identifier of the span.
+	SpanId [8]byte
+
+	// Trace flags, e.g. the 'sampled' flag.
+	TraceFlags byte
+
+	// Vendor-specific trace information. It is propagated as is.
+	TraceState string
+}
+
+// spanContextKey is a key of a span context stored in a context.
+type spanContextKey struct{}
+
+// ParseTraceParent parses values of the 'traceparent' and 'tracestate' HTTP
+// headers.
+func ParseTraceParent(traceParent string, traceState string) (sc SpanContext, err error) {
+	parts := strings.Split(traceParent, "-")
+	if len(parts) < 4 {
+		return sc, errors.New(ErrTraceParentIsMalformed)
+	}
+
+	version := parts[0]
+	if (len(version) != 2) || (version == TraceParentVersionInvalid) || !isLowerHex(version) {
+		return sc, errors.New(ErrTraceParentIsMalformed)
+	}
+
+	// Version '00' has exactly four fields, while future versions may append
+	// more fields which are ignored.
+	if (version == TraceParentVersion) && (len(traceParent) != TraceParentLength) {
+		return sc, errors.New(ErrTraceParentIsMalformed)
+	}
+
+	if (len(parts[1]) != 2*len(sc.TraceId)) ||
+		(len(parts[2]) != 2*len(sc.SpanId)) ||
+		(len(parts[3]) != 2) ||
+		!isLowerHex(parts[1]) || !isLowerHex(parts[2]) || !isLowerHex(parts[3]) {
+		return sc, errors.New(ErrTraceParentIsMalformed)
+	}
+
+	// Lengths and symbols are already checked, so decoding can not fail.
+	_, _ = hex.Decode(sc.TraceId[:], []byte(parts[1]))
+	_, _ = hex.Decode(sc.SpanId[:], []byte(parts[2]))
+	var flags [1]byte
+	_, _ = hex.Decode(flags[:], []byte(parts[3]))
+	sc.TraceFlags = flags[0]
+	sc.TraceState = traceState
+
+	if !sc.IsValid() {
+		return SpanContext{}, errors.New(ErrTraceParentIsInvalid)
+	}
+
+	return sc, nil
+}
+
+// IsValid tells whether both identifiers of the span context are set.
+func (sc SpanContext) IsValid() bool {
+	return (sc.TraceId != [16]byte{}) && (sc.SpanId != [8]byte{})
+}
+
+// IsSampled tells whether the 'sampled' flag is set.
+func (sc SpanContext) IsSampled() bool {
+	return sc.TraceFlags&TraceFlagSampled != 0
+}
+
+// TraceParent returns a value of the 'traceparent' HTTP header.
+func (sc SpanContext) TraceParent() string {
+	return fmt.Sprintf("%s-%s-%s-%02x",
+		TraceParentVersion,
+		hex.EncodeToString(sc.TraceId[:]),
+		hex.EncodeToString(sc.SpanId[:]),
+		sc.TraceFlags,
+	)
+}
+
+// ContextWithSpanContext returns a copy of the context containing the span
+// context. The client propagates such a span context to the RPC server.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext reads a span context from the context.
+func SpanContextFromContext(ctx context.Context) (sc SpanContext, ok bool) {
+	sc, ok = ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// isLowerHex tells whether the text consists of lower-case hexadecimal digits.
+func isLowerHex(s string) bool {
+	for _, r := range s {
+		if !(('0' <= r && r <= '9') || ('a' <= r && r <= 'f')) {
+			return false
+		}
+	}
+
+	return true
+}
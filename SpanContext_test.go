@@ -0,0 +1,95 @@
+package jrm1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_ParseTraceParent(t *testing.T) {
+	aTest := tester.New(t)
+	var sc SpanContext
+	var err error
+
+	// Test #1. Valid header.
+	sc, err = ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "congo=t61rcWkgMzE")
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(sc.TraceId, [16]byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36})
+	aTest.MustBeEqual(sc.SpanId, [8]byte{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7})
+	aTest.MustBeEqual(sc.TraceFlags, byte(1))
+	aTest.MustBeEqual(sc.TraceState, "congo=t61rcWkgMzE")
+	aTest.MustBeEqual(sc.IsSampled(), true)
+
+	// Test #2. Future version with additional fields.
+	sc, err = ParseTraceParent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-what-the-future-will-be-like", "")
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(sc.IsSampled(), false)
+
+	// Test #3. Malformed headers.
+	for _, tp := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-xx",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-0100",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902bz-01",
+	} {
+		_, err = ParseTraceParent(tp, "")
+		aTest.MustBeAnError(err)
+		aTest.MustBeEqual(err.Error(), ErrTraceParentIsMalformed)
+	}
+
+	// Test #4. Zero identifiers.
+	_, err = ParseTraceParent("00-00000000000000000000000000000000-00f067aa0ba902b7-01", "")
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrTraceParentIsInvalid)
+	_, err = ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", "")
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrTraceParentIsInvalid)
+}
+
+func Test_SpanContext_TraceParent(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	tp := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceParent(tp, "")
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(sc.TraceParent(), tp)
+}
+
+func Test_SpanContext_IsValid(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	aTest.MustBeEqual(SpanContext{}.IsValid(), false)
+	aTest.MustBeEqual(SpanContext{TraceId: [16]byte{1}}.IsValid(), false)
+	aTest.MustBeEqual(SpanContext{TraceId: [16]byte{1}, SpanId: [8]byte{1}}.IsValid(), true)
+}
+
+func Test_ContextWithSpanContext(t *testing.T) {
+	aTest := tester.New(t)
+	var ok bool
+
+	// Test #1. No span context.
+	_, ok = SpanContextFromContext(context.Background())
+	aTest.MustBeEqual(ok, false)
+
+	// Test #2. Span context.
+	scIn := SpanContext{TraceId: [16]byte{1}, SpanId: [8]byte{2}}
+	ctx := ContextWithSpanContext(context.Background(), scIn)
+	scOut, ok := SpanContextFromContext(ctx)
+	aTest.MustBeEqual(ok, true)
+	aTest.MustBeEqual(scOut, scIn)
+}
+
+func Test_isLowerHex(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	aTest.MustBeEqual(isLowerHex("0123456789abcdef"), true)
+	aTest.MustBeEqual(isLowerHex("A"), false)
+	aTest.MustBeEqual(isLowerHex("g"), false)
+}
@@ -0,0 +1,147 @@
+package jrm1
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+
+	ae "github.com/vault-thirteen/auxie/errors"
+)
+
+// StdioTransport is a transport of the client which sends function calls over
+// a pair of streams, e.g. standard input and output of a child process
+// serving the calls with the 'ServeStdio' method of the socket server. It
+// implements the 'http.RoundTripper' interface, so the client works without
+// changes, while the URL of the RPC server set in the client settings is
+// ignored. Several calls may be in flight at the same time, even calls of
+// several clients sharing the transport, they are matched to responses by
+// request IDs made unique on the connection. Batches are not supported. Headers
+// describing a single call are sent inside of its message, and notifications
+// wait for the acknowledgement of the server.
+// When the server closes its output, pending and next calls fail.
+type StdioTransport struct {
+	// Framing of messages. It must be the same as on the server.
+	Framing SocketFraming
+
+	// Maximum size of a received message in bytes. Zero means the default
+	// size.
+	MaxMessageSize uint
+
+	// Function receiving push messages of the server. Push messages are
+	// dropped when it is not set. It must not block for a long time.
+	OnPush func(topic string, data json.RawMessage)
+
+	stream *stdioStream
+
+	// Child process started by the transport. It is null when the transport
+	// is attached to existing streams.
+	cmd *exec.Cmd
+
+	guard    *sync.Mutex
+	isDialed bool
+	pipeline *pipeline
+}
+
+// NewStdioTransport is a constructor of the client transport attached to
+// existing streams. Requests are written to the writer, while responses are
+// read from the reader.
+func NewStdioTransport(r io.Reader, w io.Writer) (st *StdioTransport) {
+	st = &StdioTransport{
+		stream: newStdioStream(r, w),
+		guard:  new(sync.Mutex),
+	}
+	st.pipeline = newPipeline(st.dial, st.handlePush)
+
+	return st
+}
+
+// StartStdioTransport starts the command as a child process and creates the
+// client transport attached to its standard input and output. Standard error
+// of the command is not changed. The process is expected to exit when its
+// input is closed.
+func StartStdioTransport(cmd *exec.Cmd) (st *StdioTransport, err error) {
+	var stdinReader, stdinWriter, stdoutReader, stdoutWriter *os.File
+	stdinReader, stdinWriter, err = os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stdoutReader, stdoutWriter, err = os.Pipe()
+	if err != nil {
+		return nil, ae.Combine(err, closeFiles(stdinReader, stdinWriter))
+	}
+
+	cmd.Stdin = stdinReader
+	cmd.Stdout = stdoutWriter
+	err = cmd.Start()
+
+	// Ends of pipes used by the child process are not needed any more.
+	_ = closeFiles(stdinReader, stdoutWriter)
+
+	if err != nil {
+		return nil, ae.Combine(err, closeFiles(stdinWriter, stdoutReader))
+	}
+
+	st = NewStdioTransport(stdoutReader, stdinWriter)
+	st.cmd = cmd
+
+	return st, nil
+}
+
+// RoundTrip sends the function call over the streams and waits for the
+// response.
+// 'RoundTrip' is a required method of the 'http.RoundTripper' interface.
+func (st *StdioTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	return st.pipeline.roundTrip(req)
+}
+
+// Close closes the streams. Pending and next calls fail. If the transport has
+// started a child process, its input is closed first and the process is
+// awaited, so that it can finish the calls being executed.
+func (st *StdioTransport) Close() (err error) {
+	if st.cmd != nil {
+		err = st.stream.closeWriter()
+		err = ae.Combine(err, st.cmd.Wait())
+	}
+
+	err = ae.Combine(err, st.pipeline.close())
+	return ae.Combine(err, st.stream.Close())
+}
+
+// handlePush passes a push message to the receiver.
+func (st *StdioTransport) handlePush(topic string, data json.RawMessage) {
+	if st.OnPush != nil {
+		st.OnPush(topic, data)
+	}
+}
+
+// dial attaches the pipeline to the streams. Streams can not be re-opened, so
+// only the first attempt succeeds.
+func (st *StdioTransport) dial(_ *http.Request) (mc messageConn, err error) {
+	st.guard.Lock()
+	defer st.guard.Unlock()
+
+	if st.isDialed {
+		return nil, errors.New(ErrConnectionIsClosed)
+	}
+	st.isDialed = true
+
+	maxMessageSize := DefaultSocketMaxMessageSize
+	if st.MaxMessageSize > 0 {
+		maxMessageSize = int(st.MaxMessageSize)
+	}
+
+	return newStreamSocketConn(st.stream, st.Framing, maxMessageSize), nil
+}
+
+// closeFiles closes the files.
+func closeFiles(files ...*os.File) (err error) {
+	for _, f := range files {
+		err = ae.Combine(err, f.Close())
+	}
+
+	return err
+}
@@ -0,0 +1,88 @@
+package jrm1
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+// _stdioHelperEnvVar is an environment variable which makes the test binary
+// act as a plugin serving function calls over standard streams.
+const _stdioHelperEnvVar = "JRM1_STDIO_HELPER"
+
+// Test_StdioHelperProcess is not a real test. It serves function calls over
+// standard streams when the test binary is started by another test.
+func Test_StdioHelperProcess(t *testing.T) {
+	if os.Getenv(_stdioHelperEnvVar) == "" {
+		return
+	}
+
+	p, err := NewProcessor(&ProcessorSettings{})
+	if err != nil {
+		os.Exit(1)
+	}
+	err = p.AddFunc(RpcFunctionSum)
+	if err != nil {
+		os.Exit(1)
+	}
+	ss, err := NewSocketServer(p, &SocketSettings{Framing: SocketFraming_NewlineDelimited})
+	if err != nil {
+		os.Exit(1)
+	}
+	err = ss.ServeStdio(os.Stdin, os.Stdout)
+	if err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func Test_NewStdioTransport(t *testing.T) {
+	aTest := tester.New(t)
+
+	r, w := strings.NewReader(""), new(strings.Builder)
+
+	// Test.
+	st := NewStdioTransport(r, w)
+	aTest.MustBeEqual(st.stream.r, any(r))
+	aTest.MustBeEqual(st.stream.w, any(w))
+	aTest.MustBeEqual(st.cmd, (*exec.Cmd)(nil))
+}
+
+func Test_StartStdioTransport(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	// Test #1. Command which can not be started.
+	_, err = StartStdioTransport(exec.Command("/nonexistent/plugin"))
+	aTest.MustBeAnError(err)
+
+	// Test #2. Plugin process.
+	cmd := exec.Command(os.Args[0], "-test.run=^Test_StdioHelperProcess$")
+	cmd.Env = append(os.Environ(), _stdioHelperEnvVar+"=1")
+	st, err := StartStdioTransport(cmd)
+	aTest.MustBeNoError(err)
+	st.Framing = SocketFraming_NewlineDelimited
+
+	cs, err := NewClientSettings("http", "localhost", 1, "/", nil, nil, false)
+	aTest.MustBeNoError(err)
+	cs.SetStdioTransport(st)
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+
+	var result SumResult
+	re, err := c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 1, B: 2}, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeEqual(result.C, byte(3))
+
+	// Test #3. Shutdown of the plugin.
+	err = st.Close()
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(cmd.ProcessState.ExitCode(), 0)
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 1, B: 2}, &result)
+	aTest.MustBeAnError(err)
+}
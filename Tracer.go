@@ -0,0 +1,38 @@
+package jrm1
+
+// Names of span attributes.
+const (
+	SpanAttr_RpcSystem = "rpc.system"
+	SpanAttr_Method    = "rpc.method"
+	SpanAttr_RequestId = "rpc.jrm1.request_id"
+	SpanAttr_Outcome   = "rpc.jrm1.outcome"
+	SpanAttr_ErrorCode = "rpc.jrm1.error_code"
+	SpanAttr_BatchSize = "rpc.jrm1.batch_size"
+)
+
+const SpanAttrValue_RpcSystem = "jrm1"
+
+// SpanNameBatch is a name of the span of a batch of function calls made by the
+// RPC client.
+const SpanNameBatch = "(batch)"
+
+// Tracer creates spans. It is a small interface which may be implemented on
+// top of any tracing library or used without a live collector.
+type Tracer interface {
+	// StartSpan starts a span. If the parent span context is not valid, a new
+	// trace is started.
+	StartSpan(name string, parent SpanContext) Span
+}
+
+// Span is a single operation within a trace.
+type Span interface {
+	// SpanContext returns the identity of the span which is propagated to
+	// other services.
+	SpanContext() SpanContext
+
+	// SetAttribute sets an attribute of the span.
+	SetAttribute(key string, value any)
+
+	// End finishes the span.
+	End()
+}
@@ -0,0 +1,77 @@
+package jrm1
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// errWebSocketConnectionIsAlreadyClosed is returned when a closed connection
+// is closed again.
+var errWebSocketConnectionIsAlreadyClosed = errors.New(ErrWebSocketIsClosed)
+
+// WebSocketConnection is a WebSocket connection served by the RPC processor
+// (server).
+type WebSocketConnection struct {
+	conn    *serverConnection
+	handler *WebSocketHandler
+	wsc     *wsConn
+}
+
+// newWebSocketConnection is a constructor of a WebSocket connection served by
+// the RPC processor (server).
+func newWebSocketConnection(handler *WebSocketHandler, wsc *wsConn, req *http.Request) (c *WebSocketConnection) {
+	return &WebSocketConnection{
+		conn:    newServerConnection(handler.p, wsc, req, handler.settings.getMaxConcurrentCalls(), errWebSocketConnectionIsAlreadyClosed, false),
+		handler: handler,
+		wsc:     wsc,
+	}
+}
+
+// Id returns the identifier of the connection.
+func (c *WebSocketConnection) Id() string {
+	return c.conn.id
+}
+
+// Request returns the HTTP request of the upgrade. It may be used to
+// identify the client.
+func (c *WebSocketConnection) Request() *http.Request {
+	return c.conn.req
+}
+
+// Push sends a push message to the client.
+func (c *WebSocketConnection) Push(topic string, data any) (err error) {
+	return c.conn.push(topic, data)
+}
+
+// Close closes the connection. Calls being executed are finished, but their
+// responses are not delivered.
+func (c *WebSocketConnection) Close() (err error) {
+	return c.conn.close()
+}
+
+// serve reads messages and serves function calls until the connection is
+// closed.
+func (c *WebSocketConnection) serve() {
+	go c.keepAlive()
+
+	c.conn.serve()
+}
+
+// keepAlive sends ping frames periodically until the connection is closed.
+func (c *WebSocketConnection) keepAlive() {
+	ticker := time.NewTicker(c.handler.settings.getPingInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.conn.closed:
+			return
+		case <-ticker.C:
+			err := c.wsc.ping()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package jrm1
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	ae "github.com/vault-thirteen/auxie/errors"
+)
+
+// WebSocketHandler is an HTTP handler which upgrades HTTP connections to the
+// WebSocket protocol and serves function calls of the RPC processor (server)
+// over them. Each message is a request or a batch of requests, while each
+// response is sent as a separate message. Calls of a single connection are
+// executed concurrently and responses are matched to requests by their IDs.
+// Messages are served in the same way as HTTP requests, so the HTTP request
+// of the upgrade is visible to the access policy.
+type WebSocketHandler struct {
+	p        *Processor
+	settings *WebSocketSettings
+
+	guard       *sync.RWMutex
+	connections map[string]*WebSocketConnection
+}
+
+// NewWebSocketHandler is a constructor of a WebSocket handler serving the RPC
+// processor (server).
+func NewWebSocketHandler(p *Processor, settings *WebSocketSettings) (wsh *WebSocketHandler, err error) {
+	err = settings.Check()
+	if err != nil {
+		return nil, err
+	}
+
+	wsh = &WebSocketHandler{
+		p:           p,
+		settings:    settings,
+		guard:       new(sync.RWMutex),
+		connections: make(map[string]*WebSocketConnection),
+	}
+
+	return wsh, nil
+}
+
+// ServeHTTP upgrades the HTTP connection to the WebSocket protocol and serves
+// it until it is closed.
+// 'ServeHTTP' is a required method of the 'http.Handler' interface.
+func (wsh *WebSocketHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if !isWebSocketUpgrade(req) {
+		rw.Header().Set(HttpHeaderUpgrade, "websocket")
+		rw.WriteHeader(http.StatusUpgradeRequired)
+		return
+	}
+
+	conn, brw, err := http.NewResponseController(rw).Hijack()
+	if err != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = fmt.Fprintf(brw.Writer,
+		"HTTP/1.1 101 Switching Protocols\r\n%s: websocket\r\n%s: Upgrade\r\n%s: %s\r\n\r\n",
+		HttpHeaderUpgrade,
+		HttpHeaderConnection,
+		HttpHeaderSecWebSocketAccept, computeWebSocketAccept(req.Header.Get(HttpHeaderSecWebSocketKey)),
+	)
+	if err == nil {
+		err = brw.Writer.Flush()
+	}
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+
+	wsc := newWsConn(conn, bufio.NewReader(brw.Reader), false,
+		wsh.settings.getMaxMessageSize(),
+		wsh.settings.getReadTimeout(),
+		wsh.settings.getWriteTimeout(),
+	)
+	c := newWebSocketConnection(wsh, wsc, req)
+
+	wsh.addConnection(c)
+	defer wsh.removeConnection(c)
+
+	c.serve()
+}
+
+// Connections returns the list of open connections.
+func (wsh *WebSocketHandler) Connections() (connections []*WebSocketConnection) {
+	wsh.guard.RLock()
+	defer wsh.guard.RUnlock()
+
+	connections = make([]*WebSocketConnection, 0, len(wsh.connections))
+	for _, c := range wsh.connections {
+		connections = append(connections, c)
+	}
+
+	return connections
+}
+
+// Broadcast sends a push message to all open connections.
+func (wsh *WebSocketHandler) Broadcast(topic string, data any) (err error) {
+	for _, c := range wsh.Connections() {
+		err = ae.Combine(err, c.Push(topic, data))
+	}
+
+	return err
+}
+
+// Close closes all open connections.
+func (wsh *WebSocketHandler) Close() (err error) {
+	for _, c := range wsh.Connections() {
+		cerr := c.Close()
+		if (cerr != nil) && !errors.Is(cerr, errWebSocketConnectionIsAlreadyClosed) {
+			err = ae.Combine(err, cerr)
+		}
+	}
+
+	return err
+}
+
+// addConnection registers an open connection.
+func (wsh *WebSocketHandler) addConnection(c *WebSocketConnection) {
+	wsh.guard.Lock()
+	wsh.connections[c.Id()] = c
+	wsh.guard.Unlock()
+
+	if wsh.settings.OnConnect != nil {
+		wsh.settings.OnConnect(c)
+	}
+}
+
+// removeConnection unregisters a closed connection.
+func (wsh *WebSocketHandler) removeConnection(c *WebSocketConnection) {
+	wsh.guard.Lock()
+	delete(wsh.connections, c.Id())
+	wsh.guard.Unlock()
+
+	if wsh.settings.OnDisconnect != nil {
+		wsh.settings.OnDisconnect(c)
+	}
+}
@@ -0,0 +1,144 @@
+package jrm1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_NewWebSocketHandler(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	p, err := NewProcessor(&ProcessorSettings{})
+	aTest.MustBeNoError(err)
+
+	// Test #1. Invalid settings.
+	_, err = NewWebSocketHandler(p, &WebSocketSettings{ReadTimeout: time.Second})
+	aTest.MustBeAnError(err)
+
+	// Test #2. Valid settings.
+	wsh, err := NewWebSocketHandler(p, &WebSocketSettings{})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(len(wsh.Connections()), 0)
+}
+
+func Test_WebSocketHandler_ServeHTTP(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	p, err := NewProcessor(&ProcessorSettings{EnableBatches: true})
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	err = p.AddStreamFunc(RpcFunctionCounter)
+	aTest.MustBeNoError(err)
+	err = p.addNamedFunc("RpcFunctionBarrier", _newRpcFunctionBarrier(10), nil, false, false)
+	aTest.MustBeNoError(err)
+
+	connected := make(chan *WebSocketConnection, 1)
+	disconnected := make(chan *WebSocketConnection, 1)
+	wss := &WebSocketSettings{
+		OnConnect:    func(c *WebSocketConnection) { connected <- c },
+		OnDisconnect: func(c *WebSocketConnection) { disconnected <- c },
+	}
+	wsh, err := NewWebSocketHandler(p, wss)
+	aTest.MustBeNoError(err)
+	srv := httptest.NewServer(wsh)
+	defer srv.Close()
+
+	// Test #1. Ordinary HTTP request.
+	resp, err := http.Post(srv.URL, "application/json", nil)
+	aTest.MustBeNoError(err)
+	_ = resp.Body.Close()
+	aTest.MustBeEqual(resp.StatusCode, http.StatusUpgradeRequired)
+
+	// Test #2. Concurrent calls of two clients over a single connection. Both
+	// clients use the same request IDs, while all the calls are in flight at
+	// the same time.
+	cs, err := _newClientSettingsForUrl(srv.URL)
+	aTest.MustBeNoError(err)
+	wst := NewWebSocketTransport()
+	pushes := make(chan string, 1)
+	wst.OnPush = func(topic string, data json.RawMessage) { pushes <- topic + ":" + string(data) }
+	cs.SetWebSocketTransport(wst)
+	c, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+	c2, err := NewClient(cs)
+	aTest.MustBeNoError(err)
+
+	for _, isMet := range _callBarrierConcurrently([]*Client{c, c2}, 10) {
+		aTest.MustBeEqual(isMet, true)
+	}
+	aTest.MustBeEqual(c.GetRequestsCount(), "5")
+	aTest.MustBeEqual(c2.GetRequestsCount(), "5")
+
+	var wg sync.WaitGroup
+	results := make([]SumResult, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: byte(i), B: 1}, &results[i])
+		}()
+	}
+	wg.Wait()
+	for i := range results {
+		aTest.MustBeEqual(results[i].C, byte(i+1))
+	}
+	conn := <-connected
+	aTest.MustBeEqual(len(wsh.Connections()), 1)
+	aTest.MustBeEqual(wsh.Connections()[0].Id(), conn.Id())
+
+	// Test #3. Error of a function.
+	var result SumResult
+	re, err := c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 255, B: 1}, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re.Code, RpcErrorCode(1))
+
+	// Test #4. Streaming function.
+	rs, re, err := c.CallStream(context.Background(), "RpcFunctionCounter", 3)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	var items []int
+	for item := range DecodeStreamItems[int](rs) {
+		items = append(items, item)
+	}
+	aTest.MustBeEqual(items, []int{1, 2, 3})
+	re, _, err = rs.Result()
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(re, (*RpcError)(nil))
+	aTest.MustBeNoError(rs.Close())
+
+	// Test #5. Batches are not supported by the client transport.
+	err = c.CallBatch(context.Background(), []*BatchCall{NewBatchCall("RpcFunctionSum", SumParams{}, new(SumResult))})
+	aTest.MustBeAnError(err)
+
+	// Test #6. Push message.
+	err = wsh.Broadcast("news", 42)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(<-pushes, "news:42")
+
+	// Test #7. Closing by the client.
+	err = wst.Close()
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual((<-disconnected).Id(), conn.Id())
+
+	// Test #8. Connection is re-established.
+	_, err = c.Call(context.Background(), "RpcFunctionSum", SumParams{A: 1, B: 2}, &result)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(result.C, byte(3))
+	conn = <-connected
+
+	// Test #9. Closing by the server.
+	err = wsh.Close()
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual((<-disconnected).Id(), conn.Id())
+	aTest.MustBeEqual(len(wsh.Connections()), 0)
+}
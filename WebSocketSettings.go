@@ -0,0 +1,104 @@
+package jrm1
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DefaultWebSocketMaxMessageSize     = 1024 * 1024
+	DefaultWebSocketMaxConcurrentCalls = 16
+	DefaultWebSocketPingInterval       = 30 * time.Second
+	DefaultWebSocketReadTimeout        = 60 * time.Second
+	DefaultWebSocketWriteTimeout       = 10 * time.Second
+)
+
+const (
+	ErrWebSocketReadTimeoutIsTooShort = "read timeout must be longer than ping interval"
+)
+
+// WebSocketSettings are settings of the WebSocket transport of the RPC
+// processor (server). Zero values mean default settings.
+type WebSocketSettings struct {
+	// Maximum size of a received message in bytes. Connection sending a
+	// larger message is closed.
+	MaxMessageSize uint
+
+	// Maximum number of function calls executed concurrently for a single
+	// connection. When the limit is reached, next messages are not read until
+	// some call finishes.
+	MaxConcurrentCalls uint
+
+	// Interval between ping frames sent to the client.
+	PingInterval time.Duration
+
+	// Time limit of receiving anything, including answers to pings, from the
+	// client. When the limit is exceeded, the connection is closed.
+	ReadTimeout time.Duration
+
+	// Time limit of writing a message to the client.
+	WriteTimeout time.Duration
+
+	// Function called when a connection is established. It may be used to
+	// remember the connection for push messages.
+	OnConnect func(c *WebSocketConnection)
+
+	// Function called when a connection is closed.
+	OnDisconnect func(c *WebSocketConnection)
+}
+
+// Check verifies the settings.
+func (wss *WebSocketSettings) Check() (err error) {
+	if wss.getReadTimeout() <= wss.getPingInterval() {
+		return errors.New(ErrWebSocketReadTimeoutIsTooShort)
+	}
+
+	return nil
+}
+
+// getMaxMessageSize returns the maximum size of a received message.
+func (wss *WebSocketSettings) getMaxMessageSize() int {
+	if wss.MaxMessageSize == 0 {
+		return DefaultWebSocketMaxMessageSize
+	}
+
+	return int(wss.MaxMessageSize)
+}
+
+// getMaxConcurrentCalls returns the maximum number of function calls executed
+// concurrently for a single connection.
+func (wss *WebSocketSettings) getMaxConcurrentCalls() int {
+	if wss.MaxConcurrentCalls == 0 {
+		return DefaultWebSocketMaxConcurrentCalls
+	}
+
+	return int(wss.MaxConcurrentCalls)
+}
+
+// getPingInterval returns the interval between ping frames.
+func (wss *WebSocketSettings) getPingInterval() time.Duration {
+	if wss.PingInterval <= 0 {
+		return DefaultWebSocketPingInterval
+	}
+
+	return wss.PingInterval
+}
+
+// getReadTimeout returns the time limit of receiving anything from the
+// client.
+func (wss *WebSocketSettings) getReadTimeout() time.Duration {
+	if wss.ReadTimeout <= 0 {
+		return DefaultWebSocketReadTimeout
+	}
+
+	return wss.ReadTimeout
+}
+
+// getWriteTimeout returns the time limit of writing a message.
+func (wss *WebSocketSettings) getWriteTimeout() time.Duration {
+	if wss.WriteTimeout <= 0 {
+		return DefaultWebSocketWriteTimeout
+	}
+
+	return wss.WriteTimeout
+}
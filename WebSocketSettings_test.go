@@ -0,0 +1,55 @@
+package jrm1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_WebSocketSettings_Check(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	// Test #1. Default settings.
+	wss := &WebSocketSettings{}
+	err = wss.Check()
+	aTest.MustBeNoError(err)
+
+	// Test #2. Read timeout is not longer than ping interval.
+	wss = &WebSocketSettings{PingInterval: time.Minute, ReadTimeout: time.Minute}
+	err = wss.Check()
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrWebSocketReadTimeoutIsTooShort)
+
+	// Test #3. Read timeout is shorter than the default ping interval.
+	wss = &WebSocketSettings{ReadTimeout: time.Second}
+	err = wss.Check()
+	aTest.MustBeAnError(err)
+}
+
+func Test_WebSocketSettings_getters(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test #1. Default values.
+	wss := &WebSocketSettings{}
+	aTest.MustBeEqual(wss.getMaxMessageSize(), DefaultWebSocketMaxMessageSize)
+	aTest.MustBeEqual(wss.getMaxConcurrentCalls(), DefaultWebSocketMaxConcurrentCalls)
+	aTest.MustBeEqual(wss.getPingInterval(), DefaultWebSocketPingInterval)
+	aTest.MustBeEqual(wss.getReadTimeout(), DefaultWebSocketReadTimeout)
+	aTest.MustBeEqual(wss.getWriteTimeout(), DefaultWebSocketWriteTimeout)
+
+	// Test #2. Custom values.
+	wss = &WebSocketSettings{
+		MaxMessageSize:     100,
+		MaxConcurrentCalls: 2,
+		PingInterval:       time.Second,
+		ReadTimeout:        2 * time.Second,
+		WriteTimeout:       3 * time.Second,
+	}
+	aTest.MustBeEqual(wss.getMaxMessageSize(), 100)
+	aTest.MustBeEqual(wss.getMaxConcurrentCalls(), 2)
+	aTest.MustBeEqual(wss.getPingInterval(), time.Second)
+	aTest.MustBeEqual(wss.getReadTimeout(), 2*time.Second)
+	aTest.MustBeEqual(wss.getWriteTimeout(), 3*time.Second)
+}
@@ -0,0 +1,145 @@
+package jrm1
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+const (
+	ErrFWebSocketUpgradeIsRefused = "WebSocket upgrade is refused: %v"
+)
+
+// WebSocketTransport is a transport of the client which sends function calls
+// over a single persistent WebSocket connection. It implements the
+// 'http.RoundTripper' interface, so the client works without changes. The
+// connection is established on the first call to the URL of the RPC server
+// and is re-established after failures. Concurrent calls, even calls of
+// several clients sharing the transport, are multiplexed by request IDs made
+// unique on the connection. Batches are not supported. Headers describing a
+// single call, e.g. the trace context, are sent inside of its message, and
+// notifications wait for the acknowledgement of the server.
+type WebSocketTransport struct {
+	// Custom HTTP headers of the upgrade request, e.g. for authorisation.
+	Header http.Header
+
+	// Configuration of TLS used for the 'https' schema.
+	TLSConfig *tls.Config
+
+	// Maximum size of a received message in bytes. Zero means the default
+	// size.
+	MaxMessageSize uint
+
+	// Function receiving push messages of the server. Push messages are
+	// dropped when it is not set. It must not block for a long time.
+	OnPush func(topic string, data json.RawMessage)
+
+	pipeline *pipeline
+}
+
+// NewWebSocketTransport is a constructor of the WebSocket transport of the
+// client.
+func NewWebSocketTransport() (wst *WebSocketTransport) {
+	wst = &WebSocketTransport{
+		Header: make(http.Header),
+	}
+	wst.pipeline = newPipeline(wst.dial, wst.handlePush)
+
+	return wst
+}
+
+// RoundTrip sends the function call over the WebSocket connection and waits
+// for the response.
+// 'RoundTrip' is a required method of the 'http.RoundTripper' interface.
+func (wst *WebSocketTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	return wst.pipeline.roundTrip(req)
+}
+
+// Close closes the WebSocket connection. Pending calls fail, while next calls
+// establish a new connection.
+func (wst *WebSocketTransport) Close() (err error) {
+	return wst.pipeline.close()
+}
+
+// handlePush passes a push message to the receiver.
+func (wst *WebSocketTransport) handlePush(topic string, data json.RawMessage) {
+	if wst.OnPush != nil {
+		wst.OnPush(topic, data)
+	}
+}
+
+// dial connects to the server and performs the WebSocket handshake.
+func (wst *WebSocketTransport) dial(req *http.Request) (mc messageConn, err error) {
+	ctx := req.Context()
+
+	address := req.URL.Host
+	if len(req.URL.Port()) == 0 {
+		port := 80
+		if req.URL.Scheme == "https" {
+			port = 443
+		}
+		address = net.JoinHostPort(req.URL.Hostname(), strconv.Itoa(port))
+	}
+
+	var conn net.Conn
+	if req.URL.Scheme == "https" {
+		dialer := &tls.Dialer{Config: wst.TLSConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	} else {
+		dialer := new(net.Dialer)
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := newWebSocketKey()
+	upgradeReq, err := http.NewRequestWithContext(ctx, http.MethodGet, req.URL.String(), nil)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	for name, values := range wst.Header {
+		upgradeReq.Header[name] = values
+	}
+	upgradeReq.Header.Set(HttpHeaderUpgrade, "websocket")
+	upgradeReq.Header.Set(HttpHeaderConnection, "Upgrade")
+	upgradeReq.Header.Set(HttpHeaderSecWebSocketKey, key)
+	upgradeReq.Header.Set(HttpHeaderSecWebSocketVersion, WebSocketVersion)
+
+	err = upgradeReq.Write(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	var upgradeResp *http.Response
+	upgradeResp, err = http.ReadResponse(br, upgradeReq)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = upgradeResp.Body.Close()
+
+	if upgradeResp.StatusCode != http.StatusSwitchingProtocols {
+		_ = conn.Close()
+		return nil, fmt.Errorf(ErrFWebSocketUpgradeIsRefused, upgradeResp.Status)
+	}
+	if upgradeResp.Header.Get(HttpHeaderSecWebSocketAccept) != computeWebSocketAccept(key) {
+		_ = conn.Close()
+		return nil, errors.New(ErrWebSocketHandshakeIsInvalid)
+	}
+
+	maxMessageSize := DefaultWebSocketMaxMessageSize
+	if wst.MaxMessageSize > 0 {
+		maxMessageSize = int(wst.MaxMessageSize)
+	}
+
+	return newWsConn(conn, br, true, maxMessageSize, 0, DefaultWebSocketWriteTimeout), nil
+}
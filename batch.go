@@ -0,0 +1,102 @@
+package jrm1
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+
+	ae "github.com/vault-thirteen/auxie/errors"
+)
+
+const (
+	// DefaultMaxBatchSize is a maximum number of function calls in a batch
+	// used when the maximum is not set.
+	DefaultMaxBatchSize = 100
+)
+
+const (
+	ErrBatchIsEmpty    = "batch is empty"
+	ErrBatchIsTooLarge = "batch is too large"
+	ErrBatchIsNotArray = "batch is not an array"
+)
+
+// bufferedReadCloser is a buffered reader which closes the original reader.
+type bufferedReadCloser struct {
+	*bufio.Reader
+	io.Closer
+}
+
+// peekIsJsonArray tells whether the JSON text in the stream is an array. The
+// returned stream must be used instead of the original one, while the first
+// bytes of the original stream are already read. Leading white space of any
+// length is skipped.
+func peekIsJsonArray(input io.ReadCloser) (isArray bool, output io.ReadCloser) {
+	br := bufio.NewReader(input)
+	output = bufferedReadCloser{Reader: br, Closer: input}
+
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return false, output
+		}
+
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+
+		// Unreading of the byte which has just been read never fails.
+		_ = br.UnreadByte()
+
+		return b == '[', output
+	}
+}
+
+// readBatch reads a batch of raw function calls from the stream. Function
+// calls are not decoded, so that each of them is processed independently.
+// The array is read item by item and reading stops as soon as the batch has
+// more than the maximum number of items, so that the limit bounds the memory
+// used by the batch.
+func readBatch(input io.ReadCloser, maxSize int) (items []json.RawMessage, isTooLarge bool, err error) {
+	defer func() {
+		derr := input.Close()
+		if derr != nil {
+			items = nil
+			err = ae.Combine(err, derr)
+		}
+	}()
+
+	decoder := json.NewDecoder(input)
+
+	// Opening delimiter.
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, false, err
+	}
+	if token != json.Delim('[') {
+		return nil, false, errors.New(ErrBatchIsNotArray)
+	}
+
+	for decoder.More() {
+		if len(items) == maxSize {
+			return nil, true, nil
+		}
+
+		var item json.RawMessage
+		err = decoder.Decode(&item)
+		if err != nil {
+			return nil, false, err
+		}
+
+		items = append(items, item)
+	}
+
+	// Closing delimiter.
+	_, err = decoder.Token()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return items, false, nil
+}
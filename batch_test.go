@@ -0,0 +1,73 @@
+package jrm1
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_peekIsJsonArray(t *testing.T) {
+	aTest := tester.New(t)
+	var isArray bool
+	var output io.ReadCloser
+	var data []byte
+	var err error
+
+	// Test #1. Array.
+	isArray, output = peekIsJsonArray(io.NopCloser(strings.NewReader(" \r\n\t[1,2]")))
+	aTest.MustBeEqual(isArray, true)
+	data, err = io.ReadAll(output)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(data), "[1,2]")
+
+	// Test #2. Object.
+	isArray, output = peekIsJsonArray(io.NopCloser(strings.NewReader(`{"a":[]}`)))
+	aTest.MustBeEqual(isArray, false)
+	data, err = io.ReadAll(output)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(data), `{"a":[]}`)
+
+	// Test #3. Empty stream.
+	isArray, _ = peekIsJsonArray(io.NopCloser(strings.NewReader("  ")))
+	aTest.MustBeEqual(isArray, false)
+
+	// Test #4. White space is longer than the buffer.
+	isArray, output = peekIsJsonArray(io.NopCloser(strings.NewReader(strings.Repeat(" ", 10000) + "[1]")))
+	aTest.MustBeEqual(isArray, true)
+	data, err = io.ReadAll(output)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(data), "[1]")
+}
+
+func Test_readBatch(t *testing.T) {
+	aTest := tester.New(t)
+	var items []json.RawMessage
+	var isTooLarge bool
+	var err error
+
+	// Test #1. Not an array.
+	_, _, err = readBatch(io.NopCloser(strings.NewReader(`{}`)), 10)
+	aTest.MustBeAnError(err)
+	_, _, err = readBatch(io.NopCloser(strings.NewReader(`[1,`)), 10)
+	aTest.MustBeAnError(err)
+
+	// Test #2. Close error.
+	items, _, err = readBatch(_badCloser{strings.NewReader(`[]`)}, 10)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(items, []json.RawMessage(nil))
+
+	// Test #3. Items.
+	items, isTooLarge, err = readBatch(io.NopCloser(strings.NewReader(`[{"a":1}, 2]`)), 2)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(isTooLarge, false)
+	aTest.MustBeEqual(items, []json.RawMessage{json.RawMessage(`{"a":1}`), json.RawMessage(`2`)})
+
+	// Test #4. Too many items, the rest is not read.
+	items, isTooLarge, err = readBatch(io.NopCloser(strings.NewReader(`[1, 2, 3, {`)), 2)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(isTooLarge, true)
+	aTest.MustBeEqual(items, []json.RawMessage(nil))
+}
@@ -0,0 +1,421 @@
+package jrm1
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"unicode/utf8"
+)
+
+const (
+	ErrCborStringIsNotValidUtf8 = "CBOR text string is not valid UTF-8"
+	ErrCborBreakIsUnexpected    = "CBOR break is unexpected"
+)
+
+// Major types of CBOR data items.
+const (
+	cborMajor_Unsigned = 0
+	cborMajor_Negative = 1
+	cborMajor_Bytes    = 2
+	cborMajor_Text     = 3
+	cborMajor_Array    = 4
+	cborMajor_Map      = 5
+	cborMajor_Tag      = 6
+	cborMajor_Simple   = 7
+)
+
+// cborIndefinite is the additional information of an item having an
+// indefinite length. Within simple values it is the 'break' stop code.
+const cborIndefinite = 31
+
+// appendCbor appends the tree of generic values encoded in the CBOR format.
+// Definite lengths are always used.
+func appendCbor(buf []byte, v any) (out []byte, err error) {
+	switch x := v.(type) {
+	case nil:
+		return append(buf, 0xf6), nil
+
+	case bool:
+		if x {
+			return append(buf, 0xf5), nil
+		}
+		return append(buf, 0xf4), nil
+
+	case json.Number:
+		var kind jsonNumberKind
+		var u uint64
+		var i int64
+		var f float64
+		kind, u, i, f, err = classifyJsonNumber(x)
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case jsonNumberKind_Unsigned:
+			return appendCborHead(buf, cborMajor_Unsigned, u), nil
+		case jsonNumberKind_Signed:
+			return appendCborHead(buf, cborMajor_Negative, uint64(-1-i)), nil
+		default:
+			return binary.BigEndian.AppendUint64(append(buf, 0xfb), math.Float64bits(f)), nil
+		}
+
+	case string:
+		buf = appendCborHead(buf, cborMajor_Text, uint64(len(x)))
+		return append(buf, x...), nil
+
+	case []byte:
+		buf = appendCborHead(buf, cborMajor_Bytes, uint64(len(x)))
+		return append(buf, x...), nil
+
+	case []any:
+		buf = appendCborHead(buf, cborMajor_Array, uint64(len(x)))
+		for _, item := range x {
+			buf, err = appendCbor(buf, item)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+
+	case *jsonObject:
+		buf = appendCborHead(buf, cborMajor_Map, uint64(len(x.keys)))
+		for i, key := range x.keys {
+			buf, err = appendCbor(buf, key)
+			if err != nil {
+				return nil, err
+			}
+			buf, err = appendCbor(buf, x.values[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+
+	default:
+		return nil, fmt.Errorf(ErrFCodecValueIsNotSupported, v)
+	}
+}
+
+// appendCborHead appends the head of a data item with the major type and the
+// argument.
+func appendCborHead(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major<<5|byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, major<<5|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, major<<5|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, major<<5|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, major<<5|27), n)
+	}
+}
+
+// decodeCbor decodes data in the CBOR format into a tree of generic values.
+// Tags are ignored, while their contents are decoded.
+func decodeCbor(data []byte) (tree any, err error) {
+	br := &binaryReader{data: data}
+
+	tree, err = readCborItem(br)
+	if err != nil {
+		return nil, err
+	}
+
+	err = br.checkEnd()
+	if err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// cborBreak is a marker of the 'break' stop code.
+type cborBreak struct{}
+
+// readCborHead reads the head of a data item. For items of indefinite length
+// the flag is set and the argument is zero.
+func readCborHead(br *binaryReader) (major byte, info byte, n uint64, isIndefinite bool, err error) {
+	var b byte
+	b, err = br.readByte()
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+
+	major, info = b>>5, b&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), false, nil
+	case info <= 27:
+		n, err = br.readUint(1 << (info - 24))
+		return major, info, n, false, err
+	case info == cborIndefinite:
+		return major, info, 0, true, nil
+	default:
+		return 0, 0, 0, false, fmt.Errorf(ErrFCodecTypeIsNotSupported, b)
+	}
+}
+
+// readCborValue reads the next data item. The 'break' stop code is returned
+// as a marker, callers reading indefinite items handle it.
+func readCborValue(br *binaryReader) (v any, err error) {
+	start := br.pos
+
+	var major, info byte
+	var n uint64
+	var isIndefinite bool
+	major, info, n, isIndefinite, err = readCborHead(br)
+	if err != nil {
+		return nil, err
+	}
+
+	if isIndefinite {
+		switch major {
+		case cborMajor_Bytes, cborMajor_Text:
+			return readCborChunks(br, major)
+		case cborMajor_Array:
+			return readCborArray(br, 0, true)
+		case cborMajor_Map:
+			return readCborMap(br, 0, true)
+		case cborMajor_Simple:
+			return cborBreak{}, nil
+		default:
+			return nil, fmt.Errorf(ErrFCodecTypeIsNotSupported, br.data[start])
+		}
+	}
+
+	switch major {
+	case cborMajor_Unsigned:
+		return json.Number(strconv.FormatUint(n, 10)), nil
+
+	case cborMajor_Negative:
+		if n <= math.MaxInt64 {
+			return json.Number(strconv.FormatInt(-1-int64(n), 10)), nil
+		}
+		x := new(big.Int).SetUint64(n)
+		x.Add(x, big.NewInt(1))
+		return json.Number("-" + x.String()), nil
+
+	case cborMajor_Bytes:
+		var data []byte
+		data, err = br.readBytes(n)
+		if err != nil {
+			return nil, err
+		}
+		return newJsonBinary(data), nil
+
+	case cborMajor_Text:
+		var data []byte
+		data, err = br.readBytes(n)
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.Valid(data) {
+			return nil, errors.New(ErrCborStringIsNotValidUtf8)
+		}
+		return string(data), nil
+
+	case cborMajor_Array:
+		return readCborArray(br, n, false)
+
+	case cborMajor_Map:
+		return readCborMap(br, n, false)
+
+	case cborMajor_Tag:
+		err = br.enter()
+		if err != nil {
+			return nil, err
+		}
+		defer br.leave()
+		return readCborItem(br)
+
+	default:
+		return readCborSimple(br, info, n, br.data[start])
+	}
+}
+
+// readCborItem reads the next data item which must not be the 'break' stop
+// code.
+func readCborItem(br *binaryReader) (v any, err error) {
+	v, err = readCborValue(br)
+	if err != nil {
+		return nil, err
+	}
+
+	_, isBreak := v.(cborBreak)
+	if isBreak {
+		return nil, errors.New(ErrCborBreakIsUnexpected)
+	}
+
+	return v, nil
+}
+
+// readCborSimple reads a simple value or a float.
+func readCborSimple(br *binaryReader, info byte, n uint64, t byte) (v any, err error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		// Both 'null' and 'undefined' are null.
+		return nil, nil
+	case 25:
+		return newJsonFloat(float16ToFloat64(uint16(n)))
+	case 26:
+		return newJsonFloat(float64(math.Float32frombits(uint32(n))))
+	case 27:
+		return newJsonFloat(math.Float64frombits(n))
+	default:
+		return nil, fmt.Errorf(ErrFCodecTypeIsNotSupported, t)
+	}
+}
+
+// readCborChunks reads a byte or text string of indefinite length, which
+// consists of definite strings of the same major type.
+func readCborChunks(br *binaryReader, major byte) (v any, err error) {
+	var data []byte
+	for {
+		var b byte
+		b, err = br.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == 0xff {
+			break
+		}
+		br.pos--
+
+		var chunkMajor byte
+		var n uint64
+		var isIndefinite bool
+		chunkMajor, _, n, isIndefinite, err = readCborHead(br)
+		if err != nil {
+			return nil, err
+		}
+		if (chunkMajor != major) || isIndefinite {
+			return nil, fmt.Errorf(ErrFCodecTypeIsNotSupported, b)
+		}
+
+		var chunk []byte
+		chunk, err = br.readBytes(n)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, chunk...)
+	}
+
+	if major == cborMajor_Bytes {
+		return newJsonBinary(data), nil
+	}
+
+	if !utf8.Valid(data) {
+		return nil, errors.New(ErrCborStringIsNotValidUtf8)
+	}
+
+	return string(data), nil
+}
+
+// readCborArray reads an array of the definite length or of indefinite
+// length finished by the 'break' stop code.
+func readCborArray(br *binaryReader, n uint64, isIndefinite bool) (array []any, err error) {
+	err = br.enter()
+	if err != nil {
+		return nil, err
+	}
+	defer br.leave()
+
+	array = make([]any, 0, br.capacityFor(n))
+	for i := uint64(0); isIndefinite || (i < n); i++ {
+		var item any
+		item, err = readCborValue(br)
+		if err != nil {
+			return nil, err
+		}
+
+		_, isBreak := item.(cborBreak)
+		if isBreak {
+			if !isIndefinite {
+				return nil, errors.New(ErrCborBreakIsUnexpected)
+			}
+			break
+		}
+
+		array = append(array, item)
+	}
+
+	return array, nil
+}
+
+// readCborMap reads a map of the definite length or of indefinite length
+// finished by the 'break' stop code. Keys must be text strings.
+func readCborMap(br *binaryReader, n uint64, isIndefinite bool) (object *jsonObject, err error) {
+	err = br.enter()
+	if err != nil {
+		return nil, err
+	}
+	defer br.leave()
+
+	object = &jsonObject{
+		keys:   make([]string, 0, br.capacityFor(n)),
+		values: make([]any, 0, br.capacityFor(n)),
+	}
+	for i := uint64(0); isIndefinite || (i < n); i++ {
+		var key, item any
+		key, err = readCborValue(br)
+		if err != nil {
+			return nil, err
+		}
+
+		_, isBreak := key.(cborBreak)
+		if isBreak {
+			if !isIndefinite {
+				return nil, errors.New(ErrCborBreakIsUnexpected)
+			}
+			break
+		}
+
+		keyStr, ok := key.(string)
+		if !ok {
+			return nil, errors.New(ErrCodecMapKeyIsNotString)
+		}
+
+		item, err = readCborItem(br)
+		if err != nil {
+			return nil, err
+		}
+
+		object.keys = append(object.keys, keyStr)
+		object.values = append(object.values, item)
+	}
+
+	return object, nil
+}
+
+// float16ToFloat64 converts a half-precision float into a double-precision
+// float.
+func float16ToFloat64(h uint16) float64 {
+	sign := 1.0
+	if (h & 0x8000) != 0 {
+		sign = -1.0
+	}
+	exponent := int((h >> 10) & 0x1f)
+	mantissa := float64(h & 0x3ff)
+
+	switch exponent {
+	case 0:
+		return sign * math.Ldexp(mantissa, -24)
+	case 0x1f:
+		if mantissa == 0 {
+			return math.Inf(int(sign))
+		}
+		return math.NaN()
+	default:
+		return sign * math.Ldexp(mantissa+1024, exponent-25)
+	}
+}
@@ -0,0 +1,157 @@
+package jrm1
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_appendCbor(t *testing.T) {
+	aTest := tester.New(t)
+
+	type TestData struct {
+		json string
+		cbor string
+	}
+
+	// Examples are taken from the appendix A of RFC 8949.
+	tests := []TestData{
+		{json: `null`, cbor: "f6"},
+		{json: `true`, cbor: "f5"},
+		{json: `false`, cbor: "f4"},
+		{json: `0`, cbor: "00"},
+		{json: `23`, cbor: "17"},
+		{json: `24`, cbor: "1818"},
+		{json: `1000`, cbor: "1903e8"},
+		{json: `1000000`, cbor: "1a000f4240"},
+		{json: `1000000000000`, cbor: "1b000000e8d4a51000"},
+		{json: `18446744073709551615`, cbor: "1bffffffffffffffff"},
+		{json: `-1`, cbor: "20"},
+		{json: `-1000`, cbor: "3903e7"},
+		{json: `1.1`, cbor: "fb3ff199999999999a"},
+		{json: `""`, cbor: "60"},
+		{json: `"IETF"`, cbor: "6449455446"},
+		{json: `"ü"`, cbor: "62c3bc"},
+		{json: `[1,[2,3]]`, cbor: "8201820203"},
+		{json: `{"a":1,"b":[2,3]}`, cbor: "a26161016162820203"},
+	}
+
+	for _, test := range tests {
+		tree, err := parseJsonTree([]byte(test.json))
+		aTest.MustBeNoError(err)
+		data, err := appendCbor(nil, tree)
+		aTest.MustBeNoError(err)
+		aTest.MustBeEqual(hex.EncodeToString(data), test.cbor)
+
+		// Decoding returns the original JSON.
+		tree, err = decodeCbor(data)
+		aTest.MustBeNoError(err)
+		aTest.MustBeEqual(_jsonTreeText(t, tree), test.json)
+	}
+
+	// Long strings, arrays and maps.
+	for _, n := range []int{23, 24, 255, 256, 65535, 65536} {
+		for _, v := range _newLongJsonTrees(n) {
+			data, err := appendCbor(nil, v)
+			aTest.MustBeNoError(err)
+			decoded, err := decodeCbor(data)
+			aTest.MustBeNoError(err)
+			aTest.MustBeEqual(decoded, v)
+		}
+	}
+
+	// Binary strings are decoded as Base64 text.
+	data, err := appendCbor(nil, []byte{1, 2})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(hex.EncodeToString(data), "420102")
+	decoded, err := decodeCbor(data)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(decoded, "AQI=")
+}
+
+func Test_decodeCbor(t *testing.T) {
+	aTest := tester.New(t)
+
+	type TestData struct {
+		cbor    string
+		json    string
+		isError bool
+	}
+
+	// Examples are taken from the appendix A of RFC 8949.
+	tests := []TestData{
+		// Items which are not produced by the encoder.
+		{cbor: "3bffffffffffffffff", json: `-18446744073709551616`},
+		{cbor: "f93e00", json: `1.5`},
+		{cbor: "f90001", json: `5.960464477539063e-08`},
+		{cbor: "f9c400", json: `-4`},
+		{cbor: "fa47c35000", json: `100000`},
+		{cbor: "f7", json: `null`},
+		{cbor: "4401020304", json: `"AQIDBA=="`},
+		{cbor: "c074323031332d30332d32315432303a30343a30305a", json: `"2013-03-21T20:04:00Z"`},
+		{cbor: "5f42010243030405ff", json: `"AQIDBAU="`},
+		{cbor: "7f657374726561646d696e67ff", json: `"streaming"`},
+		{cbor: "9f018202039f0405ffff", json: `[1,[2,3],[4,5]]`},
+		{cbor: "9fff", json: `[]`},
+		{cbor: "bf61610161629f0203ffff", json: `{"a":1,"b":[2,3]}`},
+
+		// Errors.
+		{cbor: "", isError: true},
+		{cbor: "19", isError: true},
+		{cbor: "f6f6", isError: true},
+		{cbor: "1c", isError: true},
+		{cbor: "ff", isError: true},
+		{cbor: "81ff", isError: true},
+		{cbor: "a1ff", isError: true},
+		{cbor: "a10101", isError: true},
+		{cbor: "a161ff", isError: true},
+		{cbor: "bf6161ff", isError: true},
+		{cbor: "62c328", isError: true},
+		{cbor: "7f62c3ff", isError: true},
+		{cbor: "5f6161ff", isError: true},
+		{cbor: "5f5f4101ffff", isError: true},
+		{cbor: "3f", isError: true},
+		{cbor: "f97c00", isError: true},
+		{cbor: "f97e00", isError: true},
+		{cbor: "faff800000", isError: true},
+		{cbor: "fb7ff8000000000000", isError: true},
+		{cbor: "f0", isError: true},
+		{cbor: "f820", isError: true},
+		{cbor: "c0ff", isError: true},
+		{cbor: "9f01", isError: true},
+		{cbor: "5f", isError: true},
+	}
+
+	for _, test := range tests {
+		data, err := hex.DecodeString(test.cbor)
+		aTest.MustBeNoError(err)
+		tree, err := decodeCbor(data)
+		if test.isError {
+			aTest.MustBeAnError(err)
+			continue
+		}
+		aTest.MustBeNoError(err)
+		aTest.MustBeEqual(_jsonTreeText(t, tree), test.json)
+	}
+
+	// Nesting is too deep.
+	data := make([]byte, maxCodecNestingDepth+1)
+	for i := range data {
+		data[i] = 0x81
+	}
+	_, err := decodeCbor(append(data, 0xf6))
+	aTest.MustBeAnError(err)
+}
+
+func Test_float16ToFloat64(t *testing.T) {
+	aTest := tester.New(t)
+
+	aTest.MustBeEqual(float16ToFloat64(0x0000), 0.0)
+	aTest.MustBeEqual(float16ToFloat64(0x3c00), 1.0)
+	aTest.MustBeEqual(float16ToFloat64(0x7bff), 65504.0)
+	aTest.MustBeEqual(float16ToFloat64(0x0400), 6.103515625e-05)
+	aTest.MustBeEqual(float16ToFloat64(0xfc00), math.Inf(-1))
+	aTest.MustBeEqual(math.IsNaN(float16ToFloat64(0x7e00)), true)
+}
@@ -1,10 +1,17 @@
 package jrm1
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type _badCloser struct {
@@ -13,6 +20,9 @@ type _badCloser struct {
 
 var _metaFieldName_RID = "rid"
 var _metaFieldName_Duration = "dur"
+var _metaFieldName_Span = "span"
+var _notifiedParams = make(chan string, 100)
+var _metaFieldName_JobContext = "jobCtx"
 
 func (bc _badCloser) Close() error { return errors.New("close error") }
 
@@ -54,6 +64,114 @@ func RpcFunctionExampleCrasher(_ *json.RawMessage, _ *ResponseMetaData) (result
 	return `haha`, nil
 }
 
+func RpcFunctionExampleTraced(_ *json.RawMessage, metaData *ResponseMetaData) (result any, re *RpcError) {
+	span, ok := metaData.GetField(_metaFieldName_Span).(Span)
+	if !ok {
+		return nil, NewRpcErrorByUser(1, "span is not found", nil)
+	}
+	span.SetAttribute("user.attribute", "value")
+	return span.SpanContext().TraceParent(), nil
+}
+
+// RpcFunctionNotified is an example of a function called as a notification.
+// It passes its parameters to the '_notifiedParams' channel.
+func RpcFunctionNotified(params *json.RawMessage, _ *ResponseMetaData) (result any, re *RpcError) {
+	_notifiedParams <- string(*params)
+	return nil, nil
+}
+
+// RpcFunctionWaiter is an example of a long-running function. It waits until
+// its job is cancelled.
+func RpcFunctionWaiter(_ *json.RawMessage, metaData *ResponseMetaData) (result any, re *RpcError) {
+	ctx, ok := metaData.GetField(_metaFieldName_JobContext).(context.Context)
+	if !ok {
+		return nil, NewRpcErrorByUser(1, "context is not found", nil)
+	}
+	<-ctx.Done()
+	return nil, NewRpcErrorByUser(2, "cancelled", nil)
+}
+
+// _newRpcFunctionBarrier creates a function which waits until the specified
+// number of its calls are in flight at the same time. Its result tells
+// whether the calls have met before the timeout.
+func _newRpcFunctionBarrier(callsCount int) RpcFunction {
+	var arrivedCount atomic.Int32
+	met := make(chan struct{})
+
+	return func(_ *json.RawMessage, _ *ResponseMetaData) (result any, re *RpcError) {
+		if arrivedCount.Add(1) == int32(callsCount) {
+			close(met)
+		}
+
+		select {
+		case <-met:
+			return true, nil
+		case <-time.After(5 * time.Second):
+			return false, nil
+		}
+	}
+}
+
+// _callBarrierConcurrently calls the barrier function the specified number of
+// times at the same time, the clients take turns. It returns results of the
+// calls.
+func _callBarrierConcurrently(clients []*Client, callsCount int) (results []bool) {
+	var wg sync.WaitGroup
+	results = make([]bool, callsCount)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = clients[i%len(clients)].Call(context.Background(), "RpcFunctionBarrier", struct{}{}, &results[i])
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// RpcFunctionCounter is an example of a streaming function. It streams numbers
+// from one to the requested number. Numbers above three are not allowed.
+func RpcFunctionCounter(params *json.RawMessage, _ *ResponseMetaData, yield func(item any) bool) (re *RpcError) {
+	var n int
+	re = ParseParameters(params, &n)
+	if re != nil {
+		return re
+	}
+
+	for i := 1; i <= n; i++ {
+		if i > 3 {
+			return NewRpcErrorByUser(1, "too many", nil)
+		}
+		if !yield(i) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// RpcFunctionFind returns ordinary Go errors depending on the identifier
+// passed in parameters.
+func RpcFunctionFind(params *json.RawMessage, _ *ResponseMetaData) (result any, err error) {
+	var id int
+	re := ParseParameters(params, &id)
+	if re != nil {
+		return nil, re.AsError()
+	}
+
+	switch id {
+	case 1:
+		return "one", nil
+	case 2:
+		return nil, fmt.Errorf("object %d: %w", id, _errNotFound)
+	case 3:
+		return nil, &_quotaError{limit: id}
+	default:
+		return nil, errors.New("disk failure")
+	}
+}
+
 // SumParams are parameters for the 'Sum' function.
 type SumParams struct {
 	A byte `json:"a"`
@@ -83,3 +201,27 @@ func RpcFunctionSum(params *json.RawMessage, _ *ResponseMetaData) (result any, r
 
 	return r, nil
 }
+
+// _newClientSettingsForUrl creates client settings for the server located at
+// the specified URL, e.g. for a test HTTP server.
+func _newClientSettingsForUrl(serverUrl string) (cs *ClientSettings, err error) {
+	var u *url.URL
+	u, err = url.Parse(serverUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	var host, portStr string
+	host, portStr, err = net.SplitHostPort(u.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	var port uint64
+	port, err = strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClientSettings(u.Scheme, host, uint16(port), "/", nil, nil, true)
+}
@@ -0,0 +1,357 @@
+package jrm1
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	ae "github.com/vault-thirteen/auxie/errors"
+	"github.com/vault-thirteen/auxie/header"
+)
+
+// Content encodings supported for compression of requests and responses.
+// The 'deflate' encoding is the 'zlib' format as required by HTTP.
+const (
+	ContentEncoding_Identity = "identity"
+	ContentEncoding_Gzip     = "gzip"
+	ContentEncoding_Deflate  = "deflate"
+)
+
+const (
+	ErrFContentEncodingIsNotSupported = "content encoding is not supported: %v"
+	ErrDecompressedDataIsTooLarge     = "decompressed data is too large"
+)
+
+// contentEncoder is a compressor of data.
+type contentEncoder interface {
+	io.WriteCloser
+
+	// Flush writes the compressed part of data to the underlying writer.
+	Flush() error
+}
+
+// isContentEncodingSupported tells whether the content encoding is supported
+// for compression.
+func isContentEncodingSupported(encoding string) bool {
+	switch encoding {
+	case ContentEncoding_Gzip, ContentEncoding_Deflate:
+		return true
+	default:
+		return false
+	}
+}
+
+// newContentEncoder creates a compressor of data written to the writer using
+// the supported content encoding.
+func newContentEncoder(encoding string, w io.Writer) (ce contentEncoder, err error) {
+	switch encoding {
+	case ContentEncoding_Gzip:
+		return gzip.NewWriter(w), nil
+	case ContentEncoding_Deflate:
+		return zlib.NewWriter(w), nil
+	default:
+		return nil, fmt.Errorf(ErrFContentEncodingIsNotSupported, encoding)
+	}
+}
+
+// newContentDecoder creates a reader decompressing data of the reader. Data
+// without encoding is read as is. The compressed format is checked when data
+// is read.
+func newContentDecoder(encoding string, r io.Reader) (rc io.ReadCloser, err error) {
+	encoding = strings.ToLower(strings.TrimSpace(encoding))
+	switch encoding {
+	case "", ContentEncoding_Identity:
+		return io.NopCloser(r), nil
+	case ContentEncoding_Gzip, ContentEncoding_Deflate:
+		return &decompressingReadCloser{encoding: encoding, r: r}, nil
+	default:
+		return nil, fmt.Errorf(ErrFContentEncodingIsNotSupported, encoding)
+	}
+}
+
+// compress compresses the data using the supported content encoding.
+func compress(encoding string, data []byte) (compressed []byte, err error) {
+	var buf bytes.Buffer
+
+	var ce contentEncoder
+	ce, err = newContentEncoder(encoding, &buf)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = ce.Write(data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ce.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// negotiateContentEncoding selects a supported content encoding accepted by
+// the client according to the value of the 'Accept-Encoding' HTTP header.
+// The encoding with the highest quality is selected, 'gzip' is preferred on
+// equal qualities. If no supported encoding is accepted, an empty string is
+// returned.
+func negotiateContentEncoding(acceptEncoding string) (encoding string) {
+	var bestQuality float64
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if !found || (strings.ToLower(strings.TrimSpace(key)) != "q") {
+				continue
+			}
+
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				q = 0
+			}
+			quality = q
+		}
+
+		if name == "*" {
+			name = ContentEncoding_Gzip
+		}
+		if !isContentEncodingSupported(name) || (quality <= 0) {
+			continue
+		}
+
+		if (quality > bestQuality) ||
+			((quality == bestQuality) && (name == ContentEncoding_Gzip)) {
+			encoding = name
+			bestQuality = quality
+		}
+	}
+
+	return encoding
+}
+
+// decompressingReadCloser is a reader decompressing data. The decompressor is
+// created on the first read, as it reads the header of compressed data.
+type decompressingReadCloser struct {
+	encoding string
+	r        io.Reader
+
+	// Decompressor. It is null before the first read.
+	rc io.ReadCloser
+}
+
+// Read is a standard method of the io.Reader interface.
+func (drc *decompressingReadCloser) Read(p []byte) (n int, err error) {
+	if drc.rc == nil {
+		switch drc.encoding {
+		case ContentEncoding_Gzip:
+			drc.rc, err = gzip.NewReader(drc.r)
+		default:
+			drc.rc, err = zlib.NewReader(drc.r)
+		}
+		if err != nil {
+			drc.rc = nil
+			return 0, err
+		}
+	}
+
+	return drc.rc.Read(p)
+}
+
+// Close is a standard method of the io.Closer interface.
+func (drc *decompressingReadCloser) Close() (err error) {
+	if drc.rc == nil {
+		return nil
+	}
+
+	return drc.rc.Close()
+}
+
+// decodedBody is a decompressed body of an HTTP message. Closing it closes
+// both the decompressor and the original body.
+type decodedBody struct {
+	io.ReadCloser
+	body io.Closer
+}
+
+// newDecodedBody decompresses the body of an HTTP message according to its
+// content encoding. Body without encoding is returned as is. Decompressed body
+// fails when it is larger than the limit.
+func newDecodedBody(encoding string, body io.ReadCloser, maxSize int64) (rc io.ReadCloser, err error) {
+	if len(encoding) == 0 {
+		return body, nil
+	}
+
+	rc, err = newContentDecoder(encoding, body)
+	if err != nil {
+		return nil, err
+	}
+	if isDecompressing(rc) {
+		rc = newLimitedReadCloser(rc, maxSize)
+	}
+
+	return &decodedBody{ReadCloser: rc, body: body}, nil
+}
+
+// isDecompressing tells whether the reader created by the content decoder
+// decompresses data. Data without encoding is read as is.
+func isDecompressing(rc io.ReadCloser) bool {
+	_, ok := rc.(*decompressingReadCloser)
+	return ok
+}
+
+// Close is a standard method of the io.Closer interface.
+func (db *decodedBody) Close() (err error) {
+	err = db.ReadCloser.Close()
+	return ae.Combine(err, db.body.Close())
+}
+
+// limitedReadCloser is a reader which fails when more than the limited
+// number of bytes is read. It protects from decompression bombs.
+type limitedReadCloser struct {
+	io.ReadCloser
+
+	// Number of bytes which may still be read.
+	remaining int64
+}
+
+// newLimitedReadCloser is a constructor of a reader which fails when more
+// than the limited number of bytes is read.
+func newLimitedReadCloser(rc io.ReadCloser, limit int64) (lrc *limitedReadCloser) {
+	return &limitedReadCloser{ReadCloser: rc, remaining: limit}
+}
+
+// Read is a standard method of the io.Reader interface.
+func (lrc *limitedReadCloser) Read(p []byte) (n int, err error) {
+	if int64(len(p)) > lrc.remaining+1 {
+		p = p[:lrc.remaining+1]
+	}
+
+	n, err = lrc.ReadCloser.Read(p)
+	if int64(n) > lrc.remaining {
+		lrc.remaining = 0
+		return 0, errors.New(ErrDecompressedDataIsTooLarge)
+	}
+	lrc.remaining -= int64(n)
+
+	return n, err
+}
+
+// compressingResponseWriter compresses a response written to the HTTP
+// response writer. Data is buffered until its size reaches the threshold.
+// Larger responses are compressed, smaller ones are written as is.
+type compressingResponseWriter struct {
+	rw        http.ResponseWriter
+	encoding  string
+	threshold int
+
+	buf *bytes.Buffer
+
+	// Compressor of the response. It is null when the response is not
+	// compressed.
+	ce contentEncoder
+
+	// Flag showing that the choice between compression and plain writing is
+	// made.
+	isDecided bool
+}
+
+// newCompressingResponseWriter is a constructor of a writer compressing the
+// response using the supported content encoding when its size reaches the
+// threshold.
+func newCompressingResponseWriter(rw http.ResponseWriter, encoding string, threshold int) (crw *compressingResponseWriter) {
+	return &compressingResponseWriter{
+		rw:        rw,
+		encoding:  encoding,
+		threshold: threshold,
+		buf:       new(bytes.Buffer),
+	}
+}
+
+// Write is a standard method of the io.Writer interface.
+func (crw *compressingResponseWriter) Write(p []byte) (n int, err error) {
+	if crw.isDecided {
+		if crw.ce != nil {
+			return crw.ce.Write(p)
+		}
+		return crw.rw.Write(p)
+	}
+
+	crw.buf.Write(p)
+	if crw.buf.Len() < crw.threshold {
+		return len(p), nil
+	}
+
+	err = crw.startCompression()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// flush writes the buffered part of the response. As the size of a flushed
+// response is not known in advance, compression is started even before the
+// threshold is reached.
+func (crw *compressingResponseWriter) flush() (err error) {
+	if !crw.isDecided {
+		err = crw.startCompression()
+		if err != nil {
+			return err
+		}
+	}
+
+	if crw.ce == nil {
+		return nil
+	}
+
+	return crw.ce.Flush()
+}
+
+// close finishes the response. A response smaller than the threshold is
+// written without compression.
+func (crw *compressingResponseWriter) close() (err error) {
+	if crw.isDecided {
+		if crw.ce != nil {
+			return crw.ce.Close()
+		}
+		return nil
+	}
+
+	crw.isDecided = true
+	if crw.buf.Len() == 0 {
+		return nil
+	}
+
+	_, err = crw.rw.Write(crw.buf.Bytes())
+	return err
+}
+
+// startCompression sets the HTTP header of content encoding and compresses
+// the buffered data.
+func (crw *compressingResponseWriter) startCompression() (err error) {
+	crw.isDecided = true
+
+	crw.rw.Header().Set(header.HttpHeaderContentEncoding, crw.encoding)
+	crw.rw.Header().Del(header.HttpHeaderContentLength)
+
+	crw.ce, err = newContentEncoder(crw.encoding, crw.rw)
+	if err != nil {
+		return err
+	}
+
+	_, err = crw.ce.Write(crw.buf.Bytes())
+	crw.buf.Reset()
+
+	return err
+}
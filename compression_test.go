@@ -0,0 +1,148 @@
+package jrm1
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/header"
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_negotiateContentEncoding(t *testing.T) {
+	aTest := tester.New(t)
+
+	type TestData struct {
+		acceptEncoding string
+		encoding       string
+	}
+
+	tests := []TestData{
+		{acceptEncoding: "", encoding: ""},
+		{acceptEncoding: "br", encoding: ""},
+		{acceptEncoding: "gzip", encoding: ContentEncoding_Gzip},
+		{acceptEncoding: "deflate, br", encoding: ContentEncoding_Deflate},
+		{acceptEncoding: "deflate, gzip", encoding: ContentEncoding_Gzip},
+		{acceptEncoding: "gzip;q=0.5, deflate", encoding: ContentEncoding_Deflate},
+		{acceptEncoding: "GZIP ; q=0.8 , deflate;q=0.2", encoding: ContentEncoding_Gzip},
+		{acceptEncoding: "gzip;q=0, deflate;q=0", encoding: ""},
+		{acceptEncoding: "*", encoding: ContentEncoding_Gzip},
+		{acceptEncoding: "gzip;q=x", encoding: ""},
+	}
+
+	for _, test := range tests {
+		aTest.MustBeEqual(negotiateContentEncoding(test.acceptEncoding), test.encoding)
+	}
+}
+
+func Test_newContentDecoder(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+	const text = `{"a":1}`
+
+	// Test #1. Compressed data.
+	for _, encoding := range []string{ContentEncoding_Gzip, ContentEncoding_Deflate} {
+		compressed, err := compress(encoding, []byte(text))
+		aTest.MustBeNoError(err)
+		rc, err := newContentDecoder(" "+strings.ToUpper(encoding), strings.NewReader(string(compressed)))
+		aTest.MustBeNoError(err)
+		data, err := io.ReadAll(rc)
+		aTest.MustBeNoError(err)
+		aTest.MustBeEqual(string(data), text)
+		aTest.MustBeNoError(rc.Close())
+	}
+
+	// Test #2. Data without encoding.
+	for _, encoding := range []string{"", ContentEncoding_Identity} {
+		rc, err := newContentDecoder(encoding, strings.NewReader(text))
+		aTest.MustBeNoError(err)
+		data, err := io.ReadAll(rc)
+		aTest.MustBeNoError(err)
+		aTest.MustBeEqual(string(data), text)
+	}
+
+	// Test #3. Corrupted data.
+	rc, err := newContentDecoder(ContentEncoding_Gzip, strings.NewReader(text))
+	aTest.MustBeNoError(err)
+	_, err = io.ReadAll(rc)
+	aTest.MustBeAnError(err)
+	aTest.MustBeNoError(rc.Close())
+
+	// Test #4. Unsupported encoding.
+	_, err = newContentDecoder("br", strings.NewReader(text))
+	aTest.MustBeAnError(err)
+	_, err = compress("br", []byte(text))
+	aTest.MustBeAnError(err)
+}
+
+func Test_limitedReadCloser(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test #1. Data within the limit.
+	data, err := io.ReadAll(newLimitedReadCloser(io.NopCloser(strings.NewReader("abc")), 3))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(data), "abc")
+
+	// Test #2. Data exceeding the limit.
+	_, err = io.ReadAll(newLimitedReadCloser(io.NopCloser(strings.NewReader("abcd")), 3))
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrDecompressedDataIsTooLarge)
+}
+
+func Test_compressingResponseWriter(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test #1. Response below the threshold.
+	recorder := httptest.NewRecorder()
+	crw := newCompressingResponseWriter(recorder, ContentEncoding_Gzip, 10)
+	_, err := crw.Write([]byte("abc"))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(recorder.Body.Len(), 0)
+	aTest.MustBeNoError(crw.close())
+	aTest.MustBeEqual(recorder.Body.String(), "abc")
+	aTest.MustBeEqual(recorder.Header().Get(header.HttpHeaderContentEncoding), "")
+
+	// Test #2. Response reaching the threshold.
+	recorder = httptest.NewRecorder()
+	crw = newCompressingResponseWriter(recorder, ContentEncoding_Deflate, 3)
+	_, err = crw.Write([]byte("ab"))
+	aTest.MustBeNoError(err)
+	_, err = crw.Write([]byte("cd"))
+	aTest.MustBeNoError(err)
+	_, err = crw.Write([]byte("ef"))
+	aTest.MustBeNoError(err)
+	aTest.MustBeNoError(crw.close())
+	aTest.MustBeEqual(recorder.Header().Get(header.HttpHeaderContentEncoding), ContentEncoding_Deflate)
+	aTest.MustBeEqual(_decompressBody(t, recorder.Result()), "abcdef")
+
+	// Test #3. Flushed response.
+	recorder = httptest.NewRecorder()
+	crw = newCompressingResponseWriter(recorder, ContentEncoding_Gzip, 1000)
+	_, err = crw.Write([]byte("abc"))
+	aTest.MustBeNoError(err)
+	aTest.MustBeNoError(crw.flush())
+	aTest.MustBeEqual(recorder.Body.Len() > 0, true)
+	aTest.MustBeNoError(crw.close())
+	aTest.MustBeEqual(_decompressBody(t, recorder.Result()), "abc")
+
+	// Test #4. Empty response.
+	recorder = httptest.NewRecorder()
+	crw = newCompressingResponseWriter(recorder, ContentEncoding_Gzip, 1000)
+	aTest.MustBeNoError(crw.close())
+	aTest.MustBeEqual(recorder.Body.Len(), 0)
+}
+
+// _decompressBody reads the decompressed body of the HTTP response.
+func _decompressBody(t *testing.T, resp *http.Response) string {
+	aTest := tester.New(t)
+
+	body, err := newDecodedBody(resp.Header.Get(header.HttpHeaderContentEncoding), resp.Body, DefaultMaxDecompressedSize)
+	aTest.MustBeNoError(err)
+	data, err := io.ReadAll(body)
+	aTest.MustBeNoError(err)
+	aTest.MustBeNoError(body.Close())
+
+	return string(data)
+}
@@ -0,0 +1,146 @@
+package jrm1
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"sync"
+)
+
+// serverConnection serves function calls received over a connection
+// transferring whole messages. It is the common part of connections of the
+// WebSocket handler and of the socket server.
+type serverConnection struct {
+	id string
+	p  *Processor
+	mc messageConn
+
+	// HTTP request which is a template for requests made of messages.
+	req *http.Request
+
+	// Slots limiting the number of concurrent calls.
+	callSlots chan struct{}
+	calls     *sync.WaitGroup
+
+	// Channel which is closed when the connection is closed.
+	closed    chan struct{}
+	closeOnce *sync.Once
+
+	// Error returned when a closed connection is closed again.
+	errAlreadyClosed error
+
+	// Flag showing that responses of the calls being executed are sent
+	// before closing when the client stops sending messages. Otherwise, the
+	// connection is closed at once.
+	isDrainedBeforeClose bool
+}
+
+// newServerConnection is a constructor of a connection served by the RPC
+// processor (server).
+func newServerConnection(p *Processor, mc messageConn, req *http.Request, maxConcurrentCalls int, errAlreadyClosed error, isDrainedBeforeClose bool) (sc *serverConnection) {
+	return &serverConnection{
+		id:                   newRandomId(),
+		p:                    p,
+		mc:                   mc,
+		req:                  req,
+		callSlots:            make(chan struct{}, maxConcurrentCalls),
+		calls:                new(sync.WaitGroup),
+		closed:               make(chan struct{}),
+		closeOnce:            new(sync.Once),
+		errAlreadyClosed:     errAlreadyClosed,
+		isDrainedBeforeClose: isDrainedBeforeClose,
+	}
+}
+
+// push sends a push message to the client.
+func (sc *serverConnection) push(topic string, data any) (err error) {
+	var buf []byte
+	buf, err = json.Marshal(NewPushMessage(topic, data))
+	if err != nil {
+		return err
+	}
+
+	return sc.mc.writeMessage(buf)
+}
+
+// close closes the connection. Calls being executed are finished, but their
+// responses are not delivered.
+func (sc *serverConnection) close() (err error) {
+	err = sc.errAlreadyClosed
+	sc.closeOnce.Do(func() {
+		close(sc.closed)
+		err = sc.mc.close()
+	})
+
+	return err
+}
+
+// serve reads messages and serves function calls until the connection is
+// closed. Calls are executed concurrently, so responses may be sent in an
+// order different from the order of requests.
+func (sc *serverConnection) serve() {
+	defer func() {
+		if sc.isDrainedBeforeClose {
+			sc.calls.Wait()
+			_ = sc.close()
+		} else {
+			_ = sc.close()
+			sc.calls.Wait()
+		}
+	}()
+
+	for {
+		data, err := sc.mc.nextMessage()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				select {
+				case <-sc.closed:
+				default:
+					sc.p.settings.getLogger().Debug(err.Error())
+				}
+			}
+			return
+		}
+
+		select {
+		case sc.callSlots <- struct{}{}:
+		case <-sc.closed:
+			return
+		}
+
+		sc.calls.Add(1)
+		go sc.serveMessage(data)
+	}
+}
+
+// serveMessage serves a message and sends the response, if any, to the
+// client. An exception which escaped from the call is journaled and the
+// connection is closed, so that it does not crash the whole program.
+func (sc *serverConnection) serveMessage(data []byte) {
+	defer func() {
+		x := recover()
+		if x != nil {
+			sc.p.logEscapedPanic("", "", x, debug.Stack())
+			_ = sc.close()
+		}
+
+		<-sc.callSlots
+		sc.calls.Done()
+	}()
+
+	// Headers describing the function call are sent inside of the message.
+	headers, data := takeMessageHeaders(data)
+	req := newMessageHttpRequest(sc.req, headers)
+
+	response := sc.p.handle(req.Context(), req, data, true)
+	if len(response) == 0 {
+		return
+	}
+
+	err := sc.mc.writeMessage(response)
+	if err != nil {
+		sc.p.settings.getLogger().Debug(err.Error())
+	}
+}
@@ -0,0 +1,43 @@
+package jrm1
+
+import (
+	"io"
+)
+
+// countingReadCloser is a reader which counts the read bytes.
+type countingReadCloser struct {
+	io.ReadCloser
+	n int64
+}
+
+// newCountingReadCloser is a constructor of a reader which counts the read
+// bytes.
+func newCountingReadCloser(rc io.ReadCloser) (crc *countingReadCloser) {
+	return &countingReadCloser{ReadCloser: rc}
+}
+
+// Read is a standard method of the io.Reader interface.
+func (crc *countingReadCloser) Read(p []byte) (n int, err error) {
+	n, err = crc.ReadCloser.Read(p)
+	crc.n += int64(n)
+	return n, err
+}
+
+// countingWriter is a writer which counts the written bytes.
+type countingWriter struct {
+	io.Writer
+	n int64
+}
+
+// newCountingWriter is a constructor of a writer which counts the written
+// bytes.
+func newCountingWriter(w io.Writer) (cw *countingWriter) {
+	return &countingWriter{Writer: w}
+}
+
+// Write is a standard method of the io.Writer interface.
+func (cw *countingWriter) Write(p []byte) (n int, err error) {
+	n, err = cw.Writer.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,34 @@
+package jrm1
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_countingReadCloser(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	crc := newCountingReadCloser(io.NopCloser(strings.NewReader("12345")))
+	data, err := io.ReadAll(crc)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(data), "12345")
+	aTest.MustBeEqual(crc.n, int64(5))
+}
+
+func Test_countingWriter(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	var buf strings.Builder
+	cw := newCountingWriter(&buf)
+	_, err := cw.Write([]byte("123"))
+	aTest.MustBeNoError(err)
+	_, err = cw.Write([]byte("45"))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(cw.n, int64(5))
+	aTest.MustBeEqual(buf.String(), "12345")
+}
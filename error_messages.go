@@ -16,7 +16,7 @@ func initErrorMessages() {
 		RpcErrorCode_UnknownMethod:        RpcErrorMsg_UnknownMethod,
 		RpcErrorCode_InvalidParameters:    RpcErrorMsg_InvalidParameters,
 		RpcErrorCode_InternalRpcError:     RpcErrorMsg_InternalRpcError,
-		RpcErrorCode_ReservedForFuture_1:  RpcErrorMsg_ReservedForFuture_1,
+		RpcErrorCode_AccessDenied:         RpcErrorMsg_AccessDenied,
 		RpcErrorCode_ReservedForFuture_2:  RpcErrorMsg_ReservedForFuture_2,
 		RpcErrorCode_ReservedForFuture_3:  RpcErrorMsg_ReservedForFuture_3,
 	}
@@ -65,6 +65,10 @@ func (s *Server) initHttpServer() (err error) {
 	return nil
 }
 
+func (s *Server) Processor() *jrm1.Processor {
+	return s.p
+}
+
 func (s *Server) RootHandler(rw http.ResponseWriter, req *http.Request) {
 	s.p.ServeHTTP(rw, req)
 }
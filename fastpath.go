@@ -0,0 +1,416 @@
+package jrm1
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sync"
+
+	ae "github.com/vault-thirteen/auxie/errors"
+)
+
+// The fast path decodes requests and encodes responses without reflection in
+// the most common cases, while the result is always the same as of the
+// standard JSON encoder and decoder. Whenever the input is unusual, e.g. a
+// string contains escape sequences, the work is passed to the standard
+// decoder or encoder.
+
+// maxPooledBufferSize is the maximum capacity of a buffer which is returned
+// into the pool. Larger buffers are left to the garbage collector, so that
+// a single huge message does not keep memory forever.
+const maxPooledBufferSize = 64 * 1024
+
+// bufferPool is a pool of buffers used by the fast path.
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// getBuffer takes an empty buffer from the pool.
+func getBuffer() (buf *bytes.Buffer) {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns the buffer into the pool.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// decodeRpcRequestFast reads the input and decodes a raw RPC request. The
+// input is closed. The result is the same as of the 'NewRpcRequest' function.
+func decodeRpcRequestFast(input io.ReadCloser) (rr *RpcRequest, err error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	_, err = buf.ReadFrom(input)
+	err = ae.Combine(err, input.Close())
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRpcRequestBytes(buf.Bytes())
+}
+
+// decodeRpcRequestBytes decodes a raw RPC request. Data is not retained by
+// the request. The result is the same as of the 'NewRpcRequest' function.
+func decodeRpcRequestBytes(data []byte) (rr *RpcRequest, err error) {
+	if json.Valid(data) {
+		var ok bool
+		rr, ok = scanRpcRequest(data)
+		if ok {
+			return rr, nil
+		}
+	}
+
+	return NewRpcRequest(io.NopCloser(bytes.NewReader(data)))
+}
+
+// scanRpcRequest extracts fields of a raw RPC request from a valid JSON text
+// without reflection. If the text can not be handled by the scanner, e.g. it
+// contains escape sequences or names of fields in another letter case, 'False'
+// is returned and the text must be decoded by the standard decoder.
+func scanRpcRequest(data []byte) (rr *RpcRequest, ok bool) {
+	s := jsonScanner{data: data}
+
+	s.skipSpaces()
+	if !s.consume('{') {
+		return nil, false
+	}
+
+	rr = new(RpcRequest)
+	s.skipSpaces()
+	if s.consume('}') {
+		return rr, true
+	}
+
+	for {
+		s.skipSpaces()
+		var key []byte
+		key, ok = s.readSimpleString()
+		if !ok {
+			return nil, false
+		}
+		s.skipSpaces()
+		s.consume(':')
+		s.skipSpaces()
+
+		switch string(key) {
+		case "jsonrpc":
+			rr.ProtocolName, ok = s.readStringField()
+		case "id":
+			rr.Id, ok = s.readStringField()
+		case "method":
+			rr.Method, ok = s.readStringField()
+		case "params":
+			rr.Parameters = s.readRawField()
+		default:
+			// Names of fields are matched by the standard decoder without
+			// regard to the letter case.
+			if isFoldedRpcRequestField(key) {
+				return nil, false
+			}
+			s.skipValue()
+		}
+		if !ok {
+			return nil, false
+		}
+
+		s.skipSpaces()
+		if s.consume('}') {
+			return rr, true
+		}
+		s.consume(',')
+	}
+}
+
+// isFoldedRpcRequestField tells whether the name matches a field of a raw RPC
+// request in another letter case.
+func isFoldedRpcRequestField(key []byte) bool {
+	for _, name := range []string{"jsonrpc", "id", "method", "params"} {
+		if bytes.EqualFold(key, []byte(name)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jsonScanner reads a valid JSON text.
+type jsonScanner struct {
+	data []byte
+	pos  int
+}
+
+// skipSpaces skips white space.
+func (s *jsonScanner) skipSpaces() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\r', '\n':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// consume skips the next byte if it is the expected one.
+func (s *jsonScanner) consume(b byte) bool {
+	if (s.pos < len(s.data)) && (s.data[s.pos] == b) {
+		s.pos++
+		return true
+	}
+
+	return false
+}
+
+// readSimpleString reads a string which contains only ASCII symbols and no
+// escape sequences. The returned slice refers to the scanned data.
+func (s *jsonScanner) readSimpleString() (str []byte, ok bool) {
+	if !s.consume('"') {
+		return nil, false
+	}
+
+	start := s.pos
+	for s.pos < len(s.data) {
+		b := s.data[s.pos]
+		switch {
+		case b == '"':
+			s.pos++
+			return s.data[start : s.pos-1], true
+		case (b == '\\') || (b >= 0x80):
+			return nil, false
+		}
+		s.pos++
+	}
+
+	return nil, false
+}
+
+// readStringField reads a value of a string field. Null is read as a null
+// pointer. Values of other types can not be handled by the scanner.
+func (s *jsonScanner) readStringField() (value *string, ok bool) {
+	if s.consumeNull() {
+		return nil, true
+	}
+
+	var str []byte
+	str, ok = s.readSimpleString()
+	if !ok {
+		return nil, false
+	}
+
+	v := string(str)
+	return &v, true
+}
+
+// readRawField reads a value of a raw field. Null is read as a null pointer.
+// The value is copied.
+func (s *jsonScanner) readRawField() (value *json.RawMessage) {
+	if s.consumeNull() {
+		return nil
+	}
+
+	start := s.pos
+	s.skipValue()
+	raw := json.RawMessage(bytes.Clone(s.data[start:s.pos]))
+
+	return &raw
+}
+
+// consumeNull skips the next value if it is null.
+func (s *jsonScanner) consumeNull() bool {
+	if bytes.HasPrefix(s.data[s.pos:], []byte("null")) {
+		s.pos += len("null")
+		return true
+	}
+
+	return false
+}
+
+// skipValue skips the next value.
+func (s *jsonScanner) skipValue() {
+	depth := 0
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case '"':
+			s.skipString()
+			if depth == 0 {
+				return
+			}
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth == 0 {
+				return
+			}
+			depth--
+			if depth == 0 {
+				s.pos++
+				return
+			}
+		case ',', ' ', '\t', '\r', '\n', ':':
+			if depth == 0 {
+				return
+			}
+		}
+		s.pos++
+	}
+}
+
+// skipString skips the next string.
+func (s *jsonScanner) skipString() {
+	s.pos++
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case '\\':
+			s.pos += 2
+			continue
+		case '"':
+			s.pos++
+			return
+		}
+		s.pos++
+	}
+}
+
+// appendRpcResponses encodes the response or the array of responses. The
+// result is the same as of the standard JSON encoder, including the new line
+// symbol at the end. If the value is not a response, 'False' is returned and
+// nothing is written.
+func appendRpcResponses(buf *bytes.Buffer, v any) (ok bool, err error) {
+	switch x := v.(type) {
+	case *RpcResponse:
+		err = appendRpcResponse(buf, x)
+
+	case []*RpcResponse:
+		if x == nil {
+			buf.WriteString("null\n")
+			return true, nil
+		}
+
+		buf.WriteByte('[')
+		for i, resp := range x {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			err = appendRpcResponse(buf, resp)
+			if err != nil {
+				break
+			}
+		}
+		buf.WriteByte(']')
+
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return true, err
+	}
+
+	buf.WriteByte('\n')
+	return true, nil
+}
+
+// appendRpcResponse encodes the RPC response without reflection, except for
+// values which are not pre-encoded.
+func appendRpcResponse(buf *bytes.Buffer, resp *RpcResponse) (err error) {
+	if resp == nil {
+		buf.WriteString("null")
+		return nil
+	}
+
+	buf.WriteString(`{"jsonrpc":`)
+	err = appendJsonString(buf, resp.ProtocolName, true)
+	if err != nil {
+		return err
+	}
+
+	buf.WriteString(`,"id":`)
+	if resp.Id == nil {
+		buf.WriteString("null")
+	} else {
+		err = appendJsonString(buf, *resp.Id, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	buf.WriteString(`,"result":`)
+	err = appendJsonResult(buf, resp.Result)
+	if err != nil {
+		return err
+	}
+
+	buf.WriteString(`,"error":`)
+	if resp.Error == nil {
+		buf.WriteString("null")
+	} else {
+		err = appendJsonValue(buf, resp.Error, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	if resp.Meta != nil {
+		buf.WriteString(`,"meta":`)
+		err = appendJsonValue(buf, resp.Meta, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	if resp.OK {
+		buf.WriteString(`,"ok":true}`)
+	} else {
+		buf.WriteString(`,"ok":false}`)
+	}
+
+	return nil
+}
+
+// appendJsonResult encodes the result of a function. Pre-encoded results,
+// i.e. raw messages and values implementing the json.Marshaler interface, are
+// written as is when they are already compact and need no escaping. Other
+// results are encoded by the standard encoder.
+func appendJsonResult(buf *bytes.Buffer, result any) (err error) {
+	var raw []byte
+	switch x := result.(type) {
+	case nil:
+		buf.WriteString("null")
+		return nil
+
+	case json.RawMessage:
+		raw = x
+
+	case json.Marshaler:
+		// The standard encoder writes null for null pointers.
+		rv := reflect.ValueOf(x)
+		if (rv.Kind() == reflect.Pointer) && rv.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+
+		raw, err = x.MarshalJSON()
+		if err != nil {
+			return appendJsonValue(buf, result, true)
+		}
+
+	default:
+		return appendJsonValue(buf, result, true)
+	}
+
+	if !isCompactJson(raw) {
+		return appendJsonValue(buf, result, true)
+	}
+
+	buf.Write(raw)
+	return nil
+}
@@ -0,0 +1,199 @@
+package jrm1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+// _rawResult is a pre-encoded result implementing the json.Marshaler
+// interface.
+type _rawResult struct {
+	data string
+	err  error
+}
+
+func (rr *_rawResult) MarshalJSON() ([]byte, error) {
+	return []byte(rr.data), rr.err
+}
+
+func Test_getBuffer(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test #1. Buffer is empty.
+	buf := getBuffer()
+	aTest.MustBeEqual(buf.Len(), 0)
+	buf.WriteString("abc")
+	putBuffer(buf)
+	buf = getBuffer()
+	aTest.MustBeEqual(buf.Len(), 0)
+	putBuffer(buf)
+
+	// Test #2. Large buffer is not pooled.
+	buf = bytes.NewBuffer(make([]byte, 0, maxPooledBufferSize+1))
+	putBuffer(buf)
+}
+
+func Test_decodeRpcRequestBytes(t *testing.T) {
+	aTest := tester.New(t)
+
+	type TestData struct {
+		data      string
+		isScanned bool
+	}
+
+	tests := []TestData{
+		// Common requests.
+		{data: `{"jsonrpc":"M1","id":"1","method":"Sum","params":{"a":1,"b":[2,"}"]}}`, isScanned: true},
+		{data: " \n{ \"params\" : [ 1 , {} ] , \"id\" : \"2\" }\n", isScanned: true},
+		{data: `{"x":{"y":["\"",{}]},"z":-1.5e3,"w":true,"id":"3","params":"s"}`, isScanned: true},
+		{data: `{"jsonrpc":null,"id":"1","params":null}`, isScanned: true},
+		{data: `{"id":"1","id":"2","params":1,"params":[]}`, isScanned: true},
+		{data: `{}`, isScanned: true},
+
+		// Requests passed to the standard decoder.
+		{data: `{"id":"\u0031"}`},
+		{data: `{"method":"Ϩ"}`},
+		{data: `{"Id":"1"}`},
+		{data: `{"ид":"1"}`},
+		{data: `{"id":1}`},
+		{data: `[]`},
+		{data: `null`},
+		{data: `{"id":"1"} {}`},
+		{data: `{"id":"1"`},
+		{data: ``},
+	}
+
+	for _, test := range tests {
+		_, isScanned := scanRpcRequest([]byte(test.data))
+		aTest.MustBeEqual(isScanned && json.Valid([]byte(test.data)), test.isScanned)
+
+		rrExpected, errExpected := NewRpcRequest(io.NopCloser(bytes.NewReader([]byte(test.data))))
+		rr, err := decodeRpcRequestBytes([]byte(test.data))
+		aTest.MustBeEqual(err != nil, errExpected != nil)
+		aTest.MustBeEqual(rr, rrExpected)
+	}
+
+	// Parameters do not refer to the data.
+	data := []byte(`{"params":[1]}`)
+	rr, err := decodeRpcRequestBytes(data)
+	aTest.MustBeNoError(err)
+	data[11] = '2'
+	aTest.MustBeEqual(string(*rr.Parameters), `[1]`)
+}
+
+func Test_decodeRpcRequestFast(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	// Test #1. Positive.
+	rr, err := decodeRpcRequestFast(io.NopCloser(bytes.NewReader([]byte(`{"id":"1"}`))))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(*rr.Id, "1")
+
+	// Test #2. Negative. Bad closing.
+	_, err = decodeRpcRequestFast(_badCloser{bytes.NewBufferString(`{}`)})
+	aTest.MustBeAnError(err)
+}
+
+func Test_appendRpcResponses(t *testing.T) {
+	aTest := tester.New(t)
+
+	id, specialId := "1", "<\"\n ü"
+	meta := ResponseMetaData{"b": 2, "a": "x"}
+	tests := []any{
+		&RpcResponse{ProtocolName: ProtocolNameM1},
+		&RpcResponse{ProtocolName: ProtocolNameM1, Id: &id, Result: map[string]int{"c": 3}, OK: true},
+		&RpcResponse{ProtocolName: ProtocolNameM1, Id: &specialId, Result: "<b>", Meta: &meta},
+		&RpcResponse{ProtocolName: ProtocolNameM1, Id: &id, Error: NewRpcErrorByUser(1, "overflow", []int{1})},
+		&RpcResponse{ProtocolName: ProtocolNameM1, Result: json.RawMessage(`{"a":[1,"x"]}`)},
+		&RpcResponse{ProtocolName: ProtocolNameM1, Result: json.RawMessage(` { "a" : "<" } `)},
+		&RpcResponse{ProtocolName: ProtocolNameM1, Result: json.RawMessage(nil)},
+		&RpcResponse{ProtocolName: ProtocolNameM1, Result: &_rawResult{data: `[1,2]`}},
+		&RpcResponse{ProtocolName: ProtocolNameM1, Result: &_rawResult{data: "\" \""}},
+		&RpcResponse{ProtocolName: ProtocolNameM1, Result: (*_rawResult)(nil)},
+		&RpcResponse{ProtocolName: ProtocolNameM1, Result: &meta},
+		[]*RpcResponse{{ProtocolName: ProtocolNameM1, Id: &id}, nil, {OK: true}},
+		[]*RpcResponse{},
+		[]*RpcResponse(nil),
+	}
+
+	for _, v := range tests {
+		var expected bytes.Buffer
+		err := json.NewEncoder(&expected).Encode(v)
+		aTest.MustBeNoError(err)
+
+		var buf bytes.Buffer
+		ok, err := appendRpcResponses(&buf, v)
+		aTest.MustBeEqual(ok, true)
+		aTest.MustBeNoError(err)
+		aTest.MustBeEqual(buf.String(), expected.String())
+	}
+
+	// Test. Value is not a response.
+	var buf bytes.Buffer
+	ok, err := appendRpcResponses(&buf, "x")
+	aTest.MustBeEqual(ok, false)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(buf.Len(), 0)
+
+	// Test. Results which are not encoded.
+	for _, result := range []any{&_rawResult{data: `[1,`}, &_rawResult{err: errors.New("error")}, func() {}} {
+		ok, err = appendRpcResponses(&buf, &RpcResponse{Result: result})
+		aTest.MustBeEqual(ok, true)
+		aTest.MustBeAnError(err)
+	}
+}
+
+func Test_Processor_fastPath(t *testing.T) {
+	aTest := tester.New(t)
+
+	messages := []string{
+		`{"jsonrpc":"M1","id":"1","method":"RpcFunctionSum","params":{"a":1,"b":2}}`,
+		`{"jsonrpc":"M1","id":"2","method":"RpcFunctionSum","params":{"a":200,"b":100}}`,
+		`{"jsonrpc":"M1","id":"3","method":"RpcFunctionSum","params":{"a":1,"b":1}}`,
+		`{"jsonrpc":"M1","id":"4","method":"NoSuchFunction","params":{}}`,
+		`{"jsonrpc":"M1","id":"5","method":"RpcFunctionSum"}`,
+		`[{"jsonrpc":"M1","id":"6","method":"RpcFunctionSum","params":{"a":2,"b":2}},{"id":"7"}]`,
+		`{`,
+	}
+
+	var processors []*Processor
+	for _, isFast := range []bool{false, true} {
+		p, err := NewProcessor(&ProcessorSettings{EnableBatches: true, EnableFastPath: isFast})
+		aTest.MustBeNoError(err)
+		err = p.AddFunc(RpcFunctionSum)
+		aTest.MustBeNoError(err)
+		processors = append(processors, p)
+	}
+
+	// Responses are the same with and without the fast path.
+	for _, message := range messages {
+		expected := processors[0].Handle(context.Background(), []byte(message))
+		received := processors[1].Handle(context.Background(), []byte(message))
+		aTest.MustBeEqual(string(received), string(expected))
+	}
+}
+
+func Test_Processor_fastPathJsonProfile(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Pretty responses are the same with and without the fast path.
+	message := `{"jsonrpc":"M1","id":"1","method":"RpcFunctionSum","params":{"a":1,"b":2}}`
+	var responses []string
+	for _, isFast := range []bool{false, true} {
+		p, err := NewProcessor(&ProcessorSettings{EnableFastPath: isFast, JsonProfile: JsonProfile_Pretty})
+		aTest.MustBeNoError(err)
+		err = p.AddFunc(RpcFunctionSum)
+		aTest.MustBeNoError(err)
+		responses = append(responses, string(p.Handle(context.Background(), []byte(message))))
+	}
+
+	aTest.MustBeEqual(responses[1], responses[0])
+	aTest.MustBeEqual(responses[0], "{\n\t\"jsonrpc\": \"M1\",\n\t\"id\": \"1\",\n\t\"result\": {\n\t\t\"c\": 3\n\t},\n\t\"error\": null,\n\t\"ok\": true\n}")
+}
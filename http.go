@@ -2,36 +2,103 @@ package jrm1
 
 import (
 	"net/http"
+	"strconv"
 
 	mime "github.com/vault-thirteen/auxie/MIME"
 	h "github.com/vault-thirteen/auxie/header"
 	hh "github.com/vault-thirteen/auxie/http-helper"
 )
 
-// checkHttpRequest checks the HTTP request and responds on error.
+// HttpHeaderNotification is an HTTP header which marks a function call as a
+// notification, i.e. a call whose result is not awaited by the client.
+const HttpHeaderNotification = "X-M1-Notification"
+
+// checkHttpRequest checks the HTTP request and responds on error. Codecs of
+// the request and of the response are selected from the supported codecs
+// according to the 'Content-Type' and 'Accept' HTTP headers.
 // If the request is correct and ready to be processed, 'True' is returned.
 // When 'False' is returned, the caller must stop serving the request.
-func checkHttpRequest(rw http.ResponseWriter, req *http.Request) (proceed bool) {
+func checkHttpRequest(rw http.ResponseWriter, req *http.Request, codecs []Codec) (requestCodec Codec, responseCodec Codec, proceed bool) {
 	// 1. Check HTTP method.
 	if req.Method != http.MethodPost {
 		rw.WriteHeader(http.StatusMethodNotAllowed)
-		return false
+		return nil, nil, false
 	}
 
 	// 2. Check HTTP content type.
 	ctype, err := hh.GetSingleHttpHeader(req, h.HttpHeaderContentType)
-	if (err != nil) || (ctype != mime.TypeApplicationJson) {
+	if err == nil {
+		requestCodec = findCodec(codecs, ctype)
+	}
+	if requestCodec == nil {
 		rw.WriteHeader(http.StatusUnsupportedMediaType)
-		return false
+		return nil, nil, false
 	}
 
 	// 3. Check accepted HTTP content type.
-	var ok bool
-	ok, err = hh.CheckBrowserSupportForJson(req)
-	if (err != nil) || !ok {
+	responseCodec, err = negotiateResponseCodec(req, codecs, requestCodec)
+	if (err != nil) || (responseCodec == nil) {
 		rw.WriteHeader(http.StatusNotAcceptable)
+		return nil, nil, false
+	}
+
+	return requestCodec, responseCodec, true
+}
+
+// findCodec searches for a codec by the type of content. If the codec is not
+// found, null is returned.
+func findCodec(codecs []Codec, contentType string) (codec Codec) {
+	for _, codec = range codecs {
+		if codec.ContentType() == contentType {
+			return codec
+		}
+	}
+
+	return nil
+}
+
+// negotiateResponseCodec selects a codec of the response accepted by the
+// client according to the 'Accept' HTTP header. Accepted types are checked in
+// the order of their weights. A wildcard type selects the codec of the
+// request. If no supported codec is accepted, null is returned.
+func negotiateResponseCodec(req *http.Request, codecs []Codec, requestCodec Codec) (responseCodec Codec, err error) {
+	var accept string
+	accept, err = hh.GetSingleHttpHeader(req, h.HttpHeaderAccept)
+	if err != nil {
+		return nil, err
+	}
+
+	var amts *hh.AcceptedMimeTypes
+	amts, err = hh.NewAcceptedMimeTypesFromHeader(accept)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		amt, nerr := amts.Next()
+		if nerr != nil {
+			return nil, nil
+		}
+
+		switch amt.MimeType {
+		case mime.TypeApplicationAny, mime.TypeAny:
+			return requestCodec, nil
+		}
+
+		responseCodec = findCodec(codecs, amt.MimeType)
+		if responseCodec != nil {
+			return responseCodec, nil
+		}
+	}
+}
+
+// isNotificationRequested tells whether the HTTP request marks the function
+// call as a notification.
+func isNotificationRequested(req *http.Request) bool {
+	isNotification, err := strconv.ParseBool(req.Header.Get(HttpHeaderNotification))
+	if err != nil {
 		return false
 	}
 
-	return true
+	return isNotification
 }
@@ -3,6 +3,7 @@ package jrm1
 import (
 	"bytes"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	mime "github.com/vault-thirteen/auxie/MIME"
@@ -18,6 +19,7 @@ func Test_checkHttpRequest(t *testing.T) {
 	var proceed bool
 	var headersIn, headersOut http.Header
 	var at hh.AverageTest
+	codecs := []Codec{NewJsonCodec()}
 
 	// 1.
 	{
@@ -30,7 +32,7 @@ func Test_checkHttpRequest(t *testing.T) {
 				RequestHeaders: headersIn,
 				RequestBody:    bytes.NewReader([]byte{}),
 				RequestHandler: func(rw http.ResponseWriter, req *http.Request) {
-					proceed = checkHttpRequest(rw, req)
+					_, _, proceed = checkHttpRequest(rw, req, codecs)
 				},
 			},
 			ResultExpected: hh.AverageTestResult{
@@ -58,7 +60,7 @@ func Test_checkHttpRequest(t *testing.T) {
 				RequestHeaders: headersIn,
 				RequestBody:    bytes.NewReader([]byte{}),
 				RequestHandler: func(rw http.ResponseWriter, req *http.Request) {
-					proceed = checkHttpRequest(rw, req)
+					_, _, proceed = checkHttpRequest(rw, req, codecs)
 				},
 			},
 			ResultExpected: hh.AverageTestResult{
@@ -87,7 +89,7 @@ func Test_checkHttpRequest(t *testing.T) {
 				RequestHeaders: headersIn,
 				RequestBody:    bytes.NewReader([]byte{}),
 				RequestHandler: func(rw http.ResponseWriter, req *http.Request) {
-					proceed = checkHttpRequest(rw, req)
+					_, _, proceed = checkHttpRequest(rw, req, codecs)
 				},
 			},
 			ResultExpected: hh.AverageTestResult{
@@ -116,7 +118,7 @@ func Test_checkHttpRequest(t *testing.T) {
 				RequestHeaders: headersIn,
 				RequestBody:    bytes.NewReader([]byte{}),
 				RequestHandler: func(rw http.ResponseWriter, req *http.Request) {
-					proceed = checkHttpRequest(rw, req)
+					_, _, proceed = checkHttpRequest(rw, req, codecs)
 				},
 			},
 			ResultExpected: hh.AverageTestResult{
@@ -132,3 +134,78 @@ func Test_checkHttpRequest(t *testing.T) {
 		aTest.MustBeEqual(proceed, true)
 	}
 }
+
+func Test_findCodec(t *testing.T) {
+	aTest := tester.New(t)
+	codecs := []Codec{NewJsonCodec(), NewMessagePackCodec()}
+
+	// Test #1. Codec is found.
+	aTest.MustBeEqual(findCodec(codecs, MessagePackContentType), Codec(codecs[1]))
+
+	// Test #2. Codec is not found.
+	aTest.MustBeEqual(findCodec(codecs, CborContentType), nil)
+}
+
+func Test_negotiateResponseCodec(t *testing.T) {
+	aTest := tester.New(t)
+	jsonCodec, msgpackCodec, cborCodec := NewJsonCodec(), NewMessagePackCodec(), NewCborCodec()
+	codecs := []Codec{jsonCodec, msgpackCodec, cborCodec}
+	var req *http.Request
+	var codec Codec
+	var err error
+
+	// Test #1. Accept header is missing.
+	req = httptest.NewRequest(http.MethodPost, "http://example.org", nil)
+	_, err = negotiateResponseCodec(req, codecs, cborCodec)
+	aTest.MustBeAnError(err)
+
+	// Test #2. Wildcard selects the codec of the request.
+	req.Header.Set(header.HttpHeaderAccept, mime.TypeAny)
+	codec, err = negotiateResponseCodec(req, codecs, cborCodec)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(codec, Codec(cborCodec))
+
+	// Test #3. Type with the highest weight is selected.
+	req.Header.Set(header.HttpHeaderAccept, "application/cbor;q=0.5, application/msgpack")
+	codec, err = negotiateResponseCodec(req, codecs, cborCodec)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(codec, Codec(msgpackCodec))
+
+	// Test #4. Unsupported types are skipped.
+	req.Header.Set(header.HttpHeaderAccept, "text/plain, application/json;q=0.1")
+	codec, err = negotiateResponseCodec(req, codecs, cborCodec)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(codec, Codec(jsonCodec))
+
+	// Test #5. No supported type is accepted.
+	req.Header.Set(header.HttpHeaderAccept, mime.TypeTextPlain)
+	codec, err = negotiateResponseCodec(req, codecs, cborCodec)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(codec, nil)
+
+	// Test #6. Accept header is malformed.
+	req.Header.Set(header.HttpHeaderAccept, "application/json;x;y")
+	_, err = negotiateResponseCodec(req, codecs, cborCodec)
+	aTest.MustBeAnError(err)
+}
+
+func Test_isNotificationRequested(t *testing.T) {
+	aTest := tester.New(t)
+	var req *http.Request
+
+	// Test #1. No header.
+	req = httptest.NewRequest(http.MethodPost, "http://example.org", nil)
+	aTest.MustBeEqual(isNotificationRequested(req), false)
+
+	// Test #2. Header is set.
+	req.Header.Set(HttpHeaderNotification, "true")
+	aTest.MustBeEqual(isNotificationRequested(req), true)
+
+	// Test #3. Header is disabled.
+	req.Header.Set(HttpHeaderNotification, "0")
+	aTest.MustBeEqual(isNotificationRequested(req), false)
+
+	// Test #4. Header is not readable.
+	req.Header.Set(HttpHeaderNotification, "maybe")
+	aTest.MustBeEqual(isNotificationRequested(req), false)
+}
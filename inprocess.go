@@ -0,0 +1,106 @@
+package jrm1
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// inProcessResponseWriter is an HTTP response writer of the in-process
+// transport. Its header is passed to the client when the processor writes it,
+// while the body is passed through a pipe.
+type inProcessResponseWriter struct {
+	header http.Header
+
+	// Status code and header of the response, sent to the client.
+	statusCode int
+	sentHeader http.Header
+
+	// Error which has prevented the response.
+	err error
+
+	headerCh   chan struct{}
+	headerOnce *sync.Once
+
+	pr *io.PipeReader
+	pw *io.PipeWriter
+}
+
+// newInProcessResponseWriter is a constructor of the response writer of the
+// in-process transport.
+func newInProcessResponseWriter() (rw *inProcessResponseWriter) {
+	rw = &inProcessResponseWriter{
+		header:     make(http.Header),
+		headerCh:   make(chan struct{}),
+		headerOnce: new(sync.Once),
+	}
+	rw.pr, rw.pw = io.Pipe()
+
+	return rw
+}
+
+// Header returns the header of the response.
+// 'Header' is a required method of the 'http.ResponseWriter' interface.
+func (rw *inProcessResponseWriter) Header() http.Header {
+	return rw.header
+}
+
+// WriteHeader sends the header of the response with the status code.
+// 'WriteHeader' is a required method of the 'http.ResponseWriter' interface.
+func (rw *inProcessResponseWriter) WriteHeader(statusCode int) {
+	rw.headerOnce.Do(func() {
+		rw.statusCode = statusCode
+		rw.sentHeader = rw.header.Clone()
+		close(rw.headerCh)
+	})
+}
+
+// Write writes a part of the body. It blocks until the client reads it.
+// 'Write' is a required method of the 'http.ResponseWriter' interface.
+func (rw *inProcessResponseWriter) Write(data []byte) (n int, err error) {
+	rw.WriteHeader(http.StatusOK)
+
+	return rw.pw.Write(data)
+}
+
+// Flush does nothing, while the written data is already passed to the client.
+// 'Flush' is a required method of the 'http.Flusher' interface.
+func (rw *inProcessResponseWriter) Flush() {}
+
+// finish completes the response.
+func (rw *inProcessResponseWriter) finish() {
+	rw.WriteHeader(http.StatusOK)
+	_ = rw.pw.Close()
+}
+
+// fail aborts the response with an error. If the header is already sent, the
+// client gets the error while reading the body.
+func (rw *inProcessResponseWriter) fail(err error) {
+	rw.headerOnce.Do(func() {
+		rw.err = err
+		close(rw.headerCh)
+	})
+	_ = rw.pw.CloseWithError(err)
+}
+
+// cancel stops the writing of the response, e.g. when the client does not
+// wait for it any more.
+func (rw *inProcessResponseWriter) cancel(err error) {
+	_ = rw.pr.CloseWithError(err)
+}
+
+// newHttpResponse creates an HTTP response for the client. It must be called
+// after the header is sent.
+func (rw *inProcessResponseWriter) newHttpResponse(req *http.Request) (resp *http.Response) {
+	return &http.Response{
+		Status:        http.StatusText(rw.statusCode),
+		StatusCode:    rw.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rw.sentHeader,
+		Body:          rw.pr,
+		ContentLength: -1,
+		Request:       req,
+	}
+}
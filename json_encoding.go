@@ -0,0 +1,127 @@
+package jrm1
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Helpers of JSON encoding write common values without reflection, while the
+// result is the same as of the standard JSON encoder. Other values are
+// encoded by the standard encoder.
+
+// appendJsonString encodes the string. Strings which need escaping are
+// encoded by the standard encoder.
+func appendJsonString(buf *bytes.Buffer, s string, escapeHtml bool) (err error) {
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		if (b < 0x20) || (b >= 0x80) || (b == '"') || (b == '\\') ||
+			(escapeHtml && ((b == '<') || (b == '>') || (b == '&'))) {
+			return appendJsonValue(buf, s, escapeHtml)
+		}
+	}
+
+	buf.WriteByte('"')
+	buf.WriteString(s)
+	buf.WriteByte('"')
+
+	return nil
+}
+
+// appendJsonStringPtr encodes the string. Null pointer is encoded as null.
+func appendJsonStringPtr(buf *bytes.Buffer, s *string, escapeHtml bool) (err error) {
+	if s == nil {
+		buf.WriteString("null")
+		return nil
+	}
+
+	return appendJsonString(buf, *s, escapeHtml)
+}
+
+// appendJsonRaw writes the raw JSON text. Text which is not compact is
+// compacted. Null message is encoded as null.
+func appendJsonRaw(buf *bytes.Buffer, raw json.RawMessage, escapeHtml bool) (err error) {
+	if raw == nil {
+		buf.WriteString("null")
+		return nil
+	}
+
+	if isCompactJson(raw) {
+		buf.Write(raw)
+		return nil
+	}
+
+	return appendJsonValue(buf, raw, escapeHtml)
+}
+
+// isCompactJson tells whether the text is a valid JSON text which the
+// standard encoder writes as is, i.e. it has no white space and no symbols
+// which are escaped.
+func isCompactJson(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+
+	for _, b := range data {
+		switch b {
+		case ' ', '\t', '\r', '\n', '<', '>', '&', 0xe2:
+			return false
+		}
+	}
+
+	return json.Valid(data)
+}
+
+// appendJsonValue encodes the value by the standard encoder.
+func appendJsonValue(buf *bytes.Buffer, v any, escapeHtml bool) (err error) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(escapeHtml)
+	err = enc.Encode(v)
+	if err != nil {
+		return err
+	}
+
+	// The encoder writes a new line symbol at the end.
+	buf.Truncate(buf.Len() - 1)
+
+	return nil
+}
+
+// appendRpcRequest encodes the RPC request. Parameters are written as is
+// when they are already compact.
+func appendRpcRequest(buf *bytes.Buffer, rr *RpcRequest, escapeHtml bool) (err error) {
+	if rr == nil {
+		buf.WriteString("null")
+		return nil
+	}
+
+	buf.WriteString(`{"jsonrpc":`)
+	err = appendJsonStringPtr(buf, rr.ProtocolName, escapeHtml)
+	if err != nil {
+		return err
+	}
+
+	buf.WriteString(`,"id":`)
+	err = appendJsonStringPtr(buf, rr.Id, escapeHtml)
+	if err != nil {
+		return err
+	}
+
+	buf.WriteString(`,"method":`)
+	err = appendJsonStringPtr(buf, rr.Method, escapeHtml)
+	if err != nil {
+		return err
+	}
+
+	buf.WriteString(`,"params":`)
+	if rr.Parameters == nil {
+		buf.WriteString("null")
+	} else {
+		err = appendJsonRaw(buf, *rr.Parameters, escapeHtml)
+		if err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+
+	return nil
+}
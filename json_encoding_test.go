@@ -0,0 +1,72 @@
+package jrm1
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_appendJsonString(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Output is the same as of a JSON encoder.
+	for _, s := range []string{"", "abc", "<a&b>", "\"\\", "\t", "ü", " "} {
+		for _, escapeHtml := range []bool{false, true} {
+			var expected bytes.Buffer
+			enc := json.NewEncoder(&expected)
+			enc.SetEscapeHTML(escapeHtml)
+			aTest.MustBeNoError(enc.Encode(s))
+
+			var buf bytes.Buffer
+			aTest.MustBeNoError(appendJsonString(&buf, s, escapeHtml))
+			aTest.MustBeEqual(buf.String()+"\n", expected.String())
+		}
+	}
+}
+
+func Test_appendJsonRaw(t *testing.T) {
+	aTest := tester.New(t)
+	var buf bytes.Buffer
+
+	// Test #1. Null message.
+	aTest.MustBeNoError(appendJsonRaw(&buf, nil, true))
+	aTest.MustBeEqual(buf.String(), "null")
+
+	// Test #2. Compact message.
+	buf.Reset()
+	aTest.MustBeNoError(appendJsonRaw(&buf, json.RawMessage(`{"a":[1]}`), true))
+	aTest.MustBeEqual(buf.String(), `{"a":[1]}`)
+
+	// Test #3. Message which is not compact.
+	buf.Reset()
+	aTest.MustBeNoError(appendJsonRaw(&buf, json.RawMessage(` { "a" : "<" } `), false))
+	aTest.MustBeEqual(buf.String(), `{"a":"<"}`)
+
+	// Test #4. Negative.
+	buf.Reset()
+	aTest.MustBeAnError(appendJsonRaw(&buf, json.RawMessage(`{`), true))
+}
+
+func Test_appendRpcRequest(t *testing.T) {
+	aTest := tester.New(t)
+
+	pn, id, m := ProtocolNameM1, "<1>", "m"
+	params := json.RawMessage(`[1, 2]`)
+	tests := []*RpcRequest{
+		nil,
+		{},
+		{ProtocolName: &pn, Id: &id, Method: &m, Parameters: &params},
+	}
+
+	// Output is the same as of a JSON encoder.
+	for _, rr := range tests {
+		var expected bytes.Buffer
+		aTest.MustBeNoError(json.NewEncoder(&expected).Encode(rr))
+
+		var buf bytes.Buffer
+		aTest.MustBeNoError(appendRpcRequest(&buf, rr, true))
+		aTest.MustBeEqual(buf.String()+"\n", expected.String())
+	}
+}
@@ -0,0 +1,183 @@
+package jrm1
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/vault-thirteen/auxie/header"
+)
+
+const (
+	// MessageFieldHeaders is a name of the field of a request sent over a
+	// transport of messages, e.g. WebSocket, which contains HTTP headers of
+	// the function call. Such transports have no headers of their own, while
+	// marks of notifications, trace context and locales belong to a single
+	// call rather than to the whole connection.
+	MessageFieldHeaders = "headers"
+
+	// MessageFieldNotification is a name of the field of a message with which
+	// the RPC server acknowledges a notification received over a transport of
+	// messages.
+	MessageFieldNotification = "notification"
+)
+
+const (
+	NotificationStatus_Accepted = "accepted"
+	NotificationStatus_Refused  = "refused"
+)
+
+const (
+	ErrMessageIsNotAnObject = "message is not an object"
+)
+
+// messageHeaderNames are names of HTTP headers which describe a single
+// function call and are sent inside of messages.
+var messageHeaderNames = []string{
+	HttpHeaderNotification,
+	HttpHeaderTraceParent,
+	HttpHeaderTraceState,
+	header.HttpHeaderAcceptLanguage,
+}
+
+// notificationAck is a message acknowledging a notification.
+type notificationAck struct {
+	ProtocolName string  `json:"jsonrpc"`
+	Id           *string `json:"id"`
+	Status       string  `json:"notification"`
+}
+
+// newNotificationAck creates a message acknowledging the notification with the
+// specified ID.
+func newNotificationAck(id *string, outcome callOutcome) (data []byte, err error) {
+	ack := &notificationAck{
+		ProtocolName: ProtocolNameM1,
+		Id:           id,
+		Status:       NotificationStatus_Accepted,
+	}
+	if outcome == callOutcome_Refused {
+		ack.Status = NotificationStatus_Refused
+	}
+
+	return json.Marshal(ack)
+}
+
+// addMessageHeaders puts the HTTP headers describing the function call into
+// the message containing the request. When the HTTP request has no such
+// headers, the message is not changed.
+func addMessageHeaders(data []byte, req *http.Request) (result []byte, err error) {
+	headers := make(map[string]string)
+	for _, name := range messageHeaderNames {
+		value := req.Header.Get(name)
+		if len(value) > 0 {
+			headers[name] = value
+		}
+	}
+	if len(headers) == 0 {
+		return data, nil
+	}
+
+	start := bytes.IndexByte(data, '{')
+	if (start < 0) || (len(bytes.TrimSpace(data[:start])) > 0) {
+		return nil, errors.New(ErrMessageIsNotAnObject)
+	}
+
+	var field []byte
+	field, err = json.Marshal(map[string]any{MessageFieldHeaders: headers})
+	if err != nil {
+		return nil, err
+	}
+
+	// Field is inserted as the first field of the object. The request has an
+	// ID, so the object is not empty.
+	result = make([]byte, 0, len(data)+len(field))
+	result = append(result, data[:start+1]...)
+	result = append(result, field[1:len(field)-1]...)
+	result = append(result, ',')
+	result = append(result, data[start+1:]...)
+
+	return result, nil
+}
+
+// takeMessageHeaders removes the field with HTTP headers from the message
+// containing the request and returns the headers. Only the headers describing
+// the function call are taken. When the message is not an object or has no
+// such field, the message is not changed.
+func takeMessageHeaders(data []byte) (headers http.Header, result []byte) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	token, err := decoder.Token()
+	if (err != nil) || (token != json.Delim('{')) {
+		return nil, data
+	}
+
+	isFirst := true
+	for decoder.More() {
+		start := int(decoder.InputOffset())
+
+		token, err = decoder.Token()
+		if err != nil {
+			return nil, data
+		}
+
+		var value json.RawMessage
+		err = decoder.Decode(&value)
+		if err != nil {
+			return nil, data
+		}
+
+		if token != MessageFieldHeaders {
+			isFirst = false
+			continue
+		}
+
+		var fields map[string]string
+		err = json.Unmarshal(value, &fields)
+		if err != nil {
+			return nil, data
+		}
+
+		headers = make(http.Header)
+		for _, name := range messageHeaderNames {
+			v, ok := fields[name]
+			if ok {
+				headers.Set(name, v)
+			}
+		}
+
+		// Offset before a field which is not first points to the comma
+		// preceding the field. The first field is followed by a comma, when
+		// it is not the last one.
+		end := int(decoder.InputOffset())
+		if isFirst {
+			rest := bytes.TrimLeft(data[end:], " \t\r\n")
+			if bytes.HasPrefix(rest, []byte(",")) {
+				end = len(data) - len(rest) + 1
+			}
+		}
+
+		result = make([]byte, 0, len(data)-(end-start))
+		result = append(result, data[:start]...)
+		result = append(result, data[end:]...)
+
+		return headers, result
+	}
+
+	return nil, data
+}
+
+// newMessageHttpRequest creates an HTTP request describing the function call
+// received in a message. Headers describing a single function call are taken
+// from the message rather than from the request of the connection.
+func newMessageHttpRequest(req *http.Request, headers http.Header) (callReq *http.Request) {
+	callReq = req.Clone(req.Context())
+	for _, name := range messageHeaderNames {
+		callReq.Header.Del(name)
+	}
+	for name, values := range headers {
+		callReq.Header[name] = values
+	}
+
+	return callReq
+}
@@ -0,0 +1,98 @@
+package jrm1
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/header"
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_newNotificationAck(t *testing.T) {
+	aTest := tester.New(t)
+	id := "1"
+
+	// Test #1. Accepted notification.
+	data, err := newNotificationAck(&id, callOutcome_Accepted)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(data), `{"jsonrpc":"M1","id":"1","notification":"accepted"}`)
+
+	// Test #2. Refused notification.
+	data, err = newNotificationAck(&id, callOutcome_Refused)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(data), `{"jsonrpc":"M1","id":"1","notification":"refused"}`)
+}
+
+func Test_addMessageHeaders(t *testing.T) {
+	aTest := tester.New(t)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://localhost", nil)
+	aTest.MustBeNoError(err)
+
+	// Test #1. No headers of the call.
+	req.Header.Set(header.HttpHeaderContentType, "application/json")
+	result, err := addMessageHeaders([]byte(`{"id":"1"}`), req)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(result), `{"id":"1"}`)
+
+	// Test #2. Headers of the call.
+	req.Header.Set(header.HttpHeaderAcceptLanguage, "de")
+	req.Header.Set(HttpHeaderNotification, "true")
+	result, err = addMessageHeaders([]byte(` {"id":"1"}`), req)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(result), ` {"headers":{"Accept-Language":"de","X-M1-Notification":"true"},"id":"1"}`)
+
+	// Test #3. Message is not an object.
+	_, err = addMessageHeaders([]byte(`["id"]`), req)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrMessageIsNotAnObject)
+}
+
+func Test_takeMessageHeaders(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test #1. First field.
+	headers, result := takeMessageHeaders([]byte(`{"headers":{"Traceparent":"x","Other":"y"} , "id":"1"}`))
+	aTest.MustBeEqual(headers, http.Header{"Traceparent": []string{"x"}})
+	aTest.MustBeEqual(string(result), `{ "id":"1"}`)
+
+	// Test #2. Last field.
+	headers, result = takeMessageHeaders([]byte(`{"id":"1", "headers":{"Accept-Language":"de"}}`))
+	aTest.MustBeEqual(headers, http.Header{"Accept-Language": []string{"de"}})
+	aTest.MustBeEqual(string(result), `{"id":"1"}`)
+
+	// Test #3. Single field.
+	headers, result = takeMessageHeaders([]byte(`{"headers":{}}`))
+	aTest.MustBeEqual(headers, http.Header{})
+	aTest.MustBeEqual(string(result), `{}`)
+
+	// Test #4. No headers.
+	headers, result = takeMessageHeaders([]byte(`{"id":"1","params":{"headers":{}}}`))
+	aTest.MustBeEqual(headers, http.Header(nil))
+	aTest.MustBeEqual(string(result), `{"id":"1","params":{"headers":{}}}`)
+
+	// Test #5. Malformed headers and messages are not changed.
+	for _, message := range []string{`{"headers":1,"id":"1"}`, `[{"headers":{}}]`, `{"id":`} {
+		headers, result = takeMessageHeaders([]byte(message))
+		aTest.MustBeEqual(headers, http.Header(nil))
+		aTest.MustBeEqual(string(result), message)
+	}
+}
+
+func Test_newMessageHttpRequest(t *testing.T) {
+	aTest := tester.New(t)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost", nil)
+	aTest.MustBeNoError(err)
+	req.Header.Set(header.HttpHeaderAcceptLanguage, "en")
+	req.Header.Set(HttpHeaderTraceParent, "x")
+	req.Header.Set(header.HttpHeaderUserAgent, "test")
+
+	// Headers of the call replace those of the connection.
+	callReq := newMessageHttpRequest(req, http.Header{"Accept-Language": []string{"de"}})
+	aTest.MustBeEqual(callReq.Header.Get(header.HttpHeaderAcceptLanguage), "de")
+	aTest.MustBeEqual(callReq.Header.Get(HttpHeaderTraceParent), "")
+	aTest.MustBeEqual(callReq.Header.Get(header.HttpHeaderUserAgent), "test")
+	aTest.MustBeEqual(req.Header.Get(header.HttpHeaderAcceptLanguage), "en")
+}
@@ -0,0 +1,199 @@
+package jrm1
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Types of metric families in the text exposition format.
+const (
+	MetricTypeCounter   = "counter"
+	MetricTypeGauge     = "gauge"
+	MetricTypeHistogram = "histogram"
+)
+
+const (
+	metricLabelValuesSeparator = "\xff"
+	metricLabelBucket          = "le"
+)
+
+// metricFamily is a named set of metric series having the same type and
+// label names. It is safe for concurrent use.
+type metricFamily struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+
+	// Upper bounds of histogram buckets. Is used only by histograms.
+	buckets []float64
+
+	guard  *sync.Mutex
+	series map[string]*metricSeries
+}
+
+// metricSeries is a single series of a metric family.
+type metricSeries struct {
+	labelValues []string
+
+	// Value of a counter or a gauge.
+	value float64
+
+	// Non-cumulative counts of histogram buckets, sum and count of all the
+	// observed values.
+	bucketCounts []uint64
+	sum          float64
+	count        uint64
+}
+
+// newMetricFamily is a constructor of a metric family.
+func newMetricFamily(name string, help string, kind string, buckets []float64, labelNames ...string) (mf *metricFamily) {
+	return &metricFamily{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		buckets:    buckets,
+		guard:      new(sync.Mutex),
+		series:     make(map[string]*metricSeries),
+	}
+}
+
+// getSeries returns a series having the specified label values, creating it
+// if it does not exist. The caller must hold the lock.
+func (mf *metricFamily) getSeries(labelValues []string) (ms *metricSeries) {
+	key := strings.Join(labelValues, metricLabelValuesSeparator)
+
+	ms, ok := mf.series[key]
+	if !ok {
+		ms = &metricSeries{
+			labelValues: append([]string(nil), labelValues...),
+		}
+		if mf.kind == MetricTypeHistogram {
+			ms.bucketCounts = make([]uint64, len(mf.buckets))
+		}
+		mf.series[key] = ms
+	}
+
+	return ms
+}
+
+// add adds a value to a counter or a gauge.
+func (mf *metricFamily) add(value float64, labelValues ...string) {
+	mf.guard.Lock()
+	defer mf.guard.Unlock()
+
+	mf.getSeries(labelValues).value += value
+}
+
+// observe adds an observed value to a histogram.
+func (mf *metricFamily) observe(value float64, labelValues ...string) {
+	mf.guard.Lock()
+	defer mf.guard.Unlock()
+
+	ms := mf.getSeries(labelValues)
+	for i, upperBound := range mf.buckets {
+		if value <= upperBound {
+			ms.bucketCounts[i]++
+			break
+		}
+	}
+	ms.sum += value
+	ms.count++
+}
+
+// write writes the metric family in the text exposition format.
+func (mf *metricFamily) write(w io.Writer) (err error) {
+	mf.guard.Lock()
+	defer mf.guard.Unlock()
+
+	_, err = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", mf.name, escapeMetricHelp(mf.help), mf.name, mf.kind)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(mf.series))
+	for key := range mf.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		ms := mf.series[key]
+
+		if mf.kind != MetricTypeHistogram {
+			_, err = fmt.Fprintf(w, "%s%s %s\n", mf.name, mf.formatLabels(ms.labelValues, ""), formatMetricValue(ms.value))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		var cumulativeCount uint64
+		for i, upperBound := range mf.buckets {
+			cumulativeCount += ms.bucketCounts[i]
+			_, err = fmt.Fprintf(w, "%s_bucket%s %d\n", mf.name, mf.formatLabels(ms.labelValues, formatMetricValue(upperBound)), cumulativeCount)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			mf.name, mf.formatLabels(ms.labelValues, formatMetricValue(math.Inf(+1))), ms.count,
+			mf.name, mf.formatLabels(ms.labelValues, ""), formatMetricValue(ms.sum),
+			mf.name, mf.formatLabels(ms.labelValues, ""), ms.count,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// formatLabels formats label pairs of a series. When the bucket bound is not
+// empty, it is added as the 'le' label.
+func (mf *metricFamily) formatLabels(labelValues []string, bucketBound string) string {
+	if (len(labelValues) == 0) && (len(bucketBound) == 0) {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(labelValues)+1)
+	for i, labelValue := range labelValues {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, mf.labelNames[i], escapeMetricLabelValue(labelValue)))
+	}
+	if len(bucketBound) > 0 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, metricLabelBucket, bucketBound))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatMetricValue formats a sample value in the text exposition format.
+func formatMetricValue(v float64) string {
+	switch {
+	case math.IsInf(v, +1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// escapeMetricLabelValue escapes a label value in the text exposition format.
+func escapeMetricLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// escapeMetricHelp escapes a help text in the text exposition format.
+func escapeMetricHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,69 @@
+package jrm1
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_metricFamily_write(t *testing.T) {
+	aTest := tester.New(t)
+	var mf *metricFamily
+	var buf bytes.Buffer
+	var err error
+
+	// Test #1. Counter.
+	mf = newMetricFamily("c_total", "Help\ntext.", MetricTypeCounter, nil, "a", "b")
+	mf.add(1, "x", "y")
+	mf.add(2, "x", "y")
+	mf.add(1, `q"`, "\\")
+	buf.Reset()
+	err = mf.write(&buf)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(buf.String(), `# HELP c_total Help\ntext.
+# TYPE c_total counter
+c_total{a="q\"",b="\\"} 1
+c_total{a="x",b="y"} 3
+`)
+
+	// Test #2. Gauge without labels.
+	mf = newMetricFamily("g", "Gauge.", MetricTypeGauge, nil)
+	mf.add(+1)
+	mf.add(+1)
+	mf.add(-1)
+	buf.Reset()
+	err = mf.write(&buf)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(buf.String(), "# HELP g Gauge.\n# TYPE g gauge\ng 1\n")
+
+	// Test #3. Histogram.
+	mf = newMetricFamily("h", "Histogram.", MetricTypeHistogram, []float64{1, 5}, "m")
+	mf.observe(0.5, "x")
+	mf.observe(3, "x")
+	mf.observe(100, "x")
+	buf.Reset()
+	err = mf.write(&buf)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(buf.String(), `# HELP h Histogram.
+# TYPE h histogram
+h_bucket{m="x",le="1"} 1
+h_bucket{m="x",le="5"} 2
+h_bucket{m="x",le="+Inf"} 3
+h_sum{m="x"} 103.5
+h_count{m="x"} 3
+`)
+}
+
+func Test_formatMetricValue(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	aTest.MustBeEqual(formatMetricValue(1), "1")
+	aTest.MustBeEqual(formatMetricValue(0.25), "0.25")
+	aTest.MustBeEqual(formatMetricValue(1e21), "1e+21")
+	aTest.MustBeEqual(formatMetricValue(math.Inf(+1)), "+Inf")
+	aTest.MustBeEqual(formatMetricValue(math.Inf(-1)), "-Inf")
+	aTest.MustBeEqual(formatMetricValue(math.NaN()), "NaN")
+}
@@ -0,0 +1,320 @@
+package jrm1
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// appendMsgpack appends the tree of generic values encoded in the
+// MessagePack format.
+func appendMsgpack(buf []byte, v any) (out []byte, err error) {
+	switch x := v.(type) {
+	case nil:
+		return append(buf, 0xc0), nil
+
+	case bool:
+		if x {
+			return append(buf, 0xc3), nil
+		}
+		return append(buf, 0xc2), nil
+
+	case json.Number:
+		return appendMsgpackNumber(buf, x)
+
+	case string:
+		buf = appendMsgpackHead(buf, uint64(len(x)), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		return append(buf, x...), nil
+
+	case []byte:
+		// Binary strings have no fixed type.
+		buf = appendMsgpackHead(buf, uint64(len(x)), 0, 0, 0xc4, 0xc5, 0xc6)
+		return append(buf, x...), nil
+
+	case []any:
+		buf = appendMsgpackHead(buf, uint64(len(x)), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range x {
+			buf, err = appendMsgpack(buf, item)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+
+	case *jsonObject:
+		buf = appendMsgpackHead(buf, uint64(len(x.keys)), 0x80, 16, 0, 0xde, 0xdf)
+		for i, key := range x.keys {
+			buf, err = appendMsgpack(buf, key)
+			if err != nil {
+				return nil, err
+			}
+			buf, err = appendMsgpack(buf, x.values[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+
+	default:
+		return nil, fmt.Errorf(ErrFCodecValueIsNotSupported, v)
+	}
+}
+
+// appendMsgpackHead appends the header of a string, an array or a map. Short
+// lengths are stored in the fixed type byte. A zero 8-bit type means that
+// the family has no 8-bit length.
+func appendMsgpackHead(buf []byte, n uint64, fixType byte, fixLimit uint64, type8 byte, type16 byte, type32 byte) []byte {
+	switch {
+	case n < fixLimit:
+		return append(buf, fixType|byte(n))
+	case (type8 != 0) && (n <= math.MaxUint8):
+		return append(buf, type8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, type16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, type32), uint32(n))
+	}
+}
+
+// appendMsgpackNumber appends the number using the most compact type.
+func appendMsgpackNumber(buf []byte, n json.Number) (out []byte, err error) {
+	kind, u, i, f, err := classifyJsonNumber(n)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case jsonNumberKind_Unsigned:
+		switch {
+		case u <= math.MaxInt8:
+			return append(buf, byte(u)), nil
+		case u <= math.MaxUint8:
+			return append(buf, 0xcc, byte(u)), nil
+		case u <= math.MaxUint16:
+			return binary.BigEndian.AppendUint16(append(buf, 0xcd), uint16(u)), nil
+		case u <= math.MaxUint32:
+			return binary.BigEndian.AppendUint32(append(buf, 0xce), uint32(u)), nil
+		default:
+			return binary.BigEndian.AppendUint64(append(buf, 0xcf), u), nil
+		}
+
+	case jsonNumberKind_Signed:
+		switch {
+		case i >= -32:
+			return append(buf, byte(i)), nil
+		case i >= math.MinInt8:
+			return append(buf, 0xd0, byte(i)), nil
+		case i >= math.MinInt16:
+			return binary.BigEndian.AppendUint16(append(buf, 0xd1), uint16(i)), nil
+		case i >= math.MinInt32:
+			return binary.BigEndian.AppendUint32(append(buf, 0xd2), uint32(i)), nil
+		default:
+			return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(i)), nil
+		}
+
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xcb), math.Float64bits(f)), nil
+	}
+}
+
+// decodeMsgpack decodes data in the MessagePack format into a tree of
+// generic values.
+func decodeMsgpack(data []byte) (tree any, err error) {
+	br := &binaryReader{data: data}
+
+	tree, err = readMsgpackValue(br)
+	if err != nil {
+		return nil, err
+	}
+
+	err = br.checkEnd()
+	if err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// readMsgpackValue reads the next value.
+func readMsgpackValue(br *binaryReader) (v any, err error) {
+	var t byte
+	t, err = br.readByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case t <= 0x7f:
+		return json.Number(fmt.Sprint(t)), nil
+	case t >= 0xe0:
+		return json.Number(fmt.Sprint(int8(t))), nil
+	case (t & 0xf0) == 0x80:
+		return readMsgpackMap(br, uint64(t&0x0f))
+	case (t & 0xf0) == 0x90:
+		return readMsgpackArray(br, uint64(t&0x0f))
+	case (t & 0xe0) == 0xa0:
+		return readMsgpackString(br, uint64(t&0x1f))
+	}
+
+	var n uint64
+	switch t {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+
+	case 0xc4, 0xc5, 0xc6:
+		n, err = br.readUint(1 << (t - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		var data []byte
+		data, err = br.readBytes(n)
+		if err != nil {
+			return nil, err
+		}
+		return newJsonBinary(data), nil
+
+	case 0xca:
+		n, err = br.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return newJsonFloat(float64(math.Float32frombits(uint32(n))))
+
+	case 0xcb:
+		n, err = br.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+		return newJsonFloat(math.Float64frombits(n))
+
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err = br.readUint(1 << (t - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(fmt.Sprint(n)), nil
+
+	case 0xd0:
+		n, err = br.readUint(1)
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(fmt.Sprint(int8(n))), nil
+	case 0xd1:
+		n, err = br.readUint(2)
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(fmt.Sprint(int16(n))), nil
+	case 0xd2:
+		n, err = br.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(fmt.Sprint(int32(n))), nil
+	case 0xd3:
+		n, err = br.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(fmt.Sprint(int64(n))), nil
+
+	case 0xd9, 0xda, 0xdb:
+		n, err = br.readUint(1 << (t - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackString(br, n)
+
+	case 0xdc, 0xdd:
+		n, err = br.readUint(2 << (t - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackArray(br, n)
+
+	case 0xde, 0xdf:
+		n, err = br.readUint(2 << (t - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackMap(br, n)
+
+	default:
+		// Extension types are not supported.
+		return nil, fmt.Errorf(ErrFCodecTypeIsNotSupported, t)
+	}
+}
+
+// readMsgpackString reads a string of the specified length.
+func readMsgpackString(br *binaryReader, n uint64) (s string, err error) {
+	var data []byte
+	data, err = br.readBytes(n)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// readMsgpackArray reads an array of the specified length.
+func readMsgpackArray(br *binaryReader, n uint64) (array []any, err error) {
+	err = br.enter()
+	if err != nil {
+		return nil, err
+	}
+	defer br.leave()
+
+	array = make([]any, 0, br.capacityFor(n))
+	for range n {
+		var item any
+		item, err = readMsgpackValue(br)
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, item)
+	}
+
+	return array, nil
+}
+
+// readMsgpackMap reads a map of the specified length. Keys must be strings.
+func readMsgpackMap(br *binaryReader, n uint64) (object *jsonObject, err error) {
+	err = br.enter()
+	if err != nil {
+		return nil, err
+	}
+	defer br.leave()
+
+	object = &jsonObject{
+		keys:   make([]string, 0, br.capacityFor(n)),
+		values: make([]any, 0, br.capacityFor(n)),
+	}
+	for range n {
+		var key, item any
+		key, err = readMsgpackValue(br)
+		if err != nil {
+			return nil, err
+		}
+		keyStr, ok := key.(string)
+		if !ok {
+			return nil, errors.New(ErrCodecMapKeyIsNotString)
+		}
+
+		item, err = readMsgpackValue(br)
+		if err != nil {
+			return nil, err
+		}
+
+		object.keys = append(object.keys, keyStr)
+		object.values = append(object.values, item)
+	}
+
+	return object, nil
+}
@@ -0,0 +1,122 @@
+package jrm1
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_appendMsgpack(t *testing.T) {
+	aTest := tester.New(t)
+
+	type TestData struct {
+		json    string
+		msgpack string
+	}
+
+	tests := []TestData{
+		{json: `null`, msgpack: "c0"},
+		{json: `true`, msgpack: "c3"},
+		{json: `false`, msgpack: "c2"},
+		{json: `0`, msgpack: "00"},
+		{json: `127`, msgpack: "7f"},
+		{json: `128`, msgpack: "cc80"},
+		{json: `65535`, msgpack: "cdffff"},
+		{json: `65536`, msgpack: "ce00010000"},
+		{json: `4294967296`, msgpack: "cf0000000100000000"},
+		{json: `-1`, msgpack: "ff"},
+		{json: `-32`, msgpack: "e0"},
+		{json: `-33`, msgpack: "d0df"},
+		{json: `-129`, msgpack: "d1ff7f"},
+		{json: `-32769`, msgpack: "d2ffff7fff"},
+		{json: `-2147483649`, msgpack: "d3ffffffff7fffffff"},
+		{json: `1.5`, msgpack: "cb3ff8000000000000"},
+		{json: `""`, msgpack: "a0"},
+		{json: `"abc"`, msgpack: "a3616263"},
+		{json: `[1,[]]`, msgpack: "920190"},
+		{json: `{"a":{}}`, msgpack: "81a16180"},
+	}
+
+	for _, test := range tests {
+		tree, err := parseJsonTree([]byte(test.json))
+		aTest.MustBeNoError(err)
+		data, err := appendMsgpack(nil, tree)
+		aTest.MustBeNoError(err)
+		aTest.MustBeEqual(hex.EncodeToString(data), test.msgpack)
+
+		// Decoding returns the original JSON.
+		tree, err = decodeMsgpack(data)
+		aTest.MustBeNoError(err)
+		aTest.MustBeEqual(_jsonTreeText(t, tree), test.json)
+	}
+
+	// Long strings, arrays and maps.
+	for _, n := range []int{15, 16, 31, 32, 255, 256, 65535, 65536} {
+		for _, v := range _newLongJsonTrees(n) {
+			data, err := appendMsgpack(nil, v)
+			aTest.MustBeNoError(err)
+			decoded, err := decodeMsgpack(data)
+			aTest.MustBeNoError(err)
+			aTest.MustBeEqual(decoded, v)
+		}
+	}
+
+	// Binary strings are decoded as Base64 text.
+	data, err := appendMsgpack(nil, []byte{1, 2})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(hex.EncodeToString(data), "c4020102")
+	decoded, err := decodeMsgpack(data)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(decoded, "AQI=")
+}
+
+func Test_decodeMsgpack(t *testing.T) {
+	aTest := tester.New(t)
+
+	type TestData struct {
+		msgpack string
+		json    string
+		isError bool
+	}
+
+	tests := []TestData{
+		// Types which are not produced by the encoder.
+		{msgpack: "ca3fc00000", json: `1.5`},
+		{msgpack: "d901" + "61", json: `"a"`},
+		{msgpack: "dc0001c0", json: `[null]`},
+		{msgpack: "de0001a161c3", json: `{"a":true}`},
+		{msgpack: "c403010203", json: `"AQID"`},
+		{msgpack: "cfffffffffffffffff", json: `18446744073709551615`},
+
+		// Errors.
+		{msgpack: "", isError: true},
+		{msgpack: "cd01", isError: true},
+		{msgpack: "a3616263" + "00", isError: true},
+		{msgpack: "d40100", isError: true},
+		{msgpack: "c1", isError: true},
+		{msgpack: "810101", isError: true},
+		{msgpack: "cb7ff8000000000000", isError: true},
+		{msgpack: "dd7fffffff", isError: true},
+	}
+
+	for _, test := range tests {
+		data, err := hex.DecodeString(test.msgpack)
+		aTest.MustBeNoError(err)
+		tree, err := decodeMsgpack(data)
+		if test.isError {
+			aTest.MustBeAnError(err)
+			continue
+		}
+		aTest.MustBeNoError(err)
+		aTest.MustBeEqual(_jsonTreeText(t, tree), test.json)
+	}
+
+	// Nesting is too deep.
+	data := make([]byte, maxCodecNestingDepth+1)
+	for i := range data {
+		data[i] = 0x91
+	}
+	_, err := decodeMsgpack(append(data, 0xc0))
+	aTest.MustBeAnError(err)
+}
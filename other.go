@@ -2,6 +2,8 @@ package jrm1
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 )
 
@@ -10,17 +12,42 @@ import (
 // beginning of each RPC function to get parameters. Unfortunately, Go language
 // can not do it automatically due to its technical limits.
 func ParseParameters(params *json.RawMessage, dst any) (re *RpcError) {
+	return ParseParametersWith(params, dst, nil)
+}
+
+// ParseParametersWith parses parameters like the 'ParseParameters' function
+// using the specified options of decoding. Null options are the default ones.
+// Options matching strict decoding of the RPC processor (server) are returned
+// by its 'GetParametersDecoding' method.
+func ParseParametersWith(params *json.RawMessage, dst any, pd *ParametersDecoding) (re *RpcError) {
 	if params == nil {
 		return NewRpcErrorFast(RpcErrorCode_InvalidParameters)
 	}
 
 	decoder := json.NewDecoder(bytes.NewReader(*params))
 	decoder.DisallowUnknownFields()
+	if (pd != nil) && pd.UseNumber {
+		decoder.UseNumber()
+	}
 
 	err := decoder.Decode(dst)
 	if err != nil {
+		if (pd != nil) && pd.ShowDetails {
+			re, _ = NewRpcError(RpcErrorCode_InvalidParameters, err.Error())
+			return re
+		}
 		return NewRpcErrorFast(RpcErrorCode_InvalidParameters)
 	}
 
 	return nil
 }
+
+// newRandomId creates a random identifier of 128 bits in hexadecimal form.
+func newRandomId() string {
+	var id [16]byte
+
+	// Reading of random bytes never fails.
+	_, _ = rand.Read(id[:])
+
+	return hex.EncodeToString(id[:])
+}
@@ -70,5 +70,36 @@ func Test_ParseParameters(t *testing.T) {
 		p := P5{}
 		re = ParseParameters(&paramsRaw, &p)
 		aTest.MustBeDifferent(re, (*RpcError)(nil))
+		aTest.MustBeEqual(re.Data, nil)
 	}
+
+	// Test #6. Options of decoding do not affect other calls.
+	{
+		paramsRaw = json.RawMessage([]byte(`{"n":12345678901234567890}`))
+		var p map[string]any
+		re = ParseParametersWith(&paramsRaw, &p, &ParametersDecoding{UseNumber: true, ShowDetails: true})
+		aTest.MustBeEqual(re, (*RpcError)(nil))
+		aTest.MustBeEqual(p["n"], json.Number("12345678901234567890"))
+
+		var n byte
+		re = ParseParametersWith(&paramsRaw, &n, &ParametersDecoding{ShowDetails: true})
+		aTest.MustBeEqual(re.Code, RpcErrorCode(-16))
+		aTest.MustBeEqual(re.Data, "json: cannot unmarshal object into Go value of type uint8")
+
+		re = ParseParameters(&paramsRaw, &p)
+		aTest.MustBeEqual(re, (*RpcError)(nil))
+		aTest.MustBeEqual(p["n"], float64(12345678901234567890))
+		re = ParseParameters(&paramsRaw, &n)
+		aTest.MustBeEqual(re.Data, nil)
+	}
+}
+
+func Test_newRandomId(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	id := newRandomId()
+	aTest.MustBeEqual(len(id), 32)
+	aTest.MustBeEqual(isLowerHex(id), true)
+	aTest.MustBeDifferent(newRandomId(), id)
 }
@@ -0,0 +1,23 @@
+package jrm1
+
+// panicInfo is information about an exception (panic) caught during the
+// execution of an RPC function.
+type panicInfo struct {
+	// Value passed to the 'panic' function.
+	value any
+
+	// Stack trace of the goroutine where the exception happened.
+	stack []byte
+
+	// Identifier of the incident. It is set when details of exceptions are
+	// shown to clients.
+	incidentId string
+}
+
+// newPanicInfo is a constructor of information about an exception.
+func newPanicInfo(value any, stack []byte) (pi *panicInfo) {
+	return &panicInfo{
+		value: value,
+		stack: stack,
+	}
+}
@@ -0,0 +1,15 @@
+package jrm1
+
+import (
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_newPanicInfo(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	pi := newPanicInfo("boom", []byte("stack"))
+	aTest.MustBeEqual(pi, &panicInfo{value: "boom", stack: []byte("stack")})
+}
@@ -0,0 +1,407 @@
+package jrm1
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	mime "github.com/vault-thirteen/auxie/MIME"
+	"github.com/vault-thirteen/auxie/header"
+)
+
+const (
+	ErrTransportBatchIsNotSupported = "batches are not supported by the transport"
+	ErrTransportRequestIdIsMissing  = "request ID is missing"
+	ErrConnectionIsClosed           = "connection is closed"
+)
+
+// messageConn is a connection transferring whole messages in both
+// directions.
+type messageConn interface {
+	// writeMessage sends a message. It is safe for concurrent use.
+	writeMessage(data []byte) (err error)
+
+	// nextMessage receives the next message. When the peer closes the
+	// connection, 'io.EOF' is returned.
+	nextMessage() (data []byte, err error)
+
+	// close closes the connection.
+	close() (err error)
+}
+
+// pipeline sends function calls of the client over a single persistent
+// connection. The connection is established on the first call and is
+// re-established after failures. Several calls may be in flight at the same
+// time, their responses are matched to requests by IDs. Calls of different
+// clients sharing the pipeline may have equal IDs, so the ID of a call is
+// replaced by an ID unique within the pipeline when the call is sent, and the
+// original ID is restored in the response.
+type pipeline struct {
+	dial       func(req *http.Request) (mc messageConn, err error)
+	handlePush func(topic string, data json.RawMessage)
+
+	guard   *sync.Mutex
+	mc      messageConn
+	pending map[string]*pipelineCall
+
+	// Last ID of a call sent via the pipeline.
+	lastId uint64
+}
+
+// pipelineCall is a function call waiting for the response.
+type pipelineCall struct {
+	// Connection which has sent the call.
+	mc messageConn
+
+	replyCh chan *pipelineReply
+}
+
+// pipelineReply is a response to a pending call or a failure of the
+// connection.
+type pipelineReply struct {
+	data []byte
+	err  error
+}
+
+// pipelineEnvelope contains routing fields of an incoming message.
+type pipelineEnvelope struct {
+	Id    *string         `json:"id"`
+	Topic *string         `json:"push"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// newPipeline is a constructor of a pipeline using the specified function to
+// establish connections and the specified function to receive push messages.
+func newPipeline(dial func(req *http.Request) (mc messageConn, err error), handlePush func(topic string, data json.RawMessage)) (pl *pipeline) {
+	return &pipeline{
+		dial:       dial,
+		handlePush: handlePush,
+		guard:      new(sync.Mutex),
+		pending:    make(map[string]*pipelineCall),
+	}
+}
+
+// roundTrip sends the function call and waits for the response, which is
+// returned as an HTTP response. Notifications wait for the acknowledgement of
+// the server, which is returned as an HTTP status code.
+func (pl *pipeline) roundTrip(req *http.Request) (resp *http.Response, err error) {
+	// Messages are not compressed.
+	var input io.ReadCloser
+	input, err = newContentDecoder(req.Header.Get(header.HttpHeaderContentEncoding), req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	body, err = io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	err = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		return nil, errors.New(ErrTransportBatchIsNotSupported)
+	}
+
+	var envelope pipelineEnvelope
+	err = json.Unmarshal(body, &envelope)
+	if err != nil {
+		return nil, err
+	}
+	if envelope.Id == nil {
+		return nil, errors.New(ErrTransportRequestIdIsMissing)
+	}
+
+	var originalId []byte
+	originalId, err = json.Marshal(*envelope.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	var call *pipelineCall
+	var wireId string
+	call, wireId, err = pl.addPendingCall(req)
+	if err != nil {
+		return nil, err
+	}
+	defer pl.removePendingCall(wireId)
+
+	var wireIdJson []byte
+	wireIdJson, err = json.Marshal(wireId)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err = replaceMessageId(body, wireIdJson)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err = addMessageHeaders(body, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = call.mc.writeMessage(body)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	case reply := <-call.replyCh:
+		if reply.err != nil {
+			return nil, reply.err
+		}
+
+		// Notification is acknowledged by the server the same way as it is
+		// done over HTTP.
+		var ack notificationAck
+		if json.NewDecoder(bytes.NewReader(reply.data)).Decode(&ack) == nil {
+			switch ack.Status {
+			case NotificationStatus_Accepted:
+				return newPipelineHttpResponse(req, http.StatusAccepted, nil), nil
+			case NotificationStatus_Refused:
+				return newPipelineHttpResponse(req, http.StatusServiceUnavailable, nil), nil
+			}
+		}
+
+		var data []byte
+		data, err = replaceMessageId(reply.data, originalId)
+		if err != nil {
+			return nil, err
+		}
+
+		return newPipelineHttpResponse(req, http.StatusOK, data), nil
+	}
+}
+
+// close closes the connection. Pending calls fail, while next calls establish
+// a new connection.
+func (pl *pipeline) close() (err error) {
+	pl.guard.Lock()
+	mc := pl.mc
+	pl.mc = nil
+	pl.guard.Unlock()
+
+	if mc == nil {
+		return nil
+	}
+
+	return mc.close()
+}
+
+// addPendingCall registers a call waiting for the response and returns the
+// ID under which the call is sent. The call is bound to the connection which
+// sends it.
+func (pl *pipeline) addPendingCall(req *http.Request) (call *pipelineCall, wireId string, err error) {
+	pl.guard.Lock()
+	defer pl.guard.Unlock()
+
+	var mc messageConn
+	mc, err = pl.getConnectionUnsafe(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pl.lastId++
+	wireId = strconv.FormatUint(pl.lastId, 10)
+
+	call = &pipelineCall{
+		mc:      mc,
+		replyCh: make(chan *pipelineReply, 1),
+	}
+	pl.pending[wireId] = call
+
+	return call, wireId, nil
+}
+
+// removePendingCall unregisters a call waiting for the response.
+func (pl *pipeline) removePendingCall(id string) {
+	pl.guard.Lock()
+	defer pl.guard.Unlock()
+
+	delete(pl.pending, id)
+}
+
+// getConnection returns the open connection or establishes a new one.
+func (pl *pipeline) getConnection(req *http.Request) (mc messageConn, err error) {
+	pl.guard.Lock()
+	defer pl.guard.Unlock()
+
+	return pl.getConnectionUnsafe(req)
+}
+
+// getConnectionUnsafe returns the open connection or establishes a new one.
+// The caller must hold the lock.
+func (pl *pipeline) getConnectionUnsafe(req *http.Request) (mc messageConn, err error) {
+	if pl.mc != nil {
+		return pl.mc, nil
+	}
+
+	mc, err = pl.dial(req)
+	if err != nil {
+		return nil, err
+	}
+
+	pl.mc = mc
+	go pl.readMessages(mc)
+
+	return mc, nil
+}
+
+// readMessages reads messages of the connection and passes them to pending
+// calls and to the receiver of push messages. When the connection fails, its
+// pending calls fail and the connection is forgotten.
+func (pl *pipeline) readMessages(mc messageConn) {
+	var err error
+	for {
+		var data []byte
+		data, err = mc.nextMessage()
+		if err != nil {
+			break
+		}
+
+		// Streamed responses contain several records, the first of which
+		// has the request ID.
+		var envelope pipelineEnvelope
+		if json.NewDecoder(bytes.NewReader(data)).Decode(&envelope) != nil {
+			continue
+		}
+
+		if envelope.Topic != nil {
+			if pl.handlePush != nil {
+				pl.handlePush(*envelope.Topic, envelope.Data)
+			}
+			continue
+		}
+
+		if envelope.Id == nil {
+			continue
+		}
+
+		pl.guard.Lock()
+		call, ok := pl.pending[*envelope.Id]
+		pl.guard.Unlock()
+		if ok && (call.mc == mc) {
+			select {
+			case call.replyCh <- &pipelineReply{data: data}:
+			default:
+			}
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		err = errors.New(ErrConnectionIsClosed)
+	}
+
+	_ = mc.close()
+
+	pl.guard.Lock()
+	defer pl.guard.Unlock()
+
+	if pl.mc == mc {
+		pl.mc = nil
+	}
+	for _, call := range pl.pending {
+		if call.mc != mc {
+			continue
+		}
+		select {
+		case call.replyCh <- &pipelineReply{err: err}:
+		default:
+		}
+	}
+}
+
+// replaceMessageId replaces the value of the 'id' field of the first JSON
+// object of the message. Other bytes of the message, including the following
+// records of a streamed response, are kept as is.
+func replaceMessageId(data []byte, id []byte) (result []byte, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	var token json.Token
+	token, err = decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token != json.Delim('{') {
+		return nil, errors.New(ErrTransportRequestIdIsMissing)
+	}
+
+	for decoder.More() {
+		token, err = decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		var value json.RawMessage
+		err = decoder.Decode(&value)
+		if err != nil {
+			return nil, err
+		}
+
+		if token != "id" {
+			continue
+		}
+
+		end := int(decoder.InputOffset())
+		start := end - len(value)
+
+		result = make([]byte, 0, len(data)-len(value)+len(id))
+		result = append(result, data[:start]...)
+		result = append(result, id...)
+		result = append(result, data[end:]...)
+
+		return result, nil
+	}
+
+	return nil, errors.New(ErrTransportRequestIdIsMissing)
+}
+
+// newPipelineHttpResponse creates an HTTP response for the client from a
+// message received via the pipeline.
+func newPipelineHttpResponse(req *http.Request, statusCode int, data []byte) (resp *http.Response) {
+	resp = &http.Response{
+		Status:        http.StatusText(statusCode),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}
+
+	switch {
+	case isStreamMessage(data):
+		resp.Header.Set(header.HttpHeaderContentType, StreamContentType)
+	case data != nil:
+		resp.Header.Set(header.HttpHeaderContentType, mime.TypeApplicationJson)
+	}
+
+	return resp
+}
+
+// isStreamMessage tells whether the message is a streamed response. Streamed
+// responses are sent as a single message with several records, while
+// ordinary responses contain a single JSON value.
+func isStreamMessage(data []byte) bool {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	var value json.RawMessage
+	if decoder.Decode(&value) != nil {
+		return false
+	}
+
+	return decoder.More()
+}
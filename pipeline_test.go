@@ -0,0 +1,106 @@
+package jrm1
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	mime "github.com/vault-thirteen/auxie/MIME"
+	"github.com/vault-thirteen/auxie/header"
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_pipeline_roundTrip(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	pl := newPipeline(nil, nil)
+	newRequest := func(body string) *http.Request {
+		req, rerr := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://localhost", bytes.NewReader([]byte(body)))
+		aTest.MustBeNoError(rerr)
+		return req
+	}
+
+	// Test #1. Batch.
+	_, err = pl.roundTrip(newRequest(`[{"id":"1"}]`))
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrTransportBatchIsNotSupported)
+
+	// Test #2. Request without ID.
+	_, err = pl.roundTrip(newRequest(`{"method":"x"}`))
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrTransportRequestIdIsMissing)
+
+	// Test #3. Malformed request.
+	_, err = pl.roundTrip(newRequest(`{`))
+	aTest.MustBeAnError(err)
+
+	// Test #4. Compressed request is decompressed.
+	compressed, err := compress(ContentEncoding_Gzip, []byte(`{"method":"x"}`))
+	aTest.MustBeNoError(err)
+	req := newRequest(string(compressed))
+	req.Header.Set(header.HttpHeaderContentEncoding, ContentEncoding_Gzip)
+	_, err = pl.roundTrip(req)
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrTransportRequestIdIsMissing)
+
+	// Test #5. Unsupported encoding of the request.
+	req = newRequest(`{"method":"x"}`)
+	req.Header.Set(header.HttpHeaderContentEncoding, "br")
+	_, err = pl.roundTrip(req)
+	aTest.MustBeAnError(err)
+}
+
+func Test_replaceMessageId(t *testing.T) {
+	aTest := tester.New(t)
+	var result []byte
+	var err error
+
+	// Test #1. Ordinary message.
+	result, err = replaceMessageId([]byte(`{"jsonrpc":"M1", "id" : "1","method":"x"}`), []byte(`"42"`))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(result), `{"jsonrpc":"M1", "id" : "42","method":"x"}`)
+
+	// Test #2. Only the first record of a streamed response is changed.
+	result, err = replaceMessageId([]byte("{\"id\":\"42\"}\n{\"item\":{\"id\":\"42\"}}\n"), []byte(`"1"`))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(result), "{\"id\":\"1\"}\n{\"item\":{\"id\":\"42\"}}\n")
+
+	// Test #3. Nested fields are skipped.
+	result, err = replaceMessageId([]byte(`{"params":{"id":"x"},"id":"1"}`), []byte(`"2"`))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(result), `{"params":{"id":"x"},"id":"2"}`)
+
+	// Test #4. Message without ID.
+	_, err = replaceMessageId([]byte(`{"method":"x"}`), []byte(`"2"`))
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrTransportRequestIdIsMissing)
+
+	// Test #5. Message is not an object.
+	_, err = replaceMessageId([]byte(`[1]`), []byte(`"2"`))
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrTransportRequestIdIsMissing)
+}
+
+func Test_newPipelineHttpResponse(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test #1. Ordinary response.
+	resp := newPipelineHttpResponse(nil, http.StatusOK, []byte(`{"id":"1"}`))
+	aTest.MustBeEqual(resp.StatusCode, http.StatusOK)
+	aTest.MustBeEqual(resp.Header.Get(header.HttpHeaderContentType), mime.TypeApplicationJson)
+	body, err := io.ReadAll(resp.Body)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(body), `{"id":"1"}`)
+
+	// Test #2. Streamed response.
+	resp = newPipelineHttpResponse(nil, http.StatusOK, []byte(`{"id":"1"} {"item":1}`))
+	aTest.MustBeEqual(resp.Header.Get(header.HttpHeaderContentType), StreamContentType)
+
+	// Test #3. Acknowledgement.
+	resp = newPipelineHttpResponse(nil, http.StatusAccepted, nil)
+	aTest.MustBeEqual(resp.StatusCode, http.StatusAccepted)
+	aTest.MustBeEqual(resp.Header.Get(header.HttpHeaderContentType), "")
+}
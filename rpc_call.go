@@ -0,0 +1,1058 @@
+package jrm1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	ae "github.com/vault-thirteen/auxie/errors"
+	"github.com/vault-thirteen/auxie/header"
+)
+
+const (
+	ErrStreamIsNotSupportedWithoutOutput = "streaming is not supported by the transport"
+)
+
+// Outcomes of serving an RPC call.
+const (
+	// Response is written to the output.
+	callOutcome_Responded = callOutcome(0)
+
+	// Call is a notification which is put into the queue.
+	callOutcome_Accepted = callOutcome(1)
+
+	// Call is a notification which is refused because the queue is full or
+	// the processor is stopped.
+	callOutcome_Refused = callOutcome(2)
+)
+
+// callOutcome is an outcome of serving an RPC call.
+type callOutcome byte
+
+// rpcCall is an RPC call served by the RPC processor (server). It contains
+// the protocol handling which does not depend on a transport: decoding,
+// validation, meta-data fields, timers, metrics, tracing and the access log.
+// Transports read the input, write the output and translate outcomes into
+// their own terms.
+type rpcCall struct {
+	// Processor.
+	p *Processor
+
+	// Processor settings.
+	settings *ProcessorSettings
+
+	// Context of the call.
+	ctx context.Context
+
+	// HTTP request from which the call was received. It is null when the
+	// call is not received via HTTP, e.g. when the message is handled
+	// directly.
+	req *http.Request
+
+	// Output for the response. It is null when the response is only
+	// returned, e.g. by a batch item.
+	out io.Writer
+
+	// Function setting the type of content before the response is written.
+	setContentType func(contentType string)
+
+	// Function flushing the written part of a streamed response.
+	flush func()
+
+	// Counters of request and response sizes. Are used by metrics.
+	inCounter  *countingReadCloser
+	outCounter *countingWriter
+
+	// Input which is read by the call.
+	input io.ReadCloser
+
+	// Codecs of the request and of the response. When not set, JSON is used.
+	requestCodec  Codec
+	responseCodec Codec
+
+	// Time of start of the request processing.
+	tStart time.Time
+
+	// Time spent on processing a function call.
+	tDuration time.Duration
+
+	// Flag showing that the timer is stopped and the duration is saved.
+	isTimerStopped bool
+
+	// Durations of finished phases of request processing.
+	phaseDurations map[string]time.Duration
+
+	// RPC request.
+	rr *RpcRequest
+
+	// RPC response.
+	resp *RpcResponse
+
+	// Information about an exception caught during the function call.
+	pi *panicInfo
+
+	// Flag showing that the requested function exists.
+	isMethodKnown bool
+
+	// Span of the function call.
+	span Span
+
+	// Flag showing that the input contains a batch of function calls.
+	isBatch bool
+
+	// Flag showing that the call is an item of a batch.
+	isBatchItem bool
+
+	// Size of the item of a batch in the input.
+	batchItemSize int64
+
+	// Flag showing that response is not written to the output. It is either
+	// collected by a batch, returned to the caller, or discarded when the
+	// function call is a notification.
+	isWriteDisabled bool
+
+	// Flag showing that the transport marks the call as a notification.
+	isNotificationRequested bool
+
+	// Flag showing that the function call is a notification.
+	isNotification bool
+
+	// Flag showing that the requested function is a streaming function.
+	isStream bool
+
+	// Encoder of a streamed response. It is created when the header of the
+	// stream is written.
+	streamEncoder *json.Encoder
+
+	// Number of written result items of a streamed response.
+	streamItemsCount uint
+
+	// Problem of the request envelope found by strict decoding. Empty text
+	// means that there are no problems.
+	envelopeProblem string
+}
+
+// newRpcCall is a constructor of an RPC call. The HTTP request and the output
+// may be null.
+func newRpcCall(p *Processor, settings *ProcessorSettings, ctx context.Context, req *http.Request, out io.Writer) (c *rpcCall) {
+	c = &rpcCall{
+		p:        p,
+		settings: settings,
+		ctx:      ctx,
+		req:      req,
+		out:      out,
+	}
+
+	if c.ctx == nil {
+		c.ctx = context.Background()
+		if req != nil {
+			c.ctx = req.Context()
+		}
+	}
+
+	if out == nil {
+		c.isWriteDisabled = true
+	}
+
+	return c
+}
+
+// read reads the RPC request from the input, checks it, searches for the
+// requested function and checks access to it. If error occurs, it responds to
+// the client. If request is correct and ready to be processed further, 'True'
+// is returned. When 'False' is returned, the caller must stop serving the
+// request. If the request is a batch of function calls, it is only detected
+// here and is served by the 'serve' method.
+func (c *rpcCall) read(input io.ReadCloser) (proceed bool) {
+	c.resp = NewRpcResponse()
+
+	if c.settings.isMetricsEnabled() {
+		c.inCounter = newCountingReadCloser(input)
+		input = c.inCounter
+
+		if c.out != nil {
+			c.outCounter = newCountingWriter(c.out)
+			c.out = c.outCounter
+		}
+	}
+
+	if !isJsonCodec(c.requestCodec) {
+		var err error
+		input, err = transcodeToJson(c.requestCodec, input)
+		if err != nil {
+			c.p.incAllRequestsCounter()
+			c.startSpan()
+			c.resp.Error = NewRpcErrorFast(RpcErrorCode_RequestIsNotReadable)
+			c.respond()
+			return false
+		}
+	}
+
+	if c.settings.EnableBatches {
+		c.isBatch, input = peekIsJsonArray(input)
+		if c.isBatch {
+			c.input = input
+			return true
+		}
+	}
+
+	c.p.incAllRequestsCounter()
+
+	var err error
+	tDecodeStart := time.Now()
+	c.rr, err = c.decodeRequest(input)
+	c.savePhaseDuration(DurationPhase_Decode, tDecodeStart)
+	c.startSpan()
+	if err != nil {
+		c.resp.Error = NewRpcErrorFast(RpcErrorCode_RequestIsNotReadable)
+		c.respond()
+		return false
+	}
+
+	return c.check()
+}
+
+// decodeRequest decodes the RPC request read from the input. The input is
+// closed.
+func (c *rpcCall) decodeRequest(input io.ReadCloser) (rr *RpcRequest, err error) {
+	if c.settings.isEnvelopeCheckEnabled() {
+		var data []byte
+		data, err = io.ReadAll(input)
+		err = ae.Combine(err, input.Close())
+		if err != nil {
+			return nil, err
+		}
+
+		return c.decodeRequestBytes(data)
+	}
+
+	if c.settings.EnableFastPath {
+		return decodeRpcRequestFast(input)
+	}
+
+	return NewRpcRequest(input)
+}
+
+// decodeRequestBytes decodes the encoded RPC request. If strict decoding is
+// enabled, fields of the envelope are checked.
+func (c *rpcCall) decodeRequestBytes(data []byte) (rr *RpcRequest, err error) {
+	if c.settings.EnableFastPath {
+		rr, err = decodeRpcRequestBytes(data)
+	} else {
+		rr, err = NewRpcRequest(io.NopCloser(bytes.NewReader(data)))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.settings.isEnvelopeCheckEnabled() {
+		c.envelopeProblem = findEnvelopeProblem(data, c.settings.StrictEnvelope, c.settings.RejectDuplicateKeys)
+	}
+
+	return rr, nil
+}
+
+// serve serves the RPC request which has been read and returns the outcome.
+func (c *rpcCall) serve() (outcome callOutcome) {
+	if c.isBatch {
+		c.serveBatch()
+		return callOutcome_Responded
+	}
+
+	if c.isNotification {
+		if !c.enqueue() {
+			return callOutcome_Refused
+		}
+		return callOutcome_Accepted
+	}
+
+	if !c.run() {
+		return callOutcome_Responded
+	}
+
+	c.respond()
+	return callOutcome_Responded
+}
+
+// check checks the decoded request, searches for the requested function and
+// checks access to it. If error occurs, it responds to the client. If request
+// is correct and ready to be processed further, 'True' is returned. When
+// 'False' is returned, the caller must stop serving the request.
+func (c *rpcCall) check() (proceed bool) {
+	c.resp.Id = c.rr.Id
+
+	var ok bool
+	if len(c.envelopeProblem) > 0 {
+		c.resp.Error, _ = NewRpcError(RpcErrorCode_InvalidRequest, c.envelopeProblem)
+		c.respond()
+		return false
+	}
+
+	ok = c.rr.HasAllRootFields()
+	if !ok {
+		c.resp.Error = NewRpcErrorFast(RpcErrorCode_InvalidRequest)
+		c.respond()
+		return false
+	}
+
+	err := c.rr.CheckProtocolVersion()
+	if err != nil {
+		c.resp.Error = NewRpcErrorFast(RpcErrorCode_UnsupportedProtocol)
+		c.respond()
+		return false
+	}
+
+	err = c.p.FindFunc(*c.rr.Method)
+	if err != nil {
+		c.resp.Error = NewRpcErrorFast(RpcErrorCode_UnknownMethod)
+		c.respond()
+		return false
+	}
+	c.isMethodKnown = true
+
+	if !c.p.isAccessAllowed(c.ctx, *c.rr.Method, *c.rr.Id, c.req) {
+		c.resp.Error = NewRpcErrorFast(RpcErrorCode_AccessDenied)
+		c.respond()
+		return false
+	}
+
+	if c.settings.isParametersCheckEnabled() {
+		details := findParametersProblem(*c.rr.Parameters, c.settings.RequireObjectParameters, c.settings.RejectDuplicateKeys)
+		if len(details) > 0 {
+			c.resp.Error, _ = NewRpcError(RpcErrorCode_InvalidParameters, details)
+			c.respond()
+			return false
+		}
+	}
+
+	c.isStream = c.p.isStreamFunc(*c.rr.Method)
+	if c.isStream && c.isWriteDisabled {
+		// Only calls without output have the write disabled at this stage.
+		c.isStream = false
+		details := ErrStreamIsNotSupportedWithoutOutput
+		if c.isBatchItem {
+			details = ErrStreamIsNotSupportedInBatch
+		}
+		c.resp.Error, _ = NewRpcError(RpcErrorCode_InvalidRequest, details)
+		c.respond()
+		return false
+	}
+
+	if c.settings.EnableNotifications && !c.isWriteDisabled {
+		c.isNotification = c.isNotificationRequested || c.p.isNotificationOnly(*c.rr.Method)
+	}
+
+	return true
+}
+
+// enqueue puts the notification into the queue of the RPC processor (server).
+// If the queue is full, 'False' is returned. The caller must stop serving the
+// request after this function returns, while the function is executed
+// asynchronously.
+func (c *rpcCall) enqueue() (ok bool) {
+	c.isWriteDisabled = true
+
+	return c.p.enqueueNotification(c)
+}
+
+// runNotification executes the function of a notification. The response is
+// not written to the client, while it is still journaled and measured. An
+// exception which escaped from the function is journaled, so that it does
+// not stop the worker or crash the whole program.
+func (c *rpcCall) runNotification() {
+	defer func() {
+		x := recover()
+		if x != nil {
+			c.failOnEscapedPanic(x, debug.Stack())
+		}
+	}()
+
+	if !c.run() {
+		return
+	}
+
+	c.respond()
+}
+
+// serveBatch reads a batch of function calls from the input, serves each of
+// them independently and writes an array of responses. If the batch itself is
+// not correct, a single response with an error is written. The caller must
+// stop serving the request after this function returns.
+func (c *rpcCall) serveBatch() {
+	items, isTooLarge, err := readBatch(c.input, c.settings.getMaxBatchSize())
+	if err != nil {
+		c.p.incAllRequestsCounter()
+		c.resp.Error = NewRpcErrorFast(RpcErrorCode_RequestIsNotReadable)
+		c.respond()
+		return
+	}
+
+	if (len(items) == 0) || isTooLarge {
+		c.p.incAllRequestsCounter()
+		var re *RpcError
+		if isTooLarge {
+			re, _ = NewRpcError(RpcErrorCode_InvalidRequest, ErrBatchIsTooLarge)
+		} else {
+			re, _ = NewRpcError(RpcErrorCode_InvalidRequest, ErrBatchIsEmpty)
+		}
+		c.resp.Error = re
+		c.respond()
+		return
+	}
+
+	responses := make([]*RpcResponse, len(items))
+	if c.settings.RunBatchesInParallel {
+		wg := new(sync.WaitGroup)
+		for i := range items {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				responses[i] = c.serveBatchItem(items[i])
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := range items {
+			responses[i] = c.serveBatchItem(items[i])
+		}
+	}
+
+	if c.out == nil {
+		return
+	}
+
+	c.writeOutput(responses)
+}
+
+// serveBatchItem serves a single function call of a batch and returns its
+// response.
+func (c *rpcCall) serveBatchItem(item json.RawMessage) (resp *RpcResponse) {
+	ci := newRpcCall(c.p, c.settings, c.ctx, c.req, nil)
+	ci.isBatchItem = true
+	ci.batchItemSize = int64(len(item))
+	ci.startTimer()
+	ci.p.incAllRequestsCounter()
+	ci.resp = NewRpcResponse()
+
+	// Items of a batch may be served in separate goroutines, where an
+	// exception would crash the whole program.
+	defer func() {
+		x := recover()
+		if x != nil {
+			ci.failOnEscapedPanic(x, debug.Stack())
+			resp = ci.resp
+		}
+	}()
+
+	var err error
+	tDecodeStart := time.Now()
+	ci.rr, err = ci.decodeRequestBytes(item)
+	ci.savePhaseDuration(DurationPhase_Decode, tDecodeStart)
+	ci.startSpan()
+	if err != nil {
+		ci.resp.Error = NewRpcErrorFast(RpcErrorCode_RequestIsNotReadable)
+		ci.respond()
+		return ci.resp
+	}
+
+	return ci.serveDecoded()
+}
+
+// serveDecoded serves a decoded function call whose response is not written
+// to the output, and returns the response.
+func (c *rpcCall) serveDecoded() (resp *RpcResponse) {
+	if !c.check() {
+		return c.resp
+	}
+
+	if !c.run() {
+		return c.resp
+	}
+
+	c.respond()
+	return c.resp
+}
+
+// startSpan starts the span of the function call if tracing is enabled. The
+// parent span context is extracted from HTTP headers, if the call is received
+// via HTTP, or from the context otherwise.
+func (c *rpcCall) startSpan() {
+	if !c.settings.isTracingEnabled() {
+		return
+	}
+
+	// Malformed trace context is ignored and a new trace is started.
+	var parent SpanContext
+	if c.req != nil {
+		parent, _ = ParseTraceParent(c.req.Header.Get(HttpHeaderTraceParent), c.req.Header.Get(HttpHeaderTraceState))
+	} else {
+		parent, _ = SpanContextFromContext(c.ctx)
+	}
+
+	name := MetricsUnknownMethod
+	if (c.rr != nil) && (c.rr.Method != nil) {
+		name = *c.rr.Method
+	}
+
+	c.span = c.settings.Tracer.StartSpan(name, parent)
+	c.span.SetAttribute(SpanAttr_RpcSystem, SpanAttrValue_RpcSystem)
+	c.span.SetAttribute(SpanAttr_Method, name)
+	if (c.rr != nil) && (c.rr.Id != nil) {
+		c.span.SetAttribute(SpanAttr_RequestId, *c.rr.Id)
+	}
+}
+
+// endSpan sets the outcome of the function call and ends the span.
+func (c *rpcCall) endSpan() {
+	if c.span == nil {
+		return
+	}
+
+	if c.resp.OK {
+		c.span.SetAttribute(SpanAttr_Outcome, AccessLogOutcomeSuccess)
+	} else {
+		c.span.SetAttribute(SpanAttr_Outcome, AccessLogOutcomeFailure)
+		c.span.SetAttribute(SpanAttr_ErrorCode, c.resp.Error.Code.Int())
+	}
+
+	c.span.End()
+}
+
+// startTimer starts the timer.
+func (c *rpcCall) startTimer() {
+	if c.settings.isTimerEnabled() {
+		c.tStart = time.Now()
+	}
+}
+
+// run calls the requested function and collects the result. If error occurs,
+// it responds to the client. If everything is correct and ready to be
+// processed further, 'True' is returned. When 'False' is returned, the caller
+// must stop serving the request.
+func (c *rpcCall) run() (proceed bool) {
+	var err error
+	if c.settings.isRequestIdShown() {
+		err = c.resp.Meta.AddField(*c.settings.RequestIdFieldName, *c.rr.Id)
+		if err != nil {
+			c.resp.Error = NewRpcErrorFast(RpcErrorCode_InternalRpcError)
+			c.respond()
+			return false
+		}
+	}
+
+	if c.settings.isSpanShown() {
+		err = c.resp.Meta.AddField(*c.settings.SpanFieldName, c.span)
+		if err != nil {
+			c.resp.Error = NewRpcErrorFast(RpcErrorCode_InternalRpcError)
+			c.respond()
+			return false
+		}
+	}
+
+	// Calls are finished even when an exception escapes from the function.
+	if c.settings.isMetricsEnabled() {
+		c.settings.Metrics.beginServerCall(*c.rr.Method)
+		defer c.settings.Metrics.endServerCall(*c.rr.Method)
+	}
+
+	tRunStart := time.Now()
+	if c.isStream {
+		c.resp.Error, c.pi = c.p.runStreamFunc(*c.rr.Method, *c.rr.Id, c.rr.Parameters, c.resp.Meta, c.writeStreamItem)
+	} else {
+		c.resp.Result, c.resp.Error, c.pi = c.p.runFunc(*c.rr.Method, *c.rr.Id, c.rr.Parameters, c.resp.Meta)
+	}
+	c.savePhaseDuration(DurationPhase_Run, tRunStart)
+
+	if c.settings.isSpanShown() {
+		err = c.resp.Meta.RemoveField(*c.settings.SpanFieldName)
+		if err != nil {
+			c.resp.Error = NewRpcErrorFast(RpcErrorCode_InternalRpcError)
+			c.respond()
+			return false
+		}
+	}
+
+	if c.settings.isRequestIdShown() {
+		err = c.resp.Meta.RemoveField(*c.settings.RequestIdFieldName)
+		if err != nil {
+			c.resp.Error = NewRpcErrorFast(RpcErrorCode_InternalRpcError)
+			c.respond()
+			return false
+		}
+	}
+
+	if !c.stopTimer() {
+		return false
+	}
+
+	return true
+}
+
+// stopTimer stops the timer and saves the duration as a meta-data field. If
+// error occurs, it responds to the client. If everything is correct and ready
+// to be processed further, 'True' is returned. When 'False' is returned, the
+// caller must stop serving the request.
+func (c *rpcCall) stopTimer() (proceed bool) {
+	if c.settings.isDurationEnabled() {
+		c.tDuration = time.Since(c.tStart)
+		c.isTimerStopped = true
+
+		err := c.resp.Meta.AddField(*c.p.settings.DurationFieldName, c.settings.DurationFormat.Format(c.tDuration))
+		if err != nil {
+			c.resp.Error = NewRpcErrorFast(RpcErrorCode_InternalRpcError)
+			c.respond()
+			return false
+		}
+	}
+
+	return true
+}
+
+// savePhaseDuration saves the duration of a finished phase of request
+// processing if phase durations are enabled.
+func (c *rpcCall) savePhaseDuration(phase string, tPhaseStart time.Time) {
+	if !c.settings.isPhaseDurationEnabled() {
+		return
+	}
+
+	if c.phaseDurations == nil {
+		c.phaseDurations = make(map[string]time.Duration)
+	}
+
+	c.phaseDurations[phase] = time.Since(tPhaseStart)
+}
+
+// encodeResult encodes the result of a successful function call in advance,
+// so that time taken to encode it can be measured. If the result can not be
+// encoded, the function call fails with an internal RPC error.
+func (c *rpcCall) encodeResult() {
+	if c.resp.hasError() || (c.resp.Result == nil) {
+		return
+	}
+
+	tEncodeStart := time.Now()
+	buf, re, pi := c.encodeInAdvance(c.resp.Result)
+	if re != nil {
+		c.resp.Result = nil
+		c.resp.Error = re
+		if pi != nil {
+			c.pi = pi
+		}
+		return
+	}
+	c.resp.Result = buf
+	c.savePhaseDuration(DurationPhase_Encode, tEncodeStart)
+}
+
+// encodeMetaData encodes values of meta-data fields in advance, so that an
+// exception in encoding of a value is caught and does not break the output.
+// If a value can not be encoded, its field is removed and the function call
+// fails.
+func (c *rpcCall) encodeMetaData() {
+	if c.resp.Meta == nil {
+		return
+	}
+
+	for name, value := range *c.resp.Meta {
+		buf, re, pi := c.encodeInAdvance(value)
+		if re != nil {
+			delete(*c.resp.Meta, name)
+			if !c.resp.hasError() {
+				c.resp.Result = nil
+				c.resp.Error = re
+			}
+			if (pi != nil) && (c.pi == nil) {
+				c.pi = pi
+			}
+			continue
+		}
+		(*c.resp.Meta)[name] = buf
+	}
+}
+
+// encodeInAdvance encodes the value for the codec of the response. Values are
+// encoded as JSON for the JSON codec and are converted into trees of generic
+// values for binary codecs, so that byte slices are not turned into text.
+func (c *rpcCall) encodeInAdvance(v any) (encoded any, re *RpcError, pi *panicInfo) {
+	if !isJsonCodec(c.getResponseCodec()) {
+		return c.p.marshalCodecTree(c.getMethod(), c.getRequestId(), v)
+	}
+
+	var buf json.RawMessage
+	buf, re, pi = c.p.marshalJson(c.getMethod(), c.getRequestId(), v)
+	if re != nil {
+		return nil, re, pi
+	}
+
+	return buf, nil, nil
+}
+
+// isEncodedSafely tells whether the response is encoded in advance to catch
+// exceptions of encoding. Only responses written to the output are encoded.
+func (c *rpcCall) isEncodedSafely() bool {
+	return c.settings.CatchExceptions && (!c.isWriteDisabled || c.isBatchItem)
+}
+
+// getMethod returns the name of the requested function, if it is known.
+func (c *rpcCall) getMethod() string {
+	if (c.rr == nil) || (c.rr.Method == nil) {
+		return ""
+	}
+
+	return *c.rr.Method
+}
+
+// getRequestId returns the ID of the request, if it is known.
+func (c *rpcCall) getRequestId() string {
+	if (c.rr == nil) || (c.rr.Id == nil) {
+		return ""
+	}
+
+	return *c.rr.Id
+}
+
+// saveDurations saves durations which are not saved yet as meta-data fields.
+// The total duration is not saved yet when the function call fails before
+// the function is executed. If a field having the same name is already set,
+// e.g. by the function, the conflict is journaled and the function call fails
+// with an internal RPC error.
+func (c *rpcCall) saveDurations() {
+	if c.settings.isDurationEnabled() && !c.isTimerStopped {
+		c.tDuration = time.Since(c.tStart)
+		c.isTimerStopped = true
+		err := c.resp.Meta.AddField(*c.settings.DurationFieldName, c.settings.DurationFormat.Format(c.tDuration))
+		if err != nil {
+			c.failWithInternalError(err)
+		}
+	}
+
+	if c.settings.isPhaseDurationEnabled() {
+		phases := make(map[string]any, len(c.phaseDurations))
+		for phase, d := range c.phaseDurations {
+			phases[phase] = c.settings.DurationFormat.Format(d)
+		}
+		err := c.resp.Meta.AddField(*c.settings.PhaseDurationsFieldName, phases)
+		if err != nil {
+			c.failWithInternalError(err)
+		}
+	}
+}
+
+// failOnEscapedPanic journals an exception (panic) which escaped from serving
+// of the function call, e.g. because exceptions are not caught, and makes the
+// function call fail with an internal RPC error.
+func (c *rpcCall) failOnEscapedPanic(x any, stack []byte) {
+	c.p.logEscapedPanic(c.getMethod(), c.getRequestId(), x, stack)
+
+	c.resp.Id = nil
+	if c.rr != nil {
+		c.resp.Id = c.rr.Id
+	}
+	c.resp.Result = nil
+	c.resp.Error = NewRpcErrorFast(RpcErrorCode_InternalRpcError)
+	c.resp.Meta = nil
+	c.resp.OK = false
+}
+
+// failWithInternalError journals the error and makes the function call fail
+// with an internal RPC error while the response is being prepared.
+func (c *rpcCall) failWithInternalError(err error) {
+	c.settings.getLogger().Error(err.Error())
+	c.resp.Result = nil
+	c.resp.Error = NewRpcErrorFast(RpcErrorCode_InternalRpcError)
+}
+
+// respond analyses the result and writes the response to the output. The
+// caller must stop serving the request after this function returns.
+func (c *rpcCall) respond() {
+	if c.settings.isPhaseDurationEnabled() || c.isEncodedSafely() {
+		c.encodeResult()
+	}
+
+	c.saveDurations()
+
+	if c.isEncodedSafely() {
+		c.encodeMetaData()
+	}
+
+	// Empty meta-data set must not be shown.
+	if len(*c.resp.Meta) == 0 {
+		c.resp.Meta = nil
+	}
+
+	if !c.resp.hasError() {
+		c.resp.OK = true
+		c.p.incSuccessfulRequestsCounter()
+	} else if c.settings.ErrorLocalization != nil {
+		c.resp.Error = c.settings.ErrorLocalization.Localize(c.resp.Error, c.getLocales())
+	}
+
+	if c.isStream {
+		c.writeStreamTrailer()
+	} else if !c.isWriteDisabled {
+		c.writeOutput(c.resp)
+	}
+
+	c.writeAccessLog()
+	c.recordMetrics()
+	c.endSpan()
+}
+
+// getLocales returns the locales of the function call. Locales of the context
+// take precedence over the 'Accept-Language' HTTP header.
+func (c *rpcCall) getLocales() (locales string) {
+	locales, ok := LocaleFromContext(c.ctx)
+	if ok {
+		return locales
+	}
+
+	if c.req != nil {
+		return c.req.Header.Get(header.HttpHeaderAcceptLanguage)
+	}
+
+	return ""
+}
+
+// writeOutput encodes the value using the codec of the response and writes it
+// to the output. If the value can not be encoded, an internal RPC error is
+// written instead, so that the client does not get an empty response.
+func (c *rpcCall) writeOutput(v any) {
+	codec := c.getResponseCodec()
+
+	if c.settings.EnableFastPath && isJsonCodec(codec) && !c.settings.JsonProfile.isPretty() {
+		buf := getBuffer()
+		defer putBuffer(buf)
+
+		ok, err := appendRpcResponses(buf, v)
+		if ok {
+			if err != nil {
+				c.writeEncodingFailure(v, err)
+				return
+			}
+			c.setOutputContentType(codec.ContentType())
+			c.writeBytes(buf.Bytes())
+			return
+		}
+	}
+
+	data, err := codec.Marshal(v)
+	if err != nil {
+		c.writeEncodingFailure(v, err)
+		return
+	}
+	c.setOutputContentType(codec.ContentType())
+	c.writeBytes(data)
+}
+
+// writeEncodingFailure journals the problem of encoding of the value and
+// writes a response with an internal RPC error encoded as JSON, which does
+// not fail. The response has the ID of the failed response, if it is known.
+func (c *rpcCall) writeEncodingFailure(v any, encodingErr error) {
+	c.settings.getLogger().Error(encodingErr.Error())
+
+	resp := NewRpcResponse()
+	resp.Meta = nil
+	resp.Error = NewRpcErrorFast(RpcErrorCode_InternalRpcError)
+	failedResp, ok := v.(*RpcResponse)
+	if ok {
+		resp.Id = failedResp.Id
+	}
+
+	jc := NewJsonCodec()
+	data, err := jc.Marshal(resp)
+	if err != nil {
+		c.settings.getLogger().Error(err.Error())
+		return
+	}
+
+	c.setOutputContentType(jc.ContentType())
+	c.writeBytes(data)
+}
+
+// writeBytes writes the encoded data to the output.
+func (c *rpcCall) writeBytes(data []byte) {
+	_, err := c.out.Write(data)
+	if err != nil {
+		c.settings.getLogger().Error(err.Error())
+	}
+}
+
+// getResponseCodec returns the codec of the response.
+func (c *rpcCall) getResponseCodec() Codec {
+	if c.responseCodec == nil {
+		return c.p.codecs[0]
+	}
+
+	return c.responseCodec
+}
+
+// setOutputContentType sets the type of content of the output if the
+// transport supports it.
+func (c *rpcCall) setOutputContentType(contentType string) {
+	if c.setContentType != nil {
+		c.setContentType(contentType)
+	}
+}
+
+// startStream writes the header of a streamed response unless it is already
+// written.
+func (c *rpcCall) startStream() (err error) {
+	if c.streamEncoder != nil {
+		return nil
+	}
+
+	c.setOutputContentType(StreamContentType)
+	c.streamEncoder = json.NewEncoder(c.out)
+
+	return c.streamEncoder.Encode(&StreamHeader{
+		ProtocolName: c.resp.ProtocolName,
+		Id:           c.resp.Id,
+	})
+}
+
+// writeStreamItem writes a result item of a streaming function to the output
+// and flushes it periodically. If the item can not be written, 'False' is
+// returned. Items of notifications are discarded.
+func (c *rpcCall) writeStreamItem(item any) (ok bool) {
+	if c.isWriteDisabled {
+		return true
+	}
+
+	err := c.startStream()
+	if err != nil {
+		return false
+	}
+
+	err = c.streamEncoder.Encode(&StreamItem{Item: item})
+	if err != nil {
+		c.settings.getLogger().Error(err.Error())
+		return false
+	}
+
+	c.streamItemsCount++
+	if (c.flush != nil) && (c.streamItemsCount%c.settings.getStreamFlushPeriod() == 0) {
+		c.flush()
+	}
+
+	return true
+}
+
+// writeStreamTrailer writes the trailer of a streamed response containing
+// the outcome of the function call.
+func (c *rpcCall) writeStreamTrailer() {
+	if c.isWriteDisabled {
+		return
+	}
+
+	err := c.startStream()
+	if err == nil {
+		err = c.streamEncoder.Encode(&StreamTrailer{
+			Error: c.resp.Error,
+			Meta:  c.resp.Meta,
+			OK:    c.resp.OK,
+		})
+	}
+	if err != nil {
+		c.settings.getLogger().Error(err.Error())
+	}
+}
+
+// recordMetrics registers the function call in metrics if they are enabled.
+func (c *rpcCall) recordMetrics() {
+	if !c.settings.isMetricsEnabled() {
+		return
+	}
+
+	// Names of unknown methods are not used as label values, while otherwise
+	// anyone could create an unlimited number of metric series.
+	method := MetricsUnknownMethod
+	if c.isMethodKnown {
+		method = *c.rr.Method
+	}
+
+	outcome := MetricOutcome_Success
+	if !c.resp.OK {
+		outcome = MetricOutcome_Failure
+	}
+
+	var reqSize, respSize int64
+	if c.inCounter != nil {
+		reqSize = c.inCounter.n
+	}
+	if c.outCounter != nil {
+		respSize = c.outCounter.n
+	}
+
+	// Items of a batch share the input and the output, so the size of the
+	// response of an item is the size of its JSON text in the batch.
+	if c.isBatchItem {
+		reqSize = c.batchItemSize
+		data, err := json.Marshal(c.resp)
+		if err == nil {
+			respSize = int64(len(data))
+		}
+	}
+
+	c.settings.Metrics.recordServerCall(method, outcome, time.Since(c.tStart), c.pi != nil, reqSize, respSize)
+}
+
+// writeAccessLog journals the function call if the access log is enabled.
+func (c *rpcCall) writeAccessLog() {
+	if !c.settings.isAccessLogEnabled() {
+		return
+	}
+
+	als := c.settings.AccessLog
+	if c.resp.OK && !als.isSampled(c.p.accessLogCounter.Add(1)) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 9)
+	if c.rr != nil {
+		if c.rr.Id != nil {
+			attrs = append(attrs, slog.String(LogAttr_RequestId, *c.rr.Id))
+		}
+		if c.rr.Method != nil {
+			attrs = append(attrs, slog.String(LogAttr_Method, *c.rr.Method))
+		}
+	}
+	if c.req != nil {
+		attrs = append(attrs, slog.String(LogAttr_RemoteAddress, c.req.RemoteAddr))
+	}
+	attrs = append(attrs, slog.Duration(LogAttr_Duration, time.Since(c.tStart)))
+
+	level := slog.LevelInfo
+	if c.resp.OK {
+		attrs = append(attrs, slog.String(LogAttr_Outcome, AccessLogOutcomeSuccess))
+	} else {
+		level = slog.LevelWarn
+		attrs = append(attrs,
+			slog.String(LogAttr_Outcome, AccessLogOutcomeFailure),
+			slog.Int(LogAttr_ErrorCode, c.resp.Error.Code.Int()),
+		)
+	}
+
+	if als.LogParameters && (c.rr != nil) && (c.rr.Method != nil) {
+		attrs = append(attrs, slog.Any(LogAttr_Parameters, als.redactParameters(*c.rr.Method, c.rr.Parameters)))
+	}
+
+	if c.pi != nil {
+		level = slog.LevelError
+		attrs = append(attrs,
+			slog.String(LogAttr_Panic, fmt.Sprint(c.pi.value)),
+			slog.String(LogAttr_Stack, string(c.pi.stack)),
+		)
+		if len(c.pi.incidentId) > 0 {
+			attrs = append(attrs, slog.String(LogAttr_IncidentId, c.pi.incidentId))
+		}
+	}
+
+	c.settings.getLogger().LogAttrs(c.ctx, level, AccessLogMessage, attrs...)
+}
@@ -0,0 +1,169 @@
+package jrm1
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	ErrSocketMessageIsTooLarge = "socket message is too large"
+)
+
+// socketMessageSizeLength is the length of the size prefix of a message in
+// bytes.
+const socketMessageSizeLength = 4
+
+// socketConn is a socket connection transferring framed messages. Writing is
+// safe for concurrent use, reading is not.
+type socketConn struct {
+	rwc io.ReadWriteCloser
+	br  *bufio.Reader
+
+	// Network connection used for time limits. It is null when messages are
+	// transferred over other streams, e.g. standard input and output.
+	conn net.Conn
+
+	framing SocketFraming
+
+	// Maximum size of a received message. The limit can not be disabled,
+	// because sizes of messages are read from the network.
+	maxMessageSize int
+
+	// Time limit of receiving a message. Zero means no limit.
+	readTimeout time.Duration
+
+	// Time limit of writing a message. Zero means no limit.
+	writeTimeout time.Duration
+
+	writeGuard *sync.Mutex
+}
+
+// newSocketConn is a constructor of a socket connection transferring framed
+// messages.
+func newSocketConn(conn net.Conn, framing SocketFraming, maxMessageSize int, readTimeout time.Duration, writeTimeout time.Duration) (sc *socketConn) {
+	sc = newStreamSocketConn(conn, framing, maxMessageSize)
+	sc.conn = conn
+	sc.readTimeout = readTimeout
+	sc.writeTimeout = writeTimeout
+
+	return sc
+}
+
+// newStreamSocketConn is a constructor of a connection transferring framed
+// messages over a stream without time limits. When the maximum size of a
+// received message is not positive, the default size is used.
+func newStreamSocketConn(rwc io.ReadWriteCloser, framing SocketFraming, maxMessageSize int) (sc *socketConn) {
+	if maxMessageSize <= 0 {
+		maxMessageSize = DefaultSocketMaxMessageSize
+	}
+
+	return &socketConn{
+		rwc:            rwc,
+		br:             bufio.NewReader(rwc),
+		framing:        framing,
+		maxMessageSize: maxMessageSize,
+		writeGuard:     new(sync.Mutex),
+	}
+}
+
+// nextMessage reads the next message. When the peer closes the connection
+// between messages, 'io.EOF' is returned.
+func (sc *socketConn) nextMessage() (data []byte, err error) {
+	if (sc.conn != nil) && (sc.readTimeout > 0) {
+		err = sc.conn.SetReadDeadline(time.Now().Add(sc.readTimeout))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if sc.framing == SocketFraming_NewlineDelimited {
+		return sc.readLine()
+	}
+
+	var prefix [socketMessageSizeLength]byte
+	_, err = io.ReadFull(sc.br, prefix[:])
+	if err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(prefix[:])
+	if uint64(size) > uint64(sc.maxMessageSize) {
+		return nil, errors.New(ErrSocketMessageIsTooLarge)
+	}
+
+	data = make([]byte, size)
+	_, err = io.ReadFull(sc.br, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// readLine reads a message terminated by a new line. Empty lines are skipped.
+func (sc *socketConn) readLine() (data []byte, err error) {
+	for {
+		var line []byte
+		line, err = sc.br.ReadSlice('\n')
+		data = append(data, line...)
+
+		if len(data) > sc.maxMessageSize+1 {
+			return nil, errors.New(ErrSocketMessageIsTooLarge)
+		}
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) && (len(data) > 0) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		data = bytes.TrimRight(data, "\r\n")
+		if len(data) > 0 {
+			return data, nil
+		}
+	}
+}
+
+// writeMessage writes a message.
+func (sc *socketConn) writeMessage(data []byte) (err error) {
+	var frame []byte
+	if sc.framing == SocketFraming_NewlineDelimited {
+		// New lines may only separate JSON values, so they are replaced with
+		// spaces, which is safe.
+		frame = make([]byte, 0, len(data)+1)
+		frame = append(frame, bytes.ReplaceAll(data, []byte("\n"), []byte(" "))...)
+		frame = append(frame, '\n')
+	} else {
+		frame = make([]byte, 0, len(data)+socketMessageSizeLength)
+		frame = binary.BigEndian.AppendUint32(frame, uint32(len(data)))
+		frame = append(frame, data...)
+	}
+
+	sc.writeGuard.Lock()
+	defer sc.writeGuard.Unlock()
+
+	if (sc.conn != nil) && (sc.writeTimeout > 0) {
+		err = sc.conn.SetWriteDeadline(time.Now().Add(sc.writeTimeout))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = sc.rwc.Write(frame)
+	return err
+}
+
+// close closes the connection.
+func (sc *socketConn) close() (err error) {
+	return sc.rwc.Close()
+}
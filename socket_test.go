@@ -0,0 +1,82 @@
+package jrm1
+
+import (
+	"io"
+	"net"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_socketConn(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	for _, framing := range []SocketFraming{SocketFraming_LengthPrefixed, SocketFraming_NewlineDelimited} {
+		client, server := _newSocketConnPair(t, framing, 20)
+
+		// Test #1. Messages are separated.
+		err = client.writeMessage([]byte(`{"a":1}`))
+		aTest.MustBeNoError(err)
+		err = client.writeMessage([]byte("{\"b\":2}\n{\"c\":3}"))
+		aTest.MustBeNoError(err)
+		data, err := server.nextMessage()
+		aTest.MustBeNoError(err)
+		aTest.MustBeEqual(string(data), `{"a":1}`)
+		data, err = server.nextMessage()
+		aTest.MustBeNoError(err)
+		if framing == SocketFraming_NewlineDelimited {
+			aTest.MustBeEqual(string(data), `{"b":2} {"c":3}`)
+		} else {
+			aTest.MustBeEqual(string(data), "{\"b\":2}\n{\"c\":3}")
+		}
+
+		// Test #2. Too large message.
+		err = client.writeMessage(make([]byte, 21))
+		aTest.MustBeNoError(err)
+		_, err = server.nextMessage()
+		aTest.MustBeAnError(err)
+		aTest.MustBeEqual(err.Error(), ErrSocketMessageIsTooLarge)
+
+		// Test #3. Closing by the peer.
+		client, server = _newSocketConnPair(t, framing, 0)
+		err = client.close()
+		aTest.MustBeNoError(err)
+		_, err = server.nextMessage()
+		aTest.MustBeEqual(err, io.EOF)
+	}
+
+	// Test #4. Size of a message is always limited, so that a message of a
+	// huge size is not allocated.
+	client, server := _newSocketConnPair(t, SocketFraming_LengthPrefixed, 0)
+	aTest.MustBeEqual(server.maxMessageSize, DefaultSocketMaxMessageSize)
+	_, err = client.conn.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF})
+	aTest.MustBeNoError(err)
+	_, err = server.nextMessage()
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrSocketMessageIsTooLarge)
+}
+
+// _newSocketConnPair creates a pair of socket connections of the client and
+// the server connected via the loopback interface.
+func _newSocketConnPair(t *testing.T, framing SocketFraming, maxMessageSize int) (client *socketConn, server *socketConn) {
+	aTest := tester.New(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	aTest.MustBeNoError(err)
+	defer func() { _ = listener.Close() }()
+
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	aTest.MustBeNoError(err)
+	serverConn, err := listener.Accept()
+	aTest.MustBeNoError(err)
+	t.Cleanup(func() {
+		_ = clientConn.Close()
+		_ = serverConn.Close()
+	})
+
+	client = newSocketConn(clientConn, framing, 0, 0, 0)
+	server = newSocketConn(serverConn, framing, maxMessageSize, 0, 0)
+
+	return client, server
+}
@@ -0,0 +1,92 @@
+package jrm1
+
+import (
+	"io"
+	"sync"
+
+	ae "github.com/vault-thirteen/auxie/errors"
+)
+
+// stdioNetwork is a name of the network and an address of the client served
+// over standard streams.
+const stdioNetwork = "stdio"
+
+// stdioStream joins a reader and a writer, e.g. standard input and output of
+// a process, into a single stream.
+type stdioStream struct {
+	r io.Reader
+	w io.Writer
+
+	closeReaderOnce *sync.Once
+	closeWriterOnce *sync.Once
+}
+
+// newStdioStream is a constructor of a stream made of a reader and a writer.
+func newStdioStream(r io.Reader, w io.Writer) (ss *stdioStream) {
+	return &stdioStream{
+		r:               r,
+		w:               w,
+		closeReaderOnce: new(sync.Once),
+		closeWriterOnce: new(sync.Once),
+	}
+}
+
+// Read reads data from the reader.
+// 'Read' is a required method of the 'io.Reader' interface.
+func (ss *stdioStream) Read(p []byte) (n int, err error) {
+	return ss.r.Read(p)
+}
+
+// Write writes data to the writer.
+// 'Write' is a required method of the 'io.Writer' interface.
+func (ss *stdioStream) Write(p []byte) (n int, err error) {
+	return ss.w.Write(p)
+}
+
+// Close closes the writer and then the reader.
+// 'Close' is a required method of the 'io.Closer' interface.
+func (ss *stdioStream) Close() (err error) {
+	err = ss.closeWriter()
+	return ae.Combine(err, ss.closeReader())
+}
+
+// closeWriter closes the writer, if it can be closed. The writer is closed
+// only once, next calls do nothing.
+func (ss *stdioStream) closeWriter() (err error) {
+	ss.closeWriterOnce.Do(func() {
+		wc, ok := ss.w.(io.Closer)
+		if ok {
+			err = wc.Close()
+		}
+	})
+
+	return err
+}
+
+// closeReader closes the reader, if it can be closed. The reader is closed
+// only once, next calls do nothing.
+func (ss *stdioStream) closeReader() (err error) {
+	ss.closeReaderOnce.Do(func() {
+		rc, ok := ss.r.(io.Closer)
+		if ok {
+			err = rc.Close()
+		}
+	})
+
+	return err
+}
+
+// stdioAddr is a network address of the client served over standard streams.
+type stdioAddr struct{}
+
+// Network returns the name of the network.
+// 'Network' is a required method of the 'net.Addr' interface.
+func (a stdioAddr) Network() string {
+	return stdioNetwork
+}
+
+// String returns the address.
+// 'String' is a required method of the 'net.Addr' interface.
+func (a stdioAddr) String() string {
+	return stdioNetwork
+}
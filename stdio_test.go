@@ -0,0 +1,37 @@
+package jrm1
+
+import (
+	"io"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_stdioStream(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	r, w := io.Pipe()
+	ss := newStdioStream(r, w)
+
+	// Test #1. Reading and writing.
+	go func() { _, _ = ss.Write([]byte("abc")) }()
+	buf := make([]byte, 3)
+	_, err = io.ReadFull(ss, buf)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(buf), "abc")
+
+	// Test #2. Closing.
+	err = ss.Close()
+	aTest.MustBeNoError(err)
+	_, err = ss.Write([]byte("abc"))
+	aTest.MustBeAnError(err)
+
+	// Test #3. Closing again.
+	err = ss.Close()
+	aTest.MustBeNoError(err)
+
+	// Test #4. Address.
+	aTest.MustBeEqual(stdioAddr{}.Network(), "stdio")
+	aTest.MustBeEqual(stdioAddr{}.String(), "stdio")
+}
@@ -0,0 +1,56 @@
+package jrm1
+
+import "encoding/json"
+
+// StreamContentType is a type of content of a streamed response. It is the
+// newline-delimited JSON.
+const StreamContentType = "application/x-ndjson"
+
+const (
+	ErrStreamIsNotSupportedInBatch = "streaming is not supported in a batch"
+	ErrStreamIsTruncated           = "stream is truncated"
+	ErrStreamRecordIsUnexpected    = "unexpected record in the stream"
+)
+
+// StreamHeader is the first record of a streamed response.
+type StreamHeader struct {
+	// RPC protocol name.
+	ProtocolName string `json:"jsonrpc"`
+
+	// Identifier of request and its response.
+	Id *string `json:"id"`
+}
+
+// StreamItem is a record of a streamed response containing a single item of
+// the result.
+type StreamItem struct {
+	Item any `json:"item"`
+}
+
+// StreamTrailer is the last record of a streamed response. It contains the
+// outcome of the function call.
+type StreamTrailer struct {
+	// Error returned by the called RPC function (method, procedure).
+	Error *RpcError `json:"error"`
+
+	// Additional meta-information about the RPC call.
+	Meta *ResponseMetaData `json:"meta,omitempty"`
+
+	// Flag of success.
+	OK bool `json:"ok"`
+}
+
+// streamRecordRaw is a record of a streamed response following the header. It
+// is either an item or the trailer, which is recognised by the flag of
+// success.
+type streamRecordRaw struct {
+	Item  json.RawMessage   `json:"item"`
+	Error *RpcError         `json:"error"`
+	Meta  *ResponseMetaData `json:"meta"`
+	OK    *bool             `json:"ok"`
+}
+
+// isTrailer tells whether the record is the trailer.
+func (srr *streamRecordRaw) isTrailer() bool {
+	return srr.OK != nil
+}
@@ -0,0 +1,16 @@
+package jrm1
+
+import (
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_streamRecordRaw_isTrailer(t *testing.T) {
+	aTest := tester.New(t)
+	ok := true
+
+	// Test.
+	aTest.MustBeEqual((&streamRecordRaw{Item: []byte(`1`)}).isTrailer(), false)
+	aTest.MustBeEqual((&streamRecordRaw{OK: &ok}).isTrailer(), true)
+}
@@ -0,0 +1,171 @@
+package jrm1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	ErrFUnknownFieldInRequest    = "unknown field in request: %v"
+	ErrFDuplicateFieldInRequest  = "duplicate field in request: %v"
+	ErrFDuplicateKeyInParameters = "duplicate key in parameters: %v"
+	ErrParametersAreNotObject    = "parameters are not an object"
+)
+
+// Strict decoding rejects requests which the standard JSON decoder silently
+// accepts: unknown fields of the envelope, duplicate keys, whose last value
+// wins, and parameters which are not an object. Details of the problem are
+// returned to the client in the 'Data' field of the RPC error.
+
+// findEnvelopeProblem checks the fields of the encoded request and describes
+// the first problem found. Empty text means that there are no problems. Names
+// of fields are matched without regard to the letter case, in the same way as
+// the JSON decoder does.
+func findEnvelopeProblem(data []byte, rejectUnknownFields bool, rejectDuplicateFields bool) (details string) {
+	keys, isObject := readJsonObjectKeys(data)
+	if !isObject {
+		return ""
+	}
+
+	seenKeys := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if rejectUnknownFields && !isFoldedRpcRequestField([]byte(key)) {
+			return fmt.Sprintf(ErrFUnknownFieldInRequest, key)
+		}
+
+		foldedKey := strings.ToLower(key)
+		if rejectDuplicateFields && seenKeys[foldedKey] {
+			return fmt.Sprintf(ErrFDuplicateFieldInRequest, key)
+		}
+		seenKeys[foldedKey] = true
+	}
+
+	return ""
+}
+
+// findParametersProblem checks the encoded parameters and describes the first
+// problem found. Empty text means that there are no problems.
+func findParametersProblem(params json.RawMessage, requireObject bool, rejectDuplicateKeys bool) (details string) {
+	if requireObject && !isJsonObject(params) {
+		return ErrParametersAreNotObject
+	}
+
+	if rejectDuplicateKeys {
+		key, found := findDuplicateJsonKey(params)
+		if found {
+			return fmt.Sprintf(ErrFDuplicateKeyInParameters, key)
+		}
+	}
+
+	return ""
+}
+
+// isJsonObject tells whether the JSON text is an object.
+func isJsonObject(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+
+	return (len(data) > 0) && (data[0] == '{')
+}
+
+// readJsonObjectKeys reads names of fields of the JSON object in their
+// order, including repeated ones. If the text is not a valid object, 'False'
+// is returned.
+func readJsonObjectKeys(data []byte) (keys []string, isObject bool) {
+	if !isJsonObject(data) {
+		return nil, false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	// Opening delimiter.
+	_, err := decoder.Token()
+	if err != nil {
+		return nil, false
+	}
+
+	for decoder.More() {
+		var token json.Token
+		token, err = decoder.Token()
+		if err != nil {
+			return nil, false
+		}
+
+		var value json.RawMessage
+		err = decoder.Decode(&value)
+		if err != nil {
+			return nil, false
+		}
+
+		keys = append(keys, token.(string))
+	}
+
+	return keys, true
+}
+
+// findDuplicateJsonKey searches for a key which is repeated within an object
+// at any depth of the JSON text. Invalid text is searched up to the first
+// syntax error.
+func findDuplicateJsonKey(data []byte) (key string, found bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	// Keys of each open object, arrays have no keys.
+	var objects []map[string]bool
+	var isKeyExpected []bool
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", false
+		}
+
+		depth := len(objects)
+		switch x := token.(type) {
+		case json.Delim:
+			switch x {
+			case '{':
+				objects = append(objects, make(map[string]bool))
+				isKeyExpected = append(isKeyExpected, true)
+				continue
+			case '[':
+				objects = append(objects, nil)
+				isKeyExpected = append(isKeyExpected, false)
+				continue
+			default:
+				objects = objects[:depth-1]
+				isKeyExpected = isKeyExpected[:depth-1]
+			}
+
+		case string:
+			if (depth > 0) && (objects[depth-1] != nil) && isKeyExpected[depth-1] {
+				if objects[depth-1][x] {
+					return x, true
+				}
+				objects[depth-1][x] = true
+				isKeyExpected[depth-1] = false
+				continue
+			}
+		}
+
+		// A value is read, so the next token of an object is a key.
+		depth = len(objects)
+		if depth == 0 {
+			return "", false
+		}
+		if objects[depth-1] != nil {
+			isKeyExpected[depth-1] = true
+		}
+	}
+}
+
+// ParametersDecoding are options of decoding of parameters by the
+// 'ParseParametersWith' function.
+type ParametersDecoding struct {
+	// If enabled, numbers are decoded into json.Number values instead of
+	// float64 values when the destination is an interface.
+	UseNumber bool
+
+	// If enabled, the RPC error has details of the problem in its 'Data'
+	// field.
+	ShowDetails bool
+}
@@ -0,0 +1,162 @@
+package jrm1
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_findEnvelopeProblem(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test #1. No problems.
+	aTest.MustBeEqual(findEnvelopeProblem([]byte(`{"jsonrpc":"M1","ID":"1","x":1}`), false, true), "")
+	aTest.MustBeEqual(findEnvelopeProblem([]byte(`{"jsonrpc":"M1","id":"1","id":"2"}`), true, false), "")
+	aTest.MustBeEqual(findEnvelopeProblem([]byte(`[1]`), true, true), "")
+	aTest.MustBeEqual(findEnvelopeProblem([]byte(`{`), true, true), "")
+
+	// Test #2. Unknown field.
+	aTest.MustBeEqual(findEnvelopeProblem([]byte(`{"jsonrpc":"M1","x":1}`), true, true), "unknown field in request: x")
+
+	// Test #3. Duplicate field.
+	aTest.MustBeEqual(findEnvelopeProblem([]byte(`{"id":"1","params":{},"Id":"2"}`), true, true), "duplicate field in request: Id")
+}
+
+func Test_findParametersProblem(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test.
+	aTest.MustBeEqual(findParametersProblem(json.RawMessage(` {"a":1}`), true, true), "")
+	aTest.MustBeEqual(findParametersProblem(json.RawMessage(`[1]`), false, true), "")
+	aTest.MustBeEqual(findParametersProblem(json.RawMessage(`[1]`), true, true), ErrParametersAreNotObject)
+	aTest.MustBeEqual(findParametersProblem(json.RawMessage(`null`), true, false), ErrParametersAreNotObject)
+	aTest.MustBeEqual(findParametersProblem(json.RawMessage(`{"a":1,"a":2}`), false, true), "duplicate key in parameters: a")
+}
+
+func Test_readJsonObjectKeys(t *testing.T) {
+	aTest := tester.New(t)
+	var keys []string
+	var isObject bool
+
+	// Test #1. Object.
+	keys, isObject = readJsonObjectKeys([]byte(`{"b":{"c":1},"a":[2],"b":null}`))
+	aTest.MustBeEqual(isObject, true)
+	aTest.MustBeEqual(keys, []string{"b", "a", "b"})
+
+	// Test #2. Empty object.
+	keys, isObject = readJsonObjectKeys([]byte(`{}`))
+	aTest.MustBeEqual(isObject, true)
+	aTest.MustBeEqual(keys, []string(nil))
+
+	// Test #3. Not an object.
+	for _, data := range []string{``, `[]`, `"a"`, `{"a":`, `{"a":1,}`} {
+		_, isObject = readJsonObjectKeys([]byte(data))
+		aTest.MustBeEqual(isObject, false)
+	}
+}
+
+func Test_findDuplicateJsonKey(t *testing.T) {
+	aTest := tester.New(t)
+
+	type testCase struct {
+		data  string
+		key   string
+		found bool
+	}
+	tests := []testCase{
+		{data: `1`},
+		{data: `{}`},
+		{data: `{"a":"a","b":"a"}`},
+		{data: `{"a":{"a":1},"b":[{"a":1},{"a":2}]}`},
+		{data: `[{"a":1,"b":[1,"b"],"c":2}]`},
+		{data: `{"a":1,"b":{"c":[],"c":2}}`, key: "c", found: true},
+		{data: `[1,{"x":{},"y":[],"x":null}]`, key: "x", found: true},
+		{data: `{"a":1,"a":`, key: "a", found: true},
+		{data: `{"a":1,`},
+	}
+
+	for _, test := range tests {
+		key, found := findDuplicateJsonKey([]byte(test.data))
+		aTest.MustBeEqual(key, test.key)
+		aTest.MustBeEqual(found, test.found)
+	}
+}
+
+// _newRpcFunctionNumber creates a function returning the number passed in
+// parameters. Parameters are decoded with options of the processor.
+func _newRpcFunctionNumber(p *Processor) RpcFunction {
+	return func(params *json.RawMessage, _ *ResponseMetaData) (result any, re *RpcError) {
+		var np struct {
+			N any `json:"n"`
+		}
+		re = ParseParametersWith(params, &np, p.GetParametersDecoding())
+		if re != nil {
+			return nil, re
+		}
+
+		return np.N, nil
+	}
+}
+
+func Test_Processor_strictDecoding(t *testing.T) {
+	aTest := tester.New(t)
+
+	ps := &ProcessorSettings{
+		EnableBatches:           true,
+		StrictEnvelope:          true,
+		RejectDuplicateKeys:     true,
+		RequireObjectParameters: true,
+		UseNumbersInParameters:  true,
+	}
+	p, err := NewProcessor(ps)
+	aTest.MustBeNoError(err)
+	err = p.AddFunc(RpcFunctionSum)
+	aTest.MustBeNoError(err)
+	err = p.addNamedFunc("RpcFunctionNumber", _newRpcFunctionNumber(p), nil, false, false)
+	aTest.MustBeNoError(err)
+
+	type testCase struct {
+		message  string
+		response string
+	}
+	tests := []testCase{
+		{
+			message:  `{"jsonrpc":"M1","id":"1","method":"RpcFunctionSum","params":{"a":1,"b":2}}`,
+			response: `{"jsonrpc":"M1","id":"1","result":{"c":3},"error":null,"ok":true}`,
+		},
+		{
+			message:  `{"jsonrpc":"M1","id":"2","method":"RpcFunctionSum","params":{},"x":1}`,
+			response: `{"jsonrpc":"M1","id":"2","result":null,"error":{"code":-2,"message":"Invalid request","data":"unknown field in request: x"},"ok":false}`,
+		},
+		{
+			message:  `{"jsonrpc":"M1","id":"3","method":"RpcFunctionSum","params":{},"id":"4"}`,
+			response: `{"jsonrpc":"M1","id":"4","result":null,"error":{"code":-2,"message":"Invalid request","data":"duplicate field in request: id"},"ok":false}`,
+		},
+		{
+			message:  `{"jsonrpc":"M1","id":"5","method":"RpcFunctionSum","params":[1,2]}`,
+			response: `{"jsonrpc":"M1","id":"5","result":null,"error":{"code":-16,"message":"Invalid parameters","data":"parameters are not an object"},"ok":false}`,
+		},
+		{
+			message:  `{"jsonrpc":"M1","id":"6","method":"RpcFunctionSum","params":{"a":1,"b":2,"a":3}}`,
+			response: `{"jsonrpc":"M1","id":"6","result":null,"error":{"code":-16,"message":"Invalid parameters","data":"duplicate key in parameters: a"},"ok":false}`,
+		},
+		{
+			message:  `{"jsonrpc":"M1","id":"7","method":"RpcFunctionNumber","params":{"n":1,"c":2}}`,
+			response: `{"jsonrpc":"M1","id":"7","result":null,"error":{"code":-16,"message":"Invalid parameters","data":"json: unknown field \"c\""},"ok":false}`,
+		},
+		{
+			message:  `{"jsonrpc":"M1","id":"8","method":"RpcFunctionNumber","params":{"n":12345678901234567890}}`,
+			response: `{"jsonrpc":"M1","id":"8","result":12345678901234567890,"error":null,"ok":true}`,
+		},
+		{
+			message:  `[{"jsonrpc":"M1","id":"9","method":"RpcFunctionNumber","params":{},"x":1}]`,
+			response: `[{"jsonrpc":"M1","id":"9","result":null,"error":{"code":-2,"message":"Invalid request","data":"unknown field in request: x"},"ok":false}]`,
+		},
+	}
+
+	for _, test := range tests {
+		aTest.MustBeEqual(string(p.Handle(context.Background(), []byte(test.message))), test.response)
+	}
+}
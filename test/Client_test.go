@@ -6,32 +6,28 @@ import (
 	"testing"
 
 	"github.com/vault-thirteen/JSON-RPC-M1"
-	"github.com/vault-thirteen/JSON-RPC-M1/example/simple/a"
 	"github.com/vault-thirteen/JSON-RPC-M1/example/simple/s"
 	"github.com/vault-thirteen/auxie/tester"
 )
 
 // This file tests two public functions of the client: Call and CallRaw.
-// It uses the provided simple example as an RPC server to emulate responses.
+// It uses the processor of the provided simple example as an RPC server to
+// emulate responses. The client is connected to the processor by the
+// in-process transport, so no network ports are used.
 // This test can be considered as a simple automated unit test.
 // For manual unit tests, see a description of the simple example.
 
 func Test_Client_Call(t *testing.T) {
 	aTest := tester.New(t)
 
-	srvApp, err := a.NewApplication()
+	srv, err := s.NewServer()
 	aTest.MustBeNoError(err)
-	srvApp.Start()
-
-	defer func() {
-		derr := srvApp.Stop()
-		aTest.MustBeNoError(derr)
-	}()
 
 	// Test.
 	var cs *jrm1.ClientSettings
 	cs, err = jrm1.NewClientSettings("http", "localhost", 80, "/", nil, nil, true)
 	aTest.MustBeNoError(err)
+	cs.SetInProcessTransport(jrm1.NewInProcessTransport(srv.Processor()))
 
 	var c *jrm1.Client
 	c, err = jrm1.NewClient(cs)
@@ -53,19 +49,14 @@ func Test_Client_Call(t *testing.T) {
 func Test_Client_CallRaw(t *testing.T) {
 	aTest := tester.New(t)
 
-	srvApp, err := a.NewApplication()
+	srv, err := s.NewServer()
 	aTest.MustBeNoError(err)
-	srvApp.Start()
-
-	defer func() {
-		derr := srvApp.Stop()
-		aTest.MustBeNoError(derr)
-	}()
 
 	// Test.
 	var cs *jrm1.ClientSettings
 	cs, err = jrm1.NewClientSettings("http", "localhost", 80, "/", nil, nil, true)
 	aTest.MustBeNoError(err)
+	cs.SetInProcessTransport(jrm1.NewInProcessTransport(srv.Processor()))
 
 	var c *jrm1.Client
 	c, err = jrm1.NewClient(cs)
@@ -91,7 +82,12 @@ func Test_Client_CallRaw(t *testing.T) {
 	aTest.MustBeEqual(*rpcResp.Id, "123")
 	aTest.MustBeEqual(string(*rpcResp.Result), `{"c":3}`)
 	aTest.MustBeEqual(rpcResp.Error, (*jrm1.RpcError)(nil))
-	aTest.MustBeEqual(rpcResp.Meta, &jrm1.ResponseMetaData{"dur": json.Number("0")})
+	aTest.MustBeEqual(len(*rpcResp.Meta), 1)
+	dur, ok := (*rpcResp.Meta)["dur"].(json.Number)
+	aTest.MustBeEqual(ok, true)
+	durValue, err := dur.Int64()
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(durValue >= 0, true)
 	aTest.MustBeEqual(rpcResp.OK, true)
 	aTest.MustBeEqual(c.GetRequestsCount(), "1")
 }
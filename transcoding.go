@@ -0,0 +1,511 @@
+package jrm1
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+
+	mime "github.com/vault-thirteen/auxie/MIME"
+	ae "github.com/vault-thirteen/auxie/errors"
+)
+
+const (
+	ErrCodecDataIsTruncated      = "encoded data is truncated"
+	ErrCodecDataHasTrailingBytes = "encoded data has trailing bytes"
+	ErrCodecNestingIsTooDeep     = "nesting of encoded data is too deep"
+	ErrCodecMapKeyIsNotString    = "key of encoded map is not a string"
+	ErrCodecNumberIsNotFinite    = "encoded number is not finite"
+	ErrFCodecTypeIsNotSupported  = "encoded type is not supported: 0x%02x"
+	ErrFCodecValueIsNotSupported = "value is not supported: %T"
+)
+
+// maxCodecNestingDepth is the maximum depth of nested arrays and maps in
+// binary encoded data.
+const maxCodecNestingDepth = 1000
+
+// Binary codecs write a tree of generic values in the binary format. Values
+// of the protocol, i.e. requests, responses, errors and meta-data, as well as
+// simple values, slices of any values and maps of any values with string
+// keys, are converted into the tree directly. Byte slices are written as
+// binary strings and raw messages are parsed without encoding. Other values,
+// e.g. structures, are encoded as JSON, which is then converted into the
+// tree. This keeps JSON field tags and custom JSON marshalers working with
+// every codec. Decoding goes through JSON in the reverse direction, and
+// binary strings are converted into Base64 text, in the same way as JSON
+// encodes byte slices. The generic values are: nil, bool, json.Number,
+// string, []byte, []any and *jsonObject.
+
+// isJsonCodec tells whether the codec encodes messages as JSON. Null codec is
+// the default one, i.e. JSON.
+func isJsonCodec(codec Codec) bool {
+	return (codec == nil) || (codec.ContentType() == mime.TypeApplicationJson)
+}
+
+// transcodeToJson reads the input encoded by the codec and returns the same
+// message encoded as JSON. The input is closed.
+func transcodeToJson(codec Codec, input io.ReadCloser) (output io.ReadCloser, err error) {
+	var data []byte
+	data, err = io.ReadAll(input)
+	err = ae.Combine(err, input.Close())
+	if err != nil {
+		return nil, err
+	}
+
+	var raw json.RawMessage
+	err = codec.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(raw)), nil
+}
+
+// jsonObject is a JSON object whose fields keep their order.
+type jsonObject struct {
+	keys   []string
+	values []any
+}
+
+// marshalAsJsonTree encodes the value as JSON and converts it into a tree of
+// generic values.
+func marshalAsJsonTree(v any) (tree any, err error) {
+	var data []byte
+	data, err = json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseJsonTree(data)
+}
+
+// newCodecTree converts the value into a tree of generic values. Values which
+// are not converted directly are encoded as JSON.
+func newCodecTree(v any) (tree any, err error) {
+	switch x := v.(type) {
+	case nil, bool, string, json.Number, []byte, *jsonObject:
+		return x, nil
+
+	case json.RawMessage:
+		if x == nil {
+			return nil, nil
+		}
+		return parseJsonTree(x)
+
+	case *json.RawMessage:
+		if x == nil {
+			return nil, nil
+		}
+		return newCodecTree(*x)
+
+	case int:
+		return json.Number(strconv.FormatInt(int64(x), 10)), nil
+	case int64:
+		return json.Number(strconv.FormatInt(x, 10)), nil
+	case int32:
+		return json.Number(strconv.FormatInt(int64(x), 10)), nil
+	case uint:
+		return json.Number(strconv.FormatUint(uint64(x), 10)), nil
+	case uint64:
+		return json.Number(strconv.FormatUint(x, 10)), nil
+	case uint32:
+		return json.Number(strconv.FormatUint(uint64(x), 10)), nil
+	case float64:
+		return newJsonFloat(x)
+
+	case []any:
+		array := make([]any, len(x))
+		for i, item := range x {
+			array[i], err = newCodecTree(item)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return array, nil
+
+	case map[string]any:
+		return newCodecTreeOfMap(x)
+
+	case *ResponseMetaData:
+		if x == nil {
+			return nil, nil
+		}
+		return newCodecTreeOfMap(*x)
+
+	case *RpcRequest:
+		if x == nil {
+			return nil, nil
+		}
+		return newCodecTreeOfObject(
+			[]string{"jsonrpc", "id", "method", "params"},
+			[]any{x.ProtocolName, x.Id, x.Method, x.Parameters},
+		)
+
+	case []*RpcRequest:
+		array := make([]any, len(x))
+		for i, item := range x {
+			array[i], err = newCodecTree(item)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return array, nil
+
+	case *RpcResponse:
+		if x == nil {
+			return nil, nil
+		}
+		keys := []string{"jsonrpc", "id", "result", "error"}
+		values := []any{x.ProtocolName, x.Id, x.Result, x.Error}
+		if x.Meta != nil {
+			keys = append(keys, "meta")
+			values = append(values, x.Meta)
+		}
+		return newCodecTreeOfObject(append(keys, "ok"), append(values, x.OK))
+
+	case []*RpcResponse:
+		array := make([]any, len(x))
+		for i, item := range x {
+			array[i], err = newCodecTree(item)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return array, nil
+
+	case *RpcError:
+		if x == nil {
+			return nil, nil
+		}
+		return newCodecTreeOfObject(
+			[]string{"code", "message", "data"},
+			[]any{int(x.Code), string(x.Message), x.Data},
+		)
+
+	case *string:
+		if x == nil {
+			return nil, nil
+		}
+		return *x, nil
+
+	default:
+		return marshalAsJsonTree(v)
+	}
+}
+
+// newCodecTreeOfMap converts the map into an object of the tree. Keys are
+// sorted in the same way as JSON does it.
+func newCodecTreeOfMap(m map[string]any) (object *jsonObject, err error) {
+	keys := slices.Sorted(maps.Keys(m))
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		values[i] = m[key]
+	}
+
+	return newCodecTreeOfObject(keys, values)
+}
+
+// newCodecTreeOfObject converts the fields into an object of the tree.
+func newCodecTreeOfObject(keys []string, values []any) (object *jsonObject, err error) {
+	object = &jsonObject{
+		keys:   keys,
+		values: make([]any, len(values)),
+	}
+
+	for i, value := range values {
+		object.values[i], err = newCodecTree(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return object, nil
+}
+
+// unmarshalJsonTree converts the tree of generic values into JSON and decodes
+// it into the value.
+func unmarshalJsonTree(tree any, v any) (err error) {
+	var buf bytes.Buffer
+	err = writeJsonTree(&buf, tree)
+	if err != nil {
+		return err
+	}
+
+	// Raw messages keep the text as is.
+	raw, ok := v.(*json.RawMessage)
+	if ok {
+		*raw = buf.Bytes()
+		return nil
+	}
+
+	return json.Unmarshal(buf.Bytes(), v)
+}
+
+// parseJsonTree converts a JSON text into a tree of generic values.
+func parseJsonTree(data []byte) (tree any, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	tree, err = readJsonTreeValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = decoder.Token()
+	if !errors.Is(err, io.EOF) {
+		return nil, errors.New(ErrCodecDataHasTrailingBytes)
+	}
+
+	return tree, nil
+}
+
+// readJsonTreeValue reads the next JSON value from the decoder.
+func readJsonTreeValue(decoder *json.Decoder) (v any, err error) {
+	var token json.Token
+	token, err = decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, isDelim := token.(json.Delim)
+	if !isDelim {
+		return token, nil
+	}
+
+	switch delim {
+	case '[':
+		array := make([]any, 0)
+		for decoder.More() {
+			var item any
+			item, err = readJsonTreeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, item)
+		}
+		_, err = decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		return array, nil
+
+	default:
+		object := new(jsonObject)
+		for decoder.More() {
+			token, err = decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			var item any
+			item, err = readJsonTreeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+
+			object.keys = append(object.keys, token.(string))
+			object.values = append(object.values, item)
+		}
+		_, err = decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		return object, nil
+	}
+}
+
+// writeJsonTree writes the tree of generic values as a JSON text.
+func writeJsonTree(buf *bytes.Buffer, v any) (err error) {
+	switch x := v.(type) {
+	case nil:
+		buf.WriteString("null")
+
+	case bool:
+		buf.WriteString(strconv.FormatBool(x))
+
+	case json.Number:
+		buf.WriteString(x.String())
+
+	case string:
+		var data []byte
+		data, err = json.Marshal(x)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+
+	case []byte:
+		err = writeJsonTree(buf, newJsonBinary(x))
+		if err != nil {
+			return err
+		}
+
+	case []any:
+		buf.WriteByte('[')
+		for i, item := range x {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			err = writeJsonTree(buf, item)
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+
+	case *jsonObject:
+		buf.WriteByte('{')
+		for i, key := range x.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			err = writeJsonTree(buf, key)
+			if err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			err = writeJsonTree(buf, x.values[i])
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+
+	default:
+		return fmt.Errorf(ErrFCodecValueIsNotSupported, v)
+	}
+
+	return nil
+}
+
+// jsonNumberKind is a kind of a number used by binary formats.
+type jsonNumberKind byte
+
+const (
+	jsonNumberKind_Unsigned = jsonNumberKind(0)
+	jsonNumberKind_Signed   = jsonNumberKind(1)
+	jsonNumberKind_Float    = jsonNumberKind(2)
+)
+
+// classifyJsonNumber finds the most compact binary kind of the JSON number.
+// Integers are preferred, while other numbers are floats.
+func classifyJsonNumber(n json.Number) (kind jsonNumberKind, u uint64, i int64, f float64, err error) {
+	u, err = strconv.ParseUint(n.String(), 10, 64)
+	if err == nil {
+		return jsonNumberKind_Unsigned, u, 0, 0, nil
+	}
+
+	i, err = strconv.ParseInt(n.String(), 10, 64)
+	if err == nil {
+		return jsonNumberKind_Signed, 0, i, 0, nil
+	}
+
+	f, err = strconv.ParseFloat(n.String(), 64)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+
+	return jsonNumberKind_Float, 0, 0, f, nil
+}
+
+// newJsonFloat creates a JSON number of the float.
+func newJsonFloat(f float64) (n json.Number, err error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", errors.New(ErrCodecNumberIsNotFinite)
+	}
+
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+}
+
+// newJsonBinary creates a JSON string of binary data.
+func newJsonBinary(data []byte) string {
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// binaryReader reads binary encoded data.
+type binaryReader struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+// readByte reads a single byte.
+func (br *binaryReader) readByte() (b byte, err error) {
+	if br.pos >= len(br.data) {
+		return 0, errors.New(ErrCodecDataIsTruncated)
+	}
+
+	b = br.data[br.pos]
+	br.pos++
+
+	return b, nil
+}
+
+// readBytes reads the specified number of bytes.
+func (br *binaryReader) readBytes(n uint64) (data []byte, err error) {
+	if n > uint64(len(br.data)-br.pos) {
+		return nil, errors.New(ErrCodecDataIsTruncated)
+	}
+
+	data = br.data[br.pos : br.pos+int(n)]
+	br.pos += int(n)
+
+	return data, nil
+}
+
+// readUint reads a big-endian unsigned integer of the specified size.
+func (br *binaryReader) readUint(size int) (n uint64, err error) {
+	var data []byte
+	data, err = br.readBytes(uint64(size))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, b := range data {
+		n = n<<8 | uint64(b)
+	}
+
+	return n, nil
+}
+
+// enter starts reading of a nested array or map.
+func (br *binaryReader) enter() (err error) {
+	br.depth++
+	if br.depth > maxCodecNestingDepth {
+		return errors.New(ErrCodecNestingIsTooDeep)
+	}
+
+	return nil
+}
+
+// leave finishes reading of a nested array or map.
+func (br *binaryReader) leave() {
+	br.depth--
+}
+
+// checkEnd checks that all the data is read.
+func (br *binaryReader) checkEnd() (err error) {
+	if br.pos != len(br.data) {
+		return errors.New(ErrCodecDataHasTrailingBytes)
+	}
+
+	return nil
+}
+
+// capacityFor returns a safe capacity of a collection having the declared
+// number of items. Each item takes at least one byte, so a declared number
+// larger than the rest of data is not trusted.
+func (br *binaryReader) capacityFor(n uint64) int {
+	rest := uint64(len(br.data) - br.pos)
+	if n > rest {
+		return int(rest)
+	}
+
+	return int(n)
+}
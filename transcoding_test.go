@@ -0,0 +1,250 @@
+package jrm1
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+// _jsonTreeText converts the tree of generic values into a JSON text.
+func _jsonTreeText(t *testing.T, tree any) string {
+	var buf bytes.Buffer
+	err := writeJsonTree(&buf, tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+// _newLongJsonTrees creates a string, an array and an object having the
+// specified number of items.
+func _newLongJsonTrees(n int) []any {
+	array := make([]any, 0, n)
+	object := &jsonObject{}
+	for i := range n {
+		array = append(array, json.Number(strconv.Itoa(i)))
+		object.keys = append(object.keys, strconv.Itoa(i))
+		object.values = append(object.values, nil)
+	}
+
+	return []any{strings.Repeat("x", n), array, object}
+}
+
+// _checkCodec checks that the codec keeps values which are encoded and then
+// decoded, and that it fails on malformed data.
+func _checkCodec(t *testing.T, codec Codec) {
+	aTest := tester.New(t)
+
+	type Envelope struct {
+		Name   string          `json:"name"`
+		Data   []byte          `json:"data"`
+		Params json.RawMessage `json:"params"`
+		Price  float64         `json:"price"`
+		Count  int64           `json:"count"`
+		Tags   []string        `json:"tags"`
+		Extra  map[string]any  `json:"extra"`
+		Next   *Envelope       `json:"next,omitempty"`
+	}
+
+	in := Envelope{
+		Name:   "<M1>",
+		Data:   []byte{0, 1, 2, 255},
+		Params: json.RawMessage(`{"a":[1,2.5,"x"]}`),
+		Price:  -0.125,
+		Count:  -1 << 62,
+		Tags:   []string{"a", "ü"},
+		Extra:  map[string]any{"k": nil},
+		Next:   &Envelope{Name: "next", Params: json.RawMessage(`[]`)},
+	}
+
+	data, err := codec.Marshal(in)
+	aTest.MustBeNoError(err)
+
+	var out Envelope
+	err = codec.Unmarshal(data, &out)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(out, in)
+
+	// Unsupported value.
+	_, err = codec.Marshal(func() {})
+	aTest.MustBeAnError(err)
+
+	// Malformed data.
+	err = codec.Unmarshal([]byte{0xc1, 0xff}, &out)
+	aTest.MustBeAnError(err)
+}
+
+func Test_isJsonCodec(t *testing.T) {
+	aTest := tester.New(t)
+
+	aTest.MustBeEqual(isJsonCodec(nil), true)
+	aTest.MustBeEqual(isJsonCodec(NewJsonCodec()), true)
+	aTest.MustBeEqual(isJsonCodec(NewCborCodec()), false)
+}
+
+func Test_transcodeToJson(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	// Test #1. Data is transcoded.
+	data, err := NewCborCodec().Marshal(map[string]int{"a": 1})
+	aTest.MustBeNoError(err)
+	rc, err := transcodeToJson(NewCborCodec(), io.NopCloser(bytes.NewReader(data)))
+	aTest.MustBeNoError(err)
+	data, err = io.ReadAll(rc)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(data), `{"a":1}`)
+
+	// Test #2. Data is malformed.
+	_, err = transcodeToJson(NewCborCodec(), io.NopCloser(strings.NewReader(`{}`)))
+	aTest.MustBeAnError(err)
+
+	// Test #3. Input is not closed.
+	_, err = transcodeToJson(NewCborCodec(), _badCloser{Reader: bytes.NewReader([]byte{0xf6})})
+	aTest.MustBeAnError(err)
+}
+
+func Test_parseJsonTree(t *testing.T) {
+	aTest := tester.New(t)
+	var tree any
+	var err error
+
+	// Test #1. Order of fields is kept and numbers are not changed.
+	const text = `{"z":[1,-2.50,"x",null,true],"a":{"b":12345678901234567890}}`
+	tree, err = parseJsonTree([]byte(text))
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(_jsonTreeText(t, tree), text)
+
+	// Test #2. Trailing data.
+	_, err = parseJsonTree([]byte(`{} {}`))
+	aTest.MustBeAnError(err)
+
+	// Test #3. Malformed data.
+	_, err = parseJsonTree([]byte(`{"a":}`))
+	aTest.MustBeAnError(err)
+}
+
+func Test_newCodecTree(t *testing.T) {
+	aTest := tester.New(t)
+	var tree any
+	var err error
+
+	// Test #1. Values converted directly.
+	id := "1"
+	md := ResponseMetaData{"z": uint(1), "a": -2.5}
+	tree, err = newCodecTree([]*RpcResponse{{
+		ProtocolName: ProtocolNameM1,
+		Id:           &id,
+		Result:       []any{json.RawMessage(`{"b":[1,"x"]}`), int64(-3), nil, "s"},
+		Error:        nil,
+		Meta:         &md,
+	}})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(_jsonTreeText(t, tree), `[{"jsonrpc":"M1","id":"1","result":[{"b":[1,"x"]},-3,null,"s"],"error":null,"meta":{"a":-2.5,"z":1},"ok":false}]`)
+
+	// Test #2. Byte slices stay binary.
+	tree, err = newCodecTree(map[string]any{"data": []byte{1, 2}})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(tree.(*jsonObject).values[0], []byte{1, 2})
+	aTest.MustBeEqual(_jsonTreeText(t, tree), `{"data":"AQI="}`)
+
+	// Test #3. Other values are encoded as JSON.
+	tree, err = newCodecTree(&RpcError{Code: 1, Message: "m", Data: struct {
+		A int `json:"a"`
+	}{A: 1}})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(_jsonTreeText(t, tree), `{"code":1,"message":"m","data":{"a":1}}`)
+
+	// Test #4. Null pointers.
+	tree, err = newCodecTree(&RpcRequest{})
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(_jsonTreeText(t, tree), `{"jsonrpc":null,"id":null,"method":null,"params":null}`)
+
+	// Test #5. Values which can not be encoded.
+	_, err = newCodecTree([]any{math.NaN()})
+	aTest.MustBeAnError(err)
+	_, err = newCodecTree(func() {})
+	aTest.MustBeAnError(err)
+}
+
+func Test_unmarshalJsonTree(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	tree, err := parseJsonTree([]byte(`{"a":[1,2]}`))
+	aTest.MustBeNoError(err)
+
+	// Test #1. Structure.
+	var s struct {
+		A []int `json:"a"`
+	}
+	err = unmarshalJsonTree(tree, &s)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(s.A, []int{1, 2})
+
+	// Test #2. Raw message.
+	var raw json.RawMessage
+	err = unmarshalJsonTree(tree, &raw)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(raw), `{"a":[1,2]}`)
+
+	// Test #3. Unsupported value.
+	err = unmarshalJsonTree(1, &raw)
+	aTest.MustBeAnError(err)
+}
+
+func Test_classifyJsonNumber(t *testing.T) {
+	aTest := tester.New(t)
+
+	type TestData struct {
+		n    json.Number
+		kind jsonNumberKind
+	}
+
+	tests := []TestData{
+		{n: "0", kind: jsonNumberKind_Unsigned},
+		{n: "18446744073709551615", kind: jsonNumberKind_Unsigned},
+		{n: "-9223372036854775808", kind: jsonNumberKind_Signed},
+		{n: "18446744073709551616", kind: jsonNumberKind_Float},
+		{n: "1.0", kind: jsonNumberKind_Float},
+		{n: "1e3", kind: jsonNumberKind_Float},
+	}
+
+	for _, test := range tests {
+		kind, _, _, _, err := classifyJsonNumber(test.n)
+		aTest.MustBeNoError(err)
+		aTest.MustBeEqual(kind, test.kind)
+	}
+
+	_, _, _, _, err := classifyJsonNumber("x")
+	aTest.MustBeAnError(err)
+}
+
+func Test_binaryReader(t *testing.T) {
+	aTest := tester.New(t)
+	br := &binaryReader{data: []byte{1, 2, 3}}
+
+	// Test #1. Capacity is limited by the rest of data.
+	aTest.MustBeEqual(br.capacityFor(1_000_000), 3)
+	aTest.MustBeEqual(br.capacityFor(2), 2)
+
+	// Test #2. Reading.
+	n, err := br.readUint(2)
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(n, uint64(0x0102))
+	aTest.MustBeAnError(br.checkEnd())
+	_, err = br.readBytes(2)
+	aTest.MustBeAnError(err)
+	_, err = br.readByte()
+	aTest.MustBeNoError(err)
+	aTest.MustBeNoError(br.checkEnd())
+	_, err = br.readByte()
+	aTest.MustBeAnError(err)
+}
@@ -0,0 +1,357 @@
+package jrm1
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebSocket protocol constants.
+// https://www.rfc-editor.org/rfc/rfc6455
+const (
+	WebSocketVersion = "13"
+	WebSocketGuid    = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	HttpHeaderUpgrade             = "Upgrade"
+	HttpHeaderConnection          = "Connection"
+	HttpHeaderSecWebSocketKey     = "Sec-WebSocket-Key"
+	HttpHeaderSecWebSocketAccept  = "Sec-WebSocket-Accept"
+	HttpHeaderSecWebSocketVersion = "Sec-WebSocket-Version"
+)
+
+// Operation codes of WebSocket frames.
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// Status codes of closing WebSocket connections.
+const (
+	wsCloseNormal          = 1000
+	wsCloseProtocolError   = 1002
+	wsCloseMessageTooLarge = 1009
+)
+
+const (
+	wsMaxControlPayloadSize = 125
+	wsFinBit                = 0x80
+	wsMaskBit               = 0x80
+)
+
+const (
+	ErrWebSocketHandshakeIsInvalid = "WebSocket handshake is invalid"
+	ErrWebSocketProtocolError      = "WebSocket protocol error"
+	ErrWebSocketMessageIsTooLarge  = "WebSocket message is too large"
+	ErrWebSocketIsClosed           = "WebSocket connection is closed"
+)
+
+// wsConn is a WebSocket connection. It reads and writes whole messages, while
+// control frames are handled automatically. Writing is safe for concurrent
+// use, reading is not.
+type wsConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+
+	// Client side masks its frames, server side does not.
+	isClient bool
+
+	// Maximum size of a received message. The limit can not be disabled,
+	// because sizes of frames are read from the network.
+	maxMessageSize int
+
+	// Time limit of receiving a frame. Zero means no limit.
+	readTimeout time.Duration
+
+	// Time limit of writing a frame. Zero means no limit.
+	writeTimeout time.Duration
+
+	writeGuard *sync.Mutex
+}
+
+// newWsConn is a constructor of a WebSocket connection over an established
+// network connection which has passed the handshake. When the maximum size of
+// a received message is not positive, the default size is used.
+func newWsConn(conn net.Conn, br *bufio.Reader, isClient bool, maxMessageSize int, readTimeout time.Duration, writeTimeout time.Duration) (wsc *wsConn) {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	if maxMessageSize <= 0 {
+		maxMessageSize = DefaultWebSocketMaxMessageSize
+	}
+
+	return &wsConn{
+		conn:           conn,
+		br:             br,
+		isClient:       isClient,
+		maxMessageSize: maxMessageSize,
+		readTimeout:    readTimeout,
+		writeTimeout:   writeTimeout,
+		writeGuard:     new(sync.Mutex),
+	}
+}
+
+// computeWebSocketAccept computes the value of the 'Sec-WebSocket-Accept' HTTP
+// header for the key sent by the client.
+func computeWebSocketAccept(key string) string {
+	h := sha1.Sum([]byte(key + WebSocketGuid))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// newWebSocketKey creates a random value of the 'Sec-WebSocket-Key' HTTP
+// header.
+func newWebSocketKey() string {
+	var key [16]byte
+
+	// Reading of random bytes never fails.
+	_, _ = rand.Read(key[:])
+
+	return base64.StdEncoding.EncodeToString(key[:])
+}
+
+// isWebSocketUpgrade tells whether the HTTP request asks for the WebSocket
+// protocol.
+func isWebSocketUpgrade(req *http.Request) bool {
+	return (req.Method == http.MethodGet) &&
+		headerContainsToken(req.Header, HttpHeaderConnection, "upgrade") &&
+		headerContainsToken(req.Header, HttpHeaderUpgrade, "websocket") &&
+		(req.Header.Get(HttpHeaderSecWebSocketVersion) == WebSocketVersion) &&
+		(len(req.Header.Get(HttpHeaderSecWebSocketKey)) > 0)
+}
+
+// headerContainsToken tells whether a comma-separated HTTP header contains the
+// token. Tokens are compared case-insensitively.
+func headerContainsToken(h http.Header, name string, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// readMessage reads the next data message. Ping frames are answered, pong
+// frames are skipped. When the peer closes the connection, the closing frame
+// is answered and 'io.EOF' is returned.
+func (wsc *wsConn) readMessage() (opCode byte, data []byte, err error) {
+	var message bytes.Buffer
+	var isFragmented bool
+
+	for {
+		var fin bool
+		var frameOpCode byte
+		var payload []byte
+		fin, frameOpCode, payload, err = wsc.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch frameOpCode {
+		case wsOpPing:
+			err = wsc.writeFrame(wsOpPong, payload)
+			if err != nil {
+				return 0, nil, err
+			}
+			continue
+
+		case wsOpPong:
+			continue
+
+		case wsOpClose:
+			_ = wsc.writeFrame(wsOpClose, payload)
+			return 0, nil, io.EOF
+
+		case wsOpText, wsOpBinary:
+			if isFragmented {
+				return 0, nil, wsc.fail(wsCloseProtocolError, ErrWebSocketProtocolError)
+			}
+			opCode = frameOpCode
+
+		case wsOpContinuation:
+			if !isFragmented {
+				return 0, nil, wsc.fail(wsCloseProtocolError, ErrWebSocketProtocolError)
+			}
+
+		default:
+			return 0, nil, wsc.fail(wsCloseProtocolError, ErrWebSocketProtocolError)
+		}
+
+		if message.Len()+len(payload) > wsc.maxMessageSize {
+			return 0, nil, wsc.fail(wsCloseMessageTooLarge, ErrWebSocketMessageIsTooLarge)
+		}
+		message.Write(payload)
+
+		if fin {
+			return opCode, message.Bytes(), nil
+		}
+		isFragmented = true
+	}
+}
+
+// readFrame reads a single frame and unmasks its payload.
+func (wsc *wsConn) readFrame() (fin bool, opCode byte, payload []byte, err error) {
+	if wsc.readTimeout > 0 {
+		err = wsc.conn.SetReadDeadline(time.Now().Add(wsc.readTimeout))
+		if err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	var header [2]byte
+	_, err = io.ReadFull(wsc.br, header[:])
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	fin = header[0]&wsFinBit != 0
+	opCode = header[0] & 0x0F
+	isMasked := header[1]&wsMaskBit != 0
+	size := uint64(header[1] & 0x7F)
+
+	// Client frames must be masked, server frames must not.
+	if isMasked == wsc.isClient {
+		return false, 0, nil, wsc.fail(wsCloseProtocolError, ErrWebSocketProtocolError)
+	}
+
+	switch size {
+	case 126:
+		var ext [2]byte
+		_, err = io.ReadFull(wsc.br, ext[:])
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		_, err = io.ReadFull(wsc.br, ext[:])
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	isControl := opCode&0x8 != 0
+	if isControl && (!fin || (size > wsMaxControlPayloadSize)) {
+		return false, 0, nil, wsc.fail(wsCloseProtocolError, ErrWebSocketProtocolError)
+	}
+	if size > uint64(wsc.maxMessageSize) {
+		return false, 0, nil, wsc.fail(wsCloseMessageTooLarge, ErrWebSocketMessageIsTooLarge)
+	}
+
+	var mask [4]byte
+	if isMasked {
+		_, err = io.ReadFull(wsc.br, mask[:])
+		if err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload = make([]byte, size)
+	_, err = io.ReadFull(wsc.br, payload)
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	if isMasked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return fin, opCode, payload, nil
+}
+
+// writeFrame writes a single unfragmented frame. Frames of the client side are
+// masked.
+func (wsc *wsConn) writeFrame(opCode byte, payload []byte) (err error) {
+	frame := make([]byte, 0, len(payload)+14)
+	frame = append(frame, wsFinBit|opCode)
+
+	var maskBit byte
+	if wsc.isClient {
+		maskBit = wsMaskBit
+	}
+
+	size := len(payload)
+	switch {
+	case size <= 125:
+		frame = append(frame, maskBit|byte(size))
+	case size <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(size))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(size))
+	}
+
+	if wsc.isClient {
+		var mask [4]byte
+
+		// Reading of random bytes never fails.
+		_, _ = rand.Read(mask[:])
+
+		frame = append(frame, mask[:]...)
+		offset := len(frame)
+		frame = append(frame, payload...)
+		for i := range payload {
+			frame[offset+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	wsc.writeGuard.Lock()
+	defer wsc.writeGuard.Unlock()
+
+	if wsc.writeTimeout > 0 {
+		err = wsc.conn.SetWriteDeadline(time.Now().Add(wsc.writeTimeout))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = wsc.conn.Write(frame)
+	return err
+}
+
+// nextMessage reads the next data message.
+func (wsc *wsConn) nextMessage() (data []byte, err error) {
+	_, data, err = wsc.readMessage()
+	return data, err
+}
+
+// writeMessage writes a text message.
+func (wsc *wsConn) writeMessage(data []byte) (err error) {
+	return wsc.writeFrame(wsOpText, data)
+}
+
+// ping sends a ping frame.
+func (wsc *wsConn) ping() (err error) {
+	return wsc.writeFrame(wsOpPing, nil)
+}
+
+// fail sends a closing frame with the status code and returns an error with
+// the message.
+func (wsc *wsConn) fail(statusCode uint16, msg string) (err error) {
+	_ = wsc.writeFrame(wsOpClose, binary.BigEndian.AppendUint16(nil, statusCode))
+	return errors.New(msg)
+}
+
+// close sends a normal closing frame and closes the network connection.
+func (wsc *wsConn) close() (err error) {
+	_ = wsc.writeFrame(wsOpClose, binary.BigEndian.AppendUint16(nil, wsCloseNormal))
+	return wsc.conn.Close()
+}
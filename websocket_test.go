@@ -0,0 +1,114 @@
+package jrm1
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/vault-thirteen/auxie/tester"
+)
+
+func Test_computeWebSocketAccept(t *testing.T) {
+	aTest := tester.New(t)
+
+	// Test. Example of RFC 6455.
+	aTest.MustBeEqual(computeWebSocketAccept("dGhlIHNhbXBsZSBub25jZQ=="), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=")
+}
+
+func Test_isWebSocketUpgrade(t *testing.T) {
+	aTest := tester.New(t)
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.org", nil)
+	aTest.MustBeNoError(err)
+
+	// Test #1. Ordinary request.
+	aTest.MustBeEqual(isWebSocketUpgrade(req), false)
+
+	// Test #2. Upgrade request.
+	req.Header.Set(HttpHeaderConnection, "keep-alive, Upgrade")
+	req.Header.Set(HttpHeaderUpgrade, "WebSocket")
+	req.Header.Set(HttpHeaderSecWebSocketVersion, WebSocketVersion)
+	req.Header.Set(HttpHeaderSecWebSocketKey, newWebSocketKey())
+	aTest.MustBeEqual(isWebSocketUpgrade(req), true)
+
+	// Test #3. Unsupported version.
+	req.Header.Set(HttpHeaderSecWebSocketVersion, "8")
+	aTest.MustBeEqual(isWebSocketUpgrade(req), false)
+}
+
+func Test_wsConn(t *testing.T) {
+	aTest := tester.New(t)
+	var err error
+
+	// Test #1. Message of the client is masked and read by the server.
+	client, server := _newWsConnPair(t, 200)
+	err = client.writeMessage([]byte("hello"))
+	aTest.MustBeNoError(err)
+	opCode, data, err := server.readMessage()
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(opCode, byte(wsOpText))
+	aTest.MustBeEqual(string(data), "hello")
+
+	// Test #2. Ping is answered automatically, pong is skipped.
+	err = server.ping()
+	aTest.MustBeNoError(err)
+	err = server.writeMessage(make([]byte, 150))
+	aTest.MustBeNoError(err)
+	_, data, err = client.readMessage()
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(len(data), 150)
+	err = client.writeMessage([]byte("bye"))
+	aTest.MustBeNoError(err)
+	_, data, err = server.readMessage()
+	aTest.MustBeNoError(err)
+	aTest.MustBeEqual(string(data), "bye")
+
+	// Test #3. Too large message.
+	err = client.writeMessage(make([]byte, 201))
+	aTest.MustBeNoError(err)
+	_, _, err = server.readMessage()
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrWebSocketMessageIsTooLarge)
+
+	// Test #4. Closing by the peer.
+	client, server = _newWsConnPair(t, 0)
+	err = client.close()
+	aTest.MustBeNoError(err)
+	_, _, err = server.readMessage()
+	aTest.MustBeEqual(err, io.EOF)
+
+	// Test #5. Size of a message is always limited, so that a frame of a huge
+	// size is not allocated.
+	client, server = _newWsConnPair(t, 0)
+	aTest.MustBeEqual(server.maxMessageSize, DefaultWebSocketMaxMessageSize)
+	_, err = client.conn.Write([]byte{0x82, 0x80 | 127, 0x40, 0, 0, 0, 0, 0, 0, 0})
+	aTest.MustBeNoError(err)
+	_, _, err = server.readMessage()
+	aTest.MustBeAnError(err)
+	aTest.MustBeEqual(err.Error(), ErrWebSocketMessageIsTooLarge)
+}
+
+// _newWsConnPair creates a pair of WebSocket connections of the client and
+// the server connected via the loopback interface.
+func _newWsConnPair(t *testing.T, maxMessageSize int) (client *wsConn, server *wsConn) {
+	aTest := tester.New(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	aTest.MustBeNoError(err)
+	defer func() { _ = listener.Close() }()
+
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	aTest.MustBeNoError(err)
+	serverConn, err := listener.Accept()
+	aTest.MustBeNoError(err)
+	t.Cleanup(func() {
+		_ = clientConn.Close()
+		_ = serverConn.Close()
+	})
+
+	client = newWsConn(clientConn, nil, true, 0, 0, 0)
+	server = newWsConn(serverConn, nil, false, maxMessageSize, 0, 0)
+
+	return client, server
+}